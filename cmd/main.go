@@ -1,10 +1,23 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime/pprof"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/sreio/tradify-cli/internal"
@@ -22,6 +35,12 @@ func main() {
 		runMySQL(os.Args[2:])
 	case "file":
 		runFile(os.Args[2:])
+	case "doctor":
+		runDoctor(os.Args[2:])
+	case "serve":
+		runServe(os.Args[2:])
+	case "convert":
+		runConvert(os.Args[2:])
 	case "-h", "--help", "help":
 		printRootHelp()
 	default:
@@ -32,7 +51,7 @@ func main() {
 }
 
 func printRootHelp() {
-	fmt.Println(`tradify-cli - 简繁体批量转换工具
+	fmt.Print(`tradify-cli - 简繁体批量转换工具
 
 [github]: https://github.com/sreio/tradify-cli
 
@@ -40,12 +59,19 @@ func printRootHelp() {
   tradify-cli <子命令> [参数]
 
 子命令：
-  mysql   批量转换 MySQL 表指定列为繁体（支持配置文件 & 模板生成）
+  mysql   批量转换 MySQL 表指定列为繁体（支持配置文件 & 模板生成 & 整库 mysql all）
   file    批量转换目录内文档内容为繁体
+  doctor  检查运行环境与数据库连通性
+  serve   以本地 HTTP 服务提供转换接口（供其它服务调用）
+  convert 查看可用的转换配置（convert list）与转换自检（convert selftest）
 
 查看子命令帮助：
-  tradify-cli mysql --help
-  tradify-cli file  --help
+  tradify-cli mysql  --help
+  tradify-cli file   --help
+  tradify-cli doctor --help
+  tradify-cli serve  --help
+  tradify-cli convert list --help
+  tradify-cli convert selftest --help
 `)
 }
 
@@ -57,6 +83,21 @@ func runMySQL(args []string) {
 		runGenConfig(args[1:])
 		return
 	}
+	// 子子命令：mysql validate（检查配置）
+	if len(args) > 0 && args[0] == "validate" {
+		runMySQLValidate(args[1:])
+		return
+	}
+	// 子子命令：mysql plan（合并执行计划）
+	if len(args) > 0 && args[0] == "plan" {
+		runMySQLPlan(args[1:])
+		return
+	}
+	// 子子命令：mysql all（整库）
+	if len(args) > 0 && args[0] == "all" {
+		runMySQLAll(args[1:])
+		return
+	}
 
 	fs := flag.NewFlagSet("mysql", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
@@ -65,23 +106,81 @@ func runMySQL(args []string) {
 		// 配置文件模式
 		confPath = fs.String("conf", "", "【可选】配置文件或目录路径：指定文件(如 a.json)或目录(批量执行目录下 *.json)")
 		// 单表直接参数模式（与 --conf 互斥）
-		dsn        = fs.String("dsn", "", "【必填】MySQL 连接串，例如：user:pass@tcp(127.0.0.1:3306)/db?charset=utf8mb4&parseTime=true")
-		table      = fs.String("table", "", "【必填】表名")
-		columnsStr = fs.String("columns", "", "【必填】要转换的列名，逗号分隔，如：name,content")
-		to         = fs.String("to", "s2twp", "OpenCC 转换配置（默认 s2twp），可选如：s2t、t2s 等")
-		batchSize  = fs.Int("batch-size", 500, "每批处理行数（默认 500）")
-		workers    = fs.Int("workers", 8, "并发 worker 数（默认 8）")
-		rps        = fs.Int("rps", 0, "每秒最大处理行数（默认 0 不限速）")
-		dryRun     = fs.Bool("dry-run", true, "试运行：不落库，仅打印将运行的更新")
-		maxOpen    = fs.Int("max-open", 200, "数据库最大打开连接数（默认200）")
-		maxIdle    = fs.Int("max-idle", 20, "数据库最大空闲连接数（默认20）")
-		connLife   = fs.Duration("conn-max-lifetime", 30*time.Minute, "单连接最大生命周期（默认30m）")
+		dsn         = fs.String("dsn", "", "【必填】MySQL 连接串，例如：user:pass@tcp(127.0.0.1:3306)/db?charset=utf8mb4&parseTime=true")
+		readDSN     = fs.String("read-dsn", "", "只读副本连接串（可选）：批次 SELECT、计数与表结构读取走副本，UPDATE 仍走 --dsn（配置文件模式使用 read_dsn）")
+		table       = fs.String("table", "", "【必填】表名")
+		columnsStr  = fs.String("columns", "", "【必填】要转换的列名，逗号分隔，如：name,content")
+		selectSQL   = fs.String("select-sql", "", "自定义行来源 SELECT（高级用法）：须依次返回 --pk 列与 --columns 列，更新仍按主键执行")
+		to          = fs.String("to", "s2twp", "OpenCC 转换配置（默认 s2twp），可选如：s2t、t2s 等；auto-trad / auto-simp 按内容自动判定方向；用 > 串联多个配置依次应用（如 s2t>t2tw）；custom:词典文件 为自定义词典级（如 custom:brands.txt>s2twp）")
+		normalize   = fs.String("normalize", "none", "转换输出的 Unicode 规范化：nfc | nfkc | none（默认 none）")
+		normInput   = fs.Bool("normalize-input", false, "转换前也对输入做同样的规范化（默认 false）")
+		punctOnly   = fs.Bool("punct-only", false, "仅标点模式：照常转换后还原所有汉字改动，只保留标点、全半角等非汉字改动（配合 --normalize nfkc 或替换规则使用；配置文件模式使用 punct_only）")
+		smart       = fs.Bool("smart", false, "按值判定方向（需 s2* 配置或 auto-trad）：以繁体为主的值只用 s2t 修正零散的简体字，不做整段 s2twp 改写；以简体为主的值照常转换（配置文件模式使用 smart）")
+		convertKeys = fs.Bool("convert-keys", false, "JSON 列的对象键也转换（默认只转换值）；转换后与同一对象中其它键重名的键保留原样并告警（配置文件模式使用 convert_keys）")
+		explainSkip = fs.Bool("explain-skip", false, "逐行逐列记录未转换的原因（ascii_only、no_chinese、no_change 等），排查用，日志量大（配置文件模式使用 explain_skip）")
+		batchSize   = fs.Int("batch-size", 500, "每批处理行数（默认 500）")
+		workers     = fs.Int("workers", 8, "并发 worker 数（默认 8）")
+		rps         = fs.Int("rps", 0, "每秒最大处理行数（默认 0 不限速）")
+		dryRun      = fs.Bool("dry-run", true, "试运行：不落库，仅打印将运行的更新")
+		maxOpen     = poolSizeVar(fs, "max-open", 200, "每张表连接池的最大打开连接数（默认200）；auto 按实际占用估算（每表 1 个，读游标保持打开时 2 个）")
+		maxIdle     = poolSizeVar(fs, "max-idle", 20, "每张表连接池的最大空闲连接数（默认20）；auto 与 --max-open 相同")
+		connLife    = fs.Duration("conn-max-lifetime", 30*time.Minute, "单连接最大生命周期（默认30m）")
+		stream      = fs.Bool("stream-results", false, "边读边处理结果集，不在客户端缓存整批（默认 false，需 max-open >= 2）")
+		interp      = fs.Bool("interpolate-params", false, "驱动端插值参数，省去每次查询的 prepare 往返（默认 false）")
+		collation   = fs.String("session-collation", "", "每个新连接执行 SET NAMES <字符集> COLLATE <排序规则>（如 utf8mb4_bin），使分页比较不随服务器默认排序规则变化（配置文件模式使用 session_collation）")
+		isolation   = fs.String("isolation", "", "每个新连接设置的事务隔离级别：READ COMMITTED | REPEATABLE READ | READ UNCOMMITTED | SERIALIZABLE（默认沿用服务器设置；配置文件模式使用 isolation）")
+		summary     = fs.String("summary", "text", "结束时输出统计摘要的格式：text | json（配置文件模式同样生效）")
+		reportOut   = fs.String("report-file", "", "结束时（含失败与中止）把各表结果、合计与统计摘要另存为文本报告（配置文件模式同样生效）")
+		profile     = fs.Bool("profile", false, "在统计摘要中输出耗时分解：转换（CPU）与 SQL / 文件 IO 的累计耗时，用于判断该加 workers 还是调 batch_size、连接池（配置文件模式同样生效）")
+		cpuProf     = fs.String("cpu-profile", "", "将 pprof CPU 采样写入该文件（隐含 --profile），可用 go tool pprof 分析")
+		lengthRep   = fs.Bool("length-report", false, "输出按列的长度变化报告，并结合列定义上限标记可能的截断（建议配合 --dry-run，配置文件模式同样生效）")
+		autoWiden   = fs.Bool("auto-widen", false, "仅限 dry-run：为转换后会溢出的字符列生成 ALTER TABLE ... MODIFY COLUMN 加宽语句（只输出，不执行；隐含 --length-report）")
+		maxRuntime  = fs.Duration("max-runtime", 0, "整个进程的运行时间上限（如 30m；默认 0 不限制），到期后处理完当前批次即停止（配置文件模式同样生效）")
+		incCol      = fs.String("incremental-column", "", "增量列（如 updated_at）：仅处理该列 > --since 的行")
+		since       = fs.String("since", "", "增量起点：时间/数值（如 \"2024-01-01 00:00:00\"），或 Go duration（如 24h 表示最近 24 小时）；为空时读取 --watermark-file")
+		watermark   = fs.String("watermark-file", "", "水位文件：成功完成后写入本次读到的增量列最大值，下次运行自动续跑（dry-run 不写入）")
+		metricsOut  = fs.String("metrics", "", "结束时将按表的 Prometheus 文本格式指标写入该文件（配置文件模式同样生效）")
+		metricsURL  = fs.String("metrics-push", "", "结束时将指标推送到 Pushgateway（如 http://pushgateway:9091）")
+		changesOut  = fs.String("dry-run-output", "", "仅限 dry-run：将每一项拟变更（table/key/column/old/new）以 JSONL 写入该文件（配置文件模式同样生效）")
+		eventsOut   = fs.String("events", "", "向前端输出 JSONL 进度事件：文件路径，或父进程传入的文件描述符编号（如 3）（配置文件模式同样生效）")
+		approvedIn  = fs.String("apply-approved", "", "只应用批准清单中的变更（每行一个变更 ID，或筛选后的 --dry-run-output JSONL），其余跳过（配置文件模式同样生效）")
+		backupOut   = fs.String("row-backup", "", "仅限真实写入：每次 UPDATE 前把将被改写的列原值写成还原用 UPDATE 语句（.sql），可 mysql < 文件 回滚（配置文件模式同样生效）")
+		hookCmd     = fs.String("post-batch-cmd", "", "每批真实写入完成后执行的命令，占位符 {table} {batch} {rows} {changed} {failed} {done} {pk_from} {pk_to}（不经过 shell；配置文件模式同样生效）")
+		hookURL     = fs.String("post-batch-webhook", "", "每批真实写入完成后向该地址 POST 本批统计（JSON；配置文件模式同样生效）")
+		hookAbort   = fs.Bool("post-batch-abort", false, "批次钩子失败时中止该表（默认只记录日志并继续）")
 	)
 
 	var pks multiCSV
 	var idBy multiCSV
 	fs.Var(&pks, "pk", "主键列名（可多次指定或逗号分隔，支持复合主键）")
-	fs.Var(&idBy, "identify-by", "无主键时用于定位的列（可多次指定或逗号分隔）")
+	fs.Var(&idBy, "identify-by", "无主键时用于定位的列（可多次指定或逗号分隔；未提供时自动选用 NOT NULL 唯一索引）")
+	idUnique := fs.Bool("identify-unique", false, "要求 --identify-by 被唯一索引覆盖，否则报错（默认仅告警）")
+	strictAff := fs.Bool("strict-affected", false, "按主键/identify-by 的 UPDATE 影响超过 1 行时中止（默认仅告警并计入统计）")
+	retryMax := fs.Int("query-retry-max", 3, "批次查询遇暂时性错误（死锁、锁等待超时等）的最大重试次数（默认 3，0 或负数不重试）；语法错误、未知列等立即中止")
+	retryDelay := fs.Duration("query-retry-delay", 5*time.Second, "查询重试间隔（默认 5s）")
+	prefilter := fs.Bool("prefilter-nonascii", false, "只读取至少一个 --columns 列含非 ASCII 字符的行（默认 false，仅适用于 utf8mb4/gbk 等多字节字符集）")
+	connTimeout := fs.Duration("connect-timeout", 10*time.Second, "建立数据库连接的超时（默认 10s），不可达时快速失败")
+	var pkMin, pkMax optInt64
+	fs.Var(&pkMin, "pk-min", "只处理首个主键列 >= N 的行（数值主键，需 --pk），与 --pk-max 组成闭区间，用于把大表分片到多台机器")
+	fs.Var(&pkMax, "pk-max", "只处理首个主键列 <= N 的行（数值主键，需 --pk）")
+	keysFile := fs.String("keys-file", "", "只处理清单中的主键对应的行（CSV，每行一个主键元组，复合主键各列逗号分隔；需 --pk）")
+	stateDir := fs.String("state-dir", internal.DefaultStateDir, "状态目录：未指定 --watermark-file 时增量水位保存在这里（配置文件模式使用配置中的 state_dir）")
+	countMode := fs.String("count-mode", internal.CountExact, "进度条总量来源：exact（COUNT(*)）| information_schema（近似值，超大表启动更快）| none（不统计）")
+	termRep := fs.Int("term-report", 0, "仅限 dry-run：结束时输出替换次数最多的 N 个词条（原词 -> 替换为），用于发现系统性误转（默认 0 不统计，配置文件模式同样生效）")
+	verify := fs.Bool("verify", false, "真实写入后按主键逐批回读已更新的行，核对库中的值与拟写入值，不一致时告警并计入统计（需 --pk；配置文件模式使用 verify）")
+	failOnVerify := fs.Bool("fail-on-verify", false, "写后校验发现不一致时以退出码 1 结束（单表模式隐含 --verify，配置文件模式同样生效）")
+	maxErrors := fs.Int("max-errors", 1000, "熔断：累计 UPDATE/转换失败的行与写后校验不一致超过 N 时中止（默认 1000，0 为首个错误即中止，负数不限制；配置文件模式使用 max_errors）")
+	heavyRPS := fs.Int("heavy-index-rps", 0, "目标列带 FULLTEXT/SPATIAL 索引时，该表每秒最多处理的行数（默认 0 仅告警；配置文件模式使用 heavy_index_rps）")
+	wsEmpty := fs.Bool("treat-whitespace-empty", false, "只含空白字符（含全角空格）的值与空串一样跳过（默认 false；NULL 与空串始终跳过；配置文件模式使用 treat_whitespace_empty）")
+	allowEmpty := fs.Bool("allow-empty-result", false, "允许非空值的转换结果为空串并写入（默认拒绝：视为转换失败、原值不变；配置文件模式使用 allow_empty_result）")
+	rowsThreshold := fs.Int64("confirm-rows-threshold", 0, "真实写入前先只读统计将更新的行数，超过 N 行时要求输入 yes 确认，不超过则直接写入（默认 0 不统计、不确认；配置文件模式同样生效）")
+	shadow := fs.Bool("shadow-table", false, "仅限 dry-run：将转换结果写入影子表 <table>_tradify_preview（主键 + 待转换列），原表不变（需 --pk；配置文件模式使用 shadow_table）")
+	// 开发用隐藏参数（不出现在 --help 中）：模拟运行，不连数据库
+	pretendRows := fs.Int64("pretend-rows", 0, "开发用：模拟处理 N 行（不连接数据库、不读写文件），用于调试进度条与中止流程")
+	pretendTables := fs.Int("pretend-tables", 3, "开发用：模拟表数量（默认 3）")
+	pretendDelay := fs.Duration("pretend-delay", time.Millisecond, "开发用：每行的模拟处理耗时（默认 1ms）")
+	var printCfg printConfigFlag
+	fs.Var(&printCfg, "print-config", "仅限配置文件模式：以 JSON 输出合并默认值与表级覆盖后各表实际生效的配置（DSN 隐去密码）后退出；--print-config=continue 输出到标准错误后继续运行")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `用法：
@@ -93,8 +192,18 @@ func runMySQL(args []string) {
      tradify-cli mysql --dsn "user:pass@tcp(127.0.0.1:3306)/db?charset=utf8mb4&parseTime=true" \
        --table articles --pk id --columns "title,content" --to s2twp --batch-size 200 --workers 10 --dry-run=true
 
-  3) 生成配置模板：
+  3) 生成配置模板（--preset 按场景生成，--list 查看全部预设）：
      tradify-cli mysql gen-config --dir ./configs
+     tradify-cli mysql gen-config --dir ./configs --preset no-pk
+
+  4) 整库模式（自动发现所有表的文本列）：
+     tradify-cli mysql all --dsn "..." --exclude-tables "log_*" --manifest ./all.manifest
+
+  5) 检查配置（默认离线，适合 CI）：
+     tradify-cli mysql validate --conf ./configs [--check-schema]
+
+  6) 只读预览数据变更与所需 DDL（加宽 / SET、ENUM 成员 / 注释）：
+     tradify-cli mysql plan --conf ./configs [--format json] [--sql-out plan.sql] [--apply]
 
 说明：
   - 配置文件模式与单表模式**互斥**。若提供 --conf，将忽略 --table/--columns 等单表参数。
@@ -102,7 +211,7 @@ func runMySQL(args []string) {
 
 参数（单表模式）：
 `)
-		fs.PrintDefaults()
+		printDefaults(fs, "pretend-rows", "pretend-tables", "pretend-delay")
 		fmt.Fprintf(os.Stderr, `
 示例（复合主键 & 真实写入）：
   tradify-cli mysql --dsn "user:pass@tcp(127.0.0.1:3306)/mydb" \
@@ -112,6 +221,68 @@ func runMySQL(args []string) {
 	if err := fs.Parse(args); err != nil {
 		os.Exit(2)
 	}
+	checkSummaryFormat(*summary)
+	stats := &internal.Stats{}
+	stopProfile := startProfile(stats, *profile, *cpuProf)
+	var lengths *internal.LengthReport
+	if *lengthRep || *autoWiden {
+		lengths = internal.NewLengthReport()
+	}
+	terms := newTermReport(*termRep)
+	metrics := newMetrics(*metricsOut, *metricsURL)
+	changes := openChangeLog(*changesOut)
+	events := openEvents(*eventsOut)
+	approved := loadApprovals(*approvedIn)
+	backup := openRowBackup(*backupOut)
+	hook := newBatchHook(*hookCmd, *hookURL, *hookAbort)
+	runReport := internal.NewRunReport(*reportOut, os.Args[1:])
+	report := func() {
+		stopProfile()
+		closeChangeLog(changes)
+		closeEvents(events)
+		closeRowBackup(backup, *backupOut)
+		reportApprovals(approved)
+		stats.WriteSummary(os.Stdout, *summary)
+		if lengths != nil {
+			lengths.Write(os.Stdout)
+		}
+		if *autoWiden {
+			lengths.WriteWidenSQL(os.Stdout)
+		}
+		if terms != nil {
+			terms.Write(os.Stdout, *termRep)
+		}
+		exportMetrics(metrics, *metricsOut, *metricsURL)
+		writeRunReport(runReport, stats)
+	}
+	ctx, cancel := runContext(*maxRuntime)
+	defer cancel()
+
+	// 模拟运行：忽略 --conf / --dsn 等参数，只驱动进度条、统计与汇总输出
+	if *pretendRows != 0 {
+		if *pretendRows < 0 || *pretendTables <= 0 || *pretendDelay < 0 {
+			fmt.Fprintln(os.Stderr, "--pretend-rows、--pretend-tables 须大于 0，--pretend-delay 不可为负")
+			os.Exit(2)
+		}
+		rs, err := internal.RunPretend(ctx, internal.PretendConfig{
+			Rows:      *pretendRows,
+			Tables:    *pretendTables,
+			Delay:     *pretendDelay,
+			BatchSize: *batchSize,
+			Stats:     stats,
+			Metrics:   metrics,
+			Events:    events,
+		})
+		runReport.Add("模拟运行（--pretend-rows，非真实数据）", rs, err)
+		report()
+		if err != nil {
+			exitStopped(err, *maxRuntime)
+			fmt.Fprintf(os.Stderr, "模拟运行失败：%v\n", err)
+			os.Exit(exitCode(err))
+		}
+		fmt.Fprintln(os.Stderr, "[模拟] 以上为模拟运行结果，未连接数据库")
+		return
+	}
 
 	// 如果使用 --conf，则走配置文件模式
 	if *confPath != "" {
@@ -124,33 +295,125 @@ func runMySQL(args []string) {
 			fmt.Fprintln(os.Stderr, "未在目标找到任何 .json 配置文件")
 			os.Exit(2)
 		}
+		if printCfg.mode == "exit" {
+			for _, p := range paths {
+				cfg, err := internal.LoadMySQLFileConfig(p)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "解析配置失败 %s：%v\n", p, err)
+					os.Exit(exitCode(err))
+				}
+				printEffectiveConfig(os.Stdout, p, cfg)
+			}
+			return
+		}
 		for _, p := range paths {
 			cfg, err := internal.LoadMySQLFileConfig(p)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "解析配置失败 %s：%v\n", p, err)
-				os.Exit(1)
+				os.Exit(exitCode(err))
+			}
+			if printCfg.mode == "continue" {
+				printEffectiveConfig(os.Stderr, p, cfg)
+			}
+			if *autoWiden && !cfg.DryRun {
+				fmt.Fprintf(os.Stderr, "--auto-widen 仅可用于 dry_run=true 的配置：%s\n", p)
+				os.Exit(2)
+			}
+			if *changesOut != "" && !cfg.DryRun {
+				fmt.Fprintf(os.Stderr, "--dry-run-output 仅可用于 dry_run=true 的配置：%s\n", p)
+				os.Exit(2)
 			}
-			if err := internal.RunMySQLFromFileConfig(cfg, filepath.Dir(p)); err != nil {
+			if terms != nil && !cfg.DryRun {
+				fmt.Fprintf(os.Stderr, "--term-report 仅可用于 dry_run=true 的配置：%s\n", p)
+				os.Exit(2)
+			}
+			if backup != nil && cfg.DryRun {
+				fmt.Fprintf(os.Stderr, "--row-backup 仅可用于 dry_run=false 的配置：%s\n", p)
+				os.Exit(2)
+			}
+			if !cfg.DryRun {
+				confirmLargeWrite(*rowsThreshold, func() (int64, error) { return internal.CountRowsToChange(ctx, cfg, filepath.Dir(p)) })
+			}
+			rs, err := internal.RunMySQLFromFileConfig(ctx, cfg, filepath.Dir(p), internal.MySQLRunOptions{
+				Stats: stats, LengthReport: lengths, TermReport: terms, Metrics: metrics, ChangeLog: changes,
+				Events: events, Approved: approved, RowBackup: backup, BatchHook: hook,
+			})
+			runReport.Add("配置 "+p, rs, err)
+			if err != nil {
+				report()
+				exitStopped(err, *maxRuntime)
 				fmt.Fprintf(os.Stderr, "执行失败（配置 %s）：%v\n", p, err)
-				os.Exit(1)
+				os.Exit(exitCode(err))
 			}
 		}
+		report()
+		exitVerifyFailed(stats, *failOnVerify)
 		return
 	}
 
 	// 单表模式校验
+	if printCfg.mode != "" {
+		fmt.Fprintln(os.Stderr, "--print-config 仅可用于配置文件模式（--conf）")
+		os.Exit(2)
+	}
 	if *dsn == "" || *table == "" || *columnsStr == "" {
 		fs.Usage()
 		os.Exit(2)
 	}
+	if *autoWiden && !*dryRun {
+		fmt.Fprintln(os.Stderr, "--auto-widen 仅可与 --dry-run=true 一起使用")
+		os.Exit(2)
+	}
+	if *changesOut != "" && !*dryRun {
+		fmt.Fprintln(os.Stderr, "--dry-run-output 仅可与 --dry-run=true 一起使用")
+		os.Exit(2)
+	}
+	if terms != nil && !*dryRun {
+		fmt.Fprintln(os.Stderr, "--term-report 仅可与 --dry-run=true 一起使用")
+		os.Exit(2)
+	}
+	if backup != nil && *dryRun {
+		fmt.Fprintln(os.Stderr, "--row-backup 仅可与 --dry-run=false 一起使用")
+		os.Exit(2)
+	}
+	if (*verify || *failOnVerify) && *dryRun {
+		fmt.Fprintln(os.Stderr, "--verify / --fail-on-verify 仅可与 --dry-run=false 一起使用")
+		os.Exit(2)
+	}
+	if *shadow && (!*dryRun || len(pks.Values()) == 0) {
+		fmt.Fprintln(os.Stderr, "--shadow-table 仅可与 --dry-run=true 一起使用，且需提供 --pk")
+		os.Exit(2)
+	}
+
+	var keys []internal.KeyTuple
+	if *keysFile != "" {
+		k, err := internal.LoadKeysFile(*keysFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if len(k) == 0 {
+			fmt.Fprintf(os.Stderr, "主键清单 %s 为空\n", *keysFile)
+			os.Exit(2)
+		}
+		keys = k
+	}
 
 	cfg := internal.MySQLConfig{
 		DSN:             *dsn,
+		ReadDSN:         *readDSN,
 		Table:           *table,
 		PK:              pks.Values(),
 		IdentifyBy:      idBy.Values(),
 		Columns:         internal.SplitCSV(*columnsStr),
+		SelectSQL:       *selectSQL,
 		To:              *to,
+		Normalize:       *normalize,
+		NormalizeInput:  *normInput,
+		PunctOnly:       *punctOnly,
+		Smart:           *smart,
+		ConvertKeys:     *convertKeys,
+		ExplainSkip:     *explainSkip,
 		BatchSize:       *batchSize,
 		Workers:         *workers,
 		RPS:             *rps,
@@ -158,99 +421,1393 @@ func runMySQL(args []string) {
 		MaxOpenConns:    *maxOpen,
 		MaxIdleConns:    *maxIdle,
 		ConnMaxLifetime: *connLife,
+		Stats:           stats,
+		LengthReport:    lengths,
+		TermReport:      terms,
+		Metrics:         metrics,
+		ChangeLog:       changes,
+		Events:          events,
+		Approved:        approved,
+		RowBackup:       backup,
+		BatchHook:       hook,
+
+		StreamResults:     *stream,
+		InterpolateParams: *interp,
+
+		IncrementalColumn: *incCol,
+		Since:             *since,
+		WatermarkFile:     *watermark,
+		IdentifyUnique:    *idUnique,
+		StrictAffected:    *strictAff,
+		QueryRetryMax:     *retryMax,
+		QueryRetryDelay:   *retryDelay,
+		PrefilterNonASCII: *prefilter,
+		ConnectTimeout:    *connTimeout,
+		Keys:              keys,
+		PKMin:             pkMin.v,
+		PKMax:             pkMax.v,
+		StateDir:          internal.ResolveStateDir(*stateDir, "."),
+		CountMode:         *countMode,
+		Shadow:            *shadow,
+		Verify:            *verify || *failOnVerify,
+		HeavyIndexRPS:     *heavyRPS,
+		MaxErrors:         *maxErrors,
+
+		TreatWhitespaceEmpty: *wsEmpty,
+		AllowEmptyResult:     *allowEmpty,
+		SessionCollation:     *collation,
+		Isolation:            *isolation,
 	}
 
-	if err := internal.RunMySQL(cfg); err != nil {
+	if !*dryRun {
+		confirmLargeWrite(*rowsThreshold, func() (int64, error) { return internal.CountTableRowsToChange(ctx, cfg) })
+	}
+	rs, err := internal.RunMySQL(ctx, cfg)
+	runReport.Add("", rs, err)
+	if err != nil {
+		report()
+		exitStopped(err, *maxRuntime)
 		fmt.Fprintf(os.Stderr, "运行失败：%v\n", err)
+		os.Exit(exitCode(err))
+	}
+	report()
+	exitVerifyFailed(stats, *failOnVerify)
+}
+
+// startProfile 按 --profile / --cpu-profile 开启耗时分解与 CPU 采样，返回结束采样的函数（可重复调用）
+func startProfile(stats *internal.Stats, enabled bool, cpuPath string) func() {
+	if !enabled && cpuPath == "" {
+		return func() {}
+	}
+	stats.EnableTiming()
+	if cpuPath == "" {
+		return func() {}
+	}
+	f, err := os.Create(cpuPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "创建 CPU profile 文件失败：%v\n", err)
+		os.Exit(1)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		fmt.Fprintf(os.Stderr, "开启 CPU profile 失败：%v\n", err)
+		os.Exit(1)
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			pprof.StopCPUProfile()
+			if err := f.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "写 CPU profile 失败：%v\n", err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "CPU profile 已写入 %s（go tool pprof %s）\n", cpuPath, cpuPath)
+		})
+	}
+}
+
+// printDefaults 同 fs.PrintDefaults，但不列出 hidden 中的参数（开发用参数）
+func printDefaults(fs *flag.FlagSet, hidden ...string) {
+	visible := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	visible.SetOutput(fs.Output())
+	fs.VisitAll(func(f *flag.Flag) {
+		if !slices.Contains(hidden, f.Name) {
+			visible.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	visible.PrintDefaults()
+}
+
+// unescapeFlag 解释参数值中的 \n \t \\（命令行中不便直接输入换行）
+func unescapeFlag(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t").Replace(s)
+}
+
+// exitCode 按错误分类映射退出码：配置无效 2，数据库连接失败 4，转换失败 5，行更新失败 6，文件读写失败 7，其余 1
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, internal.ErrConfigInvalid):
+		return 2
+	case errors.Is(err, internal.ErrDBConnect):
+		return 4
+	case errors.Is(err, internal.ErrConvert):
+		return 5
+	case errors.Is(err, internal.ErrRowUpdate):
+		return 6
+	case errors.Is(err, internal.ErrIO):
+		return 7
+	}
+	return 1
+}
+
+// exitVerifyFailed --fail-on-verify 且写后校验有不一致时以退出码 1 结束
+func exitVerifyFailed(stats *internal.Stats, failOnVerify bool) {
+	if n := stats.Snapshot().VerifyFailed; n > 0 && failOnVerify {
+		fmt.Fprintf(os.Stderr, "写后校验发现 %d 处不一致（--fail-on-verify），详见上方日志\n", n)
 		os.Exit(1)
 	}
 }
 
+// runContext 返回随 SIGINT/SIGTERM 取消、并受 maxRuntime 约束的上下文。
+// 首次信号触发安全停止，之后恢复默认行为（再按一次 Ctrl+C 立即退出）
+func runContext(maxRuntime time.Duration) (context.Context, context.CancelFunc) {
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCtx.Done()
+		stop()
+	}()
+	if maxRuntime <= 0 {
+		return sigCtx, stop
+	}
+	ctx, cancel := context.WithTimeout(sigCtx, maxRuntime)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// exitStopped 若错误来自中止（超时/信号），输出说明并以退出码 3 结束
+func exitStopped(err error, maxRuntime time.Duration) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		fmt.Fprintf(os.Stderr, "已达到 --max-runtime（%s），已在批次边界安全停止，进度见上方日志\n", maxRuntime)
+	case errors.Is(err, context.Canceled):
+		fmt.Fprintln(os.Stderr, "收到中止信号，已在批次边界安全停止，进度见上方日志")
+	default:
+		return
+	}
+	os.Exit(3)
+}
+
 // -------------- mysql gen-config --------------
 
 func runGenConfig(args []string) {
 	fs := flag.NewFlagSet("mysql gen-config", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	dir := fs.String("dir", ".", "模板生成目录（默认当前目录）")
+	preset := fs.String("preset", internal.PresetFull, "模板预设（默认 full 完整模板）；--list 查看全部预设")
+	name := fs.String("name", "", "模板文件名标识：生成 tradify_config_<name>.json（默认 full 为 template，其余为预设名）")
+	list := fs.Bool("list", false, "列出可用的模板预设后退出")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, `用法：tradify-cli mysql gen-config [--dir 目录]
+		fmt.Fprintf(os.Stderr, `用法：tradify-cli mysql gen-config [--dir 目录] [--preset 预设] [--name 标识] [--list]
 
 说明：
-  在指定目录生成 JSON 配置模板（含字段解释与示例）。
+  在指定目录生成 JSON 配置模板（含字段解释与示例）。--preset 按场景生成只含相关字段的模板，
+  便于从接近自己需求的配置开始修改。
 
+参数：
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
 示例：
   tradify-cli mysql gen-config --dir ./configs
+  tradify-cli mysql gen-config --list
+  tradify-cli mysql gen-config --dir ./configs --preset incremental --name posts_daily
 `)
 	}
 	if err := fs.Parse(args); err != nil {
 		os.Exit(2)
 	}
 
-	path, err := internal.GenerateConfigTemplate(*dir)
+	if *list {
+		for _, p := range internal.ConfigPresets() {
+			fmt.Printf("%-22s %s\n", p.Name, p.Desc)
+		}
+		return
+	}
+	path, err := internal.GenerateConfigPreset(*dir, *preset, *name)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "生成模板失败：%v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 	fmt.Printf("模板已生成：%s\n", path)
 }
 
-// -------------- file 子命令 --------------
+// mysql validate：离线检查配置文件，--check-schema 时再连库核对表与列
+func runMySQLValidate(args []string) {
+	fs := flag.NewFlagSet("mysql validate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	confPath := fs.String("conf", "", "【必填】配置文件或目录路径（目录时检查其中所有 *.json）")
+	checkSchema := fs.Bool("check-schema", false, "同时连接数据库，核对表与 columns/pk/identify_by/incremental_column 是否存在（默认 false，完全离线）")
 
-func runFile(args []string) {
-	fs := flag.NewFlagSet("file", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `用法：tradify-cli mysql validate --conf <文件或目录> [--check-schema]
+
+说明：
+  默认完全离线（不连接数据库）：检查 JSON 结构与未知字段、duration、转换配置、枚举取值，
+  以及表条目的逻辑一致性（重复的表/列、pk 与 select_sql/keys 等字段的依赖），适合在无数据库的 CI 中运行。
+  一次列出所有问题（指向 tables[序号].字段），有问题时退出码为 1。
+
+参数：
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	if *confPath == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	paths, err := internal.ResolveConfigTargets(*confPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取配置失败：%v\n", err)
+		os.Exit(1)
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "未在目标找到任何 .json 配置文件")
+		os.Exit(2)
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+	failed := 0
+	for _, p := range paths {
+		cfg, issues := internal.LintMySQLFileConfig(p)
+		if len(issues) == 0 && *checkSchema {
+			issues = internal.CheckConfigSchema(ctx, cfg)
+		}
+		if len(issues) == 0 {
+			fmt.Printf("[OK] %s\n", p)
+			continue
+		}
+		failed++
+		fmt.Printf("[FAIL] %s（%d 个问题）\n", p, len(issues))
+		for _, i := range issues {
+			fmt.Printf("  - %s\n", i)
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// mysql plan：只读试运行配置，输出数据变更预估与 DDL 的合并计划
+func runMySQLPlan(args []string) {
+	fs := flag.NewFlagSet("mysql plan", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	confPath := fs.String("conf", "", "【必填】配置文件或目录路径（目录时合并其中所有 *.json 的计划）")
+	format := fs.String("format", "text", "计划输出格式：text | json")
+	sqlOut := fs.String("sql-out", "", "另将 DDL 写入该 .sql 文件，复核后手动执行")
+	apply := fs.Bool("apply", false, "输出计划后应用：先在各配置的主库（dsn）依次执行 DDL，再以 dry_run=false 运行各配置转换数据；有需手动处理的 DDL 时拒绝应用")
+	yes := fs.Bool("yes", false, "配合 --apply：跳过确认提示")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `用法：tradify-cli mysql plan --conf <文件或目录> [--format text|json] [--sql-out plan.sql] [--apply [--yes]]
+
+说明：
+  以只读方式试运行配置（强制 dry_run，忽略 shadow_table / verify，不写水位与清单），输出合并的执行计划：
+  各表扫描 / 将更新 / 失败的行数，以及执行前需要的 DDL——加宽会溢出的列、为 SET/ENUM 补充转换后的成员、
+  转换表与列的注释。同一列的多项变更合并为一条 MODIFY COLUMN。计划期间不执行任何写入或 DDL。
+
+  应用：复核后手动执行 DDL（--sql-out），再以 dry_run=false 运行 tradify-cli mysql --conf 转换数据；
+  或加 --apply 由工具在输出计划后依次执行 DDL 并转换数据（需输入 yes 确认，--yes 跳过）。
+
+参数：
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	if *confPath == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "不支持的 --format：%q（可选 text、json）\n", *format)
+		os.Exit(2)
+	}
+	if *yes && !*apply {
+		fmt.Fprintln(os.Stderr, "--yes 需配合 --apply 使用")
+		os.Exit(2)
+	}
+	paths, err := internal.ResolveConfigTargets(*confPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取配置失败：%v\n", err)
+		os.Exit(1)
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "未在目标找到任何 .json 配置文件")
+		os.Exit(2)
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+	plan := &internal.MySQLPlan{}
+	for _, p := range paths {
+		cfg, err := internal.LoadMySQLFileConfig(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "解析配置失败 %s：%v\n", p, err)
+			os.Exit(exitCode(err))
+		}
+		if err := plan.AddConfig(ctx, cfg, filepath.Dir(p)); err != nil {
+			fmt.Fprintf(os.Stderr, "生成计划失败（配置 %s）：%v\n", p, err)
+			os.Exit(exitCode(err))
+		}
+	}
+
+	if *format == "json" {
+		err = plan.WriteJSON(os.Stdout)
+	} else {
+		err = plan.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "输出计划失败：%v\n", err)
+		os.Exit(1)
+	}
+	if *sqlOut != "" {
+		f, err := os.Create(*sqlOut)
+		if err == nil {
+			err = plan.WriteSQL(f)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "写入 %s 失败：%v\n", *sqlOut, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "DDL 已写入：%s\n", *sqlOut)
+	}
+	if *apply {
+		applyMySQLPlan(ctx, plan, paths, *yes)
+	}
+}
+
+// applyMySQLPlan mysql plan --apply：确认后执行计划中的 DDL，再以 dry_run=false 依次运行各配置转换数据
+func applyMySQLPlan(ctx context.Context, plan *internal.MySQLPlan, paths []string, yes bool) {
+	if manual := plan.ManualDDL(); len(manual) > 0 {
+		fmt.Fprintf(os.Stderr, "计划中有 %d 项变更需手动处理（见上方 DDL 中的说明），已拒绝应用；处理后重新运行 mysql plan\n", len(manual))
+		os.Exit(1)
+	}
+	if !yes && !confirm(fmt.Sprintf("将执行 %d 条 DDL 并更新约 %d 行数据，输入 yes 继续：", len(plan.DDL), plan.RowsToChange())) {
+		fmt.Fprintln(os.Stderr, "已取消")
+		os.Exit(1)
+	}
+	if err := plan.ApplyDDL(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "应用 DDL 失败：%v\n", err)
+		os.Exit(exitCode(err))
+	}
+	stats := &internal.Stats{}
+	for _, p := range paths {
+		cfg, err := internal.LoadMySQLFileConfig(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "解析配置失败 %s：%v\n", p, err)
+			os.Exit(exitCode(err))
+		}
+		cfg.DryRun = false
+		rs, err := internal.RunMySQLFromFileConfig(ctx, cfg, filepath.Dir(p), internal.MySQLRunOptions{Stats: stats})
+		fmt.Fprintf(os.Stderr, "[plan] 配置 %s：%s\n", p, rs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "转换数据失败（配置 %s）：%v\n", p, err)
+			os.Exit(exitCode(err))
+		}
+	}
+	stats.WriteSummary(os.Stderr, "text")
+}
+
+// mysql all：枚举当前库所有基表的文本列，复用配置文件模式的执行流程
+func runMySQLAll(args []string) {
+	fs := flag.NewFlagSet("mysql all", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 
 	var (
-		dir     = fs.String("dir", ".", "【必填】要处理的根目录路径（默认当前目录）")
-		extsCSV = fs.String("ext", "", "过滤的文档扩展名（可逗号分隔，如：.txt,.md；留空表示处理所有文档）")
-		to      = fs.String("to", "s2twp", "OpenCC 转换配置（默认 s2twp）")
-		backup  = fs.Bool("backup", false, "是否对每个被修改的文档生成 .bak 备份（默认 false）")
-		dryRun  = fs.Bool("dry-run", true, "试运行：不写回，仅列出将被修改的文档")
-		workers = fs.Int("workers", 4, "并发 worker 数（缺省 4）")
+		dsn         = fs.String("dsn", "", "【必填】MySQL 连接串（需指定库名）")
+		readDSN     = fs.String("read-dsn", "", "只读副本连接串（可选）：批次 SELECT、计数与表结构读取走副本，UPDATE 仍走 --dsn")
+		typesCSV    = fs.String("types", strings.Join(internal.DefaultSchemaTypes, ","), "要转换的列类型，逗号分隔")
+		exTables    = fs.String("exclude-tables", "", "排除的表，逗号分隔，支持通配符（如 log_*,tmp_*）")
+		exColumns   = fs.String("exclude-columns", "", "排除的列，逗号分隔：col 或 table.col，支持通配符（如 *.password）")
+		manifest    = fs.String("manifest", "", "已完成表清单文件：每张表成功完成后追加表名，重跑时跳过（dry-run 不写入）")
+		resume      = fs.Bool("resume", false, "在 --state-dir 中记录已完成的表，重跑时跳过（未指定 --manifest 时生效）")
+		stateDir    = fs.String("state-dir", internal.DefaultStateDir, "状态目录（--resume 清单等），删除即清空状态")
+		yes         = fs.Bool("yes", false, "真实写入时跳过确认提示")
+		to          = fs.String("to", "s2twp", "OpenCC 转换配置（默认 s2twp）；auto-trad / auto-simp 按内容自动判定方向；用 > 串联多个配置（如 s2t>t2tw，custom:词典文件 为自定义词典级）")
+		normalize   = fs.String("normalize", "none", "转换输出的 Unicode 规范化：nfc | nfkc | none（默认 none）")
+		normInput   = fs.Bool("normalize-input", false, "转换前也对输入做同样的规范化（默认 false）")
+		punctOnly   = fs.Bool("punct-only", false, "仅标点模式：照常转换后还原所有汉字改动，只保留标点、全半角等非汉字改动（配合 --normalize nfkc 使用）")
+		smart       = fs.Bool("smart", false, "按值判定方向（需 s2* 配置或 auto-trad）：以繁体为主的值只用 s2t 修正零散的简体字，以简体为主的值照常转换")
+		convertKeys = fs.Bool("convert-keys", false, "JSON 列的对象键也转换（默认只转换值）；转换后与同一对象中其它键重名的键保留原样并告警")
+		explainSkip = fs.Bool("explain-skip", false, "逐行逐列记录未转换的原因（ascii_only、no_chinese、no_change 等），排查用，日志量大")
+		batchSize   = fs.Int("batch-size", 500, "每批处理行数（默认 500）")
+		workers     = fs.Int("workers", 8, "并发 worker 数（默认 8）")
+		rps         = fs.Int("rps", 0, "每张表每秒最大处理行数（默认 0 不限速）")
+		dryRun      = fs.Bool("dry-run", true, "试运行：不落库，仅打印将运行的更新")
+		parallel    = fs.Int("tables-parallel", 1, "同时并发处理的表数量（默认 1）")
+		tableOrder  = fs.String("table-order", internal.TableOrderConfig, "表的调度顺序：config（枚举顺序）| size-asc | size-desc（按估算行数，降序通常总耗时最短）")
+		inflight    = fs.Int("global-max-inflight", 0, "所有表合计同时进行中的 UPDATE 上限（默认 0 不限制）")
+		globalRPS   = fs.Int("global-rps", 0, "所有表合计每秒最大处理行数（默认 0 不限制），与 --rps 同时生效")
+		maxOpen     = poolSizeVar(fs, "max-open", 200, "每张表连接池的最大打开连接数（默认200）；auto 按实际占用估算（每表 1 个，读游标保持打开时 2 个）")
+		maxIdle     = poolSizeVar(fs, "max-idle", 20, "每张表连接池的最大空闲连接数（默认20）；auto 与 --max-open 相同")
+		connLife    = fs.Duration("conn-max-lifetime", 30*time.Minute, "单连接最大生命周期（默认30m）")
+		summary     = fs.String("summary", "text", "结束时输出统计摘要的格式：text | json")
+		reportOut   = fs.String("report-file", "", "结束时（含失败与中止）把各表结果、合计与统计摘要另存为文本报告")
+		profile     = fs.Bool("profile", false, "在统计摘要中输出耗时分解：转换（CPU）与 SQL / 文件 IO 的累计耗时，用于判断该加 workers 还是调 batch_size、连接池")
+		cpuProf     = fs.String("cpu-profile", "", "将 pprof CPU 采样写入该文件（隐含 --profile），可用 go tool pprof 分析")
+		lengthRep   = fs.Bool("length-report", false, "输出按列的长度变化报告（建议配合 --dry-run）")
+		maxRuntime  = fs.Duration("max-runtime", 0, "整个进程的运行时间上限（默认 0 不限制），到期后处理完当前批次即停止")
+		metricsOut  = fs.String("metrics", "", "结束时将按表的 Prometheus 文本格式指标写入该文件")
+		metricsURL  = fs.String("metrics-push", "", "结束时将指标推送到 Pushgateway（如 http://pushgateway:9091）")
+		changesOut  = fs.String("dry-run-output", "", "仅限 dry-run：将每一项拟变更以 JSONL 写入该文件")
+		eventsOut   = fs.String("events", "", "向前端输出 JSONL 进度事件：文件路径，或父进程传入的文件描述符编号（如 3）")
+		strictAff   = fs.Bool("strict-affected", false, "按主键/identify_by 的 UPDATE 影响超过 1 行时中止该表（默认仅告警并计入统计）")
+		retryMax    = fs.Int("query-retry-max", 3, "批次查询遇暂时性错误的最大重试次数（默认 3，0 或负数不重试）")
+		retryDelay  = fs.Duration("query-retry-delay", 5*time.Second, "查询重试间隔（默认 5s）")
+		prefilter   = fs.Bool("prefilter-nonascii", false, "只读取至少一个目标列含非 ASCII 字符的行（默认 false）")
+		connTO      = fs.Duration("connect-timeout", 10*time.Second, "建立数据库连接的超时（默认 10s），不可达时快速失败")
+		approvedIn  = fs.String("apply-approved", "", "只应用批准清单中的变更（每行一个变更 ID，或筛选后的 --dry-run-output JSONL），其余跳过")
+		termRep     = fs.Int("term-report", 0, "仅限 dry-run：结束时输出替换次数最多的 N 个词条（默认 0 不统计）")
+		countMode   = fs.String("count-mode", internal.CountExact, "进度条总量来源：exact | information_schema | none")
+		shadow      = fs.Bool("shadow-table", false, "仅限 dry-run：将转换结果写入各表的影子表 <table>_tradify_preview，原表不变（无主键表跳过）")
+		verify      = fs.Bool("verify", false, "真实写入后按主键逐批回读核对已更新的值（无主键表不校验）")
+		failVerify  = fs.Bool("fail-on-verify", false, "写后校验发现不一致时以退出码 1 结束（隐含 --verify）")
+		backupOut   = fs.String("row-backup", "", "仅限真实写入：每次 UPDATE 前把将被改写的列原值写成还原用 UPDATE 语句（.sql）")
+		hookCmd     = fs.String("post-batch-cmd", "", "每批真实写入完成后执行的命令，占位符 {table} {batch} {rows} {changed} {failed} {done} {pk_from} {pk_to}（不经过 shell）")
+		hookURL     = fs.String("post-batch-webhook", "", "每批真实写入完成后向该地址 POST 本批统计（JSON）")
+		hookAbort   = fs.Bool("post-batch-abort", false, "批次钩子失败时中止该表（默认只记录日志并继续）")
+		heavyRPS    = fs.Int("heavy-index-rps", 0, "目标列带 FULLTEXT/SPATIAL 索引的表每秒最多处理的行数（默认 0 仅告警）")
+		maxErrors   = fs.Int("max-errors", 1000, "熔断：所有表累计失败的行与写后校验不一致超过 N 时中止（默认 1000，0 为首个错误即中止，负数不限制）")
+		wsEmpty     = fs.Bool("treat-whitespace-empty", false, "只含空白字符的值与空串一样跳过（默认 false）")
+		allowEmpty  = fs.Bool("allow-empty-result", false, "允许非空值的转换结果为空串并写入（默认拒绝，原值不变）")
+		collation   = fs.String("session-collation", "", "每个新连接执行 SET NAMES <字符集> COLLATE <排序规则>（如 utf8mb4_bin）")
+		isolation   = fs.String("isolation", "", "每个新连接设置的事务隔离级别：READ COMMITTED | REPEATABLE READ | READ UNCOMMITTED | SERIALIZABLE（默认沿用服务器设置）")
+		rowsLimit   = fs.Int64("confirm-rows-threshold", 0, "真实写入前先只读统计将更新的行数，仅超过 N 行时才要求确认（默认 0：总是确认）")
 	)
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, `用法：tradify-cli file [参数...]
+		fmt.Fprintf(os.Stderr, `用法：tradify-cli mysql all --dsn "..." [参数...]
 
 说明：
-  递归遍历目录，将匹配扩展名的文档内容从简体转换为繁体（默认 s2twp）。
-  支持 dry-run 试运行与备份。
+  枚举库中所有基表，自动选出指定类型的文本列（主键列除外）逐表转换。
+  影响范围大：建议先 dry-run 并配合 --length-report 评估；真实写入前会列出计划并要求输入 yes 确认。
+  配合 --manifest 可在中断后重跑时跳过已完成的表。
 
 参数：
 `)
 		fs.PrintDefaults()
 		fmt.Fprintf(os.Stderr, `
 示例：
-  1) 处理当前目录所有 .txt 与 .md 文档，先试运行：
-     tradify-cli file --dir . --ext ".txt,.md" --dry-run=true
-
-  2) 实际写回并按需备份：
-     tradify-cli file --dir /var/www --ext ".php" --backup --dry-run=false
+  tradify-cli mysql all --dsn "user:pass@tcp(127.0.0.1:3306)/mydb?charset=utf8mb4" \
+    --exclude-tables "log_*" --exclude-columns "*.password" --manifest ./mydb.manifest --dry-run=false
 `)
 	}
-
 	if err := fs.Parse(args); err != nil {
 		os.Exit(2)
 	}
+	if *dsn == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *changesOut != "" && !*dryRun {
+		fmt.Fprintln(os.Stderr, "--dry-run-output 仅可与 --dry-run=true 一起使用")
+		os.Exit(2)
+	}
+	if *termRep > 0 && !*dryRun {
+		fmt.Fprintln(os.Stderr, "--term-report 仅可与 --dry-run=true 一起使用")
+		os.Exit(2)
+	}
+	if *shadow && !*dryRun {
+		fmt.Fprintln(os.Stderr, "--shadow-table 仅可与 --dry-run=true 一起使用")
+		os.Exit(2)
+	}
+	if *backupOut != "" && *dryRun {
+		fmt.Fprintln(os.Stderr, "--row-backup 仅可与 --dry-run=false 一起使用")
+		os.Exit(2)
+	}
+	checkSummaryFormat(*summary)
+	stats := &internal.Stats{}
+	stopProfile := startProfile(stats, *profile, *cpuProf)
+	var lengths *internal.LengthReport
+	if *lengthRep {
+		lengths = internal.NewLengthReport()
+	}
+	terms := newTermReport(*termRep)
+	metrics := newMetrics(*metricsOut, *metricsURL)
+	changes := openChangeLog(*changesOut)
+	events := openEvents(*eventsOut)
+	approved := loadApprovals(*approvedIn)
+	backup := openRowBackup(*backupOut)
+	hook := newBatchHook(*hookCmd, *hookURL, *hookAbort)
+	runReport := internal.NewRunReport(*reportOut, os.Args[1:])
+	report := func() {
+		stopProfile()
+		closeChangeLog(changes)
+		closeEvents(events)
+		closeRowBackup(backup, *backupOut)
+		reportApprovals(approved)
+		stats.WriteSummary(os.Stdout, *summary)
+		if lengths != nil {
+			lengths.Write(os.Stdout)
+		}
+		if terms != nil {
+			terms.Write(os.Stdout, *termRep)
+		}
+		exportMetrics(metrics, *metricsOut, *metricsURL)
+		writeRunReport(runReport, stats)
+	}
+	ctx, cancel := runContext(*maxRuntime)
+	defer cancel()
 
-	exts := internal.SplitCSV(*extsCSV)
-	cfg := internal.FileConfig{
-		RootDir: *dir,
-		Exts:    exts,
-		To:      *to,
-		Backup:  *backup,
-		DryRun:  *dryRun,
-		Workers: *workers,
+	schemaDSN := *dsn
+	if *readDSN != "" {
+		schemaDSN = *readDSN // 表结构读取走只读副本
+	}
+	tables, err := internal.DiscoverSchemaTables(ctx, schemaDSN, internal.SchemaDiscoverOptions{
+		Types:          internal.SplitCSV(*typesCSV),
+		ExcludeTables:  internal.SplitCSV(*exTables),
+		ExcludeColumns: internal.SplitCSV(*exColumns),
+		ConnectTimeout: *connTO,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "发现表失败：%v\n", err)
+		os.Exit(exitCode(err))
+	}
+	if *shadow {
+		// 影子表按主键与原表对照，无主键表无法生成
+		kept := tables[:0]
+		for _, t := range tables {
+			if len(t.PK) == 0 {
+				fmt.Fprintf(os.Stderr, "表 %s 无主键，影子表模式下跳过\n", t.Table)
+				continue
+			}
+			kept = append(kept, t)
+		}
+		tables = kept
+	}
+	if len(tables) == 0 {
+		fmt.Fprintln(os.Stderr, "未发现任何包含待转换文本列的表")
+		return
 	}
 
-	if err := internal.RunFile(cfg); err != nil {
-		fmt.Fprintf(os.Stderr, "运行失败：%v\n", err)
+	fmt.Fprintf(os.Stderr, "将处理 %d 张表：\n", len(tables))
+	for _, t := range tables {
+		key := "无主键"
+		if len(t.PK) > 0 {
+			key = "pk=" + strings.Join(t.PK, ",")
+		}
+		fmt.Fprintf(os.Stderr, "  %s（%s）：%s\n", t.Table, key, strings.Join(t.Columns, ","))
+	}
+	if !*dryRun && !*yes && *rowsLimit <= 0 && !confirm(fmt.Sprintf("即将对以上 %d 张表真实写入，输入 yes 继续：", len(tables))) {
+		fmt.Fprintln(os.Stderr, "已取消")
+		os.Exit(1)
+	}
+
+	cfg := &internal.MySQLFileConfig{
+		DSN:             *dsn,
+		ReadDSN:         *readDSN,
+		To:              *to,
+		Normalize:       *normalize,
+		NormalizeInput:  *normInput,
+		PunctOnly:       *punctOnly,
+		Smart:           *smart,
+		ConvertKeys:     *convertKeys,
+		ExplainSkip:     *explainSkip,
+		BatchSize:       *batchSize,
+		Workers:         *workers,
+		RPS:             *rps,
+		DryRun:          *dryRun,
+		MaxOpenConns:    *maxOpen,
+		MaxIdleConns:    *maxIdle,
+		ConnMaxLifetime: connLife.String(),
+		TablesParallel:  *parallel,
+		TableOrder:      *tableOrder,
+		Manifest:        *manifest,
+		StrictAffected:  *strictAff,
+		Tables:          tables,
+
+		QueryRetryMax:   retryMax,
+		QueryRetryDelay: retryDelay.String(),
+
+		PrefilterNonASCII: *prefilter,
+		ConnectTimeout:    connTO.String(),
+
+		GlobalMaxInflight: *inflight,
+		GlobalRPS:         *globalRPS,
+
+		StateDir: *stateDir,
+		Resume:   *resume,
+
+		CountMode: *countMode,
+
+		ShadowTable: *shadow,
+		Verify:      *verify || *failVerify,
+
+		HeavyIndexRPS: *heavyRPS,
+		MaxErrors:     maxErrors,
+
+		TreatWhitespaceEmpty: *wsEmpty,
+		AllowEmptyResult:     *allowEmpty,
+		SessionCollation:     *collation,
+		Isolation:            *isolation,
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "配置无效：%v\n", err)
+		os.Exit(2)
+	}
+	if !*dryRun && !*yes {
+		confirmLargeWrite(*rowsLimit, func() (int64, error) { return internal.CountRowsToChange(ctx, cfg, ".") })
+	}
+	rs, err := internal.RunMySQLFromFileConfig(ctx, cfg, ".", internal.MySQLRunOptions{
+		Stats: stats, LengthReport: lengths, TermReport: terms, Metrics: metrics, ChangeLog: changes,
+		Events: events, Approved: approved, RowBackup: backup, BatchHook: hook,
+	})
+	runReport.Add("", rs, err)
+	if err != nil {
+		report()
+		exitStopped(err, *maxRuntime)
+		fmt.Fprintf(os.Stderr, "执行失败：%v\n", err)
+		os.Exit(exitCode(err))
+	}
+	report()
+	exitVerifyFailed(stats, *failVerify)
+}
+
+// confirmLargeWrite 超过 --confirm-rows-threshold 时要求确认（见 internal.ConfirmLargeWrite），统计失败或未确认时退出
+func confirmLargeWrite(threshold int64, count func() (int64, error)) {
+	ok, err := internal.ConfirmLargeWrite(threshold, count, os.Stdin, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "统计将更新的行数失败：%v\n", err)
+		os.Exit(exitCode(err))
+	}
+	if !ok {
+		fmt.Fprintln(os.Stderr, "已取消")
 		os.Exit(1)
 	}
 }
 
-// --------- 工具：支持 --pk/--identify-by 多次/逗号混用 ---------
+// confirm 在终端提示并读取一行，仅输入 yes 视为确认
+func confirm(prompt string) bool {
+	fmt.Fprint(os.Stderr, prompt)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line) == "yes"
+}
+
+// -------------- file 子命令 --------------
+
+func runFile(args []string) {
+	fs := flag.NewFlagSet("file", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var (
+		extsCSV = fs.String("ext", "", "过滤的文档扩展名（可逗号分隔，如：.txt,.md；留空表示处理所有文档）")
+		exclCSV = fs.String("exclude-ext", "", "排除的扩展名（可逗号分隔，如：.png,.jpg,.zip）；--ext 为空时从所有文档中排除，否则从 --ext 中排除")
+		to      = fs.String("to", "s2twp", "OpenCC 转换配置（默认 s2twp）；auto-trad / auto-simp 按内容自动判定方向；用 > 串联多个配置（如 s2t>t2tw，custom:词典文件 为自定义词典级）")
+		backup  = fs.Bool("backup", false, "是否对每个被修改的文档生成 .bak 备份（默认 false；已有内容相同的备份时不重复写入）")
+		dryRun  = fs.Bool("dry-run", true, "试运行：不写回，仅列出将被修改的文档")
+		workers = fs.Int("workers", 4, "并发 worker 数（缺省 4）")
+
+		normalize   = fs.String("normalize", "none", "转换输出的 Unicode 规范化：nfc | nfkc | none（默认 none）")
+		normInput   = fs.Bool("normalize-input", false, "转换前也对输入做同样的规范化（默认 false）")
+		punctOnly   = fs.Bool("punct-only", false, "仅标点模式：照常转换后还原所有汉字改动，只保留标点、全半角等非汉字改动（配合 --normalize nfkc 使用）")
+		explainSkip = fs.Bool("explain-skip", false, "逐个记录未转换的文档及原因（filtered_ext、ignored、ascii_only、no_change 等），排查用")
+		invalidUTF8 = fs.String("invalid-utf8", internal.InvalidUTF8Error, "含无效 UTF-8 字节序列的文档：error（默认，报错跳过）| lenient（无效字节原样保留，只转换其余部分）| replace（替换为 U+FFFD 后转换）")
+		replaceBad  = fs.Bool("replace-invalid", false, "同 --invalid-utf8 replace")
+		eol         = fs.String("eol", "keep", "写回文档的换行风格：keep 保持原文风格（默认；混用的文档原样不动）| lf | crlf；只作用于有转换的文档")
+		summary     = fs.String("summary", "text", "结束时输出统计摘要的格式：text | json")
+		reportOut   = fs.String("report-file", "", "结束时（含失败与中止）把合计与统计摘要另存为文本报告")
+		profile     = fs.Bool("profile", false, "在统计摘要中输出耗时分解：转换（CPU）与读文件、写文件的累计耗时，转换占比高时可加 --workers")
+		cpuProf     = fs.String("cpu-profile", "", "将 pprof CPU 采样写入该文件（隐含 --profile），可用 go tool pprof 分析")
+
+		checksumSkip = fs.Bool("checksum-skip", false, "跳过自上次运行以来未变化的文档（按 mtime/大小/内容哈希判断，默认 false）")
+		cacheFile    = fs.String("cache-file", "", "--checksum-skip 使用的缓存文件路径（默认 <state-dir>/file/cache.json）；--to/--normalize 变化时自动失效")
+		resume       = fs.Bool("resume", false, "在 --state-dir 中记录已处理完的文档，中断后重跑时跳过（--to 等转换参数变化时记录作废；全部成功后删除记录）")
+		stateDir     = fs.String("state-dir", internal.DefaultStateDir, "状态目录：--checksum-skip 缓存与 --resume 记录保存在这里，删除即清空状态")
+
+		rename     = fs.Bool("rename", false, "同时转换文件名（内容写回后改名；目标已存在时告警并跳过）")
+		renameDirs = fs.Bool("rename-dirs", false, "同时转换目录名（根目录除外，所有文件处理完后由深到浅改名）")
+		changesOut = fs.String("dry-run-output", "", "仅限 dry-run：将每个文件的拟变更（逐行差异 / 重命名）以 JSONL 写入该文件")
+		patchOut   = fs.String("patch-out", "", "仅限 dry-run：将所有拟变更写成一份统一 diff（路径相对 --dir），可用 git apply 应用")
+
+		outputDir     = fs.String("output-dir", "", "将转换结果写入该目录（保持相对路径），原文件不动；不可与 --rename/--rename-dirs/--checksum-skip 同用")
+		copyUnchanged = fs.Bool("copy-unchanged", false, "配合 --output-dir：无需转换的文件也复制到输出目录（默认跳过）")
+		concatOut     = fs.String("concat-out", "", "不写回原文件，把所有匹配文档（含无需转换的）转换后的内容按相对路径排序拼接写入该文件；有文档失败时不写入")
+		concatHeader  = fs.String("concat-header", "", "配合 --concat-out：每个文档内容之前写入的标题，占位符 {path} {index}，支持 \\n \\t（如 \"<!-- {path} -->\\n\"）")
+		concatSep     = fs.String("concat-sep", `\n`, "配合 --concat-out：相邻两个文档之间的分隔，支持 \\n \\t（默认一个换行）")
+		eventsOut     = fs.String("events", "", "向前端输出 JSONL 进度事件：文件路径，或父进程传入的文件描述符编号（如 3）")
+		termRep       = fs.Int("term-report", 0, "仅限 dry-run：结束时输出替换次数最多的 N 个词条（默认 0 不统计）")
+		failOnErrors  = fs.Bool("fail-on-errors", false, "有文件处理失败（如无读取权限）时以退出码 1 结束（默认 false，仅在摘要中计数）")
+		onError       = fs.String("on-error", "continue", "文件出错时的处理：continue 记录后继续（默认）/ stop 立即停止派发其余文件、放弃处理中的文件并以退出码 1 结束")
+		pruneBackups  = fs.Bool("prune-backups", false, "处理前后各删除一次此前 --backup 留下、已无用的 .bak（与当前文件相同，或转换后与当前文件相同；本次写入的备份保留，dry-run 下只列出）")
+		requireFM     = fs.String("require-frontmatter", "", "只转换 YAML front-matter 中该字段等于该值的文档，格式 字段=取值（如 lang=zh-CN）；没有 front-matter 或取值不同的文档跳过")
+		rewriteFM     = fs.String("rewrite-frontmatter", "", "配合 --require-frontmatter：转换后把该字段改写为此值（如 zh-TW），避免下次重复转换")
+		noIgnore      = fs.Bool("no-ignore-file", false, "不读取各级目录中的 .tradifyignore（默认按其中类似 .gitignore 的规则跳过文件与目录）")
+		dedupe        = fs.Bool("dedupe-identical-files", false, "内容完全相同的文档只转换一次，结果复用到其余副本（需计算内容哈希，副本从首个文件的输出读回结果，默认 false）")
+		interactive   = fs.Bool("interactive", false, "写回前逐个显示差异并确认 [y]es/[n]o/[a]ll/[q]uit（需终端输入与 --dry-run=false，串行处理）")
+	)
+	var dirs multiCSV
+	fs.Var(&dirs, "dir", "要处理的根目录（可多次指定或逗号分隔，默认当前目录）")
+	pathsFrom := fs.String("paths-from", "", "从文件读取待处理的文件路径（- 为标准输入），代替遍历 --dir；仍按 --ext 过滤（如 git diff --name-only | tradify-cli file --paths-from -）")
+	pathsNUL := fs.Bool("0", false, "配合 --paths-from：路径以 NUL 分隔（git diff -z、find -print0）")
+	gitSince := fs.String("git-since", "", "只处理 --dir（默认当前目录）中自该 git 引用以来有改动的文件（含未提交的修改，不含已删除、未跟踪的文件）；仍按 --ext 过滤")
+	var codeStrings multiCSV
+	fs.Var(&codeStrings, "code-strings", "这些语言的源文件只转换字符串字面量的内容，代码与注释不变（可逗号分隔：php, js, go；js 含 .ts/.jsx 等），其余文件照常整体转换")
+	var extTo multiCSV
+	fs.Var(&extTo, "ext-to", "按文件名后缀选择转换配置：后缀=配置（可多次指定或逗号分隔，如 .zh-TW.md=s2twp,.zh-HK.md=s2hk）；最长后缀优先，未匹配的文件使用 --to")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `用法：tradify-cli file [参数...]
+
+说明：
+  递归遍历目录，将匹配扩展名的文档内容从简体转换为繁体（默认 s2twp）。
+  支持 dry-run 试运行与备份。
+
+参数：
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+示例：
+  1) 处理当前目录所有 .txt 与 .md 文档，先试运行：
+     tradify-cli file --dir . --ext ".txt,.md" --dry-run=true
+
+  2) 实际写回并按需备份：
+     tradify-cli file --dir /var/www --ext ".php" --backup --dry-run=false
+
+  3) 一次处理多个目录（共用一个统计摘要）：
+     tradify-cli file --dir ./docs --dir ./site,./blog --ext ".md" --dry-run=true
+
+  4) CI 中反复运行，只处理有变化的文档：
+     tradify-cli file --dir ./docs --ext ".md" --checksum-skip --cache-file .cache/tradify.json --dry-run=false
+
+  5) 连同文件名与目录名一起转换（先试运行查看改名列表）：
+     tradify-cli file --dir ./docs --rename --rename-dirs --dry-run=true
+
+  6) 不改动原文件，输出一份完整的繁体副本：
+     tradify-cli file --dir ./docs --output-dir ./docs-tw --copy-unchanged --dry-run=false
+
+  7) 敏感配置目录逐个确认后再写回：
+     tradify-cli file --dir ./conf --interactive --dry-run=false
+
+  8) 按地区后缀分别本地化（其余文件仍用 --to）：
+     tradify-cli file --dir ./docs --ext-to .zh-TW.md=s2twp --ext-to .zh-HK.md=s2hk --dry-run=true
+
+  9) 只处理本次改动的文件（路径来自标准输入，-0 为 NUL 分隔）：
+     git diff -z --name-only | tradify-cli file --paths-from - -0 --ext .md --dry-run=false
+
+     或直接由 git 列出 docs 目录中相对 origin/main 的改动：
+     tradify-cli file --dir ./docs --git-since origin/main --ext .md --dry-run=false
+
+  10) 只本地化源码中的字符串字面量（标识符、注释不变）：
+     tradify-cli file --dir ./src --ext .php,.js --code-strings php,js --dry-run=true
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	checkSummaryFormat(*summary)
+	stats := &internal.Stats{}
+	stopProfile := startProfile(stats, *profile, *cpuProf)
+
+	exts := internal.SplitCSV(*extsCSV)
+	cfg := internal.FileConfig{
+		RootDirs: dirs.Values(),
+		Exts:     exts,
+		ExclExts: internal.SplitCSV(*exclCSV),
+		To:       *to,
+		Backup:   *backup,
+		DryRun:   *dryRun,
+		Workers:  *workers,
+
+		Normalize:      *normalize,
+		NormalizeInput: *normInput,
+		PunctOnly:      *punctOnly,
+		ExplainSkip:    *explainSkip,
+		EOL:            *eol,
+		Stats:          stats,
+	}
+	extToMap, err := internal.ParseExtTo(extTo.Values())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	cfg.ExtTo = extToMap
+	cfg.CodeStrings = codeStrings.Values()
+	cfg.PruneBackups = *pruneBackups
+	cfg.DedupeIdentical = *dedupe
+	cfg.NoIgnoreFile = *noIgnore
+	cfg.RequireFrontMatter, cfg.RewriteFrontMatter = *requireFM, *rewriteFM
+	cfg.InvalidUTF8, err = internal.ParseInvalidUTF8(*invalidUTF8)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *replaceBad {
+		if cfg.InvalidUTF8 != internal.InvalidUTF8Error && cfg.InvalidUTF8 != internal.InvalidUTF8Replace {
+			fmt.Fprintln(os.Stderr, "--replace-invalid 不可与 --invalid-utf8 "+cfg.InvalidUTF8+" 同时使用")
+			os.Exit(2)
+		}
+		cfg.InvalidUTF8 = internal.InvalidUTF8Replace
+	}
+	if *pathsFrom != "" {
+		switch {
+		case len(dirs.Values()) > 0:
+			fmt.Fprintln(os.Stderr, "--paths-from 不可与 --dir 同时使用")
+			os.Exit(2)
+		case *renameDirs || *pruneBackups:
+			fmt.Fprintln(os.Stderr, "--paths-from 不可与 --rename-dirs/--prune-backups 同时使用（不遍历目录）")
+			os.Exit(2)
+		}
+		if *pathsFrom == "-" {
+			cfg.PathsFrom = os.Stdin
+		} else {
+			f, err := os.Open(*pathsFrom)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "打开路径清单失败：%v\n", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			cfg.PathsFrom = f
+		}
+		cfg.PathsNUL = *pathsNUL
+	} else if *pathsNUL {
+		fmt.Fprintln(os.Stderr, "-0 需配合 --paths-from 使用")
+		os.Exit(2)
+	}
+	if *gitSince != "" {
+		switch {
+		case *pathsFrom != "":
+			fmt.Fprintln(os.Stderr, "--git-since 不可与 --paths-from 同时使用")
+			os.Exit(2)
+		case *renameDirs || *pruneBackups:
+			fmt.Fprintln(os.Stderr, "--git-since 不可与 --rename-dirs/--prune-backups 同时使用（不遍历目录）")
+			os.Exit(2)
+		}
+		paths, err := internal.GitChangedPaths(dirs.Values(), *gitSince)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		cfg.RootDirs = nil
+		cfg.PathsFrom = bytes.NewReader(paths)
+		cfg.PathsNUL = true
+	}
+	if *checksumSkip {
+		cfg.CacheFile = *cacheFile
+		if cfg.CacheFile == "" {
+			cfg.CacheFile = internal.ResolveStateDir(*stateDir, ".").FileCachePath()
+		}
+	}
+	if *resume {
+		cfg.ResumeFile = internal.ResolveStateDir(*stateDir, ".").FileResumePath()
+	}
+	cfg.Rename = *rename
+	cfg.RenameDirs = *renameDirs
+	if *outputDir != "" {
+		if *rename || *renameDirs || *checksumSkip {
+			fmt.Fprintln(os.Stderr, "--output-dir 不可与 --rename/--rename-dirs/--checksum-skip 同时使用")
+			os.Exit(2)
+		}
+		cfg.OutputDir = *outputDir
+		cfg.CopyUnchanged = *copyUnchanged
+	} else if *copyUnchanged {
+		fmt.Fprintln(os.Stderr, "--copy-unchanged 需配合 --output-dir 使用")
+		os.Exit(2)
+	}
+	if *concatOut != "" {
+		if *outputDir != "" || *rename || *renameDirs || *checksumSkip || *resume || *interactive || *backup {
+			fmt.Fprintln(os.Stderr, "--concat-out 不可与 --output-dir/--rename/--rename-dirs/--checksum-skip/--resume/--interactive/--backup 同时使用")
+			os.Exit(2)
+		}
+		cfg.ConcatOut = *concatOut
+		cfg.ConcatHeader = unescapeFlag(*concatHeader)
+		cfg.ConcatSep = unescapeFlag(*concatSep)
+	}
+	if *changesOut != "" {
+		if !*dryRun {
+			fmt.Fprintln(os.Stderr, "--dry-run-output 仅可与 --dry-run=true 一起使用")
+			os.Exit(2)
+		}
+		cfg.ChangeLog = openChangeLog(*changesOut)
+	}
+	if *patchOut != "" {
+		if !*dryRun {
+			fmt.Fprintln(os.Stderr, "--patch-out 仅可与 --dry-run=true 一起使用")
+			os.Exit(2)
+		}
+		cfg.Patch = openPatch(*patchOut)
+	}
+	if *termRep > 0 {
+		if !*dryRun {
+			fmt.Fprintln(os.Stderr, "--term-report 仅可与 --dry-run=true 一起使用")
+			os.Exit(2)
+		}
+		cfg.TermReport = internal.NewTermReport()
+	}
+
+	if *interactive {
+		switch {
+		case *dryRun:
+			fmt.Fprintln(os.Stderr, "--interactive 需配合 --dry-run=false 使用（只有确认的文件会被写回）")
+			os.Exit(2)
+		case *rename || *renameDirs:
+			fmt.Fprintln(os.Stderr, "--interactive 不可与 --rename/--rename-dirs 同时使用")
+			os.Exit(2)
+		case *pathsFrom == "-":
+			fmt.Fprintln(os.Stderr, "--interactive 从标准输入读取确认，不可与 --paths-from - 同时使用（请改用清单文件）")
+			os.Exit(2)
+		case !stdinIsTerminal():
+			fmt.Fprintln(os.Stderr, "--interactive 需要在终端中运行（标准输入不是 TTY）")
+			os.Exit(2)
+		}
+		cfg.Confirm = promptFile(bufio.NewReader(os.Stdin))
+	}
+	switch *onError {
+	case "continue":
+	case "stop":
+		cfg.StopOnError = true
+	default:
+		fmt.Fprintf(os.Stderr, "不支持的 --on-error：%q（可选 continue、stop）\n", *onError)
+		os.Exit(2)
+	}
+	cfg.Events = openEvents(*eventsOut)
+	// 状态目录与本次写出的报告、输出文件可能位于被处理目录内，不作为文档处理
+	cfg.ExcludePaths = []string{string(internal.ResolveStateDir(*stateDir, ".")), *reportOut, *cpuProf, *changesOut, *patchOut, *eventsOut}
+
+	runReport := internal.NewRunReport(*reportOut, os.Args[1:])
+	results, rs, err := internal.RunFileWithResult(cfg)
+	stopProfile()
+	closeChangeLog(cfg.ChangeLog)
+	closePatch(cfg.Patch)
+	closeEvents(cfg.Events)
+	fmt.Fprintf(os.Stderr, "[file] %s\n", rs)
+	runReport.Add("", rs, err)
+	writeRunReport(runReport, stats)
+	if errors.Is(err, internal.ErrInteractiveQuit) {
+		stats.WriteSummary(os.Stdout, *summary)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(3)
+	}
+	if errors.Is(err, internal.ErrStoppedOnError) {
+		stats.WriteSummary(os.Stdout, *summary)
+		fmt.Fprintf(os.Stderr, "运行失败：%v\n", err)
+		os.Exit(exitCode(err))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "运行失败：%v\n", err)
+		os.Exit(exitCode(err))
+	}
+	stats.WriteSummary(os.Stdout, *summary)
+	if cfg.TermReport != nil {
+		cfg.TermReport.Write(os.Stdout, *termRep)
+	}
+	if *failOnErrors {
+		var failed []string
+		for _, r := range results {
+			if r.Err != nil {
+				failed = append(failed, r.Path)
+			}
+		}
+		if n := len(failed); n > 0 {
+			if n > 5 {
+				failed = append(failed[:5], "…")
+			}
+			fmt.Fprintf(os.Stderr, "有 %d 个文件处理失败（--fail-on-errors）：%s\n", n, strings.Join(failed, ", "))
+			os.Exit(1)
+		}
+	}
+}
+
+// stdinIsTerminal 标准输入是否为终端（字符设备）
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// promptFile 显示差异并读取 y/n/a/q；输入结束（EOF）视为退出
+func promptFile(in *bufio.Reader) func(path, diff string) internal.ConfirmChoice {
+	return func(path, diff string) internal.ConfirmChoice {
+		fmt.Fprintf(os.Stderr, "\n=== %s\n%s", path, diff)
+		for {
+			fmt.Fprint(os.Stderr, "写回该文件？[y]es/[n]o/[a]ll/[q]uit: ")
+			line, err := in.ReadString('\n')
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				return internal.ConfirmYes
+			case "n", "no":
+				return internal.ConfirmNo
+			case "a", "all":
+				return internal.ConfirmAll
+			case "q", "quit":
+				return internal.ConfirmQuit
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr)
+				return internal.ConfirmQuit
+			}
+		}
+	}
+}
+
+// openChangeLog 打开 --dry-run-output 文件；未指定时返回 nil
+func openChangeLog(path string) *internal.ChangeLog {
+	if path == "" {
+		return nil
+	}
+	c, err := internal.NewChangeLog(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return c
+}
+
+func closeChangeLog(c *internal.ChangeLog) {
+	if err := c.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "写变更清单失败：%v\n", err)
+	}
+}
+
+func openPatch(path string) *internal.Patch {
+	p, err := internal.NewPatch(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return p
+}
+
+func closePatch(p *internal.Patch) {
+	if err := p.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "写补丁文件失败：%v\n", err)
+	}
+}
+
+// writeRunReport 写出 --report-file；失败只提示，不影响退出码
+func writeRunReport(r *internal.RunReport, stats *internal.Stats) {
+	if err := r.Write(stats); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// newBatchHook 按 --post-batch-cmd / --post-batch-webhook 创建批次钩子；均未指定时返回 nil
+func newBatchHook(cmdTmpl, webhook string, abort bool) *internal.BatchHook {
+	h, err := internal.NewBatchHook(cmdTmpl, webhook, abort)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return h
+}
+
+// openRowBackup 打开 --row-backup 文件；未指定时返回 nil
+func openRowBackup(path string) *internal.RowBackup {
+	if path == "" {
+		return nil
+	}
+	b, err := internal.NewRowBackup(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return b
+}
+
+func closeRowBackup(b *internal.RowBackup, path string) {
+	if b == nil {
+		return
+	}
+	if err := b.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "写行备份失败：%v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "行备份：已写入 %d 条还原语句 -> %s\n", b.Count(), path)
+}
+
+// newTermReport --term-report N 大于 0 时创建词条报告，否则返回 nil
+func newTermReport(n int) *internal.TermReport {
+	if n <= 0 {
+		return nil
+	}
+	return internal.NewTermReport()
+}
+
+// openEvents 打开 --events 目标；未指定时返回 nil
+func openEvents(target string) *internal.EventStream {
+	if target == "" {
+		return nil
+	}
+	e, err := internal.OpenEventStream(target)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return e
+}
+
+func closeEvents(e *internal.EventStream) {
+	if err := e.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "写进度事件失败：%v\n", err)
+	}
+}
+
+// loadApprovals 读取 --apply-approved 批准清单；未指定时返回 nil（全部放行）
+func loadApprovals(path string) *internal.ApprovalSet {
+	if path == "" {
+		return nil
+	}
+	a, err := internal.LoadApprovals(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return a
+}
+
+func reportApprovals(a *internal.ApprovalSet) {
+	if a == nil {
+		return
+	}
+	matched, unmatched, skipped := a.Report()
+	fmt.Fprintf(os.Stderr, "批准清单：已应用 %d 项，未批准而跳过 %d 项，清单中未匹配到 %d 项\n", matched, skipped, unmatched)
+}
+
+// newMetrics 仅在需要输出指标时创建收集器
+func newMetrics(path, url string) *internal.Metrics {
+	if path == "" && url == "" {
+		return nil
+	}
+	return internal.NewMetrics()
+}
+
+// exportMetrics 写出/推送指标；失败只告警，不影响退出码
+func exportMetrics(m *internal.Metrics, path, url string) {
+	if path != "" {
+		if err := m.WriteFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "写指标文件失败：%v\n", err)
+		}
+	}
+	if url != "" {
+		if err := m.Push(url); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+// checkSummaryFormat 提前校验 --summary，避免跑完才发现格式写错
+func checkSummaryFormat(format string) {
+	if err := (&internal.Stats{}).WriteSummary(io.Discard, format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+
+// -------------- doctor 子命令 --------------
+
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var (
+		dsn     = fs.String("dsn", "", "【必填】MySQL 连接串")
+		to      = fs.String("to", "s2twp", "需要验证的 OpenCC 转换配置（默认 s2twp）")
+		timeout = fs.Duration("timeout", 10*time.Second, "单项数据库检查超时（默认 10s）")
+	)
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `用法：tradify-cli doctor --dsn "..." [--to s2twp]
+
+说明：
+  依次检查：OpenCC 初始化、DSN 解析、服务器可达、连接字符集、SELECT/UPDATE 权限，
+  输出检查清单与修复建议。存在失败项时退出码为 1。
+
+参数：
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	if *dsn == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+	cfg := internal.DoctorConfig{DSN: *dsn, To: *to, Timeout: *timeout}
+	if !internal.RunDoctor(ctx, cfg, os.Stdout) {
+		os.Exit(1)
+	}
+}
+
+// -------------- convert 子命令 --------------
+
+func runConvert(args []string) {
+	if len(args) > 0 && args[0] == "selftest" {
+		runConvertSelfTest(args[1:])
+		return
+	}
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Fprintln(os.Stderr, "用法：tradify-cli convert list [--json] | convert selftest [--to s2twp]")
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("convert list", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	asJSON := fs.Bool("json", false, "以 JSON 数组输出（[{\"name\":\"s2twp\",\"description\":\"...\"}]），供前端下拉框等工具使用")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `用法：tradify-cli convert list [--json]
+
+说明：
+  列出可用于 --to 的转换配置及说明（取自内置 OpenCC 配置），默认输出表格。
+
+参数：
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if err := internal.WriteConvertConfigs(os.Stdout, internal.ConvertConfigs(), *asJSON); err != nil {
+		fmt.Fprintf(os.Stderr, "输出失败：%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runConvertSelfTest(args []string) {
+	fs := flag.NewFlagSet("convert selftest", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	to := fs.String("to", "s2twp", "需要自检的转换配置（默认 s2twp）；all 表示全部内置了用例的配置")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `用法：tradify-cli convert selftest [--to s2twp]
+
+说明：
+  用内置的已知输入/期望输出（标点、常用词、专有名词、规范化）检验转换配置，
+  发现内置 OpenCC 词典缺失、损坏或版本不符。存在不符项时退出码为 1。
+
+参数：
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	tos := []string{*to}
+	if *to == "all" {
+		tos = internal.SelfTestConfigs()
+	}
+	failed := 0
+	for _, t := range tos {
+		n, err := internal.RunSelfTest(t, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "自检失败：%v\n", err)
+			os.Exit(2)
+		}
+		failed += n
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// -------------- serve 子命令 --------------
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var (
+		listen    = fs.String("listen", "127.0.0.1:8080", "监听地址（默认仅本机；对外提供服务时如 0.0.0.0:8080）")
+		to        = fs.String("to", "s2twp", "请求未指定 to 时使用的 OpenCC 转换配置（默认 s2twp）")
+		normalize = fs.String("normalize", "none", "转换输出的 Unicode 规范化：nfc | nfkc | none（默认 none）")
+		maxBody   = fs.Int64("max-body", 1<<20, "单个请求体上限（字节，默认 1MiB），超出返回 413")
+		maxBatch  = fs.Int("max-batch", 1000, "批量接口单次最多的文本数（默认 1000）")
+		grace     = fs.Duration("shutdown-timeout", 10*time.Second, "收到 Ctrl+C/SIGTERM 后等待进行中请求的时长（默认 10s）")
+	)
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `用法：tradify-cli serve [--listen 127.0.0.1:8080] [--to s2twp]
+
+说明：
+  启动常驻 HTTP 服务，转换规则与 mysql/file 子命令一致：
+    POST /convert        {"to":"s2twp","text":"软件"}      -> {"text":"軟體","changed":true}
+    POST /convert/batch  {"to":"s2twp","texts":["软件"]}   -> {"texts":["軟體"],"changed":[true]}
+    GET  /healthz
+  to 可省略（使用 --to）。收到 Ctrl+C/SIGTERM 后优雅停止。
+
+参数：
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+	cfg := internal.ServeConfig{
+		Addr:            *listen,
+		To:              *to,
+		Normalize:       *normalize,
+		MaxBodyBytes:    *maxBody,
+		MaxBatch:        *maxBatch,
+		ShutdownTimeout: *grace,
+	}
+	if err := internal.Serve(ctx, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "服务运行失败：%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// --------- 工具：支持 --pk/--identify-by/--dir 多次/逗号混用 ---------
+
+// optInt64 可选的整数参数：未指定时 v 为 nil（0 也是有效取值）
+type optInt64 struct{ v *int64 }
+
+func (o *optInt64) String() string {
+	if o.v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*o.v, 10)
+}
+func (o *optInt64) Set(s string) error {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return fmt.Errorf("不是有效的整数：%q", s)
+	}
+	o.v = &n
+	return nil
+}
+
+// printConfigFlag --print-config：单独使用时为 exit（输出后退出），=continue 输出后继续运行
+type printConfigFlag struct{ mode string }
+
+func (f *printConfigFlag) String() string   { return f.mode }
+func (f *printConfigFlag) IsBoolFlag() bool { return true }
+func (f *printConfigFlag) Set(s string) error {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "exit":
+		f.mode = "exit"
+	case "continue":
+		f.mode = "continue"
+	case "false":
+		f.mode = ""
+	default:
+		return fmt.Errorf("不支持的取值：%q（可选 exit、continue）", s)
+	}
+	return nil
+}
+
+// printEffectiveConfig 以 JSON 输出一个配置文件实际生效的配置
+func printEffectiveConfig(w io.Writer, path string, cfg *internal.MySQLFileConfig) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	out := struct {
+		File   string                   `json:"file"`
+		Config internal.MySQLFileConfig `json:"config"`
+	}{path, cfg.Effective()}
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, "输出配置失败：%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// poolSizeVar 注册取值为非负整数或 auto 的连接池参数
+func poolSizeVar(fs *flag.FlagSet, name string, def internal.PoolSize, usage string) *internal.PoolSize {
+	p := def
+	fs.Var(&p, name, usage)
+	return &p
+}
 
 type multiCSV struct{ items []string }
 
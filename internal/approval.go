@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ApprovalSet 已批准的变更 ID（见 ChangeID）；设置后只应用 ID 在集合中的列变更，其余跳过。方法对 nil 安全（nil 表示全部放行）
+type ApprovalSet struct {
+	mu      sync.Mutex
+	ids     map[string]bool // id -> 是否已匹配到
+	skipped int64
+}
+
+// LoadApprovals 读取批准清单：每行一个 ID，或直接使用（筛选后的）--dry-run-output JSONL 行，取其 id 字段；
+// 空行与 # 开头的注释行忽略
+func LoadApprovals(path string) (*ApprovalSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取批准清单 %s: %w", path, err)
+	}
+	defer f.Close()
+	a := &ApprovalSet{ids: map[string]bool{}}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			var rec struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal([]byte(line), &rec); err != nil {
+				return nil, fmt.Errorf("解析批准清单 %s 第 %d 行：%w", path, n, err)
+			}
+			if rec.ID == "" {
+				continue // 文档变更等不带 id 的记录
+			}
+			line = rec.ID
+		}
+		a.ids[line] = false
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("读取批准清单 %s: %w", path, err)
+	}
+	return a, nil
+}
+
+// Allows 判断该列变更是否已批准，未批准时计入跳过数
+func (a *ApprovalSet) Allows(table string, key map[string]*string, column string) bool {
+	if a == nil {
+		return true
+	}
+	id := ChangeID(table, key, column)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.ids[id]; ok {
+		a.ids[id] = true
+		return true
+	}
+	a.skipped++
+	return false
+}
+
+// Report 返回已批准并匹配到的数量、批准清单中未匹配到的数量（数据已变化或已被处理）、因未批准而跳过的数量
+func (a *ApprovalSet) Report() (matched, unmatched, skipped int64) {
+	if a == nil {
+		return 0, 0, 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, hit := range a.ids {
+		if hit {
+			matched++
+		} else {
+			unmatched++
+		}
+	}
+	return matched, unmatched, a.skipped
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"bytes"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// backupSuffix --backup 生成的备份文件后缀
+const backupSuffix = ".bak"
+
+// pruneBackups 删除此前 --backup 留下、已无用的 <文件>.bak：只处理同目录下原文件仍存在的备份，
+// 且备份内容与当前文件相同，或按本次转换配置转换后与当前文件相同（即转换前的原稿、转换早已写回）。
+// 当前文件此后又被改动过的备份一律保留。只处理原文件按扩展名纳入处理的备份；本次运行写入的备份（cfg.backups）不动。
+// 处理前后各调用一次，when 标明阶段
+func pruneBackups(cfg FileConfig, roots []string, exts extFilter, when string) {
+	var removed, kept int
+	for _, root := range roots {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), backupSuffix) {
+				return nil
+			}
+			orig := strings.TrimSuffix(path, backupSuffix)
+			if !exts.allows(orig) {
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if cfg.backups != nil {
+				if _, ok := cfg.backups.Load(cacheAbs(path)); ok {
+					return nil
+				}
+			}
+			if ok, reason := staleBackup(cfg, orig, path); !ok {
+				if reason != "" {
+					kept++
+					log.Printf("[file] 保留备份 %s：%s", path, reason)
+				}
+				return nil
+			}
+			if cfg.DryRun {
+				removed++
+				log.Printf("[DRYRUN] 将删除备份：%s", path)
+				return nil
+			}
+			if err := os.Remove(path); err != nil {
+				kept++
+				log.Printf("[file] 删除备份失败 %s: %v", path, err)
+				return nil
+			}
+			removed++
+			log.Printf("[OK] 已删除备份：%s", path)
+			return nil
+		})
+	}
+	log.Printf("[file] 清理备份（%s）：删除 %d 个，保留 %d 个", when, removed, kept)
+}
+
+// staleBackup 判断 bak 是否为 orig 已无用的备份；reason 为空表示不是本工具的备份（无原文件），不计入保留数
+func staleBackup(cfg FileConfig, orig, bak string) (bool, string) {
+	fi, err := os.Stat(orig)
+	if err != nil || !fi.Mode().IsRegular() {
+		return false, ""
+	}
+	cur, err := os.ReadFile(orig)
+	if err != nil {
+		return false, "读取原文件失败"
+	}
+	old, err := os.ReadFile(bak)
+	if err != nil {
+		return false, "读取备份失败"
+	}
+	if bytes.Equal(old, cur) {
+		return true, ""
+	}
+	opts := ConvertOptions{To: cfg.toFor(orig), Normalize: cfg.Normalize, NormalizeInput: cfg.NormalizeInput, PunctOnly: cfg.PunctOnly}
+	out, _, err := ConvertDetail(opts, string(old))
+	if err != nil {
+		return false, "转换备份内容失败"
+	}
+	if out != string(cur) {
+		return false, "原文件在转换后又有改动（或使用了其它转换配置）"
+	}
+	return true, ""
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPruneBackupsAfterRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		// 处理前不算无用（转换后与当前文件不同），本次写回后转换结果与备份一致，处理后删除
+		"a.md": "简體", "a.md.bak": "简体",
+		// 与当前文件相同：处理前删除
+		"b.md": "繁體", "b.md.bak": "繁體",
+		// 与原文件无关的内容：始终保留
+		"c.md": "简体", "c.md.bak": "别的内容",
+		// 没有原文件：不是本工具的备份
+		"d.md.bak": "简体",
+	})
+	if _, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", PruneBackups: true}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"a.md.bak": false, "b.md.bak": false, "c.md.bak": true, "d.md.bak": true} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+}
+
+func TestPruneBackupsKeepsThisRunsBackups(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.md": "简体", "b.md": "简体", "b.md.bak": "简体"})
+	if _, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", Backup: true, PruneBackups: true}); err != nil {
+		t.Fatal(err)
+	}
+	// 备份与原稿一致、转换后与当前文件相同，但是本次写入的备份，不能删除
+	for _, name := range []string{"a.md.bak", "b.md.bak"} {
+		if bs, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(bs) != "简体" {
+			t.Errorf("%s = %q, %v", name, bs, err)
+		}
+	}
+}
+
+func TestPruneBackupsDryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.md": "繁體", "a.md.bak": "繁體"})
+	if _, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", DryRun: true, PruneBackups: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.md.bak")); err != nil {
+		t.Errorf("dry-run removed the backup: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// batchHookTimeout 单次钩子（命令或 webhook）的超时
+const batchHookTimeout = 30 * time.Second
+
+// BatchHook 每批真实写入完成后调用的外部钩子（--post-batch-cmd / --post-batch-webhook），供 CDC 等下游按批增量处理。
+// 按批同步调用，调用期间该表不处理下一批；dry-run 不调用。方法对 nil 安全，多表共享
+type BatchHook struct {
+	cmd     []string // 命令模板按空白切分后的参数，占位符逐个参数替换，不经过 shell
+	webhook string
+	abort   bool // 钩子失败时中止该表；默认只记录日志
+	client  *http.Client
+}
+
+// BatchInfo 一批的处理结果（webhook 的 JSON 请求体）
+type BatchInfo struct {
+	Table   string   `json:"table"`
+	Batch   int64    `json:"batch"`             // 该表的批次序号，从 1 开始
+	Rows    int64    `json:"rows"`              // 本批读取的行数
+	Changed int64    `json:"changed"`           // 本批更新的行数
+	Failed  int64    `json:"failed"`            // 本批失败的行数
+	Done    int64    `json:"done"`              // 该表累计已处理行数
+	PKFrom  []string `json:"pk_from,omitempty"` // 本批首行主键（复合主键按 pk 顺序；NULL 为 "NULL"），无主键表省略
+	PKTo    []string `json:"pk_to,omitempty"`   // 本批末行主键
+}
+
+// NewBatchHook cmdTmpl 与 webhook 均为空时返回 nil
+func NewBatchHook(cmdTmpl, webhook string, abort bool) (*BatchHook, error) {
+	cmdTmpl, webhook = strings.TrimSpace(cmdTmpl), strings.TrimSpace(webhook)
+	if cmdTmpl == "" && webhook == "" {
+		return nil, nil
+	}
+	if webhook != "" && !strings.HasPrefix(webhook, "http://") && !strings.HasPrefix(webhook, "https://") {
+		return nil, fmt.Errorf("无效的 post-batch-webhook %q（须为 http:// 或 https:// 地址）", webhook)
+	}
+	return &BatchHook{
+		cmd:     strings.Fields(cmdTmpl),
+		webhook: webhook,
+		abort:   abort,
+		client:  &http.Client{Timeout: batchHookTimeout},
+	}, nil
+}
+
+// expandBatchPlaceholders 替换命令参数中的占位符：{table} {batch} {rows} {changed} {failed} {done} {pk_from} {pk_to}，
+// 复合主键以逗号连接，无主键表的 {pk_from} / {pk_to} 为空串
+func expandBatchPlaceholders(arg string, b BatchInfo) string {
+	return strings.NewReplacer(
+		"{table}", b.Table,
+		"{batch}", strconv.FormatInt(b.Batch, 10),
+		"{rows}", strconv.FormatInt(b.Rows, 10),
+		"{changed}", strconv.FormatInt(b.Changed, 10),
+		"{failed}", strconv.FormatInt(b.Failed, 10),
+		"{done}", strconv.FormatInt(b.Done, 10),
+		"{pk_from}", strings.Join(b.PKFrom, ","),
+		"{pk_to}", strings.Join(b.PKTo, ","),
+	).Replace(arg)
+}
+
+// batchDone 调用钩子：先执行命令，再发送 webhook。失败时记录日志；abort 时返回错误以中止该表
+func (h *BatchHook) batchDone(ctx context.Context, b BatchInfo) error {
+	if h == nil {
+		return nil
+	}
+	var errs []error
+	if len(h.cmd) > 0 {
+		if err := h.runCmd(ctx, b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if h.webhook != "" {
+		if err := h.post(ctx, b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := errors.Join(errs...)
+	if err == nil {
+		return nil
+	}
+	if h.abort {
+		return fmt.Errorf("批次钩子失败 table=%s batch=%d：%w", b.Table, b.Batch, err)
+	}
+	log.Printf("[mysql] 警告：批次钩子失败 table=%s batch=%d（继续处理）：%v", b.Table, b.Batch, err)
+	return nil
+}
+
+func (h *BatchHook) runCmd(ctx context.Context, b BatchInfo) error {
+	args := make([]string, len(h.cmd))
+	for i, a := range h.cmd {
+		args[i] = expandBatchPlaceholders(a, b)
+	}
+	cctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), batchHookTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(cctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("post-batch-cmd: %w：%s", err, msg)
+		}
+		return fmt.Errorf("post-batch-cmd: %w", err)
+	}
+	return nil
+}
+
+func (h *BatchHook) post(ctx context.Context, b BatchInfo) error {
+	body, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, h.webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("post-batch-webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post-batch-webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("post-batch-webhook: %s %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
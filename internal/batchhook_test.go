@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandBatchPlaceholders(t *testing.T) {
+	b := BatchInfo{Table: "orders", Batch: 3, Rows: 500, Changed: 42, Failed: 1, Done: 1500, PKFrom: []string{"7", "a"}, PKTo: []string{"9", "NULL"}}
+	got := expandBatchPlaceholders("t={table} b={batch} r={rows} c={changed} f={failed} d={done} {pk_from}..{pk_to} {unknown}", b)
+	if want := "t=orders b=3 r=500 c=42 f=1 d=1500 7,a..9,NULL {unknown}"; got != want {
+		t.Errorf("expand = %q, want %q", got, want)
+	}
+	// 无主键表的主键占位符为空串
+	if got := expandBatchPlaceholders("[{pk_from}]", BatchInfo{Table: "t"}); got != "[]" {
+		t.Errorf("no pk = %q", got)
+	}
+}
+
+func TestBatchHookWebhookPayload(t *testing.T) {
+	var got map[string]any
+	var ctype string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctype = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+	h, err := NewBatchHook("", srv.URL, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := BatchInfo{Table: "orders", Batch: 1, Rows: 10, Changed: 2, Done: 10, PKFrom: []string{"1"}, PKTo: []string{"10"}}
+	if err := h.batchDone(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+	if ctype != "application/json" {
+		t.Errorf("Content-Type = %q", ctype)
+	}
+	want := map[string]any{
+		"table": "orders", "batch": 1.0, "rows": 10.0, "changed": 2.0, "failed": 0.0, "done": 10.0,
+		"pk_from": []any{"1"}, "pk_to": []any{"10"},
+	}
+	wj, _ := json.Marshal(want)
+	gj, _ := json.Marshal(got)
+	if string(gj) != string(wj) {
+		t.Errorf("payload = %s, want %s", gj, wj)
+	}
+
+	// 无主键表省略 pk_from / pk_to
+	got = nil
+	if err := h.batchDone(context.Background(), BatchInfo{Table: "logs", Batch: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["pk_from"]; ok {
+		t.Errorf("payload without pk = %v", got)
+	}
+}
+
+func TestBatchHookFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	h, _ := NewBatchHook("", srv.URL, false)
+	if err := h.batchDone(context.Background(), BatchInfo{Table: "t", Batch: 1}); err != nil {
+		t.Errorf("non-abort hook should only log: %v", err)
+	}
+	h, _ = NewBatchHook("", srv.URL, true)
+	if err := h.batchDone(context.Background(), BatchInfo{Table: "t", Batch: 1}); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("abort hook err = %v", err)
+	}
+}
+
+func TestBatchHookCmd(t *testing.T) {
+	dir := t.TempDir()
+	script, out := filepath.Join(dir, "hook.sh"), filepath.Join(dir, "hook.txt")
+	writeFiles(t, dir, map[string]string{"hook.sh": `echo "$1 $2" > "$3"` + "\n"})
+	// 占位符逐个参数替换，不经过 shell
+	h, err := NewBatchHook("sh "+script+" {table} {pk_from} "+out, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.batchDone(context.Background(), BatchInfo{Table: "orders", PKFrom: []string{"1", "x"}}); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(bs)); got != "orders 1,x" {
+		t.Errorf("cmd output = %q", got)
+	}
+}
+
+func TestNewBatchHook(t *testing.T) {
+	if h, err := NewBatchHook(" ", "", false); h != nil || err != nil {
+		t.Errorf("empty = %v, %v", h, err)
+	}
+	if _, err := NewBatchHook("", "ftp://example.com/hook", false); err == nil {
+		t.Error("non-http webhook should fail")
+	}
+	var h *BatchHook
+	if err := h.batchDone(context.Background(), BatchInfo{}); err != nil {
+		t.Errorf("nil hook = %v", err)
+	}
+}
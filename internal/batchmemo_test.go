@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 重复值很多的批次：每个不同取值只转换一次，结果复用到所有相同取值的行
+func TestProcessWithPKBatchMemo(t *testing.T) {
+	db, mock := newMock(t)
+	vals := []string{"模板简体", "模板简体", "说明", "模板简体", "说明", "模板简体"}
+	want := map[string]string{"模板简体": "模板簡體", "说明": "說明"}
+	rs := sqlmock.NewRows([]string{"id", "name"})
+	for i, v := range vals {
+		rs.AddRow(fmt.Sprint(i+1), v)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` ORDER BY `id` LIMIT ?")).WithArgs(100).WillReturnRows(rs)
+	for i, v := range vals {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).
+			WithArgs(want[v], fmt.Sprint(i+1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE (`id`) > (?) ORDER BY `id` LIMIT ?")).
+		WithArgs(fmt.Sprint(len(vals)), 100).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	stats := &Stats{}
+	cfg := MySQLConfig{
+		Table: "t", PK: []string{"id"}, Columns: []string{"name"}, To: "s2t", BatchSize: 100,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{}, Stats: stats,
+	}
+	var err error
+	out := captureLog(t, func() { _, err = processWithPK(context.Background(), db, cfg, nil, nil, 0) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "批内去重 table=t：共 6 个值，实际转换 2 次") {
+		t.Errorf("missing memo log:\n%s", out)
+	}
+	// 复用的结果同样计入按值统计
+	if snap := stats.Snapshot(); snap.Converted != int64(len(vals)) {
+		t.Errorf("converted = %d, want %d", snap.Converted, len(vals))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ChangeLog 将 dry-run 中的每一项拟变更以 JSONL 写入文件，作为变更评审材料；方法对 nil 安全
+type ChangeLog struct {
+	mu  sync.Mutex
+	f   *os.File
+	w   *bufio.Writer
+	enc *json.Encoder
+	err error // 首个写入错误，Close 时返回
+}
+
+// 单条拟变更记录：mysql 为 table/key/column/old/new；file 为 path/diff 或 path/rename_to
+type changeRecord struct {
+	ID       string             `json:"id,omitempty"` // mysql：变更 ID，见 ChangeID
+	Table    string             `json:"table,omitempty"`
+	Key      map[string]*string `json:"key,omitempty"` // 定位列 -> 值（NULL 为 null）
+	Path     string             `json:"path,omitempty"`
+	Column   string             `json:"column,omitempty"`
+	Old      *string            `json:"old,omitempty"`
+	New      *string            `json:"new,omitempty"`
+	Diff     string             `json:"diff,omitempty"`
+	RenameTo string             `json:"rename_to,omitempty"`
+}
+
+func NewChangeLog(path string) (*ChangeLog, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建变更清单 %s: %w", path, err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &ChangeLog{f: f, w: w, enc: enc}, nil
+}
+
+func (c *ChangeLog) write(r changeRecord) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.enc.Encode(r); err != nil && c.err == nil {
+		c.err = err
+	}
+}
+
+// RecordRow 记录一列的拟更新
+func (c *ChangeLog) RecordRow(table string, key map[string]*string, column, old, new string) {
+	c.write(changeRecord{ID: ChangeID(table, key, column), Table: table, Key: key, Column: column, Old: &old, New: &new})
+}
+
+// ChangeID 由 table + 定位列取值 + column 生成的稳定 ID（SHA-256 前 16 位十六进制），
+// 同一行同一列在 dry-run 与真实运行中得到相同 ID，供 --apply-approved 匹配；不包含列内容
+func ChangeID(table string, key map[string]*string, column string) string {
+	names := make([]string, 0, len(key))
+	for k := range key {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", table, column)
+	for _, k := range names {
+		if v := key[k]; v != nil {
+			fmt.Fprintf(h, "\x00%s=%q", k, *v)
+		} else {
+			fmt.Fprintf(h, "\x00%s", k) // NULL 与空串区分
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// RecordFile 记录文档内容的逐行差异
+func (c *ChangeLog) RecordFile(path, old, new string) {
+	c.write(changeRecord{Path: path, Diff: lineDiff(old, new)})
+}
+
+// RecordRename 记录拟重命名
+func (c *ChangeLog) RecordRename(from, to string) {
+	c.write(changeRecord{Path: from, RenameTo: to})
+}
+
+func (c *ChangeLog) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.w.Flush(); err != nil && c.err == nil {
+		c.err = err
+	}
+	if err := c.f.Close(); err != nil && c.err == nil {
+		c.err = err
+	}
+	return c.err
+}
+
+// lineDiff 按行输出差异（简繁转换不改变换行，行号一一对应）：@@ 行号 @@ 后跟 -旧行 / +新行
+func lineDiff(old, new string) string {
+	a := strings.Split(old, "\n")
+	b := strings.Split(new, "\n")
+	var sb strings.Builder
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y string
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x == y {
+			continue
+		}
+		fmt.Fprintf(&sb, "@@ %d @@\n", i+1)
+		if i < len(a) {
+			fmt.Fprintf(&sb, "-%s\n", x)
+		}
+		if i < len(b) {
+			fmt.Fprintf(&sb, "+%s\n", y)
+		}
+	}
+	return sb.String()
+}
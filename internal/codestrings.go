@@ -0,0 +1,444 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// codeLangExts --code-strings 支持的语言及对应扩展名（小写）
+var codeLangExts = map[string][]string{
+	"php": {".php", ".phtml"},
+	"js":  {".js", ".mjs", ".cjs", ".jsx", ".ts", ".tsx"},
+	"go":  {".go"},
+}
+
+// CodeLangs 返回 --code-strings 支持的语言（排序后）
+func CodeLangs() []string {
+	out := make([]string, 0, len(codeLangExts))
+	for l := range codeLangExts {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// compileCodeStrings 校验语言列表，返回 扩展名 -> 语言
+func compileCodeStrings(langs []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, l := range langs {
+		l = strings.ToLower(strings.TrimSpace(l))
+		exts, ok := codeLangExts[l]
+		if !ok {
+			return nil, fmt.Errorf("code-strings 不支持的语言 %q（可选：%s）", l, strings.Join(CodeLangs(), ", "))
+		}
+		for _, e := range exts {
+			out[e] = l
+		}
+	}
+	return out, nil
+}
+
+// codeLangFor 返回文件按扩展名对应的 --code-strings 语言，未启用或不匹配时为空
+func (c FileConfig) codeLangFor(path string) string {
+	return c.codeExt[strings.ToLower(filepath.Ext(path))]
+}
+
+// convertCodeStrings 只转换源码中字符串字面量的内容，标识符、关键字与注释保持不变
+func convertCodeStrings(lang string, opts ConvertOptions, in string) (string, ConvertOutcome, error) {
+	if oc, skip := quickSkip(opts, in); skip {
+		return in, oc, nil
+	}
+	l := &codeLexer{src: in}
+	var err error
+	if lang == "php" {
+		err = l.php()
+	} else {
+		err = l.code(lang, 0)
+	}
+	if err != nil {
+		return "", OutcomeUnchanged, err
+	}
+	return convertSpans(in, l.spans, func(v string) (string, ConvertOutcome, error) { return ConvertDetail(opts, v) })
+}
+
+// codeLexer 只做定位字符串字面量所需的最小词法分析：跳过注释，记录各字面量内容（不含引号）的字节区间。
+// 转义只用于确定字面量的结束位置，内容原样交给转换
+type codeLexer struct {
+	src   string
+	i     int
+	spans [][2]int
+}
+
+// line 返回字节偏移所在的行号（从 1 开始），用于错误信息
+func (l *codeLexer) line(at int) int {
+	return strings.Count(l.src[:at], "\n") + 1
+}
+
+// code 扫描 go/js 代码直到 stop（js 模板字符串中 ${…} 的右括号；0 表示文件末尾）
+func (l *codeLexer) code(lang string, stop byte) error {
+	depth := 0
+	prev := byte(0) // 上一个非空白字符，用于区分 js 的正则字面量与除号
+	for l.i < len(l.src) {
+		ch := l.src[l.i]
+		switch {
+		case stop != 0 && ch == stop && depth == 0:
+			return nil
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+		case ch == '/' && l.peek(1) == '/':
+			l.skipLine()
+			continue
+		case ch == '/' && l.peek(1) == '*':
+			l.skipBlockComment()
+			continue
+		case ch == '"' || ch == '\'':
+			if err := l.quoted(ch, false); err != nil {
+				return err
+			}
+			prev = ch
+			continue
+		case ch == '`' && lang == "go":
+			if err := l.raw('`'); err != nil {
+				return err
+			}
+			prev = ch
+			continue
+		case ch == '`':
+			if err := l.template(); err != nil {
+				return err
+			}
+			prev = ch
+			continue
+		case ch == '/' && lang == "js" && regexAllowed(prev):
+			l.skipRegex()
+			prev = '/'
+			continue
+		}
+		if ch != ' ' && ch != '\t' && ch != '\n' && ch != '\r' {
+			prev = ch
+		}
+		l.i++
+	}
+	if stop != 0 {
+		return fmt.Errorf("模板字符串中的 ${ 未闭合")
+	}
+	return nil
+}
+
+// regexAllowed 判断 js 中 / 出现在 prev 之后时是否为正则字面量的开始（而非除号）
+func regexAllowed(prev byte) bool {
+	return prev == 0 || strings.IndexByte("(,=:[!&|?{};+-*%<>~^", prev) >= 0
+}
+
+func (l *codeLexer) peek(n int) byte {
+	if l.i+n < len(l.src) {
+		return l.src[l.i+n]
+	}
+	return 0
+}
+
+// skipLine 跳过单行注释（保留换行符）
+func (l *codeLexer) skipLine() {
+	if n := strings.IndexByte(l.src[l.i:], '\n'); n >= 0 {
+		l.i += n
+		return
+	}
+	l.i = len(l.src)
+}
+
+func (l *codeLexer) skipBlockComment() {
+	if n := strings.Index(l.src[l.i+2:], "*/"); n >= 0 {
+		l.i += 2 + n + 2
+		return
+	}
+	l.i = len(l.src)
+}
+
+// quoted 扫描以 q 开头的字面量（反斜杠转义），multiline 为 false 时遇到换行视为未闭合
+func (l *codeLexer) quoted(q byte, multiline bool) error {
+	start := l.i
+	for j := l.i + 1; j < len(l.src); j++ {
+		switch l.src[j] {
+		case '\\':
+			j++
+		case q:
+			l.spans = append(l.spans, [2]int{start + 1, j})
+			l.i = j + 1
+			return nil
+		case '\n':
+			if !multiline {
+				return fmt.Errorf("第 %d 行的字符串字面量未闭合", l.line(start))
+			}
+		}
+	}
+	return fmt.Errorf("第 %d 行的字符串字面量未闭合", l.line(start))
+}
+
+// raw 扫描不含转义的字面量（go 的反引号字符串）
+func (l *codeLexer) raw(q byte) error {
+	start := l.i
+	n := strings.IndexByte(l.src[start+1:], q)
+	if n < 0 {
+		return fmt.Errorf("第 %d 行的字符串字面量未闭合", l.line(start))
+	}
+	l.spans = append(l.spans, [2]int{start + 1, start + 1 + n})
+	l.i = start + 1 + n + 1
+	return nil
+}
+
+// template 扫描 js 模板字符串：文本部分作为字面量，${…} 中的表达式按代码继续扫描
+func (l *codeLexer) template() error {
+	start := l.i
+	seg := l.i + 1
+	for j := l.i + 1; j < len(l.src); j++ {
+		switch {
+		case l.src[j] == '\\':
+			j++
+		case l.src[j] == '`':
+			l.spans = append(l.spans, [2]int{seg, j})
+			l.i = j + 1
+			return nil
+		case l.src[j] == '$' && j+1 < len(l.src) && l.src[j+1] == '{':
+			l.spans = append(l.spans, [2]int{seg, j})
+			l.i = j + 2
+			if err := l.code("js", '}'); err != nil {
+				return fmt.Errorf("第 %d 行：%w", l.line(start), err)
+			}
+			j = l.i // 指向 }
+			seg = j + 1
+		}
+	}
+	return fmt.Errorf("第 %d 行的模板字符串未闭合", l.line(start))
+}
+
+// skipRegex 跳过 js 正则字面量（字符类 […] 中的 / 不结束）；同一行内未闭合时只跳过 /，按除号处理
+func (l *codeLexer) skipRegex() {
+	inClass := false
+	for j := l.i + 1; j < len(l.src); j++ {
+		switch l.src[j] {
+		case '\\':
+			j++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if !inClass {
+				l.i = j + 1
+				return
+			}
+		case '\n':
+			l.i++
+			return
+		}
+	}
+	l.i++
+}
+
+// php 扫描 PHP 文件：<?php … ?> 之外的内联 HTML 保持不变；代码中识别单/双引号字符串与 heredoc/nowdoc，
+// 跳过 //、#、/* */ 注释（#[ 为属性，不视为注释）
+func (l *codeLexer) php() error {
+	for l.i < len(l.src) {
+		n := strings.Index(l.src[l.i:], "<?")
+		if n < 0 {
+			return nil
+		}
+		l.i += n + 2
+		if err := l.phpCode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// phpCode 扫描一段 PHP 代码直到 ?> 或文件末尾
+func (l *codeLexer) phpCode() error {
+	for l.i < len(l.src) {
+		ch := l.src[l.i]
+		switch {
+		case ch == '?' && l.peek(1) == '>':
+			l.i += 2
+			return nil
+		case ch == '/' && l.peek(1) == '/', ch == '#' && l.peek(1) != '[':
+			l.skipPHPLine()
+			continue
+		case ch == '/' && l.peek(1) == '*':
+			l.skipBlockComment()
+			continue
+		case ch == '\'':
+			if err := l.quoted(ch, true); err != nil {
+				return err
+			}
+			continue
+		case ch == '"':
+			// 双引号字符串中的变量插值（$name、{$expr} 等）是代码，只转换其余文本
+			if err := l.quoted(ch, true); err != nil {
+				return err
+			}
+			l.splitPHPInterpolation()
+			continue
+		case ch == '<' && strings.HasPrefix(l.src[l.i:], "<<<"):
+			if ok, err := l.heredoc(); err != nil {
+				return err
+			} else if ok {
+				continue
+			}
+		}
+		l.i++
+	}
+	return nil
+}
+
+// skipPHPLine 跳过 PHP 单行注释：到换行或 ?> 为止
+func (l *codeLexer) skipPHPLine() {
+	for j := l.i; j < len(l.src); j++ {
+		if l.src[j] == '\n' || (l.src[j] == '?' && j+1 < len(l.src) && l.src[j+1] == '>') {
+			l.i = j
+			return
+		}
+	}
+	l.i = len(l.src)
+}
+
+// heredoc 扫描 <<<ID / <<<"ID" / <<<'ID' 开头的字面量，正文为开头行之后到结束标识所在行之前；
+// 不是合法的开头时返回 false
+func (l *codeLexer) heredoc() (bool, error) {
+	start := l.i
+	j := l.i + 3
+	for j < len(l.src) && (l.src[j] == ' ' || l.src[j] == '\t') {
+		j++
+	}
+	quote := byte(0)
+	if j < len(l.src) && (l.src[j] == '"' || l.src[j] == '\'') {
+		quote = l.src[j]
+		j++
+	}
+	k := j
+	for k < len(l.src) && isIdentByte(l.src[k]) {
+		k++
+	}
+	id := l.src[j:k]
+	if id == "" {
+		return false, nil
+	}
+	if quote != 0 {
+		if k >= len(l.src) || l.src[k] != quote {
+			return false, nil
+		}
+		k++
+	}
+	nl := strings.IndexByte(l.src[k:], '\n')
+	if nl < 0 {
+		return false, nil
+	}
+	body := k + nl + 1
+	for pos := body; pos <= len(l.src); {
+		end := strings.IndexByte(l.src[pos:], '\n')
+		lineEnd := len(l.src)
+		if end >= 0 {
+			lineEnd = pos + end
+		}
+		trimmed := strings.TrimLeft(l.src[pos:lineEnd], " \t")
+		if strings.HasPrefix(trimmed, id) && (len(trimmed) == len(id) || !isIdentByte(trimmed[len(id)])) {
+			if pos > body {
+				l.spans = append(l.spans, [2]int{body, pos - 1}) // 不含结束标识前的换行
+				if quote != '\'' {
+					l.splitPHPInterpolation() // heredoc 与双引号字符串一样插值；nowdoc（<<<'ID'）不插值
+				}
+			}
+			l.i = lineEnd - len(trimmed) + len(id)
+			return true, nil
+		}
+		if end < 0 {
+			break
+		}
+		pos = lineEnd + 1
+	}
+	return false, fmt.Errorf("第 %d 行的 heredoc %s 未闭合", l.line(start), id)
+}
+
+// splitPHPInterpolation 把最后记录的字面量区间按 PHP 变量插值拆开：$name、$name[…]、$name->prop、{$…}、${…}
+// 原样保留，只有其余文本片段作为待转换区间（\$ 为转义，不是插值）
+func (l *codeLexer) splitPHPInterpolation() {
+	last := l.spans[len(l.spans)-1]
+	l.spans = l.spans[:len(l.spans)-1]
+	s, seg := l.src, last[0]
+	for j := last[0]; j < last[1]; {
+		end := -1
+		switch {
+		case s[j] == '\\':
+			j += 2
+			continue
+		case s[j] == '{' && j+1 < last[1] && s[j+1] == '$':
+			end = matchBrace(s[:last[1]], j)
+		case s[j] == '$' && j+1 < last[1] && s[j+1] == '{':
+			end = matchBrace(s[:last[1]], j+1)
+		case s[j] == '$' && j+1 < last[1] && isIdentStart(s[j+1]):
+			end = phpSimpleVar(s[:last[1]], j+1)
+		}
+		if end < 0 {
+			j++
+			continue
+		}
+		if j > seg {
+			l.spans = append(l.spans, [2]int{seg, j})
+		}
+		j, seg = end, end
+	}
+	if last[1] > seg {
+		l.spans = append(l.spans, [2]int{seg, last[1]})
+	}
+}
+
+// matchBrace 与 s[open] 处的 { 配对的 } 之后的位置；未配对时返回 -1
+func matchBrace(s string, open int) int {
+	depth := 0
+	for k := open; k < len(s); k++ {
+		switch s[k] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return k + 1
+			}
+		}
+	}
+	return -1
+}
+
+// phpSimpleVar 简单插值 $name 之后的位置：name 可再跟一层 [下标] 或 ->属性 / ?->属性
+func phpSimpleVar(s string, name int) int {
+	k := name
+	for k < len(s) && isIdentByte(s[k]) {
+		k++
+	}
+	switch {
+	case k < len(s) && s[k] == '[':
+		if n := strings.IndexByte(s[k:], ']'); n >= 0 {
+			return k + n + 1
+		}
+	case strings.HasPrefix(s[k:], "->") && k+2 < len(s) && isIdentStart(s[k+2]):
+		k += 2
+		for k < len(s) && isIdentByte(s[k]) {
+			k++
+		}
+	case strings.HasPrefix(s[k:], "?->") && k+3 < len(s) && isIdentStart(s[k+3]):
+		k += 3
+		for k < len(s) && isIdentByte(s[k]) {
+			k++
+		}
+	}
+	return k
+}
+
+func isIdentStart(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b >= 0x80
+}
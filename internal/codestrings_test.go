@@ -0,0 +1,80 @@
+package internal
+
+import "testing"
+
+func TestConvertCodeStrings(t *testing.T) {
+	for _, tc := range []struct {
+		name, lang, in, out string
+	}{
+		// go / js：只转换字面量内容，注释与标识符不变
+		{"go comments", "go", "// 简体注释\nx := \"简体\" /* 简体 */", "// 简体注释\nx := \"簡體\" /* 简体 */"},
+		{"escaped quote", "go", `s := "说\"简体\"话"`, `s := "說\"簡體\"話"`},
+		{"nested quotes", "js", `a = '他说"简体"'; b = "它的'简体'"`, `a = '他說"簡體"'; b = "它的'簡體'"`},
+		{"escaped backslash before quote", "js", `a = "简\\"; // 简体`, `a = "簡\\"; // 简体`},
+		{"go raw string", "go", "s := `简体\\n\"体\"`", "s := `簡體\\n\"體\"`"},
+		{"go rune", "go", "r := '体' // 体", "r := '體' // 体"},
+		{"js template", "js", "t = `简体${ n + \"简体\" }简体`", "t = `簡體${ n + \"簡體\" }簡體`"},
+		{"js template nested braces", "js", "t = `体${ {a: '体'}.a }体`", "t = `體${ {a: '體'}.a }體`"},
+		{"js regex", "js", `r = /"简体/; s = "体"`, `r = /"简体/; s = "體"`},
+		{"js regex class", "js", `r = /[/"]体/g; s = "体"`, `r = /[/"]体/g; s = "體"`},
+		{"js division", "js", `x = a / b; s = "体" / 2`, `x = a / b; s = "體" / 2`},
+
+		// php：内联 HTML、注释不变；单引号与 nowdoc 整体转换
+		{"php inline html", "php", `<p>简体</p><?php echo '简体'; ?><p>简体</p>`, `<p>简体</p><?php echo '簡體'; ?><p>简体</p>`},
+		{"php comments", "php", "<?php\n# 简体\n// 简体\n/* 简体 */\n#[Attr('简体')]\n$a = '体';", "<?php\n# 简体\n// 简体\n/* 简体 */\n#[Attr('簡體')]\n$a = '體';"},
+		{"php single quote keeps dollar", "php", `<?php $a = '简体 $体';`, `<?php $a = '簡體 $體';`},
+		{"php nowdoc", "php", "<?php $a = <<<'体'\n简体 $体\n体;\n", "<?php $a = <<<'体'\n簡體 $體\n体;\n"},
+
+		// php 双引号与 heredoc：插值部分原样保留
+		{"php simple interpolation", "php", `<?php echo "简体 $简体 简体";`, `<?php echo "簡體 $简体 簡體";`},
+		{"php interpolation glued to han", "php", `<?php echo "价格$价格元";`, `<?php echo "價格$价格元";`},
+		{"php array and property", "php", `<?php echo "体$a[体]体$o->体体$o?->体。";`, `<?php echo "體$a[体]體$o->体体$o?->体。";`},
+		{"php braces", "php", `<?php echo "体{$a['体']}体${体}体";`, `<?php echo "體{$a['体']}體${体}體";`},
+		{"php escaped dollar", "php", `<?php echo "体\$体 {体}";`, `<?php echo "體\$體 {體}";`},
+		{"php heredoc", "php", "<?php $a = <<<体\n简体 {$体} 简体\n体;\n", "<?php $a = <<<体\n簡體 {$体} 簡體\n体;\n"},
+		{"php quoted heredoc", "php", "<?php $a = <<<\"EOT\"\n  简体 $体\n  EOT;\n", "<?php $a = <<<\"EOT\"\n  簡體 $体\n  EOT;\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, _, err := convertCodeStrings(tc.lang, ConvertOptions{To: "s2t"}, tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tc.out {
+				t.Errorf("convertCodeStrings(%s, %q)\n got %q\nwant %q", tc.lang, tc.in, out, tc.out)
+			}
+		})
+	}
+}
+
+func TestConvertCodeStringsOutcome(t *testing.T) {
+	for _, tc := range []struct {
+		name, lang, in string
+		oc             ConvertOutcome
+	}{
+		{"ascii", "go", `s := "hello"`, OutcomeASCIIOnly},
+		{"han only in comments", "go", "// 简体\ns := \"hello\"", OutcomeUnchanged},
+		{"han only in interpolation", "php", `<?php echo "hi $简体";`, OutcomeUnchanged},
+		{"converted", "js", `s = "简体"`, OutcomeConverted},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, oc, err := convertCodeStrings(tc.lang, ConvertOptions{To: "s2t"}, tc.in); err != nil || oc != tc.oc {
+				t.Errorf("outcome = %v, %v; want %v", oc, err, tc.oc)
+			}
+		})
+	}
+}
+
+func TestConvertCodeStringsUnclosed(t *testing.T) {
+	for _, tc := range []struct{ lang, in string }{
+		{"go", "s := \"简体\nx := 1"},
+		{"go", "s := `简体"},
+		{"js", "t = `简体${ a "},
+		{"js", "t = `简体"},
+		{"php", `<?php echo "简体;`},
+		{"php", "<?php $a = <<<EOT\n简体\n"},
+	} {
+		if _, _, err := convertCodeStrings(tc.lang, ConvertOptions{To: "s2t"}, tc.in); err == nil {
+			t.Errorf("convertCodeStrings(%s, %q) should fail", tc.lang, tc.in)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// concatWriter 拼接模式（FileConfig.ConcatOut）：各文件转换后的内容按序号（相对路径排序）依次写入同一个输出文件。
+// worker 并发完成，先完成的文件在前面的文件写出之前暂存，写出后即释放，内存占用与并发数相当而非总大小。
+// 先写同目录下的临时文件，全部成功后才改名为输出文件；dry-run 只计数不写文件。方法对 nil 安全
+type concatWriter struct {
+	path   string
+	header string // 每个文件内容之前写入的标题，占位符 {path} {index}；为空不写
+	sep    string // 相邻两个文件之间的分隔
+	dryRun bool
+
+	mu      sync.Mutex
+	f       *os.File
+	w       *bufio.Writer
+	next    int                // 下一个待写出的序号
+	pending map[int]concatPart // 已完成、尚未轮到写出的文件
+	count   int                // 已写出的文件数
+	err     error
+}
+
+// concatPart 一个文件在拼接输出中的内容；content 为 nil 表示该文件不写入（失败、跳过或被过滤）
+type concatPart struct {
+	rel     string
+	content *string
+}
+
+func newConcatWriter(path, header, sep string, dryRun bool) (*concatWriter, error) {
+	c := &concatWriter{path: path, header: header, sep: sep, dryRun: dryRun, pending: map[int]concatPart{}}
+	if dryRun {
+		return c, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建拼接输出目录失败：%w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, fmt.Errorf("创建拼接输出失败：%w", err)
+	}
+	c.f, c.w = f, bufio.NewWriterSize(f, 256<<10)
+	return c, nil
+}
+
+// owns 遍历时排除输出文件本身及其临时文件（输出位于被处理目录内时）
+func (c *concatWriter) owns(path string) bool {
+	if c == nil {
+		return false
+	}
+	abs, out := cacheAbs(path), cacheAbs(c.path)
+	if abs == out {
+		return true
+	}
+	return filepath.Dir(abs) == filepath.Dir(out) && strings.HasPrefix(filepath.Base(abs), "."+filepath.Base(out)+".tmp")
+}
+
+// put 交回序号为 seq 的文件；每个派发出去的序号都须交回一次（不写入的文件 content 为 nil），否则其后的文件会一直暂存
+func (c *concatWriter) put(seq int, rel string, content *string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending[seq] = concatPart{rel: rel, content: content}
+	for {
+		p, ok := c.pending[c.next]
+		if !ok {
+			return
+		}
+		delete(c.pending, c.next)
+		c.next++
+		c.write(p)
+	}
+}
+
+func (c *concatWriter) write(p concatPart) {
+	if p.content == nil {
+		return
+	}
+	c.count++
+	if c.dryRun || c.err != nil {
+		return
+	}
+	if c.count > 1 {
+		_, c.err = c.w.WriteString(c.sep)
+	}
+	if c.header != "" && c.err == nil {
+		h := strings.NewReplacer("{path}", filepath.ToSlash(p.rel), "{index}", strconv.Itoa(c.count)).Replace(c.header)
+		_, c.err = c.w.WriteString(h)
+	}
+	if c.err == nil {
+		_, c.err = c.w.WriteString(*p.content)
+	}
+}
+
+// close 写出剩余暂存的文件并收尾：ok 为 false（有文件失败或已中止）时丢弃临时文件，已有的输出文件保持不变
+func (c *concatWriter) close(ok bool) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seqs := make([]int, 0, len(c.pending))
+	for s := range c.pending {
+		seqs = append(seqs, s)
+	}
+	sort.Ints(seqs)
+	for _, s := range seqs {
+		c.write(c.pending[s])
+	}
+	c.pending = nil
+	if c.dryRun {
+		log.Printf("[DRYRUN] 将拼接 %d 个文件写入：%s", c.count, c.path)
+		return nil
+	}
+	tmp := c.f.Name()
+	if c.err == nil {
+		c.err = c.w.Flush()
+	}
+	if err := c.f.Close(); err != nil && c.err == nil {
+		c.err = err
+	}
+	if c.err != nil || !ok {
+		os.Remove(tmp)
+		if c.err != nil {
+			return fmt.Errorf("写拼接输出失败 %s: %w", c.path, c.err)
+		}
+		return nil
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写拼接输出失败 %s: %w", c.path, err)
+	}
+	log.Printf("[OK] 已拼接 %d 个文件：%s", c.count, c.path)
+	return nil
+}
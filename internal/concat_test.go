@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles 在 dir 下按相对路径写入文件
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// concatHeaders 返回拼接输出中各文件标题（{path}）的顺序
+func concatHeaders(t *testing.T, path string) []string {
+	t.Helper()
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, line := range strings.Split(string(bs), "\n") {
+		if p, ok := strings.CutPrefix(line, "== "); ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func TestConcatOrderedByRelativePath(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "src")
+	// WalkDir 先进入目录 a 再访问 a-b.md，但按路径排序 "a-b.md" < "a/b.md"
+	writeFiles(t, root, map[string]string{
+		"a/b.md":  "简体一",
+		"a-b.md":  "简体二",
+		"a.md":    "简体三",
+		"z/y.md":  "hello",
+		"B.md":    "简体四",
+		"a/a.md":  "",
+		"a/c.txt": "忽略",
+	})
+	out := filepath.Join(dir, "book.md")
+	for range 3 { // 与并发完成顺序无关
+		_, err := RunFile(FileConfig{
+			RootDirs: []string{root}, Exts: []string{".md"}, To: "s2t", Workers: 4,
+			ConcatOut: out, ConcatHeader: "== {path}\n", ConcatSep: "\n",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"B.md", "a-b.md", "a.md", "a/a.md", "a/b.md", "z/y.md"}
+		if got := concatHeaders(t, out); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+	bs, _ := os.ReadFile(out)
+	if !strings.Contains(string(bs), "== a-b.md\n簡體二\n") {
+		t.Errorf("output = %q", bs)
+	}
+}
+
+func TestConcatPathsFromSorted(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeFiles(t, dir, map[string]string{"docs/b.md": "简体", "docs/a.md": "简体", "c.md": "简体"})
+	_, err := RunFile(FileConfig{
+		PathsFrom: strings.NewReader("docs/b.md\nc.md\ndocs/a.md\n"), To: "s2t", Workers: 2,
+		ConcatOut: "out/book.md", ConcatHeader: "== {path}\n", ConcatSep: "\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"c.md", "docs/a.md", "docs/b.md"}
+	if got := concatHeaders(t, filepath.Join(dir, "out", "book.md")); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
@@ -1,128 +1,457 @@
 package internal
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
+	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/vbauerster/mpb/v8"
 )
 
 // 配置文件结构（JSON，使用 snake_case 字段名）
 type MySQLFileConfig struct {
-	DSN             string          `json:"dsn"`
-	To              string          `json:"to"`
-	BatchSize       int             `json:"batch_size"`
-	Workers         int             `json:"workers"`
-	RPS             int             `json:"rps"`
-	DryRun          bool            `json:"dry_run"`
-	MaxOpenConns    int             `json:"max_open"`
-	MaxIdleConns    int             `json:"max_idle"`
-	ConnMaxLifetime string          `json:"conn_max_lifetime"` // e.g. "30m"
-	TablesParallel  int             `json:"tables_parallel"`   // 同时并发处理的表数量（默认1）
-	Tables          []MySQLTblEntry `json:"tables"`
+	DSN               string          `json:"dsn"`
+	ReadDSN           string          `json:"read_dsn,omitempty"` // 只读副本，读查询走副本
+	To                string          `json:"to"`
+	Normalize         string          `json:"normalize"`              // nfc | nfkc | none（默认 none）
+	NormalizeInput    bool            `json:"normalize_input"`        // 转换前是否也对输入做规范化
+	PunctOnly         bool            `json:"punct_only,omitempty"`   // 仅标点模式：汉字不变
+	Smart             bool            `json:"smart,omitempty"`        // 按值判定：以繁体为主的值只修正零散的简体字
+	ConvertKeys       bool            `json:"convert_keys,omitempty"` // JSON 列的对象键也转换
+	ExplainSkip       bool            `json:"explain_skip,omitempty"` // 逐行逐列记录未转换的原因
+	BatchSize         int             `json:"batch_size"`
+	Workers           int             `json:"workers"`
+	RPS               int             `json:"rps"`
+	DryRun            bool            `json:"dry_run"`
+	MaxOpenConns      PoolSize        `json:"max_open"`                  // 数字或 "auto"
+	MaxIdleConns      PoolSize        `json:"max_idle"`                  // 数字或 "auto"
+	ConnMaxLifetime   string          `json:"conn_max_lifetime"`         // e.g. "30m"
+	TablesParallel    int             `json:"tables_parallel"`           // 同时并发处理的表数量（默认1）
+	StreamResults     bool            `json:"stream_results"`            // 边读边处理，不在客户端缓存整批
+	InterpolateParams bool            `json:"interpolate_params"`        // 驱动端插值参数，省去 prepare 往返
+	Manifest          string          `json:"manifest,omitempty"`        // 已完成表清单，重跑时跳过
+	StrictAffected    bool            `json:"strict_affected,omitempty"` // 按键 UPDATE 影响超过 1 行时中止该表
+	BarOrder          string          `json:"bar_order,omitempty"`       // 进度条排序：config（默认）| label | size
+	TableOrder        string          `json:"table_order,omitempty"`     // 表的调度顺序：config（默认）| size-asc | size-desc
+	Tables            []MySQLTblEntry `json:"tables"`
+
+	QueryRetryMax   *int   `json:"query_retry_max,omitempty"`   // 暂时性查询错误的最大重试次数（默认 3）
+	QueryRetryDelay string `json:"query_retry_delay,omitempty"` // 重试间隔（Go duration，默认 5s）
+
+	PrefilterNonASCII bool `json:"prefilter_nonascii,omitempty"` // 只读取目标列含非 ASCII 字符的行
+
+	ConnectTimeout string `json:"connect_timeout,omitempty"` // 建立连接的超时（Go duration，默认 10s）
+
+	GlobalMaxInflight int `json:"global_max_inflight,omitempty"` // 所有表合计同时进行中的 UPDATE 上限（默认 0 不限制）
+	GlobalRPS         int `json:"global_rps,omitempty"`          // 所有表合计每秒最大处理行数（默认 0 不限制）
+
+	StateDir string `json:"state_dir,omitempty"` // 状态目录（相对配置文件目录，默认 .tradify-state）
+	Resume   bool   `json:"resume,omitempty"`    // 在状态目录中记录已完成的表，重跑时跳过
+
+	CountMode string `json:"count_mode,omitempty"` // 进度条总量来源：exact（默认）| information_schema | none
+
+	ExcludeTables []string `json:"exclude_tables,omitempty"` // table_pattern 展开时排除的表，支持通配符
+
+	ShadowTable bool `json:"shadow_table,omitempty"` // 仅限 dry_run：转换结果写入影子表
+
+	Verify bool `json:"verify,omitempty"` // 真实写入后按主键回读并核对已更新的值
+
+	HeavyIndexRPS int `json:"heavy_index_rps,omitempty"` // 目标列带 FULLTEXT/SPATIAL 索引的表的限速（默认 0 仅告警）
+
+	MaxErrors *int `json:"max_errors,omitempty"` // 熔断：所有表累计错误超过 N 时中止（默认 1000）
+
+	TreatWhitespaceEmpty bool `json:"treat_whitespace_empty,omitempty"` // 只含空白字符的值与空串一样跳过
+	AllowEmptyResult     bool `json:"allow_empty_result,omitempty"`     // 允许非空值转换为空串
+
+	SessionCollation string `json:"session_collation,omitempty"` // 每个新连接的会话排序规则
+	Isolation        string `json:"isolation,omitempty"`         // 每个新连接的事务隔离级别
+
+	Replace map[string]string `json:"replace,omitempty"` // 转换后的自定义替换（原文 -> 替换为）
 }
 
-// 单表条目（支持主键 pk、无主键 identify_by、及表级覆盖 batch_size/workers/rps）
+// 单表条目（支持主键 pk、无主键 identify_by、及表级覆盖 to/batch_size/workers/rps）
 type MySQLTblEntry struct {
 	Table      string   `json:"table"`
 	PK         []string `json:"pk,omitempty"`
 	IdentifyBy []string `json:"identify_by,omitempty"`
 	Columns    []string `json:"columns"`
+	SelectSQL  string   `json:"select_sql,omitempty"` // 自定义行来源（高级用法），需返回 pk + columns
+	Join       string   `json:"join,omitempty"`       // 联接子句，只处理联接命中的行
+	KeyExpr    string   `json:"key_expr,omitempty"`   // 配合 join：联接后筛选行的条件
+
+	IncrementalColumn string `json:"incremental_column,omitempty"` // 增量列（如 updated_at）
+	Since             string `json:"since,omitempty"`              // 增量起点（时间/数值或 Go duration）
+	WatermarkFile     string `json:"watermark_file,omitempty"`     // 水位文件（相对路径基于配置文件所在目录）
+	IdentifyUnique    bool   `json:"identify_unique,omitempty"`    // 要求 identify_by 被唯一索引覆盖
+	Label             string `json:"label,omitempty"`              // 进度条显示名（默认表名）
+
+	Keys     []KeyTuple `json:"keys,omitempty"`      // 只处理这些主键对应的行
+	KeysFile string     `json:"keys_file,omitempty"` // 主键清单文件（CSV）
+
+	PKMin *int64 `json:"pk_min,omitempty"` // 首个主键列下限（数值主键）
+	PKMax *int64 `json:"pk_max,omitempty"` // 首个主键列上限
+
+	To        string `json:"to,omitempty"`
+	BatchSize int    `json:"batch_size,omitempty"`
+	Workers   int    `json:"workers,omitempty"`
+	RPS       *int   `json:"rps,omitempty"` // 未设置时取全局 rps，0 为不限速
+
+	TablePattern string `json:"table_pattern,omitempty"` // 代替 table：运行时展开为所有匹配的表
 
-	BatchSize int `json:"batch_size,omitempty"`
-	Workers   int `json:"workers,omitempty"`
-	RPS       int `json:"rps,omitempty"`
+	Segments map[string]SegmentSpec `json:"segments,omitempty"` // 按列只转换值中的某一段
+
+	Replace map[string]string `json:"replace,omitempty"` // 表级自定义替换，与全局 replace 合并
+
+	ConvertDefaults bool `json:"convert_defaults,omitempty"` // mysql plan 时同时转换字符列的默认值
+
+	LockGroup string `json:"lock_group,omitempty"` // 互斥组：同组的表不会同时处理
 }
 
 // 解析单个 JSON 配置文件
 func LoadMySQLFileConfig(path string) (*MySQLFileConfig, error) {
 	bs, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("read %s: %w", path, err)
+		return nil, classify(ErrIO, fmt.Errorf("read %s: %w", path, err))
 	}
 	var cfg MySQLFileConfig
 	if err := json.Unmarshal(bs, &cfg); err != nil {
-		return nil, fmt.Errorf("json parse %s: %w", path, err)
+		return nil, classify(ErrConfigInvalid, fmt.Errorf("json parse %s: %w", path, err))
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate 校验配置并填充默认值（整库模式在发现表之后调用）；错误均属于 ErrConfigInvalid
+func (c *MySQLFileConfig) Validate() error {
+	return classify(ErrConfigInvalid, c.validate())
+}
+
+func (c *MySQLFileConfig) validate() error {
 	// 基本校验 & 默认值
-	if cfg.DSN == "" {
-		return nil, errors.New("配置缺少 dsn")
+	if c.DSN == "" {
+		return errors.New("配置缺少 dsn")
 	}
-	if cfg.To == "" {
-		cfg.To = "s2twp"
+	if c.To == "" {
+		c.To = "s2twp"
 	}
-	if cfg.BatchSize <= 0 {
-		cfg.BatchSize = 500
+	if _, err := ParseNormalizeForm(c.Normalize); err != nil {
+		return err
 	}
-	if cfg.Workers <= 0 {
-		cfg.Workers = 8
+	if c.BatchSize <= 0 {
+		c.BatchSize = 500
 	}
-	if strings.TrimSpace(cfg.ConnMaxLifetime) == "" {
-		cfg.ConnMaxLifetime = "30m"
+	if c.Workers <= 0 {
+		c.Workers = 8
 	}
-	if cfg.TablesParallel <= 0 {
-		cfg.TablesParallel = 1
+	if strings.TrimSpace(c.ConnMaxLifetime) == "" {
+		c.ConnMaxLifetime = "30m"
 	}
-	if len(cfg.Tables) == 0 {
-		return nil, errors.New("配置缺少 tables")
+	if c.TablesParallel <= 0 {
+		c.TablesParallel = 1
 	}
-	for i := range cfg.Tables {
-		if cfg.Tables[i].Table == "" {
-			return nil, fmt.Errorf("tables[%d] 缺少 table", i)
+	if strings.TrimSpace(c.QueryRetryDelay) == "" {
+		c.QueryRetryDelay = "5s"
+	}
+	if _, err := time.ParseDuration(c.QueryRetryDelay); err != nil {
+		return fmt.Errorf("解析 query_retry_delay 失败：%w", err)
+	}
+	if strings.TrimSpace(c.ConnectTimeout) == "" {
+		c.ConnectTimeout = "10s"
+	}
+	if _, err := time.ParseDuration(c.ConnectTimeout); err != nil {
+		return fmt.Errorf("解析 connect_timeout 失败：%w", err)
+	}
+	if err := checkCountMode(c.CountMode); err != nil {
+		return err
+	}
+	for _, p := range c.ExcludeTables {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("无效的 exclude_tables 模式 %q：%w", p, err)
 		}
-		if len(cfg.Tables[i].Columns) == 0 {
-			return nil, fmt.Errorf("tables[%s] 缺少 columns", cfg.Tables[i].Table)
+	}
+	if c.ShadowTable && !c.DryRun {
+		return errors.New("shadow_table 仅可与 dry_run=true 一起使用")
+	}
+	if c.GlobalMaxInflight < 0 {
+		return fmt.Errorf("global_max_inflight 不能为负数：%d", c.GlobalMaxInflight)
+	}
+	if c.GlobalRPS < 0 {
+		return fmt.Errorf("global_rps 不能为负数：%d", c.GlobalRPS)
+	}
+	if c.HeavyIndexRPS < 0 {
+		return fmt.Errorf("heavy_index_rps 不能为负数：%d", c.HeavyIndexRPS)
+	}
+	if c.SessionCollation != "" {
+		if _, err := collationCharset(c.SessionCollation); err != nil {
+			return err
 		}
 	}
-	return &cfg, nil
+	if c.Isolation != "" {
+		if _, err := parseIsolation(c.Isolation); err != nil {
+			return err
+		}
+	}
+	if _, err := NewReplacer(c.Replace, nil); err != nil {
+		return err
+	}
+	switch c.BarOrder {
+	case "", "config", "label", "size":
+	default:
+		return fmt.Errorf("不支持的 bar_order：%q（可选 config、label、size）", c.BarOrder)
+	}
+	if err := checkTableOrder(c.TableOrder); err != nil {
+		return err
+	}
+	if len(c.Tables) == 0 {
+		return errors.New("配置缺少 tables")
+	}
+	for i := range c.Tables {
+		name := c.Tables[i].Table
+		switch {
+		case name == "" && c.Tables[i].TablePattern == "":
+			return fmt.Errorf("tables[%d] 缺少 table 或 table_pattern", i)
+		case name != "" && c.Tables[i].TablePattern != "":
+			return fmt.Errorf("tables[%s] table 与 table_pattern 只能二选一", name)
+		case name == "":
+			name = c.Tables[i].TablePattern
+			if err := checkTablePattern(c.Tables[i]); err != nil {
+				return fmt.Errorf("tables[%s] %w", name, err)
+			}
+		}
+		if len(c.Tables[i].Columns) == 0 {
+			return fmt.Errorf("tables[%s] 缺少 columns", name)
+		}
+		if c.Tables[i].SelectSQL != "" && len(c.Tables[i].PK) == 0 {
+			return fmt.Errorf("tables[%s] 使用 select_sql 时必须提供 pk", name)
+		}
+		if len(c.Tables[i].Keys) > 0 && c.Tables[i].KeysFile != "" {
+			return fmt.Errorf("tables[%s] keys 与 keys_file 只能二选一", name)
+		}
+		if c.Tables[i].KeysFile != "" && len(c.Tables[i].PK) == 0 {
+			return fmt.Errorf("tables[%s] 指定 keys_file 时必须提供 pk", name)
+		}
+		if err := checkKeys(c.Tables[i].PK, c.Tables[i].Keys); err != nil {
+			return fmt.Errorf("tables[%s] %w", name, err)
+		}
+		if err := checkPKRange(c.Tables[i].PK, c.Tables[i].PKMin, c.Tables[i].PKMax); err != nil {
+			return fmt.Errorf("tables[%s] %w", name, err)
+		}
+		if err := checkJoin(c.Tables[i]); err != nil {
+			return fmt.Errorf("tables[%s] %w", name, err)
+		}
+		if _, err := compileSegments(c.Tables[i].Columns, c.Tables[i].Segments); err != nil {
+			return fmt.Errorf("tables[%s] %w", name, err)
+		}
+		if _, err := NewReplacer(c.Tables[i].Replace, nil); err != nil {
+			return fmt.Errorf("tables[%s] %w", name, err)
+		}
+		if c.Tables[i].SelectSQL != "" && c.Tables[i].IncrementalColumn != "" {
+			return fmt.Errorf("tables[%s] select_sql 与 incremental_column 不可同时使用", name)
+		}
+	}
+	return nil
+}
+
+// MySQLRunOptions 配置文件模式各表共享的输出与钩子，字段含义同 MySQLConfig 的同名字段；nil 表示不启用
+type MySQLRunOptions struct {
+	Stats        *Stats
+	LengthReport *LengthReport
+	TermReport   *TermReport
+	Metrics      *Metrics
+	ChangeLog    *ChangeLog
+	Events       *EventStream
+	Approved     *ApprovalSet
+	RowBackup    *RowBackup
+	BatchHook    *BatchHook
+}
+
+// 根据文件配置执行所有表（支持并发 & 多进度条）；opts 中的输出各表共享。
+// ctx 取消后不再启动新表，进行中的表处理完当前批次即停止。返回各表汇总结果（按配置顺序；配置无效时为空）
+func RunMySQLFromFileConfig(ctx context.Context, fileCfg *MySQLFileConfig, baseDir string, opts MySQLRunOptions) (RunStats, error) {
+	start := time.Now()
+	if opts.Stats == nil {
+		opts.Stats = &Stats{} // 汇总结果中的按值统计
+	}
+	var rs RunStats
+	err := runMySQLFromFileConfig(ctx, fileCfg, baseDir, opts, &rs)
+	rs.Values, rs.Duration = opts.Stats.Snapshot(), time.Since(start)
+	return rs, err
 }
 
-// 根据文件配置执行所有表（支持并发 & 多进度条）
-func RunMySQLFromFileConfig(fileCfg *MySQLFileConfig, baseDir string) error {
+func runMySQLFromFileConfig(ctx context.Context, fileCfg *MySQLFileConfig, baseDir string, opts MySQLRunOptions, rs *RunStats) error {
 	// 解析连接生命周期
 	dur, err := time.ParseDuration(fileCfg.ConnMaxLifetime)
 	if err != nil {
-		return fmt.Errorf("解析 conn_max_lifetime 失败：%w", err)
+		return classify(ErrConfigInvalid, fmt.Errorf("解析 conn_max_lifetime 失败：%w", err))
+	}
+	retryDelay, err := time.ParseDuration(fileCfg.QueryRetryDelay)
+	if err != nil {
+		return classify(ErrConfigInvalid, fmt.Errorf("解析 query_retry_delay 失败：%w", err))
+	}
+	connTimeout, err := time.ParseDuration(fileCfg.ConnectTimeout)
+	if err != nil {
+		return classify(ErrConfigInvalid, fmt.Errorf("解析 connect_timeout 失败：%w", err))
+	}
+
+	// 预热所有用到的 OpenCC 配置（table_pattern 条目展开后沿用其 to）：任何一个初始化失败都在连库前返回
+	tos := []string{fileCfg.To}
+	for _, t := range fileCfg.Tables {
+		if t.To != "" {
+			tos = append(tos, t.To)
+		}
+	}
+	if err := WarmUpConverters(tos...); err != nil {
+		return classify(ErrConfigInvalid, err)
+	}
+	if fileCfg.Smart {
+		for _, to := range tos {
+			if err := checkSmart(to); err != nil {
+				return classify(ErrConfigInvalid, err)
+			}
+		}
+	}
+	if err := expandTablePatterns(ctx, fileCfg, connTimeout); err != nil {
+		return err
+	}
+
+	// 读取各表的主键清单文件（相对配置文件目录）
+	keys := make([][]KeyTuple, len(fileCfg.Tables))
+	for i, t := range fileCfg.Tables {
+		keys[i] = t.Keys
+		if t.KeysFile == "" {
+			continue
+		}
+		path := t.KeysFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if keys[i], err = LoadKeysFile(path); err != nil {
+			return err
+		}
+		if len(keys[i]) == 0 {
+			return fmt.Errorf("tables[%s] keys_file %s 为空", t.Table, path)
+		}
+		if err := checkKeys(t.PK, keys[i]); err != nil {
+			return fmt.Errorf("tables[%s] %w", t.Table, err)
+		}
+	}
+
+	// 已完成表清单：dry-run 只读取不写入；显式 manifest 优先，否则 resume 时使用状态目录
+	state := ResolveStateDir(fileCfg.StateDir, baseDir)
+	var manifest *tableManifest
+	if path := fileCfg.Manifest; path != "" || fileCfg.Resume {
+		if path == "" {
+			path = state.ManifestPath(fileCfg.DSN)
+		} else if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if manifest, err = loadTableManifest(path); err != nil {
+			return err
+		}
 	}
 
 	// 多表并发控制
 	sem := make(chan struct{}, fileCfg.TablesParallel)
+	var inflight chan struct{} // 各表共享的 UPDATE 并发预算
+	if n := fileCfg.GlobalMaxInflight; n > 0 {
+		inflight = make(chan struct{}, n)
+		if n < fileCfg.TablesParallel {
+			log.Printf("[mysql] global_max_inflight=%d 小于 tables_parallel=%d，各表的 UPDATE 将排队执行", n, fileCfg.TablesParallel)
+		}
+	}
+	var globalRate <-chan time.Time // 各表共享的限速令牌（global_rps）
+	if n := fileCfg.GlobalRPS; n > 0 {
+		tk := newRateTicker(n)
+		defer tk.Stop()
+		globalRate = tk.C
+	}
+	if fileCfg.MaxOpenConns == PoolAuto {
+		log.Printf("[mysql] max_open=auto：tables_parallel=%d 时预计最多同时占用 %d 个数据库连接", fileCfg.TablesParallel, fileCfg.autoPoolTotal())
+	}
+	budget := newErrorBudget(intOr(fileCfg.MaxErrors, defaultMaxErrors)) // max_errors 按所有表合计
 	var wg sync.WaitGroup
 
 	// 多进度条容器
 	p := mpb.New(mpb.WithWidth(60), mpb.WithWaitGroup(&wg))
 
+	// 进度条排序：默认按配置顺序（而非 goroutine 启动顺序），label 按显示名排序，size 按行数降序（在 RunMySQLWithProgress 中按总量设置）
+	priorities := make([]int, len(fileCfg.Tables))
+	order := make([]int, len(fileCfg.Tables))
+	for i := range order {
+		order[i] = i
+	}
+	if fileCfg.BarOrder == "label" {
+		label := func(i int) string {
+			if l := fileCfg.Tables[i].Label; l != "" {
+				return l
+			}
+			return fileCfg.Tables[i].Table
+		}
+		sort.SliceStable(order, func(a, b int) bool { return label(order[a]) < label(order[b]) })
+	}
+	for rank, i := range order {
+		priorities[i] = rank
+	}
+	var agg *aggregateBar
+	if len(fileCfg.Tables) > 1 {
+		agg = newAggregateBar(p, len(fileCfg.Tables))
+	}
+
 	// 错误收集
 	errCh := make(chan error, len(fileCfg.Tables))
+	groups := lockGroups{}
 
-	for _, t := range fileCfg.Tables {
-		// 表级覆盖
-		batch := fileCfg.BatchSize
-		if t.BatchSize > 0 {
-			batch = t.BatchSize
+	// 各表结果按配置下标缓冲，全部结束后按配置顺序统一输出（进度条仍实时刷新）
+	sums := make([]tableSummary, len(fileCfg.Tables))
+	for i := range sums {
+		sums[i].status = "未开始"
+	}
+
+	// 调度顺序：决定各表占用并发名额的先后；进度条排序与结果汇总仍按 bar_order / 配置顺序
+	for _, i := range fileCfg.tableSchedule(ctx, connTimeout) {
+		t := fileCfg.Tables[i]
+		if manifest.isDone(t.Table) {
+			log.Printf("[mysql] 清单记录表 %s 已完成，跳过", t.Table)
+			sums[i].status = "已跳过"
+			continue
 		}
-		workers := fileCfg.Workers
-		if t.Workers > 0 {
-			workers = t.Workers
+		// 表级覆盖
+		batch, workers, rps, to := fileCfg.tableOverrides(t)
+		if g := fileCfg.GlobalRPS; g > 0 && rps > g {
+			log.Printf("[mysql] table=%s rps=%d 高于 global_rps=%d，实际速率受 global_rps 限制（且与其它表共享）", t.Table, rps, g)
 		}
-		rps := fileCfg.RPS
-		if t.RPS > 0 {
-			rps = t.RPS
+		watermark := t.WatermarkFile
+		if watermark != "" && !filepath.IsAbs(watermark) {
+			watermark = filepath.Join(baseDir, watermark)
 		}
 		cfg := MySQLConfig{
 			DSN:             fileCfg.DSN,
+			ReadDSN:         fileCfg.ReadDSN,
 			Table:           t.Table,
 			PK:              t.PK,
 			IdentifyBy:      t.IdentifyBy,
 			Columns:         t.Columns,
-			To:              fileCfg.To,
+			SelectSQL:       t.SelectSQL,
+			To:              to,
+			Normalize:       fileCfg.Normalize,
+			NormalizeInput:  fileCfg.NormalizeInput,
+			PunctOnly:       fileCfg.PunctOnly,
+			Smart:           fileCfg.Smart,
+			ConvertKeys:     fileCfg.ConvertKeys,
+			ExplainSkip:     fileCfg.ExplainSkip,
 			BatchSize:       batch,
 			Workers:         workers,
 			RPS:             rps,
@@ -130,29 +459,125 @@ func RunMySQLFromFileConfig(fileCfg *MySQLFileConfig, baseDir string) error {
 			MaxOpenConns:    fileCfg.MaxOpenConns,
 			MaxIdleConns:    fileCfg.MaxIdleConns,
 			ConnMaxLifetime: dur,
+			Stats:           opts.Stats,
+			LengthReport:    opts.LengthReport,
+			TermReport:      opts.TermReport,
+			Metrics:         opts.Metrics,
+			ChangeLog:       opts.ChangeLog,
+			Events:          opts.Events,
+			Approved:        opts.Approved,
+			RowBackup:       opts.RowBackup,
+			BatchHook:       opts.BatchHook,
+
+			StreamResults:     fileCfg.StreamResults,
+			InterpolateParams: fileCfg.InterpolateParams,
+
+			IncrementalColumn: t.IncrementalColumn,
+			Since:             t.Since,
+			WatermarkFile:     watermark,
+			IdentifyUnique:    t.IdentifyUnique,
+			StrictAffected:    fileCfg.StrictAffected,
+			Label:             t.Label,
+			QueryRetryMax:     intOr(fileCfg.QueryRetryMax, defaultQueryRetryMax),
+			QueryRetryDelay:   retryDelay,
+			PrefilterNonASCII: fileCfg.PrefilterNonASCII,
+			ConnectTimeout:    connTimeout,
+			Keys:              keys[i],
+			PKMin:             t.PKMin,
+			PKMax:             t.PKMax,
+			Segments:          t.Segments,
+			Replace:           mergeReplace(fileCfg.Replace, t.Replace),
+			StateDir:          state,
+			CountMode:         fileCfg.CountMode,
+			Shadow:            fileCfg.ShadowTable,
+			Verify:            fileCfg.Verify,
+			HeavyIndexRPS:     fileCfg.HeavyIndexRPS,
+			MaxErrors:         intOr(fileCfg.MaxErrors, defaultMaxErrors),
+
+			TreatWhitespaceEmpty: fileCfg.TreatWhitespaceEmpty,
+			AllowEmptyResult:     fileCfg.AllowEmptyResult,
+			SessionCollation:     fileCfg.SessionCollation,
+			Isolation:            fileCfg.Isolation,
+
+			barOrder:    fileCfg.BarOrder,
+			barPriority: priorities[i],
+			aggregate:   agg,
+			inflight:    inflight,
+			globalRate:  globalRate,
+			errBudget:   budget,
+			counts:      &tableMetrics{},
+		}
+		if t.Join != "" {
+			cfg.SelectSQL = joinSelectSQL(t)
 		}
 
-		sem <- struct{}{}
-		wg.Add(1)
-		go func(cfg MySQLConfig) {
-			defer wg.Done()
+		// acquire 占用一个并发名额；已中止时放弃该表
+		acquire := func() bool {
+			sem <- struct{}{}
+			if ctx.Err() != nil || budget.tripped() != nil {
+				<-sem
+				log.Printf("[mysql] 已中止，跳过未开始的表 %s", t.Table)
+				return false
+			}
+			sums[i].counts = cfg.counts
+			return true
+		}
+		run := func() {
 			defer func() { <-sem }()
-			if err := RunMySQLWithProgress(cfg, p); err != nil {
+			if err := RunMySQLWithProgress(ctx, cfg, p); err != nil {
+				sums[i].status, sums[i].err = "失败", err
 				errCh <- fmt.Errorf("table %s: %w", cfg.Table, err)
+				return
+			}
+			sums[i].status = "完成"
+			if !cfg.DryRun {
+				if err := manifest.markDone(cfg.Table); err != nil {
+					errCh <- err
+				}
+			}
+		}
+		if t.LockGroup == "" {
+			if !acquire() {
+				continue
 			}
-		}(cfg)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run()
+			}()
+			continue
+		}
+		// 同一 lock_group 的表按调度顺序（默认即配置顺序）依次执行：等组内前一张表结束后才占用并发名额，不阻塞其它表
+		groups.goAfter(&wg, t.LockGroup, func() {
+			if acquire() {
+				run()
+			}
+		})
 	}
 
 	// 等待所有任务 & 进度条结束
 	wg.Wait()
+	agg.finish()
 	p.Wait()
 	close(errCh)
+	rs.DryRun = fileCfg.DryRun
+	for i, s := range sums {
+		t := tableStats(fileCfg.Tables[i].Table, s.status, s.counts, s.err)
+		t.Label = fileCfg.Tables[i].Label
+		rs.addTable(t, fileCfg.DryRun)
+	}
+
+	if len(sums) > 1 {
+		var buf strings.Builder
+		_ = writeTableSummaries(&buf, rs.Tables, fileCfg.DryRun)
+		log.Print(buf.String())
+	}
 
 	// 返回第一个错误（若有）
 	for e := range errCh {
 		return e
 	}
-	return nil
+	return ctx.Err()
 }
 
 // 解析 --conf 目标（保持不变）
@@ -190,45 +615,29 @@ func ResolveConfigTargets(conf string) ([]string, error) {
 	return nil, fmt.Errorf("不支持的 --conf 目标（需为 .json 文件或目录）：%s", target)
 }
 
-// 生成配置模板 JSON（含字段解释与示例）
+// GenerateConfigTemplate 生成完整的配置模板 JSON（含全部字段解释与示例），见 GenerateConfigPreset
 func GenerateConfigTemplate(dir string) (string, error) {
-	if strings.TrimSpace(dir) == "" {
-		dir = "."
-	}
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", err
-	}
-	out := filepath.Join(dir, "tradify_config_template.json")
+	return GenerateConfigPreset(dir, PresetFull, "")
+}
+
+// fullTemplate 完整模板：列出全部字段的解释，示例覆盖有主键、复合主键、identify_by 与整行匹配的表
+func fullTemplate() map[string]interface{} {
 	template := map[string]interface{}{
-		"_说明": map[string]interface{}{
-			"dsn":                  `MySQL 连接串 (必填)，示例：user:pass@tcp(127.0.0.1:3306)/db?charset=utf8mb4&parseTime=true`,
-			"to":                   `OpenCC 转换配置，默认 s2twp（简体->繁体（台湾））`,
-			"batch_size":           "每批处理行数，默认 500",
-			"workers":              "全局并发 worker 数，默认 8；若表条目提供同名字段则优先生效",
-			"rps":                  "全局限速（每秒最大处理行数），默认 0 不限速",
-			"dry_run":              "试运行，true=只打印更新不落库；false=真实写入",
-			"max_open":             "数据库最大打开连接数，默认 200",
-			"max_idle":             "数据库最大空闲连接数，默认 20",
-			"conn_max_lifetime":    "连接最大生命周期（Go duration），默认 30m",
-			"tables_parallel":      "同时并发处理的表数量（默认1）",
-			"tables[].table":       "表名（必填）",
-			"tables[].pk":          "主键列数组，可单列或复合主键（可选）",
-			"tables[].identify_by": "无主键时用于定位行的列（可选）。若均未提供，将退化为整行匹配（最慢，不推荐）",
-			"tables[].columns":     "需要转换的列名数组（必填）",
-			"tables[].workers":     "表级并发覆盖（可选）",
-			"tables[].batch_size":  "表级批大小覆盖（可选）",
-			"tables[].rps":         "表级限速覆盖（可选）",
-		},
-		"dsn":               `root:123456@tcp(127.0.0.1:3306)/yourdb?charset=utf8mb4&parseTime=true`,
-		"to":                "s2twp",
-		"batch_size":        500,
-		"workers":           8,
-		"rps":               0,
-		"dry_run":           true,
-		"max_open":          200,
-		"max_idle":          20,
-		"conn_max_lifetime": "30m",
-		"tables_parallel":   1,
+		"_说明":                templateDocs(),
+		"dsn":                `root:123456@tcp(127.0.0.1:3306)/yourdb?charset=utf8mb4&parseTime=true`,
+		"to":                 "s2twp",
+		"normalize":          "none",
+		"normalize_input":    false,
+		"batch_size":         500,
+		"workers":            8,
+		"rps":                0,
+		"dry_run":            true,
+		"max_open":           200,
+		"max_idle":           20,
+		"conn_max_lifetime":  "30m",
+		"tables_parallel":    1,
+		"stream_results":     false,
+		"interpolate_params": false,
 		"tables": []map[string]interface{}{
 			{
 				"table":      "posts",
@@ -255,20 +664,138 @@ func GenerateConfigTemplate(dir string) (string, error) {
 			},
 		},
 	}
+	return template
+}
+
+// templateDocs 模板中“_说明”的字段解释，键为字段路径（表级字段为 tables[].字段）
+func templateDocs() map[string]string {
+	return map[string]string{
+		"dsn":                         `MySQL 连接串 (必填)，示例：user:pass@tcp(127.0.0.1:3306)/db?charset=utf8mb4&parseTime=true`,
+		"to":                          `OpenCC 转换配置，默认 s2twp（简体->繁体（台湾））`,
+		"normalize":                   "转换输出的 Unicode 规范化：nfc | nfkc | none（默认 none）",
+		"normalize_input":             "是否在转换前也对输入做同样的规范化（默认 false）",
+		"convert_keys":                "JSON 列的对象键也转换（默认 false）",
+		"explain_skip":                "逐行逐列记录未转换的原因（默认 false，日志量大）",
+		"smart":                       "按值判定：以繁体为主的值只修正零散的简体字（默认 false）",
+		"punct_only":                  "仅标点模式：只保留标点、全半角等非汉字改动（默认 false）",
+		"batch_size":                  "每批处理行数，默认 500",
+		"workers":                     "全局并发 worker 数，默认 8；若表条目提供同名字段则优先生效",
+		"rps":                         "全局限速（每秒最大处理行数），默认 0 不限速",
+		"dry_run":                     "试运行，true=只打印更新不落库；false=真实写入",
+		"max_open":                    "每张表的最大打开连接数，默认 200；\"auto\" 按需估算",
+		"max_idle":                    "每张表的最大空闲连接数，默认 20；\"auto\" 与 max_open 相同",
+		"conn_max_lifetime":           "连接最大生命周期（Go duration），默认 30m",
+		"connect_timeout":             "建立连接的超时（Go duration），默认 10s",
+		"manifest":                    "已完成表清单文件（可选），重跑时跳过已完成的表",
+		"state_dir":                   "状态目录（可选），默认 .tradify-state",
+		"resume":                      "在状态目录中记录已完成的表，重跑时跳过（默认 false）",
+		"strict_affected":             "UPDATE 影响超过 1 行时中止该表（默认 false 仅告警）",
+		"table_order":                 "表的调度顺序：config（默认）| size-asc | size-desc",
+		"bar_order":                   "多表进度条排序：config（默认）| label | size",
+		"query_retry_max":             "批次查询遇暂时性错误的最大重试次数，默认 3",
+		"query_retry_delay":           "查询重试间隔（Go duration），默认 5s",
+		"prefilter_nonascii":          "只读取目标列含非 ASCII 字符的行（默认 false）",
+		"tables_parallel":             "同时并发处理的表数量（默认1）",
+		"session_collation":           "每个新连接的会话排序规则（可选，如 utf8mb4_bin）",
+		"isolation":                   "每个新连接的事务隔离级别（可选，如 READ COMMITTED）",
+		"allow_empty_result":          "允许非空值的转换结果为空串（默认 false 拒绝写入）",
+		"treat_whitespace_empty":      "只含空白字符的值与空串一样跳过（默认 false）",
+		"count_mode":                  "进度条总量来源：exact（默认）| information_schema | none",
+		"shadow_table":                "仅限 dry_run：转换结果写入影子表，原表不变（默认 false）",
+		"verify":                      "真实写入后按主键回读核对（默认 false）",
+		"global_max_inflight":         "所有表合计同时进行中的 UPDATE 上限（默认 0 不限制）",
+		"global_rps":                  "所有表合计每秒最大处理行数（默认 0 不限制）",
+		"stream_results":              "边读边处理结果集，不在客户端缓存整批（默认 false）",
+		"read_dsn":                    "只读副本连接串（可选），读查询走副本",
+		"interpolate_params":          "驱动端插值参数（默认 false）",
+		"exclude_tables":              "table_pattern 展开时排除的表（可选），支持通配符",
+		"tables[].table":              "表名（与 table_pattern 二选一）",
+		"tables[].table_pattern":      "表名模式（与 table 二选一），通配符或 re: 开头的正则",
+		"tables[].pk":                 "主键列数组，可单列或复合主键（可选）",
+		"tables[].identify_by":        "无主键时用于定位行的列（可选）。若均未提供，将退化为整行匹配（最慢，不推荐）",
+		"tables[].identify_unique":    "要求 identify_by 被唯一索引覆盖（默认 false 仅告警）",
+		"tables[].columns":            "需要转换的列名数组（必填）",
+		"tables[].select_sql":         "自定义行来源 SELECT（可选，高级用法），需提供 pk",
+		"tables[].join":               "联接子句（可选，高级用法），只处理联接命中的行，需提供 pk",
+		"tables[].key_expr":           "配合 join：联接后筛选行的条件（可选）",
+		"tables[].incremental_column": "增量列（可选，如 updated_at）",
+		"tables[].since":              "增量起点（可选）：时间/数值或 Go duration",
+		"tables[].watermark_file":     "水位文件（可选），下次运行自动续跑",
+		"tables[].label":              "进度条显示名（可选，默认表名）",
+		"tables[].keys":               "只处理这些主键对应的行（可选，需提供 pk）",
+		"tables[].keys_file":          "主键清单 CSV 文件（可选，与 keys 二选一）",
+		"tables[].pk_min":             "首个主键列下限（可选，数值主键，闭区间）",
+		"tables[].pk_max":             "首个主键列上限（可选，数值主键，闭区间）",
+		"tables[].segments":           "按列只转换值中的某一段（可选），键为列名",
+		"tables[].to":                 "表级 OpenCC 转换配置覆盖（可选）",
+		"tables[].workers":            "表级并发覆盖（可选）",
+		"tables[].batch_size":         "表级批大小覆盖（可选）",
+		"tables[].rps":                "表级限速覆盖（可选，0 为不限速）",
+		"replace":                     "转换后的自定义替换（可选）：{\"原文\":\"替换为\"}",
+		"tables[].replace":            "表级自定义替换（可选），与全局 replace 合并",
+		"tables[].lock_group":         "互斥组名（可选），同组的表不会同时处理",
+	}
+}
 
-	// 用 Encoder 并关闭 HTML 转义
-	f, err := os.Create(out)
-	if err != nil {
-		return "", err
+// intOr 可选整数字段的取值：未设置（nil）时为 def，显式的 0 保持为 0
+func intOr(p *int, def int) int {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+// schemaDSN 表结构读取（枚举表、表大小、列定义与索引）使用的连接串：配置了 read_dsn 时为副本，否则为主库
+func (c *MySQLFileConfig) schemaDSN() string {
+	if c.ReadDSN != "" {
+		return c.ReadDSN
+	}
+	return c.DSN
+}
+
+// tableOverrides 返回表条目实际生效的 batch_size / workers / rps / to（表级覆盖优先，否则取全局值；表级 rps 显式为 0 时不限速）
+func (c *MySQLFileConfig) tableOverrides(t MySQLTblEntry) (batch, workers, rps int, to string) {
+	batch, workers, rps, to = c.BatchSize, c.Workers, c.RPS, c.To
+	if t.BatchSize > 0 {
+		batch = t.BatchSize
+	}
+	if t.Workers > 0 {
+		workers = t.Workers
+	}
+	rps = intOr(t.RPS, rps)
+	if t.To != "" {
+		to = t.To
 	}
-	defer f.Close()
+	return
+}
 
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	enc.SetEscapeHTML(false) // 关键：避免 & < > 转义
-	if err := enc.Encode(template); err != nil {
-		return "", err
+// Effective 返回合并默认值与表级覆盖后的配置副本（供 --print-config 排查）：各表条目的 to / batch_size / workers / rps / replace
+// 填为实际生效的值，DSN 隐去密码。table_pattern 条目在运行时连库才展开，这里原样列出
+func (c *MySQLFileConfig) Effective() MySQLFileConfig {
+	out := *c
+	out.DSN = RedactDSN(c.DSN)
+	if c.ReadDSN != "" {
+		out.ReadDSN = RedactDSN(c.ReadDSN)
+	}
+	out.Tables = make([]MySQLTblEntry, len(c.Tables))
+	for i, t := range c.Tables {
+		var rps int
+		t.BatchSize, t.Workers, rps, t.To = c.tableOverrides(t)
+		t.RPS = &rps // 实际生效值为 0 时同样输出
+		t.Replace = mergeReplace(c.Replace, t.Replace)
+		out.Tables[i] = t
 	}
+	return out
+}
 
-	return out, nil
+// RedactDSN 隐去 DSN 中的密码；无法解析时整体隐去
+func RedactDSN(dsn string) string {
+	c, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "***"
+	}
+	if c.Passwd != "" {
+		c.Passwd = "***"
+	}
+	return c.FormatDSN()
 }
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadConfig 把 JSON 写入临时文件并用 LoadMySQLFileConfig 读取
+func loadConfig(t *testing.T, js string) (*MySQLFileConfig, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(js), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadMySQLFileConfig(path)
+}
+
+func TestQueryRetryMaxExplicitZero(t *testing.T) {
+	for _, tc := range []struct {
+		field string
+		want  int
+	}{
+		{``, defaultQueryRetryMax},
+		{`"query_retry_max": 0,`, 0},
+		{`"query_retry_max": 5,`, 5},
+		{`"query_retry_max": -1,`, -1},
+	} {
+		cfg, err := loadConfig(t, `{"dsn": "u:p@tcp(127.0.0.1:3306)/db", `+tc.field+` "tables": [{"table": "t", "pk": ["id"], "columns": ["c"]}]}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := intOr(cfg.QueryRetryMax, defaultQueryRetryMax); got != tc.want {
+			t.Errorf("%s: query_retry_max = %d, want %d", tc.field, got, tc.want)
+		}
+	}
+}
+
+// 表级覆盖优先于全局值；表级 rps 显式为 0 时该表不限速，不继承全局 rps
+func TestTableOverrides(t *testing.T) {
+	cfg, err := loadConfig(t, `{"dsn": "u:secret@tcp(127.0.0.1:3306)/db", "to": "s2t", "batch_size": 200, "workers": 4, "rps": 100, "tables": [
+		{"table": "a", "pk": ["id"], "columns": ["c"]},
+		{"table": "b", "pk": ["id"], "columns": ["c"], "to": "s2twp", "batch_size": 50, "workers": 2, "rps": 10},
+		{"table": "c", "pk": ["id"], "columns": ["c"], "rps": 0}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type eff struct {
+		batch, workers, rps int
+		to                  string
+	}
+	for i, want := range []eff{{200, 4, 100, "s2t"}, {50, 2, 10, "s2twp"}, {200, 4, 0, "s2t"}} {
+		var got eff
+		got.batch, got.workers, got.rps, got.to = cfg.tableOverrides(cfg.Tables[i])
+		if got != want {
+			t.Errorf("%s: overrides = %+v, want %+v", cfg.Tables[i].Table, got, want)
+		}
+	}
+
+	// --print-config 的输出：各表填为实际生效的值（含为 0 的 rps），DSN 隐去密码
+	bs, err := json.Marshal(cfg.Effective())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		DSN    string `json:"dsn"`
+		Tables []map[string]any
+	}
+	if err := json.Unmarshal(bs, &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.DSN, "secret") || !strings.Contains(out.DSN, "u:***@") {
+		t.Errorf("dsn = %q", out.DSN)
+	}
+	for i, want := range []eff{{200, 4, 100, "s2t"}, {50, 2, 10, "s2twp"}, {200, 4, 0, "s2t"}} {
+		tbl := out.Tables[i]
+		rps, ok := tbl["rps"].(float64)
+		if !ok || tbl["batch_size"] != float64(want.batch) || tbl["workers"] != float64(want.workers) || int(rps) != want.rps || tbl["to"] != want.to {
+			t.Errorf("effective table %d = %v, want %+v", i, tbl, want)
+		}
+	}
+	// 原配置不受影响
+	if cfg.Tables[0].RPS != nil || cfg.DSN != "u:secret@tcp(127.0.0.1:3306)/db" {
+		t.Error("Effective modified the original config")
+	}
+}
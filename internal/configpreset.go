@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 配置模板预设（mysql gen-config --preset）
+const (
+	PresetFull = "full" // 完整模板：全部字段的解释（默认）
+)
+
+// ConfigPreset 一种场景的配置模板：只含该场景相关的字段，“_说明”中逐项解释
+type ConfigPreset struct {
+	Name  string
+	Desc  string
+	build func() map[string]interface{}
+}
+
+// ConfigPresets 全部预设，按 gen-config --list 的展示顺序
+func ConfigPresets() []ConfigPreset {
+	return []ConfigPreset{
+		{Name: PresetFull, Desc: "完整模板：列出全部字段的解释，示例含多种表（默认）", build: fullTemplate},
+		{Name: "single-table", Desc: "单表：有主键的一张表，先 dry_run 预览再写入", build: singleTablePreset},
+		{Name: "multi-table-parallel", Desc: "多表并发：tables_parallel、调度顺序、全局并发与限速、互斥组", build: multiTablePreset},
+		{Name: "no-pk", Desc: "无主键的表：identify_by 定位、唯一性要求与整行匹配", build: noPKPreset},
+		{Name: "incremental", Desc: "增量运行：按 updated_at 等列只处理新行，水位与状态目录续跑", build: incrementalPreset},
+	}
+}
+
+// presetNameRe 模板文件名标识：字母、数字、下划线与连字符
+var presetNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// GenerateConfigPreset 在 dir 下生成预设 preset 的配置模板，返回文件路径；预设或 name 不合法时返回 ErrConfigInvalid。
+// name 为文件名标识：生成 tradify_config_<name>.json；为空时完整模板为 tradify_config_template.json，其余为 tradify_config_<preset>.json。
+// 已存在的同名文件会被覆盖
+func GenerateConfigPreset(dir, preset, name string) (string, error) {
+	var p *ConfigPreset
+	for _, c := range ConfigPresets() {
+		if c.Name == preset {
+			p = &c
+			break
+		}
+	}
+	if p == nil {
+		return "", classify(ErrConfigInvalid, fmt.Errorf("未知的模板预设：%s（可用：%s）", preset, strings.Join(presetNames(), " | ")))
+	}
+	switch {
+	case name == "" && preset == PresetFull:
+		name = "template"
+	case name == "":
+		name = preset
+	case !presetNameRe.MatchString(name):
+		return "", classify(ErrConfigInvalid, fmt.Errorf("模板文件名标识只能包含字母、数字、下划线与连字符：%s", name))
+	}
+	if strings.TrimSpace(dir) == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	out := filepath.Join(dir, "tradify_config_"+name+".json")
+
+	// 用 Encoder 并关闭 HTML 转义
+	f, err := os.Create(out)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false) // 关键：避免 & < > 转义
+	if err := enc.Encode(p.build()); err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+func presetNames() []string {
+	var names []string
+	for _, p := range ConfigPresets() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// presetTemplate 组装预设模板：_场景 为场景说明，_说明 取 templateDocs 中 fields 各项的解释（外加 dsn / to / dry_run 等通用字段）
+func presetTemplate(scene string, fields []string, body map[string]interface{}) map[string]interface{} {
+	all := templateDocs()
+	docs := map[string]string{}
+	for _, k := range append([]string{"dsn", "to", "batch_size", "workers", "dry_run", "tables[].table", "tables[].pk", "tables[].columns"}, fields...) {
+		docs[k] = all[k]
+	}
+	body["_场景"] = scene
+	body["_说明"] = docs
+	body["dsn"] = `root:123456@tcp(127.0.0.1:3306)/yourdb?charset=utf8mb4&parseTime=true`
+	body["to"] = "s2twp"
+	body["dry_run"] = true
+	return body
+}
+
+func singleTablePreset() map[string]interface{} {
+	return presetTemplate(
+		"单表：先以 dry_run=true 运行并检查输出（可加 --dry-run-output 导出变更清单），确认无误后改为 false 写入",
+		[]string{"rps", "tables[].label"},
+		map[string]interface{}{
+			"batch_size": 500,
+			"workers":    8,
+			"rps":        0,
+			"tables": []map[string]interface{}{
+				{
+					"table":   "posts",
+					"pk":      []string{"id"},
+					"columns": []string{"title", "content"},
+					"label":   "文章",
+				},
+			},
+		})
+}
+
+func multiTablePreset() map[string]interface{} {
+	return presetTemplate(
+		"多表并发：tables_parallel 张表同时处理，每张表独立建连接池（总连接数为各表之和）；用 global_max_inflight / global_rps 限制数据库的总压力，lock_group 让有关联的表依次处理",
+		[]string{"tables_parallel", "table_order", "bar_order", "global_max_inflight", "global_rps", "max_open", "tables[].workers", "tables[].rps", "tables[].lock_group"},
+		map[string]interface{}{
+			"batch_size":          500,
+			"workers":             4,
+			"tables_parallel":     4,
+			"table_order":         TableOrderSizeDesc,
+			"bar_order":           "size",
+			"global_max_inflight": 16,
+			"global_rps":          2000,
+			"max_open":            "auto",
+			"tables": []map[string]interface{}{
+				{"table": "posts", "pk": []string{"id"}, "columns": []string{"title", "content"}, "workers": 8},
+				{"table": "comments", "pk": []string{"id"}, "columns": []string{"body"}},
+				{"table": "orders", "pk": []string{"order_id"}, "columns": []string{"remark"}, "lock_group": "order"},
+				{"table": "order_items", "pk": []string{"order_id", "item_id"}, "columns": []string{"name"}, "lock_group": "order"},
+				{"table": "audit_log", "pk": []string{"id"}, "columns": []string{"message"}, "rps": 200},
+			},
+		})
+}
+
+func noPKPreset() map[string]interface{} {
+	return presetTemplate(
+		"无主键的表：优先用唯一列（identify_by）定位行；未提供时自动选用列均为 NOT NULL 的唯一索引，仍无则退化为整行匹配（最慢）",
+		[]string{"tables[].identify_by", "tables[].identify_unique", "strict_affected"},
+		map[string]interface{}{
+			"batch_size":      500,
+			"workers":         4,
+			"strict_affected": true,
+			"tables": []map[string]interface{}{
+				{"table": "comments", "identify_by": []string{"uuid"}, "identify_unique": true, "columns": []string{"body"}},
+				{"table": "legacy_table", "columns": []string{"desc"}},
+			},
+		})
+}
+
+func incrementalPreset() map[string]interface{} {
+	return presetTemplate(
+		"增量运行：每次只处理增量列大于水位的行，成功后把本次读到的最大值写入水位（state_dir 或 watermark_file），下次运行自动续跑；首次运行可用 since 指定起点",
+		[]string{"state_dir", "resume", "tables[].incremental_column", "tables[].since", "tables[].watermark_file"},
+		map[string]interface{}{
+			"batch_size": 500,
+			"workers":    8,
+			"state_dir":  ".tradify-state",
+			"resume":     true,
+			"tables": []map[string]interface{}{
+				{"table": "posts", "pk": []string{"id"}, "columns": []string{"title", "content"}, "incremental_column": "updated_at", "since": "24h"},
+				{"table": "comments", "pk": []string{"id"}, "columns": []string{"body"}, "incremental_column": "updated_at", "watermark_file": "comments.watermark"},
+			},
+		})
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigPresetsLoad(t *testing.T) {
+	for _, p := range ConfigPresets() {
+		t.Run(p.Name, func(t *testing.T) {
+			dir := t.TempDir()
+			path, err := GenerateConfigPreset(dir, p.Name, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "tradify_config_" + p.Name + ".json"
+			if p.Name == PresetFull {
+				want = "tradify_config_template.json"
+			}
+			if filepath.Base(path) != want {
+				t.Errorf("path = %s, want %s", path, want)
+			}
+			cfg, err := LoadMySQLFileConfig(path)
+			if err != nil {
+				t.Fatalf("generated preset does not load: %v", err)
+			}
+			if len(cfg.Tables) == 0 {
+				t.Error("preset has no tables")
+			}
+		})
+	}
+}
+
+func TestGenerateConfigPresetName(t *testing.T) {
+	dir := t.TempDir()
+	path, err := GenerateConfigPreset(dir, "incremental", "posts_daily-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "tradify_config_posts_daily-1.json" {
+		t.Errorf("path = %s", path)
+	}
+
+	for _, name := range []string{"../escape", "a b", "x.json", "中文"} {
+		if _, err := GenerateConfigPreset(dir, "single-table", name); !errors.Is(err, ErrConfigInvalid) {
+			t.Errorf("name %q: err = %v, want ErrConfigInvalid", name, err)
+		}
+	}
+	if _, err := GenerateConfigPreset(dir, "no-such-preset", ""); !errors.Is(err, ErrConfigInvalid) {
+		t.Errorf("unknown preset: err = %v, want ErrConfigInvalid", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("invalid names should not write files, dir has %d entries", len(entries))
+	}
+}
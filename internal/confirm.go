@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ConfirmLargeWrite 真实写入前先只读统计将更新的行数：超过 threshold 时在 out 提示并从 in 读取一行，仅输入 yes 时继续；
+// 不超过（含等于）时直接继续，threshold <= 0 时不统计。返回是否继续写入
+func ConfirmLargeWrite(threshold int64, count func() (int64, error), in io.Reader, out io.Writer) (bool, error) {
+	if threshold <= 0 {
+		return true, nil
+	}
+	fmt.Fprintf(out, "只读预扫描：统计将更新的行数（--confirm-rows-threshold=%d）…\n", threshold)
+	n, err := count()
+	if err != nil {
+		return false, err
+	}
+	if n <= threshold {
+		fmt.Fprintf(out, "将更新 %d 行，未超过阈值 %d，直接写入\n", n, threshold)
+		return true, nil
+	}
+	fmt.Fprintf(out, "将更新 %d 行，超过阈值 %d，输入 yes 继续：", n, threshold)
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	return strings.TrimSpace(line) == "yes", nil
+}
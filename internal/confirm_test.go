@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestConfirmLargeWriteThreshold(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		n             int64
+		input         string
+		want, prompts bool
+	}{
+		{"below", 9, "", true, false},
+		{"at threshold", 10, "", true, false},
+		{"above confirmed", 11, "yes\n", true, true},
+		{"above declined", 11, "y\n", false, true},
+		{"above no input", 11, "", false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			ok, err := ConfirmLargeWrite(10, func() (int64, error) { return tc.n, nil }, strings.NewReader(tc.input), &out)
+			if err != nil || ok != tc.want {
+				t.Errorf("ConfirmLargeWrite(n=%d) = %v, %v; want %v", tc.n, ok, err, tc.want)
+			}
+			if got := strings.Contains(out.String(), "输入 yes 继续"); got != tc.prompts {
+				t.Errorf("prompted = %v, want %v:\n%s", got, tc.prompts, out.String())
+			}
+		})
+	}
+
+	// 阈值为 0 时不统计
+	ok, err := ConfirmLargeWrite(0, func() (int64, error) { t.Error("count called"); return 0, nil }, nil, io.Discard)
+	if !ok || err != nil {
+		t.Errorf("disabled = %v, %v", ok, err)
+	}
+	boom := errors.New("boom")
+	if _, err := ConfirmLargeWrite(10, func() (int64, error) { return 0, boom }, nil, io.Discard); !errors.Is(err, boom) {
+		t.Errorf("count error = %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"unicode"
 
 	"github.com/longbridgeapp/opencc"
+	"golang.org/x/text/unicode/norm"
 )
 
 // OpenCC 单例池
@@ -15,22 +16,140 @@ var (
 	ccPool = map[string]*opencc.OpenCC{} // key: to (s2twp等)
 )
 
-// GetConverter 获取/初始化指定转换配置的 OpenCC 实例
+// ChainSep 转换链分隔符：如 s2t>t2tw 依次应用各级配置，前一级的输出作为后一级的输入
+const ChainSep = ">"
+
+// GetConverter 获取/初始化指定转换配置的 OpenCC 实例；转换链（a>b）合并为一个实例，
+// 各级词典链按顺序拼接，与逐级调用 Convert 的结果相同
 func GetConverter(to string) (*opencc.OpenCC, error) {
 	ccMu.Lock()
 	defer ccMu.Unlock()
+	return getConverterLocked(to)
+}
+
+func getConverterLocked(to string) (*opencc.OpenCC, error) {
 	if c, ok := ccPool[to]; ok && c != nil {
 		return c, nil
 	}
-	c, err := opencc.New(to)
-	if err != nil {
-		return nil, fmt.Errorf("init opencc(%s): %w", to, err)
+	stages := strings.Split(to, ChainSep)
+	if len(stages) == 1 {
+		var c *opencc.OpenCC
+		var err error
+		if name, ok := strings.CutPrefix(to, CustomPrefix); ok {
+			c, err = loadCustomDict(name)
+		} else if c, err = opencc.New(to); err != nil {
+			err = fmt.Errorf("init opencc(%s): %w", to, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ccPool[to] = c
+		return c, nil
+	}
+	c := &opencc.OpenCC{Conversion: to}
+	for i, s := range stages {
+		s = strings.TrimSpace(s)
+		if s == "" || strings.HasPrefix(s, "auto-") {
+			return nil, fmt.Errorf("无效的转换链 %q：第 %d 级须为 OpenCC 配置名或 custom:词典文件（auto 模式只能写在最前，如 auto-trad:s2t>t2tw）", to, i+1)
+		}
+		sc, err := getConverterLocked(s)
+		if err != nil {
+			return nil, fmt.Errorf("转换链 %q 第 %d 级：%w", to, i+1, err)
+		}
+		c.DictChains = append(c.DictChains, sc.DictChains...)
 	}
 	ccPool[to] = c
 	return c, nil
 }
 
-// HasChinese 判断是否包含汉字
+// WarmUpConverters 预先初始化所需的 OpenCC 实例（去重）：
+// 配置错误在任何 DB/文件访问前暴露，首行也不再承担冷启动延迟
+func WarmUpConverters(tos ...string) error {
+	seen := map[string]struct{}{}
+	for _, to := range tos {
+		names := []string{to}
+		if a, ok, err := parseAutoTo(to); err != nil {
+			return err
+		} else if ok {
+			names = []string{a.config, "s2t", "t2s"} // 后两者用于方向判定
+		}
+		for _, name := range names {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			if _, err := GetConverter(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 自动方向模式：--to auto-trad / auto-simp，可带 :配置 指定实际使用的 OpenCC 配置（如 auto-trad:s2t）
+const (
+	AutoTrad = "auto-trad" // 目标为繁体，默认 s2twp
+	AutoSimp = "auto-simp" // 目标为简体，默认 t2s
+)
+
+type autoTo struct {
+	toTrad bool
+	config string
+}
+
+// parseAutoTo 解析自动方向模式；非 auto-* 取值返回 ok=false
+func parseAutoTo(to string) (autoTo, bool, error) {
+	mode, config, _ := strings.Cut(to, ":")
+	var a autoTo
+	switch mode {
+	case AutoTrad:
+		a = autoTo{toTrad: true, config: "s2twp"}
+	case AutoSimp:
+		a = autoTo{config: "t2s"}
+	default:
+		return a, false, nil
+	}
+	if config = strings.TrimSpace(config); config != "" {
+		if strings.HasPrefix(config, "auto-") {
+			return a, false, fmt.Errorf("无效的转换配置 %q：auto 模式不能嵌套", to)
+		}
+		a.config = config
+	}
+	return a, true, nil
+}
+
+// DetectScript 判断文本以简体还是繁体为主：分别用 s2t、t2s 转换一遍，
+// 被 s2t 改动的字视为简体专用字、被 t2s 改动的字视为繁体专用字，两者共用的字不计。
+// 返回两类字的数量；两者皆为 0 表示无法区分（如只含简繁同形字）
+func DetectScript(s string) (simp, trad int, err error) {
+	count := func(to string) (int, error) {
+		cc, err := GetConverter(to)
+		if err != nil {
+			return 0, err
+		}
+		out, err := cc.Convert(s)
+		if err != nil {
+			return 0, fmt.Errorf("opencc convert: %w", err)
+		}
+		a, b := []rune(s), []rune(out) // s2t / t2s 为逐字映射，长度一般不变；不等时只比较公共部分
+		n := 0
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if a[i] != b[i] {
+				n++
+			}
+		}
+		return n, nil
+	}
+	if simp, err = count("s2t"); err != nil {
+		return 0, 0, err
+	}
+	if trad, err = count("t2s"); err != nil {
+		return 0, 0, err
+	}
+	return simp, trad, nil
+}
+
+// HasChinese 判断是否包含汉字（unicode.Han，含 CJK 扩展区与日文汉字，不含假名与标点）
 func HasChinese(s string) bool {
 	for _, r := range s {
 		if unicode.Is(unicode.Han, r) {
@@ -53,23 +172,161 @@ func IsASCIIOnly(s string) bool {
 	return true
 }
 
+// ConvertOptions 单次转换的选项（mysql / file 共用）
+type ConvertOptions struct {
+	To             string // OpenCC 转换配置（如 s2twp），或 auto-trad / auto-simp 按内容自动判定方向
+	Normalize      string // 输出 Unicode 规范化：nfc | nfkc | none（空等同 none）
+	NormalizeInput bool   // 转换前是否也对输入做同样的规范化
+
+	Replace *Replacer // 可选：OpenCC 与规范化之后最后一遍自定义替换（见 NewReplacer）
+
+	Smart bool // 按值判定：以繁体为主的值只修正零散的简体字（s2t），其余按 To 整体转换（见 smartConfig）
+
+	PunctOnly bool // 仅标点模式：照常转换后还原所有汉字改动，只保留标点、全半角等非汉字改动（见 keepNonHanChanges）
+}
+
 // ConvertIfNeeded 根据内容判断是否需要转换，避免不必要开销
 func ConvertIfNeeded(to, in string) (string, bool, error) {
-	if in == "" || IsASCIIOnly(in) || !HasChinese(in) {
-		return in, false, nil
+	return ConvertWithOptions(ConvertOptions{To: to}, in)
+}
+
+// ConvertOutcome 单个值的处理结果分类（用于统计快速跳过的命中情况）
+type ConvertOutcome int
+
+const (
+	OutcomeConverted ConvertOutcome = iota // 发生了转换
+	OutcomeEmpty                           // 空串，跳过
+	OutcomeASCIIOnly                       // 纯 ASCII，跳过
+	OutcomeNoChinese                       // 含非 ASCII 但不含汉字，跳过
+	OutcomeUnchanged                       // 经过转换但结果与原文相同
+)
+
+// ConvertWithOptions 同 ConvertIfNeeded，额外支持 Unicode 规范化。
+// 是否“变更”始终以最终输出与原始输入逐字节比较为准。
+func ConvertWithOptions(opts ConvertOptions, in string) (string, bool, error) {
+	out, oc, err := ConvertDetail(opts, in)
+	return out, oc == OutcomeConverted, err
+}
+
+// ConvertDetail 同 ConvertWithOptions，但返回具体的处理结果分类。
+//
+// 快速跳过规则（按顺序判定，命中即不调用 OpenCC）：
+//   - 空串 -> OutcomeEmpty
+//   - 全部字符 <= 0x7F -> OutcomeASCIIOnly
+//   - 不含任何 unicode.Han 字符（如纯假名、带重音的拉丁字母、纯全角标点）-> OutcomeNoChinese
+//
+// 不含汉字及 auto / smart 判定为无需转换的值仍执行 Normalize（如 nfkc 把 ＡＢＣ１２３ 折叠为 ABC123），结果有变化时为 OutcomeConverted。
+//
+// 只要含有至少一个汉字（含日文汉字），整串都会交给 OpenCC，
+// 因此同串中的标点、拉丁字母等即使只有它们发生变化，也会被正确识别为已转换。
+//
+// To 为 auto-trad / auto-simp 时先用 DetectScript 判定：已以目标字形为主（或无法区分）的内容原样返回
+// OutcomeUnchanged，不再经过 OpenCC，避免繁体文本被 s2t 类配置误转（如「后」→「後」）。
+//
+// Smart 时改用 smartConfig 按值选择配置（含 auto-trad 的方向判定）。
+//
+// Replace 在规范化之后执行；快速跳过与 auto / smart 判定为无需转换的值不经 OpenCC，但同样执行 Replace，
+// 有替换时视为已转换。PunctOnly 在最后执行：汉字保持原文，只保留上述各步产生的非汉字改动。
+func ConvertDetail(opts ConvertOptions, in string) (string, ConvertOutcome, error) {
+	if in == "" {
+		return in, OutcomeEmpty, nil
+	}
+	form, err := ParseNormalizeForm(opts.Normalize)
+	if err != nil {
+		return "", OutcomeUnchanged, err
+	}
+	switch {
+	case IsASCIIOnly(in):
+		out, oc := opts.finish(in, in, OutcomeASCIIOnly) // ASCII 在 NFC / NFKC 下不变
+		return out, oc, nil
+	case !HasChinese(in):
+		// 不经 OpenCC，但全角字母数字等兼容字符仍需规范化
+		out, oc := opts.finish(in, normalizeWith(form, in), OutcomeNoChinese)
+		return out, oc, nil
+	}
+	to := opts.To
+	smartFix := false
+	if opts.Smart {
+		if err := checkSmart(to); err != nil {
+			return "", OutcomeUnchanged, err
+		}
+		config, fix, ok, err := smartConfig(to, in)
+		if err != nil {
+			return "", OutcomeUnchanged, err
+		}
+		if !ok {
+			out, oc := opts.finish(in, normalizeWith(form, in), OutcomeUnchanged)
+			return out, oc, nil
+		}
+		to, smartFix = config, fix
+	} else if a, ok, err := parseAutoTo(to); err != nil {
+		return "", OutcomeUnchanged, err
+	} else if ok {
+		simp, trad, err := DetectScript(in)
+		if err != nil {
+			return "", OutcomeUnchanged, err
+		}
+		if (a.toTrad && simp <= trad) || (!a.toTrad && trad <= simp) {
+			out, oc := opts.finish(in, normalizeWith(form, in), OutcomeUnchanged)
+			return out, oc, nil
+		}
+		to = a.config
 	}
 	cc, err := GetConverter(to)
 	if err != nil {
-		return "", false, err
+		return "", OutcomeUnchanged, err
 	}
-	out, err := cc.Convert(in)
+	src := in
+	if form != nil && opts.NormalizeInput {
+		src = form.String(src)
+	}
+	out, err := cc.Convert(src)
 	if err != nil {
-		return "", false, fmt.Errorf("opencc convert: %w", err)
+		return "", OutcomeUnchanged, fmt.Errorf("opencc convert: %w", err)
+	}
+	if smartFix {
+		if out, err = keepSimplifiedFixes(src, out); err != nil {
+			return "", OutcomeUnchanged, err
+		}
+	}
+	out, oc := opts.finish(in, normalizeWith(form, out), OutcomeUnchanged)
+	return out, oc, nil
+}
+
+// finish 对 out 执行 Replace 与 PunctOnly；结果与原文 in 相同时返回 in 与 same，否则为 OutcomeConverted
+func (opts ConvertOptions) finish(in, out string, same ConvertOutcome) (string, ConvertOutcome) {
+	out = opts.Replace.Apply(out)
+	if opts.PunctOnly {
+		out = keepNonHanChanges(in, out)
 	}
 	if out == in {
-		return in, false, nil
+		return in, same
+	}
+	return out, OutcomeConverted
+}
+
+// normalizeWith 按 form 规范化 s；form 为 nil（none）时原样返回
+func normalizeWith(form *norm.Form, s string) string {
+	if form == nil {
+		return s
+	}
+	return form.String(s)
+}
+
+// ParseNormalizeForm 解析 --normalize 取值；none/空 返回 nil 表示不做规范化
+func ParseNormalizeForm(s string) (*norm.Form, error) {
+	var f norm.Form
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none":
+		return nil, nil
+	case "nfc":
+		f = norm.NFC
+	case "nfkc":
+		f = norm.NFKC
+	default:
+		return nil, fmt.Errorf("不支持的 normalize：%q（可选 nfc、nfkc、none）", s)
 	}
-	return out, true, nil
+	return &f, nil
 }
 
 // SplitCSV 将逗号分隔字符串切分并清理空白
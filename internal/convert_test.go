@@ -0,0 +1,178 @@
+package internal
+
+import "testing"
+
+func TestConvertDetailSkipRules(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts ConvertOptions
+		in   string
+		out  string
+		oc   ConvertOutcome
+	}{
+		{"empty", ConvertOptions{To: "s2twp"}, "", "", OutcomeEmpty},
+		{"ascii", ConvertOptions{To: "s2twp"}, "hello, world", "hello, world", OutcomeASCIIOnly},
+		{"accented latin", ConvertOptions{To: "s2twp"}, "café", "café", OutcomeNoChinese},
+		{"kana only", ConvertOptions{To: "s2twp"}, "カタカナ", "カタカナ", OutcomeNoChinese},
+		{"full-width punctuation only", ConvertOptions{To: "s2twp"}, "“”，。", "“”，。", OutcomeNoChinese},
+		// 不含汉字的值不经 OpenCC，但仍执行规范化
+		{"no han with nfkc", ConvertOptions{To: "s2t", Normalize: "nfkc"}, "Ｈｅｌｌｏ，", "Hello,", OutcomeConverted},
+		{"auto-trad already traditional with nfkc", ConvertOptions{To: "auto-trad", Normalize: "nfkc"}, "繁體中文ＡＢＣ", "繁體中文ABC", OutcomeConverted},
+		{"no han with nfc unchanged", ConvertOptions{To: "s2t", Normalize: "nfc"}, "カタカナ", "カタカナ", OutcomeNoChinese},
+		{"simplified", ConvertOptions{To: "s2twp"}, "简体中文", "簡體中文", OutcomeConverted},
+		{"mixed latin and han", ConvertOptions{To: "s2t"}, "Go 语言", "Go 語言", OutcomeConverted},
+		{"kanji with kana", ConvertOptions{To: "s2t"}, "漢字とかな", "漢字とかな", OutcomeUnchanged},
+		{"already traditional", ConvertOptions{To: "s2twp"}, "繁體中文", "繁體中文", OutcomeUnchanged},
+		// OpenCC 不改标点：全是汉字与全角标点、汉字不需转换时结果不变
+		{"han with full-width punctuation", ConvertOptions{To: "s2twp"}, "中文，中文。", "中文，中文。", OutcomeUnchanged},
+		// 含汉字的值整串交给转换：只有标点（经 nfkc）发生变化也识别为已转换
+		{"punctuation-only change via nfkc", ConvertOptions{To: "s2t", Normalize: "nfkc"}, "中文，中文", "中文,中文", OutcomeConverted},
+		{"han and punctuation change", ConvertOptions{To: "s2t", Normalize: "nfkc"}, "中文，测试", "中文,測試", OutcomeConverted},
+		{"auto-trad keeps traditional", ConvertOptions{To: "auto-trad"}, "後來", "後來", OutcomeUnchanged},
+		{"auto-trad converts simplified", ConvertOptions{To: "auto-trad"}, "后来的简体", "後來的簡體", OutcomeConverted},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, oc, err := ConvertDetail(tc.opts, tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tc.out || oc != tc.oc {
+				t.Errorf("ConvertDetail(%q) = %q, %v; want %q, %v", tc.in, out, oc, tc.out, tc.oc)
+			}
+			_, changed, err := ConvertWithOptions(tc.opts, tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != (tc.oc == OutcomeConverted) {
+				t.Errorf("ConvertWithOptions(%q) changed = %v", tc.in, changed)
+			}
+		})
+	}
+}
+
+func TestCharClasses(t *testing.T) {
+	for _, tc := range []struct {
+		in            string
+		ascii, hasHan bool
+	}{
+		{"", true, false},
+		{"abc 123", true, false},
+		{"café", false, false},
+		{"，", false, false},
+		{"ひらがな", false, false},
+		{"漢", false, true},
+		{"a中b", false, true},
+		{"𠀀", false, true}, // CJK 扩展 B
+	} {
+		if got := IsASCIIOnly(tc.in); got != tc.ascii {
+			t.Errorf("IsASCIIOnly(%q) = %v", tc.in, got)
+		}
+		if got := HasChinese(tc.in); got != tc.hasHan {
+			t.Errorf("HasChinese(%q) = %v", tc.in, got)
+		}
+	}
+}
+
+func TestConvertIfNeeded(t *testing.T) {
+	out, changed, err := ConvertIfNeeded("s2t", "汉字")
+	if err != nil || out != "漢字" || !changed {
+		t.Errorf("ConvertIfNeeded = %q, %v, %v", out, changed, err)
+	}
+	if _, _, err := ConvertIfNeeded("no-such-config", "汉字"); err == nil {
+		t.Error("unknown config should fail")
+	}
+}
+
+func TestConvertDetailReplace(t *testing.T) {
+	r, err := NewReplacer(map[string]string{
+		"臺":   "台",
+		"臺灣":  "台灣", // 与「臺」重叠：同一位置取最长的原文
+		"灣區":  "湾区",
+		"iOS": "iOS 系統", // 替换结果含汉字，不再参与匹配
+		"系統":  "系统",
+		"後台":  "后台",
+		"Ｔａｂ": "Tab",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		opts ConvertOptions
+		in   string
+		out  string
+		oc   ConvertOutcome
+	}{
+		{"after opencc", ConvertOptions{To: "s2t"}, "台湾", "台灣", OutcomeConverted},
+		{"longest overlapping key", ConvertOptions{To: "s2t"}, "臺灣区", "台灣區", OutcomeConverted}, // 「臺灣」先匹配，「灣區」不再匹配
+		{"adjacent keys", ConvertOptions{To: "s2t"}, "臺灣湾区", "台灣湾区", OutcomeConverted},
+		{"single char key", ConvertOptions{To: "s2t"}, "臺北", "台北", OutcomeConverted},
+		// 快速跳过的值不经 OpenCC，但仍执行替换
+		{"ascii", ConvertOptions{To: "s2t"}, "iOS app", "iOS 系統 app", OutcomeConverted},
+		{"ascii without match", ConvertOptions{To: "s2t"}, "Android", "Android", OutcomeASCIIOnly},
+		{"no chinese", ConvertOptions{To: "s2t"}, "Ｔａｂ键", "Tab鍵", OutcomeConverted},
+		{"no han", ConvertOptions{To: "s2t"}, "Ｔａｂ", "Tab", OutcomeConverted},
+		{"empty", ConvertOptions{To: "s2t"}, "", "", OutcomeEmpty},
+		// auto / smart 判定为已是目标字形的值同样替换
+		{"auto already target", ConvertOptions{To: "auto-trad"}, "臺灣的後台", "台灣的后台", OutcomeConverted},
+		{"auto already target without match", ConvertOptions{To: "auto-trad"}, "繁體", "繁體", OutcomeUnchanged},
+		{"smart already target", ConvertOptions{To: "s2t", Smart: true}, "臺灣", "台灣", OutcomeConverted},
+		// 仅标点模式：替换引入的汉字改动被还原
+		{"punct only", ConvertOptions{To: "s2t", PunctOnly: true}, "臺灣", "臺灣", OutcomeUnchanged},
+		{"punct only keeps non-han", ConvertOptions{To: "s2t", PunctOnly: true}, "Ｔａｂ", "Tab", OutcomeConverted},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Replace = r
+			out, oc, err := ConvertDetail(tc.opts, tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tc.out || oc != tc.oc {
+				t.Errorf("ConvertDetail(%q) = %q, %v; want %q, %v", tc.in, out, oc, tc.out, tc.oc)
+			}
+		})
+	}
+}
+
+func TestDetectScript(t *testing.T) {
+	for _, tc := range []struct {
+		in         string
+		simp, trad bool // 是否数到简体 / 繁体专用字
+	}{
+		{"这个软件很好用", true, false},
+		{"這個軟體很好用", false, true},
+		{"這個軟體很好，但是这里", true, true},
+		{"中文", false, false}, // 只含简繁同形字：无法区分
+	} {
+		simp, trad, err := DetectScript(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (simp > 0) != tc.simp || (trad > 0) != tc.trad {
+			t.Errorf("DetectScript(%q) = %d, %d", tc.in, simp, trad)
+		}
+	}
+}
+
+func TestConvertDetailAutoDirection(t *testing.T) {
+	for _, tc := range []struct {
+		to, in, out string
+	}{
+		{"auto-trad", "这个软件", "這個軟體"},
+		{"auto-trad", "這個軟體", "這個軟體"},
+		{"auto-trad", "這個軟體很好，但是这里", "這個軟體很好，但是这里"}, // 以繁体为主：整体原样
+		{"auto-trad", "这个软件，還有", "這個軟體，還有"},
+		{"auto-trad:s2t", "这个软件", "這個軟件"},
+		{"auto-simp", "這個軟體", "这个软体"},
+		{"auto-simp", "这个软件", "这个软件"},
+		{"auto-simp", "中文", "中文"},
+	} {
+		out, _, err := ConvertDetail(ConvertOptions{To: tc.to}, tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != tc.out {
+			t.Errorf("%s(%q) = %q, want %q", tc.to, tc.in, out, tc.out)
+		}
+	}
+}
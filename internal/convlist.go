@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// openccConfigs 内置 OpenCC 库可用的配置名。库未导出该列表，这里只列名称：
+// 描述在运行时从库内置的配置文件读取（加载失败的配置不列出），库升级后以实际可加载的为准
+var openccConfigs = []string{
+	"s2t", "t2s", "s2tw", "tw2s", "s2twp", "tw2sp", "s2hk", "hk2s", "t2tw", "t2hk", "s2hk-finance",
+}
+
+// ConvertConfig 一个可用于 --to 的转换配置
+type ConvertConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ConvertConfigs 返回所有可用于 --to 的转换配置：内置 OpenCC 配置（描述取自配置文件的 name 字段），
+// 以及本工具的自动方向模式
+func ConvertConfigs() []ConvertConfig {
+	var out []ConvertConfig
+	for _, name := range openccConfigs {
+		cc, err := GetConverter(name)
+		if err != nil {
+			continue
+		}
+		out = append(out, ConvertConfig{Name: name, Description: cc.Description})
+	}
+	return append(out,
+		ConvertConfig{Name: AutoTrad, Description: "按内容自动判定方向：简体为主的文本转为繁体（默认 s2twp，可写 auto-trad:配置）"},
+		ConvertConfig{Name: AutoSimp, Description: "按内容自动判定方向：繁体为主的文本转为简体（默认 t2s，可写 auto-simp:配置）"},
+	)
+}
+
+// WriteConvertConfigs 以表格（默认）或 JSON 数组输出转换配置列表
+func WriteConvertConfigs(w io.Writer, list []ConvertConfig, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "配置\t说明")
+	for _, c := range list {
+		fmt.Fprintf(tw, "%s\t%s\n", c.Name, c.Description)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n多个配置可用 %s 串联为转换链（如 s2t%st2tw），依次应用；%s词典文件 为自定义词典级\n", ChainSep, ChainSep, CustomPrefix)
+	return err
+}
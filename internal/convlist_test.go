@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteConvertConfigsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteConvertConfigs(&buf, ConvertConfigs(), true); err != nil {
+		t.Fatal(err)
+	}
+	var list []ConvertConfig
+	if err := json.Unmarshal(buf.Bytes(), &list); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	desc := map[string]string{}
+	for _, c := range list {
+		desc[c.Name] = c.Description
+	}
+	for _, name := range []string{"s2t", "t2s", "s2twp", "s2hk", AutoTrad, AutoSimp} {
+		if d, ok := desc[name]; !ok || d == "" {
+			t.Errorf("config %s missing or without description: %v", name, list)
+		}
+	}
+	// 描述取自 OpenCC 配置文件，而不是写死在工具里
+	if !strings.Contains(desc["s2t"], "Simplified") {
+		t.Errorf("s2t description = %q", desc["s2t"])
+	}
+}
+
+func TestWriteConvertConfigsTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteConvertConfigs(&buf, []ConvertConfig{{Name: "s2t", Description: "x"}}, false); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.HasPrefix(out, "配置") || !strings.Contains(out, "s2t  ") || strings.Contains(out, "{") {
+		t.Errorf("table output = %q", out)
+	}
+}
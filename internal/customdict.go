@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/liuzl/da"
+	"github.com/longbridgeapp/opencc"
+)
+
+// CustomPrefix 自定义词典级：custom:<词典文件>，可单独使用或作为转换链的一级（如 custom:brands>s2twp）
+const CustomPrefix = "custom:"
+
+// customKeyMax 词条原文的最大字数：OpenCC 每次最多向后取 10 个字做前缀匹配，更长的词条永远不会命中
+const customKeyMax = 10
+
+// loadCustomDict 读取 OpenCC 文本词典格式的自定义词典：每行“原文<TAB>替换为”，空行与 # 开头的行忽略。
+// name 没有扩展名且文件不存在时再尝试 name.txt；格式错误时指出行号
+func loadCustomDict(name string) (*opencc.OpenCC, error) {
+	path := strings.TrimSpace(name)
+	if path == "" {
+		return nil, errors.New("custom: 后须为词典文件路径（如 custom:brands.txt）")
+	}
+	bs, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && filepath.Ext(path) == "" {
+		if alt, aerr := os.ReadFile(path + ".txt"); aerr == nil {
+			bs, err, path = alt, nil, path+".txt"
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取自定义词典失败：%w", err)
+	}
+
+	var buf bytes.Buffer
+	sc := bufio.NewScanner(bytes.NewReader(bs))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		from, to, ok := strings.Cut(line, "\t")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		switch {
+		case !ok || from == "" || to == "" || strings.Contains(to, "\t"):
+			return nil, fmt.Errorf("自定义词典 %s 第 %d 行格式错误（应为 原文<TAB>替换为）：%q", path, n, line)
+		case utf8.RuneCountInString(from) > customKeyMax:
+			return nil, fmt.Errorf("自定义词典 %s 第 %d 行原文超过 %d 个字，无法匹配：%q", path, n, customKeyMax, from)
+		}
+		// da.Build 会丢弃没有换行结尾的最后一行，且只有一列替换时按空白拆分、只取第一段：
+		// 统一补全换行并重复写一列，保留替换中的空格
+		fmt.Fprintf(&buf, "%s\t%s\t%s\n", from, to, to)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("读取自定义词典失败：%w", err)
+	}
+	if buf.Len() == 0 {
+		return nil, fmt.Errorf("自定义词典 %s 没有词条", path)
+	}
+	d, err := da.Build(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("加载自定义词典 %s 失败：%w", path, err)
+	}
+	return &opencc.OpenCC{
+		Conversion:  CustomPrefix + name,
+		Description: "自定义词典 " + path,
+		DictChains:  []*opencc.Group{{Files: []string{path}, Dicts: []*da.Dict{d}}},
+	}, nil
+}
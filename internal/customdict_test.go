@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDict 写入自定义词典并返回路径
+func writeDict(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "brands.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCustomDictChain(t *testing.T) {
+	// 最后一行没有换行结尾；替换中含空格
+	dict := writeDict(t, "# 品牌\n小米手机\t小米手機\n苹果\tApple 公司\n\n鼠标\t滑鼠")
+	for _, tc := range []struct {
+		to, in, want string
+		oc           ConvertOutcome
+	}{
+		// 单级：只替换词典中的词条，其余原样
+		{CustomPrefix + dict, "小米手机和苹果的鼠标软件", "小米手機和Apple 公司的滑鼠软件", OutcomeConverted},
+		{CustomPrefix + dict, "没有词条", "没有词条", OutcomeUnchanged},
+		// 两级：词典先替换，s2twp 再处理其余部分（已替换的繁体不再变化）
+		{CustomPrefix + dict + ChainSep + "s2twp", "小米手机和苹果的鼠标软件", "小米手機和Apple 公司的滑鼠軟體", OutcomeConverted},
+		// 词典未命中时由 s2twp 照常转换
+		{CustomPrefix + dict + ChainSep + "s2twp", "出租车", "計程車", OutcomeConverted},
+		// 单个配置名行为不变
+		{"s2twp", "出租车", "計程車", OutcomeConverted},
+	} {
+		if err := WarmUpConverters(tc.to); err != nil {
+			t.Fatalf("%s: %v", tc.to, err)
+		}
+		got, oc, err := ConvertDetail(ConvertOptions{To: tc.to}, tc.in)
+		if err != nil || got != tc.want || oc != tc.oc {
+			t.Errorf("%s(%q) = %q, %v, %v; want %q, %v", tc.to, tc.in, got, oc, err, tc.want, tc.oc)
+		}
+	}
+}
+
+func TestCustomDictWithoutExtension(t *testing.T) {
+	dict := writeDict(t, "苹果\t蘋果\n")
+	to := CustomPrefix + strings.TrimSuffix(dict, ".txt")
+	if got, _, err := ConvertDetail(ConvertOptions{To: to}, "苹果"); err != nil || got != "蘋果" {
+		t.Errorf("custom without .txt = %q, %v", got, err)
+	}
+}
+
+func TestCustomDictErrors(t *testing.T) {
+	for name, to := range map[string]string{
+		"missing file":   CustomPrefix + filepath.Join(t.TempDir(), "nope.txt"),
+		"empty name":     CustomPrefix,
+		"no tab":         CustomPrefix + writeDict(t, "苹果 蘋果\n"),
+		"empty dict":     CustomPrefix + writeDict(t, "# 只有注释\n\n"),
+		"key too long":   CustomPrefix + writeDict(t, "一二三四五六七八九十百\t长\n"),
+		"auto in chain":  "s2t" + ChainSep + "auto-trad",
+		"bad chain step": CustomPrefix + writeDict(t, "苹果\t蘋果\n") + ChainSep + "nope",
+	} {
+		if err := WarmUpConverters(to); err == nil {
+			t.Errorf("%s: %s should be rejected", name, to)
+		}
+	}
+	err := WarmUpConverters(CustomPrefix + writeDict(t, "苹果\t蘋果\n坏行\n"))
+	if err == nil || !strings.Contains(err.Error(), "第 2 行") {
+		t.Errorf("err = %v, want line number", err)
+	}
+}
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type DoctorConfig struct {
+	DSN     string
+	To      string        // 需要验证的 OpenCC 配置（默认 s2twp）
+	Timeout time.Duration // 单项数据库检查超时（默认 10s）
+}
+
+// 单项检查结果
+type doctorCheck struct {
+	name string
+	err  error
+	warn bool   // true 表示仅提示，不计为失败
+	hint string // 失败/提示时的修复建议
+	skip bool   // 前置检查失败而跳过
+}
+
+// RunDoctor 依次执行环境与连通性检查，将清单写入 w；全部通过（允许提示项）返回 true
+func RunDoctor(ctx context.Context, cfg DoctorConfig, w io.Writer) bool {
+	if cfg.To == "" {
+		cfg.To = "s2twp"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	var checks []doctorCheck
+	add := func(c doctorCheck) bool {
+		checks = append(checks, c)
+		return c.err == nil || c.warn
+	}
+
+	// 1) OpenCC 初始化（与数据库无关，总是执行）
+	err := WarmUpConverters(cfg.To)
+	add(doctorCheck{name: fmt.Sprintf("OpenCC 初始化（%s）", cfg.To), err: err,
+		hint: "检查 --to 拼写（如 s2t、s2twp、t2s、s2hk），或确认二进制内置的 OpenCC 词典完整"})
+
+	// 2) DSN 解析
+	dbChecks := []string{"服务器可达", "连接字符集为 utf8mb4", "SELECT/UPDATE 权限"}
+	dc, err := mysql.ParseDSN(cfg.DSN)
+	if err == nil && dc.DBName == "" {
+		err = errors.New("DSN 未指定数据库名")
+	}
+	if !add(doctorCheck{name: "DSN 解析", err: err,
+		hint: "格式：user:pass@tcp(host:3306)/db?charset=utf8mb4&parseTime=true"}) {
+		for _, n := range dbChecks {
+			checks = append(checks, doctorCheck{name: n, skip: true})
+		}
+		return writeDoctorReport(w, checks)
+	}
+
+	// 3) 服务器可达
+	db, err := sql.Open("mysql", cfg.DSN)
+	if err == nil {
+		defer db.Close()
+		pctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		err = db.PingContext(pctx)
+		cancel()
+	}
+	if !add(doctorCheck{name: fmt.Sprintf("服务器可达（%s）", dc.Addr), err: err,
+		hint: "检查主机/端口、防火墙与账号密码；超时通常意味着网络不通"}) {
+		for _, n := range dbChecks[1:] {
+			checks = append(checks, doctorCheck{name: n, skip: true})
+		}
+		return writeDoctorReport(w, checks)
+	}
+
+	qctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	// 4) 字符集
+	var connCS, dbCS string
+	err = db.QueryRowContext(qctx, "SELECT @@character_set_connection, @@character_set_database").Scan(&connCS, &dbCS)
+	switch {
+	case err != nil:
+	case connCS != "utf8mb4":
+		err = fmt.Errorf("character_set_connection=%s", connCS)
+	}
+	add(doctorCheck{name: "连接字符集为 utf8mb4", err: err,
+		hint: "在 DSN 中加入 charset=utf8mb4，否则读写中文可能出现乱码或 ?"})
+	if err == nil && dbCS != "utf8mb4" {
+		add(doctorCheck{name: "库默认字符集", err: fmt.Errorf("character_set_database=%s", dbCS), warn: true,
+			hint: "非 utf8mb4 的列无法存储部分扩展区汉字，请确认待转换列的字符集"})
+	}
+
+	// 5) 权限：通过预处理语句查询 information_schema 中当前账号可见的授权
+	privs, err := currentPrivileges(qctx, db)
+	if err == nil {
+		var missing []string
+		for _, p := range []string{"SELECT", "UPDATE"} {
+			if !privs[p] && !privs["ALL PRIVILEGES"] {
+				missing = append(missing, p)
+			}
+		}
+		if len(missing) > 0 {
+			add(doctorCheck{name: "SELECT/UPDATE 权限", err: fmt.Errorf("未发现全局/库级 %s 权限", strings.Join(missing, "、")), warn: true,
+				hint: "若为表级授权可忽略；否则执行 GRANT SELECT, UPDATE ON db.* TO 'user'@'host'"})
+		} else {
+			add(doctorCheck{name: "SELECT/UPDATE 权限"})
+		}
+	} else {
+		add(doctorCheck{name: "SELECT/UPDATE 权限", err: err, hint: "当前账号无法读取 information_schema，请检查账号权限"})
+	}
+
+	return writeDoctorReport(w, checks)
+}
+
+// currentPrivileges 汇总当前账号在全局与当前库上的权限
+func currentPrivileges(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	stmt, err := db.PrepareContext(ctx, `SELECT PRIVILEGE_TYPE FROM information_schema.USER_PRIVILEGES
+		UNION SELECT PRIVILEGE_TYPE FROM information_schema.SCHEMA_PRIVILEGES WHERE TABLE_SCHEMA = DATABASE()`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	privs := map[string]bool{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		privs[strings.ToUpper(p)] = true
+	}
+	return privs, rows.Err()
+}
+
+func writeDoctorReport(w io.Writer, checks []doctorCheck) bool {
+	ok := true
+	for _, c := range checks {
+		switch {
+		case c.skip:
+			fmt.Fprintf(w, "[-] %s：已跳过（前置检查未通过）\n", c.name)
+		case c.err == nil:
+			fmt.Fprintf(w, "[✓] %s\n", c.name)
+		case c.warn:
+			fmt.Fprintf(w, "[!] %s：%v\n    提示：%s\n", c.name, c.err, c.hint)
+		default:
+			ok = false
+			fmt.Fprintf(w, "[✗] %s：%v\n    提示：%s\n", c.name, c.err, c.hint)
+		}
+	}
+	return ok
+}
@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// replace 把整个值替换为空串：默认拒绝写入并计数，allow_empty_result 时照常写入
+func TestReplaceToEmptyBlocked(t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		db, mock := newMock(t)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` ORDER BY `id` LIMIT ?")).WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "简体").AddRow("2", "软件"))
+		if allow {
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).WithArgs("", "1").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).WithArgs("軟件", "2").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE (`id`) > (?) ORDER BY `id` LIMIT ?")).WithArgs("2", 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		stats := &Stats{}
+		r, err := NewReplacer(map[string]string{"簡體": ""}, stats)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := MySQLConfig{Table: "t", PK: []string{"id"}, Columns: []string{"name"}, To: "s2t", BatchSize: 10, AllowEmptyResult: allow,
+			QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{}, Stats: stats, replacer: r}
+		if _, err := processWithPK(context.Background(), db, cfg, nil, nil, 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("allow=%v: %v", allow, err)
+		}
+		wantBlocked, wantFailed, wantChanged := int64(1), int64(1), int64(1)
+		if allow {
+			wantBlocked, wantFailed, wantChanged = 0, 0, 2
+		}
+		if got := stats.Snapshot().EmptyBlocked; got != wantBlocked {
+			t.Errorf("allow=%v: empty_blocked = %d, want %d", allow, got, wantBlocked)
+		}
+		if c := cfg.counts; c.failed != wantFailed || c.changed != wantChanged {
+			t.Errorf("allow=%v: failed = %d, changed = %d", allow, c.failed, c.changed)
+		}
+	}
+}
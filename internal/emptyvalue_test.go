@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// NULL、空串与纯空白值在有主键、无主键两条路径上的处理：NULL 与空串始终跳过（不会写成空串），
+// 纯空白值默认照常转换（此处由替换规则改写），treat_whitespace_empty 时同样跳过
+func TestEmptyValuePolicy(t *testing.T) {
+	vals := []any{nil, "", "  ", "简体"}
+	for _, wsEmpty := range []bool{false, true} {
+		want := map[int]string{3: "__", 4: "簡體"} // 行号 -> 期望写入的值
+		if wsEmpty {
+			delete(want, 3)
+		}
+		replacer, err := NewReplacer(map[string]string{" ": "_"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(fmt.Sprintf("pk/whitespace-empty=%v", wsEmpty), func(t *testing.T) {
+			db, mock := newMock(t)
+			rs := sqlmock.NewRows([]string{"id", "name"})
+			for i, v := range vals {
+				rs.AddRow(fmt.Sprint(i+1), v)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` ORDER BY `id` LIMIT ?")).WithArgs(10).WillReturnRows(rs)
+			for i := range vals {
+				if v, ok := want[i+1]; ok {
+					mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).
+						WithArgs(v, fmt.Sprint(i+1)).WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE (`id`) > (?) ORDER BY `id` LIMIT ?")).
+				WithArgs(fmt.Sprint(len(vals)), 10).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			cfg := MySQLConfig{
+				Table: "t", PK: []string{"id"}, Columns: []string{"name"}, To: "s2t", BatchSize: 10,
+				QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{},
+				TreatWhitespaceEmpty: wsEmpty, replacer: replacer,
+			}
+			if _, err := processWithPK(context.Background(), db, cfg, nil, nil, 0); err != nil {
+				t.Fatal(err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+			if cfg.counts.failed != 0 {
+				t.Errorf("failed = %d: unexpected UPDATE", cfg.counts.failed)
+			}
+		})
+
+		t.Run(fmt.Sprintf("no-pk/whitespace-empty=%v", wsEmpty), func(t *testing.T) {
+			db, mock := newMock(t)
+			expectNoPKSchema(mock, "t", []colDef{{"code", "varchar", true}, {"name", "varchar", false}}, [2]string{"uk_code", "code"})
+			rs := sqlmock.NewRows([]string{"code", "name"})
+			for i, v := range vals {
+				rs.AddRow(fmt.Sprint(i+1), v)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT `code`,`name` FROM `t` LIMIT ? OFFSET ?")).WithArgs(10, 0).WillReturnRows(rs)
+			for i := range vals {
+				if v, ok := want[i+1]; ok {
+					mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `code` = ?")).
+						WithArgs(v, fmt.Sprint(i+1)).WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT `code`,`name` FROM `t` LIMIT ? OFFSET ?")).WithArgs(10, len(vals)).
+				WillReturnRows(sqlmock.NewRows([]string{"code", "name"}))
+			cfg := noPKConfig("t", "name")
+			cfg.TreatWhitespaceEmpty, cfg.replacer = wsEmpty, replacer
+			if _, err := processNoPK(context.Background(), db, cfg, nil, nil, 0); err != nil {
+				t.Fatal(err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+			if cfg.counts.failed != 0 {
+				t.Errorf("failed = %d: unexpected UPDATE", cfg.counts.failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseEOL 校验 --eol 取值并返回规范形式：keep（默认）| lf | crlf
+func ParseEOL(s string) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(s)); v {
+	case "", "keep":
+		return "keep", nil
+	case "lf", "crlf":
+		return v, nil
+	default:
+		return "", fmt.Errorf("不支持的 eol：%q（可选 keep、lf、crlf）", s)
+	}
+}
+
+// detectEOL 返回文本统一使用的换行风格（lf / crlf）；无换行、混用或含单独的 \r 时返回空
+func detectEOL(s string) string {
+	crlf := strings.Count(s, "\r\n")
+	lf := strings.Count(s, "\n") - crlf
+	cr := strings.Count(s, "\r") - crlf
+	switch {
+	case cr > 0:
+		return ""
+	case crlf > 0 && lf == 0:
+		return "crlf"
+	case lf > 0 && crlf == 0:
+		return "lf"
+	}
+	return ""
+}
+
+// applyEOL 按 mode 处理转换结果 out 的换行（orig 为原文）：
+// keep 时原文统一为一种风格则输出也保持该风格，混用的文件原样不动；lf / crlf 统一改为该风格。单独的 \r 不视为换行
+func applyEOL(orig, out, mode string) string {
+	if mode == "keep" || mode == "" {
+		if mode = detectEOL(orig); mode == "" || detectEOL(out) == mode {
+			return out
+		}
+	}
+	out = strings.ReplaceAll(out, "\r\n", "\n")
+	if mode == "crlf" {
+		out = strings.ReplaceAll(out, "\n", "\r\n")
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseEOL(t *testing.T) {
+	for in, want := range map[string]string{"": "keep", "keep": "keep", " LF ": "lf", "CRLF": "crlf"} {
+		if got, err := ParseEOL(in); err != nil || got != want {
+			t.Errorf("ParseEOL(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseEOL("cr"); err == nil {
+		t.Error("ParseEOL(cr) should fail")
+	}
+}
+
+func TestApplyEOL(t *testing.T) {
+	const (
+		lf    = "a\nb\n"
+		crlf  = "a\r\nb\r\n"
+		mixed = "a\r\nb\n"
+	)
+	for _, tc := range []struct {
+		mode, in, want string
+	}{
+		{"keep", lf, lf},
+		{"keep", crlf, crlf},
+		{"keep", mixed, mixed},
+		{"lf", lf, lf},
+		{"lf", crlf, lf},
+		{"lf", mixed, lf},
+		{"crlf", lf, crlf},
+		{"crlf", crlf, crlf},
+		{"crlf", mixed, crlf},
+	} {
+		if got := applyEOL(tc.in, tc.in, tc.mode); got != tc.want {
+			t.Errorf("applyEOL(%q, %s) = %q, want %q", tc.in, tc.mode, got, tc.want)
+		}
+	}
+	// keep：转换过程引入的 \n（如 front matter 改写）按原文的 CRLF 风格统一
+	if got := applyEOL(crlf, mixed, "keep"); got != crlf {
+		t.Errorf("keep crlf with mixed output = %q", got)
+	}
+	// 单独的 \r 不视为换行，keep 时原样不动
+	if got := applyEOL("a\rb\n", "a\rb\n", "keep"); got != "a\rb\n" {
+		t.Errorf("keep lone CR = %q", got)
+	}
+}
+
+func TestRunFileEOLOnlyConvertedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.md": "简体\n第二行\n",
+		"b.md": "繁體\n第二行\n",
+	})
+	if _, _, err := RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", EOL: "crlf"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"a.md": "簡體\r\n第二行\r\n",
+		"b.md": "繁體\n第二行\n", // 无需转换的文件不改换行
+	} {
+		bs, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bs) != want {
+			t.Errorf("%s = %q, want %q", name, bs, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// defaultMaxErrors max_errors 未设置时的熔断阈值：足够宽松，只拦截系统性错误（字符集、转换配置、列定义不匹配等）
+const defaultMaxErrors = 1000
+
+// ErrTooManyErrors 累计错误超过 max_errors 后熔断
+var ErrTooManyErrors = errors.New("错误过多，已熔断")
+
+// errorBudget 所有表共享的错误计数（UPDATE/转换失败的行、写后校验不一致的列），超过上限后熔断；nil 时不限制
+type errorBudget struct {
+	max int64
+	n   atomic.Int64
+}
+
+// newErrorBudget 按 max_errors 创建：0 为首个错误即熔断，负数不限制（返回 nil）；默认值由调用方（命令行、配置文件）填充
+func newErrorBudget(max int) *errorBudget {
+	if max < 0 {
+		return nil
+	}
+	return &errorBudget{max: int64(max)}
+}
+
+// add 累加 k 个错误；超过上限时返回 ErrTooManyErrors
+func (b *errorBudget) add(k int64) error {
+	if b == nil {
+		return nil
+	}
+	b.n.Add(k)
+	return b.tripped()
+}
+
+// tripped 已熔断时返回错误（供批次边界与未开始的表检查）
+func (b *errorBudget) tripped() error {
+	if b == nil {
+		return nil
+	}
+	if n := b.n.Load(); n > b.max {
+		return fmt.Errorf("%w：累计 %d 个错误（UPDATE/转换失败的行与写后校验不一致），超过 max_errors=%d；请先检查字符集、转换配置或列定义", ErrTooManyErrors, n, b.max)
+	}
+	return nil
+}
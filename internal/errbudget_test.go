@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorBudget(t *testing.T) {
+	for _, tc := range []struct {
+		max     int
+		tripsAt int64 // 第几个错误触发熔断，0 表示不熔断
+	}{
+		{max: 0, tripsAt: 1}, // 显式 0：首个错误即中止
+		{max: 1, tripsAt: 2},
+		{max: defaultMaxErrors, tripsAt: defaultMaxErrors + 1},
+		{max: -1, tripsAt: 0},
+	} {
+		b := newErrorBudget(tc.max)
+		var tripped int64
+		for i := int64(1); i <= defaultMaxErrors+5; i++ {
+			if err := b.add(1); err != nil {
+				if !errors.Is(err, ErrTooManyErrors) {
+					t.Fatalf("max=%d: err = %v", tc.max, err)
+				}
+				tripped = i
+				break
+			}
+		}
+		if tripped != tc.tripsAt {
+			t.Errorf("max=%d: tripped at %d, want %d", tc.max, tripped, tc.tripsAt)
+		}
+	}
+}
+
+func TestMaxErrorsConfig(t *testing.T) {
+	for _, tc := range []struct {
+		field string
+		want  int
+	}{
+		{``, defaultMaxErrors},
+		{`"max_errors": 0,`, 0},
+		{`"max_errors": -1,`, -1},
+	} {
+		cfg, err := loadConfig(t, `{"dsn": "u:p@tcp(127.0.0.1:3306)/db", `+tc.field+` "tables": [{"table": "t", "pk": ["id"], "columns": ["c"]}]}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := intOr(cfg.MaxErrors, defaultMaxErrors); got != tc.want {
+			t.Errorf("%s: max_errors = %d, want %d", tc.field, got, tc.want)
+		}
+	}
+	if got := intOr(readOnlyCopy(&MySQLFileConfig{}).MaxErrors, defaultMaxErrors); got != -1 {
+		t.Errorf("read-only copy max_errors = %d, want -1", got)
+	}
+}
@@ -0,0 +1,33 @@
+package internal
+
+import "errors"
+
+// 错误分类：嵌入调用方可用 errors.Is 区分失败原因（cmd 据此映射退出码）；错误文本保持原样，不额外加前缀
+var (
+	ErrConfigInvalid = errors.New("配置无效")    // 配置或参数校验失败（含转换配置名无效、DSN 无法解析）
+	ErrDBConnect     = errors.New("数据库连接失败") // 建立连接失败或超时
+	ErrConvert       = errors.New("转换失败")    // 单个值或文件转换失败
+	ErrRowUpdate     = errors.New("行更新失败")   // UPDATE 执行失败，或影响行数异常而中止（strict_affected）
+	ErrIO            = errors.New("文件读写失败")  // 读写本地文件失败（配置、水位、待转换文件与备份等）
+)
+
+// classifiedError 为错误附加分类，Error() 不变，errors.Is 同时匹配分类与原错误链
+type classifiedError struct {
+	kind, err error
+}
+
+func (e *classifiedError) Error() string   { return e.err.Error() }
+func (e *classifiedError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// classify 为 err 附加分类 kind；err 为 nil 或已属于某个分类时原样返回
+func classify(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, k := range []error{ErrConfigInvalid, ErrDBConnect, ErrConvert, ErrRowUpdate, ErrIO} {
+		if errors.Is(err, k) {
+			return err
+		}
+	}
+	return &classifiedError{kind: kind, err: err}
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyKeepsFirstKind(t *testing.T) {
+	boom := errors.New("boom")
+	err := classify(ErrIO, boom)
+	if !errors.Is(err, ErrIO) || !errors.Is(err, boom) || err.Error() != "boom" {
+		t.Fatalf("classify = %v", err)
+	}
+	if got := classify(ErrConfigInvalid, fmt.Errorf("wrapped: %w", err)); errors.Is(got, ErrConfigInvalid) {
+		t.Errorf("already classified error should keep its kind: %v", got)
+	}
+	if classify(ErrIO, nil) != nil {
+		t.Error("classify(nil) should be nil")
+	}
+}
+
+func TestRunMySQLErrorKinds(t *testing.T) {
+	dir := t.TempDir()
+	base := MySQLConfig{DSN: "u:p@tcp(127.0.0.1:1)/db", Table: "t", PK: []string{"id"}, Columns: []string{"c"}, To: "s2t"}
+
+	cfg := base
+	cfg.Columns = nil
+	if _, err := RunMySQL(context.Background(), cfg); !errors.Is(err, ErrConfigInvalid) {
+		t.Errorf("missing columns: err = %v, want ErrConfigInvalid", err)
+	}
+
+	// 水位文件无法读取（此处为目录）发生在连库之前，但属于文件读写失败而非配置无效
+	cfg = base
+	cfg.IncrementalColumn, cfg.WatermarkFile = "updated_at", dir
+	_, err := RunMySQL(context.Background(), cfg)
+	if !errors.Is(err, ErrIO) || errors.Is(err, ErrConfigInvalid) {
+		t.Errorf("unreadable watermark: err = %v, want ErrIO only", err)
+	}
+}
+
+func TestLoadMySQLFileConfigErrorKinds(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadMySQLFileConfig(filepath.Join(dir, "missing.json")); !errors.Is(err, ErrIO) {
+		t.Errorf("missing file: err = %v, want ErrIO", err)
+	}
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadMySQLFileConfig(bad); !errors.Is(err, ErrConfigInvalid) {
+		t.Errorf("bad json: err = %v, want ErrConfigInvalid", err)
+	}
+}
+
+func TestReadErrorIsIO(t *testing.T) {
+	err := readError("a.md", fs.ErrPermission)
+	if !errors.Is(err, ErrIO) || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("readError = %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EventVersion 事件协议版本：字段只增不改，删改字段或改变语义时递增
+const EventVersion = 1
+
+// 事件类型
+const (
+	EventTableStarted  = "table_started"
+	EventBatchDone     = "batch_done"
+	EventRowChanged    = "row_changed"
+	EventTableFinished = "table_finished"
+	EventFileChanged   = "file_changed"
+	EventError         = "error"
+)
+
+// EventStream 以 JSONL 输出供前端（TUI/GUI）消费的进度事件，独立于日志与 stdout；方法对 nil 安全。
+// 每个事件单独写出不做缓冲，管道另一端可实时读取
+type EventStream struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+	err error // 首个写入错误，之后不再写出，Close 时返回
+}
+
+type event struct {
+	Version int    `json:"event_version"`
+	Type    string `json:"type"`
+	Time    string `json:"time"`
+
+	Table   string             `json:"table,omitempty"`
+	Label   string             `json:"label,omitempty"`
+	Path    string             `json:"path,omitempty"`
+	Columns []string           `json:"columns,omitempty"`
+	Key     map[string]*string `json:"key,omitempty"`
+	DryRun  bool               `json:"dry_run,omitempty"`
+
+	Current         *int64   `json:"current,omitempty"`
+	Total           *int64   `json:"total,omitempty"` // 总量未知时省略
+	Scanned         *int64   `json:"scanned,omitempty"`
+	Changed         *int64   `json:"changed,omitempty"`
+	Failed          *int64   `json:"failed,omitempty"`
+	DurationSeconds *float64 `json:"duration_seconds,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// OpenEventStream target 为整数时视为已打开的文件描述符（如 3，由父进程传入），否则为文件路径
+func OpenEventStream(target string) (*EventStream, error) {
+	var f *os.File
+	if fd, err := strconv.Atoi(target); err == nil {
+		f = os.NewFile(uintptr(fd), "fd"+target)
+		if f == nil {
+			return nil, fmt.Errorf("无效的文件描述符：%s", target)
+		}
+		if _, err := f.Stat(); err != nil {
+			return nil, fmt.Errorf("无效的文件描述符 %s：%w", target, err)
+		}
+	} else {
+		if f, err = os.Create(target); err != nil {
+			return nil, fmt.Errorf("创建事件文件 %s: %w", target, err)
+		}
+	}
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	return &EventStream{f: f, enc: enc}, nil
+}
+
+func (s *EventStream) emit(e event) {
+	if s == nil {
+		return
+	}
+	e.Version = EventVersion
+	e.Time = time.Now().Format(time.RFC3339Nano)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.err = s.enc.Encode(e)
+}
+
+func i64(v int64) *int64 { return &v }
+
+// TableStarted 表开始处理；total < 0 表示总量未知
+func (s *EventStream) TableStarted(table, label string, columns []string, total int64, dryRun bool) {
+	e := event{Type: EventTableStarted, Table: table, Label: label, Columns: columns, DryRun: dryRun}
+	if total >= 0 {
+		e.Total = i64(total)
+	}
+	s.emit(e)
+}
+
+// BatchDone 一批处理完毕；current 为该表累计已处理行数
+func (s *EventStream) BatchDone(table string, current, total int64) {
+	e := event{Type: EventBatchDone, Table: table, Current: i64(current)}
+	if total >= 0 {
+		e.Total = i64(total)
+	}
+	s.emit(e)
+}
+
+// RowChanged 一行已更新（dry-run 下为将会更新）；columns 为发生转换的列
+func (s *EventStream) RowChanged(table string, key map[string]*string, columns []string, dryRun bool) {
+	s.emit(event{Type: EventRowChanged, Table: table, Key: key, Columns: columns, DryRun: dryRun})
+}
+
+// TableFinished 表处理成功结束
+func (s *EventStream) TableFinished(table string, scanned, changed, failed int64, d time.Duration) {
+	sec := d.Seconds()
+	s.emit(event{Type: EventTableFinished, Table: table,
+		Scanned: i64(scanned), Changed: i64(changed), Failed: i64(failed), DurationSeconds: &sec})
+}
+
+// FileChanged 文档已转换写回（dry-run 下为将会修改）
+func (s *EventStream) FileChanged(path string, dryRun bool) {
+	s.emit(event{Type: EventFileChanged, Path: path, DryRun: dryRun})
+}
+
+// Error 表或文档处理失败（table 与 path 二选一）
+func (s *EventStream) Error(table, path string, err error) {
+	s.emit(event{Type: EventError, Table: table, Path: path, Error: err.Error()})
+}
+
+func (s *EventStream) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cerr := s.f.Close()
+	if s.err != nil {
+		return s.err
+	}
+	return cerr
+}
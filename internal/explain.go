@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 跳过原因代码（--explain-skip）：逐个记录未转换的文件 / 行列及其原因，用于排查“没有任何转换”
+const (
+	skipNull        = "null"         // 值为 NULL
+	skipEmpty       = "empty"        // 空值 / 空文件
+	skipBlank       = "blank"        // 只含空白（treat_whitespace_empty）
+	skipASCIIOnly   = "ascii_only"   // 纯 ASCII
+	skipNoChinese   = "no_chinese"   // 含非 ASCII 但不含汉字
+	skipNoChange    = "no_change"    // 已是目标字形，转换结果与原文相同
+	skipNotApproved = "not_approved" // 不在 --apply-approved 批准清单中
+	skipFilteredExt = "filtered_ext" // 扩展名被 --ext / --exclude-ext 过滤
+	skipIgnored     = "ignored"      // 被 .tradifyignore 忽略
+	skipResumed     = "resumed"      // 续跑记录中已处理完
+	skipCached      = "cached"       // 缓存命中，自上次运行以来未变化
+	skipFrontMatter = "front_matter" // front-matter 不满足 --require-frontmatter
+	skipDeclined    = "declined"     // 交互确认中选择跳过
+	skipBinary      = "binary"       // 二进制文件（含 NUL 字节且不是有效的 UTF-8）
+)
+
+var skipReasonText = map[string]string{
+	skipNull:        "值为 NULL",
+	skipEmpty:       "空值或空文件",
+	skipBlank:       "只含空白字符",
+	skipASCIIOnly:   "纯 ASCII，不含汉字",
+	skipNoChinese:   "含非 ASCII 字符但不含汉字",
+	skipNoChange:    "已是目标字形，转换结果与原文相同",
+	skipNotApproved: "不在 --apply-approved 批准清单中",
+	skipFilteredExt: "扩展名被 --ext / --exclude-ext 过滤",
+	skipIgnored:     IgnoreFileName + " 规则忽略",
+	skipResumed:     "续跑记录中已处理完",
+	skipCached:      "缓存命中，自上次运行以来未变化",
+	skipFrontMatter: "front-matter 不满足 --require-frontmatter",
+	skipDeclined:    "交互确认中跳过",
+	skipBinary:      "二进制文件（含 NUL 字节且不是有效的 UTF-8）",
+}
+
+// skipReason 未转换的处理结果对应的原因代码；OutcomeConverted 返回空串
+func (oc ConvertOutcome) skipReason() string {
+	switch oc {
+	case OutcomeEmpty:
+		return skipEmpty
+	case OutcomeASCIIOnly:
+		return skipASCIIOnly
+	case OutcomeNoChinese:
+		return skipNoChinese
+	case OutcomeUnchanged:
+		return skipNoChange
+	}
+	return ""
+}
+
+// emptyReason 被 skipEmpty 跳过的值的原因代码
+func emptyReason(v *string) string {
+	switch {
+	case v == nil:
+		return skipNull
+	case *v == "":
+		return skipEmpty
+	}
+	return skipBlank
+}
+
+func explainLine(reason string) string {
+	return fmt.Sprintf("reason=%s（%s）", reason, skipReasonText[reason])
+}
+
+// explainSkip ExplainSkip 时记录某行某列未转换的原因；where 为行的定位描述（见 explainWhere）
+func (c MySQLConfig) explainSkip(where, column, reason string) {
+	if !c.ExplainSkip || reason == "" {
+		return
+	}
+	log.Printf("[跳过] table=%s %s column=%s %s", c.Table, where, column, explainLine(reason))
+}
+
+// explainWhere 行的定位描述：有主键时为主键取值，否则为本次扫描中的行序号（从 1 开始）；未开启 ExplainSkip 时返回空串
+func (c MySQLConfig) explainWhere(pk []sql.NullString, rowNo int) string {
+	if !c.ExplainSkip {
+		return ""
+	}
+	if len(pk) > 0 {
+		return fmt.Sprintf("pk=%s", strings.Join(nullStrings(pk), ","))
+	}
+	return fmt.Sprintf("row=#%d", rowNo)
+}
+
+// explainSkip ExplainSkip 时记录文件未转换的原因
+func (c FileConfig) explainSkip(path, reason string) {
+	if !c.ExplainSkip || reason == "" {
+		return
+	}
+	log.Printf("[跳过] %s %s", path, explainLine(reason))
+}
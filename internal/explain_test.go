@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"bytes"
+	"database/sql"
+	"log"
+	"strings"
+	"testing"
+)
+
+// captureLog 在 fn 执行期间收集标准 log 的输出
+func captureLog(t *testing.T, fn func()) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(w)
+		log.SetFlags(flags)
+	}()
+	fn()
+	return buf.String()
+}
+
+func TestSkipReasonCodes(t *testing.T) {
+	for in, want := range map[string]string{
+		"":      skipEmpty,
+		"hello": skipASCIIOnly,
+		"こんにちは": skipNoChinese,
+		"café":  skipNoChinese,
+		"繁體字":   skipNoChange,
+		"简体字":   "",
+	} {
+		_, oc, err := ConvertDetail(ConvertOptions{To: "s2t"}, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := oc.skipReason(); got != want {
+			t.Errorf("%q: reason = %q, want %q", in, got, want)
+		}
+	}
+	blank, empty := "  ", ""
+	for v, want := range map[*string]string{nil: skipNull, &empty: skipEmpty, &blank: skipBlank} {
+		if got := emptyReason(v); got != want {
+			t.Errorf("emptyReason = %q, want %q", got, want)
+		}
+	}
+	for reason := range skipReasonText {
+		if !strings.Contains(explainLine(reason), "reason="+reason+"（") {
+			t.Errorf("explainLine(%q) = %q", reason, explainLine(reason))
+		}
+	}
+}
+
+func TestExplainWhere(t *testing.T) {
+	c := MySQLConfig{Table: "t"}
+	if got := c.explainWhere([]sql.NullString{{String: "1", Valid: true}}, 0); got != "" {
+		t.Errorf("disabled = %q", got)
+	}
+	c.ExplainSkip = true
+	if got := c.explainWhere([]sql.NullString{{String: "1", Valid: true}, {}}, 0); got != "pk=1,NULL" {
+		t.Errorf("pk = %q", got)
+	}
+	if got := c.explainWhere(nil, 3); got != "row=#3" {
+		t.Errorf("no pk = %q", got)
+	}
+	out := captureLog(t, func() { c.explainSkip("pk=1", "name", skipASCIIOnly) })
+	if out != "[跳过] table=t pk=1 column=name reason=ascii_only（纯 ASCII，不含汉字）\n" {
+		t.Errorf("log = %q", out)
+	}
+}
+
+func TestRunFileExplainSkip(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".tradifyignore": "vendor/\n",
+		"vendor/v.md":    "简体",
+		"a.md":           "简体",
+		"ascii.md":       "hello",
+		"empty.md":       "",
+		"trad.md":        "繁體字",
+		"img.png":        "x",
+	})
+	run := func(explain bool) string {
+		return captureLog(t, func() {
+			if _, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", DryRun: true, ExplainSkip: explain}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	out := run(true)
+	for file, reason := range map[string]string{
+		"vendor": skipIgnored, "ascii.md": skipASCIIOnly, "empty.md": skipEmpty, "trad.md": skipNoChange, "img.png": skipFilteredExt,
+	} {
+		found := false
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, "[跳过] ") && strings.Contains(line, file) && strings.Contains(line, "reason="+reason+"（") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: missing reason %s in:\n%s", file, reason, out)
+		}
+	}
+	if strings.Contains(out, "a.md reason=") {
+		t.Errorf("converted file should not be explained:\n%s", out)
+	}
+	if out := run(false); strings.Contains(out, "[跳过]") {
+		t.Errorf("explain disabled:\n%s", out)
+	}
+}
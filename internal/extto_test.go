@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseExtTo(t *testing.T) {
+	m, err := ParseExtTo([]string{" .zh-TW.md = s2twp ", "md=s2t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m[".zh-TW.md"] != "s2twp" || m["md"] != "s2t" {
+		t.Errorf("ParseExtTo = %v", m)
+	}
+	for _, bad := range [][]string{{"md"}, {"=s2t"}, {"md="}, {"md=s2t", "md=s2tw"}} {
+		if _, err := ParseExtTo(bad); err == nil {
+			t.Errorf("ParseExtTo(%q) should fail", bad)
+		}
+	}
+}
+
+func TestCompileExtTo(t *testing.T) {
+	rules, err := compileExtTo(map[string]string{"md": "s2t", ".zh-TW.md": "s2twp", ".TXT": "s2tw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 后缀转小写、补前导点，按长度降序
+	var got []string
+	for _, r := range rules {
+		got = append(got, r.suffix+"="+r.to)
+	}
+	if want := ".zh-tw.md=s2twp .txt=s2tw .md=s2t"; strings.Join(got, " ") != want {
+		t.Errorf("rules = %v, want %s", got, want)
+	}
+
+	if _, err := compileExtTo(map[string]string{".MD": "s2t", "md": "s2tw"}); err == nil || !strings.Contains(err.Error(), "不区分大小写") {
+		t.Errorf("case-insensitive duplicate: err = %v", err)
+	}
+	if _, err := compileExtTo(map[string]string{".md": "nope"}); err == nil {
+		t.Error("unknown config should fail")
+	}
+}
+
+func TestRunFileExtTo(t *testing.T) {
+	dir := t.TempDir()
+	const src = "台湾软件"
+	writeFiles(t, dir, map[string]string{"a.zh-TW.md": src, "b.MD": src, "c.txt": src})
+	cfg := FileConfig{
+		RootDirs: []string{dir},
+		Exts:     []string{".md", ".txt"},
+		To:       "s2hk",
+		ExtTo:    map[string]string{".md": "s2t", ".zh-TW.md": "s2twp"},
+	}
+	if _, _, err := RunFileWithResult(cfg); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"a.zh-TW.md": "臺灣軟體", // 最长后缀优先于 .md
+		"b.MD":       "臺灣軟件", // 后缀不区分大小写
+		"c.txt":      "台灣軟件", // 未匹配时用 To
+	} {
+		bs, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bs) != want {
+			t.Errorf("%s = %q, want %q", name, bs, want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 文件缓存：记录上次运行已处理完（已转换或无需转换）的文件状态，未变化的文件下次直接跳过
+type fileCacheEntry struct {
+	ModTime int64  `json:"mtime"` // UnixNano
+	Size    int64  `json:"size"`
+	Hash    string `json:"sha256"`
+}
+
+type fileCacheData struct {
+	Key     string                    `json:"key"` // 转换参数指纹，变化时整体失效
+	Entries map[string]fileCacheEntry `json:"entries"`
+}
+
+type fileCache struct {
+	path string
+	mu   sync.Mutex
+	data fileCacheData
+	hits int64
+}
+
+// cacheKey 由影响转换结果的参数组成
+func (c FileConfig) cacheKey() string {
+	key := fmt.Sprintf("to=%s;normalize=%s;normalize_input=%t", c.To, c.Normalize, c.NormalizeInput)
+	for _, r := range c.extTo {
+		key += ";" + r.suffix + "=" + r.to
+	}
+	if len(c.CodeStrings) > 0 {
+		key += ";code_strings=" + strings.Join(c.CodeStrings, ",")
+	}
+	if c.PunctOnly {
+		key += ";punct_only"
+	}
+	if c.InvalidUTF8 != "" && c.InvalidUTF8 != InvalidUTF8Error {
+		key += ";invalid_utf8=" + c.InvalidUTF8
+	}
+	if c.EOL != "keep" {
+		key += ";eol=" + c.EOL
+	}
+	if c.RequireFrontMatter != "" {
+		key += ";frontmatter=" + c.RequireFrontMatter + ">" + c.RewriteFrontMatter
+	}
+	return key
+}
+
+// loadFileCache 读取缓存；文件不存在或参数指纹不一致时返回空缓存
+func loadFileCache(path, key string) (*fileCache, error) {
+	fc := &fileCache{path: path, data: fileCacheData{Key: key, Entries: map[string]fileCacheEntry{}}}
+	bs, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取缓存 %s: %w", path, err)
+	}
+	var data fileCacheData
+	if err := json.Unmarshal(bs, &data); err != nil {
+		return nil, fmt.Errorf("解析缓存 %s: %w（可删除该文件后重试）", path, err)
+	}
+	if data.Key == key && data.Entries != nil {
+		fc.data = data
+	}
+	return fc, nil
+}
+
+func cacheAbs(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// fresh 判断文件是否与缓存一致：mtime 与 size 都未变则无需读取；
+// 仅 mtime 变化时由调用方比较内容哈希（见 freshContent）
+func (fc *fileCache) fresh(path string, fi os.FileInfo) bool {
+	if fc == nil {
+		return false
+	}
+	fc.mu.Lock()
+	e, ok := fc.data.Entries[cacheAbs(path)]
+	fc.mu.Unlock()
+	if ok && e.Size == fi.Size() && e.ModTime == fi.ModTime().UnixNano() {
+		atomic.AddInt64(&fc.hits, 1)
+		return true
+	}
+	return false
+}
+
+// freshContent 在 mtime 变化（如 git checkout、touch）但大小不变时按内容哈希判断；命中时刷新 mtime
+func (fc *fileCache) freshContent(path string, fi os.FileInfo, content []byte) bool {
+	if fc == nil {
+		return false
+	}
+	abs := cacheAbs(path)
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	e, ok := fc.data.Entries[abs]
+	if !ok || e.Size != fi.Size() || e.Hash != hashContent(content) {
+		return false
+	}
+	e.ModTime = fi.ModTime().UnixNano()
+	fc.data.Entries[abs] = e
+	atomic.AddInt64(&fc.hits, 1)
+	return true
+}
+
+// put 记录文件处理完成后的状态
+func (fc *fileCache) put(path string, content []byte) {
+	if fc == nil {
+		return
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	fc.mu.Lock()
+	fc.data.Entries[cacheAbs(path)] = fileCacheEntry{ModTime: fi.ModTime().UnixNano(), Size: fi.Size(), Hash: hashContent(content)}
+	fc.mu.Unlock()
+}
+
+// move 文件改名后迁移缓存记录
+func (fc *fileCache) move(from, to string) {
+	if fc == nil {
+		return
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if e, ok := fc.data.Entries[cacheAbs(from)]; ok {
+		delete(fc.data.Entries, cacheAbs(from))
+		fc.data.Entries[cacheAbs(to)] = e
+	}
+}
+
+// moveDir 目录改名后迁移其下所有文件的缓存记录，旧路径不再保留
+func (fc *fileCache) moveDir(from, to string) {
+	if fc == nil {
+		return
+	}
+	prefix, target := cacheAbs(from)+string(filepath.Separator), cacheAbs(to)
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	for k, e := range fc.data.Entries {
+		if rest, ok := strings.CutPrefix(k, prefix); ok {
+			delete(fc.data.Entries, k)
+			fc.data.Entries[filepath.Join(target, rest)] = e
+		}
+	}
+}
+
+// save 原子写回缓存文件
+func (fc *fileCache) save() error {
+	fc.mu.Lock()
+	bs, err := json.Marshal(fc.data)
+	fc.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(fc.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp := fc.path + ".tmp"
+	if err := os.WriteFile(tmp, bs, 0644); err != nil {
+		return fmt.Errorf("写缓存 %s: %w", tmp, err)
+	}
+	return os.Rename(tmp, fc.path)
+}
+
+func hashContent(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
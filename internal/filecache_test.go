@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCacheMoveDir(t *testing.T) {
+	dir := t.TempDir()
+	fc := &fileCache{data: fileCacheData{Entries: map[string]fileCacheEntry{
+		filepath.Join(dir, "简体", "a.md"):      {Size: 1},
+		filepath.Join(dir, "简体", "子", "b.md"): {Size: 2},
+		filepath.Join(dir, "简体x", "c.md"):     {Size: 3}, // 同前缀的其它目录不受影响
+		filepath.Join(dir, "other.md"):        {Size: 4},
+	}}}
+	fc.moveDir(filepath.Join(dir, "简体"), filepath.Join(dir, "簡體"))
+	want := map[string]int64{
+		filepath.Join(dir, "簡體", "a.md"):      1,
+		filepath.Join(dir, "簡體", "子", "b.md"): 2,
+		filepath.Join(dir, "简体x", "c.md"):     3,
+		filepath.Join(dir, "other.md"):        4,
+	}
+	if len(fc.data.Entries) != len(want) {
+		t.Errorf("entries = %v", fc.data.Entries)
+	}
+	for k, size := range want {
+		if e, ok := fc.data.Entries[k]; !ok || e.Size != size {
+			t.Errorf("entry %s = %+v, %v", k, e, ok)
+		}
+	}
+	var nilCache *fileCache
+	nilCache.moveDir("a", "b")
+}
+
+func TestRunFileRenameDirsMigratesCache(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "src")
+	writeFiles(t, root, map[string]string{"简体/文档.md": "简体", "简体/子目录/b.md": "hello"})
+	cacheFile := filepath.Join(dir, "cache.json")
+	cfg := FileConfig{RootDirs: []string{root}, Exts: []string{".md"}, To: "s2t", Rename: true, RenameDirs: true, CacheFile: cacheFile}
+	if _, err := RunFile(cfg); err != nil {
+		t.Fatal(err)
+	}
+	fc, err := loadFileCache(cacheFile, FileConfig{To: "s2t", EOL: "keep"}.cacheKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(root, "簡體", "文檔.md"), filepath.Join(root, "簡體", "子目錄", "b.md")}
+	if len(fc.data.Entries) != len(want) {
+		t.Errorf("entries = %v", fc.data.Entries)
+	}
+	for _, p := range want {
+		if _, ok := fc.data.Entries[p]; !ok {
+			t.Errorf("missing cache entry for %s: %v", p, fc.data.Entries)
+		}
+	}
+
+	// 第二次运行：改名后的文件全部命中缓存
+	stats := &Stats{}
+	cfg.Stats = stats
+	results, _, err := RunFileWithResult(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results = %+v", results)
+	}
+	for _, r := range results {
+		if !r.Cached {
+			t.Errorf("%s not served from cache", r.Path)
+		}
+	}
+}
+
+func TestRunFileCacheHitMiss(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "src")
+	writeFiles(t, root, map[string]string{"a.md": "简体", "b.md": "繁體"})
+	cfg := FileConfig{RootDirs: []string{root}, Exts: []string{".md"}, To: "s2t", CacheFile: filepath.Join(dir, "cache.json")}
+	run := func(cfg FileConfig) map[string]bool {
+		t.Helper()
+		results, _, err := RunFileWithResult(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cached := map[string]bool{}
+		for _, r := range results {
+			cached[filepath.Base(r.Path)] = r.Cached
+		}
+		return cached
+	}
+	if got := run(cfg); got["a.md"] || got["b.md"] {
+		t.Errorf("first run cached = %v", got)
+	}
+	if got := run(cfg); !got["a.md"] || !got["b.md"] {
+		t.Errorf("second run cached = %v, want all hits", got)
+	}
+
+	// 仅 mtime 变化（touch）：内容哈希一致仍命中；内容变化则重新处理
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "a.md"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, map[string]string{"b.md": "繁體与简体"})
+	if got := run(cfg); !got["a.md"] || got["b.md"] {
+		t.Errorf("after touch/edit cached = %v, want a hit, b miss", got)
+	}
+	if bs, _ := os.ReadFile(filepath.Join(root, "b.md")); string(bs) != "繁體與簡體" {
+		t.Errorf("b.md = %q", bs)
+	}
+
+	// 转换配置变化时缓存整体失效
+	cfg.To = "s2tw"
+	if got := run(cfg); got["a.md"] || got["b.md"] {
+		t.Errorf("after --to change cached = %v, want all misses", got)
+	}
+}
@@ -1,114 +1,1023 @@
 package internal
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 type FileConfig struct {
-	RootDir string
-	Exts    []string // 过滤扩展名（含点），为空表示全部
-	To      string
-	Backup  bool
-	DryRun  bool
-	Workers int
+	RootDirs []string // 根目录，可多个；为空表示当前目录
+	Exts     []string // 过滤扩展名（含点），为空表示全部
+	ExclExts []string // 排除的扩展名
+	To       string
+	Backup   bool
+	DryRun   bool
+	Workers  int
+
+	Normalize      string // 输出 Unicode 规范化：nfc | nfkc | none
+	NormalizeInput bool   // 转换前是否也对输入做规范化
+	PunctOnly      bool   // 仅标点模式：汉字不变
+	EOL            string // 写回的换行风格：keep | lf | crlf
+
+	Stats *Stats // 可选：统计输出
+
+	CacheFile string // 可选：文件缓存路径，跳过未变化的文件
+
+	ResumeFile string // 可选：续跑记录路径，跳过已处理完的文件
+
+	Rename     bool // 同时转换文件名
+	RenameDirs bool // 同时转换目录名（根目录除外）
+
+	ChangeLog *ChangeLog // 可选：dry-run 时记录拟变更
+	Patch     *Patch     // 可选：dry-run 时汇总为统一 diff
+
+	OutputDir     string // 可选：结果写入该目录，原文件不动
+	CopyUnchanged bool   // 配合 OutputDir：无需转换的文件也复制
+
+	ConcatOut    string // 可选：转换结果拼接写入该文件，不写回原文件
+	ConcatHeader string // 配合 ConcatOut：每个文件之前的标题
+	ConcatSep    string // 配合 ConcatOut：文件之间的分隔
+
+	Events *EventStream // 可选：向前端输出进度事件
+
+	TermReport *TermReport // 可选：统计词条替换频次（dry-run）
+
+	StopOnError bool // 首个文件出错即停止
+
+	Confirm func(path, diff string) ConfirmChoice // 可选：写回前逐个确认（串行处理）
+
+	ExtTo map[string]string // 可选：按文件名后缀选择转换配置
+
+	PruneBackups bool // 清理已无用的 .bak
+
+	PathsFrom io.Reader // 可选：从中读取待处理的文件路径（代替遍历 RootDirs）
+	PathsNUL  bool      // PathsFrom 以 NUL 分隔（默认换行分隔）
+
+	CodeStrings []string // 可选：这些语言（php / js / go）只转换字符串字面量
+
+	RequireFrontMatter string // 可选：只转换 front-matter 满足 字段=取值 的文件
+	RewriteFrontMatter string // 可选：转换后把该字段改写为此值
+
+	NoIgnoreFile bool // 不读取 .tradifyignore
+
+	DedupeIdentical bool // 内容相同的文件只转换一次
+
+	InvalidUTF8 string // 无效 UTF-8 的处理：error | lenient | replace
+
+	ExcludePaths []string // 遍历时跳过的路径（本次运行自己读写的文件）
+
+	ExplainSkip bool // 逐个记录未转换的文件及原因
+
+	confirm *confirmer
+	concat  *concatWriter
+	extTo   []extRule
+	codeExt map[string]string // 扩展名 -> CodeStrings 语言
+	dedupe  *fileDedupe
+	backups *sync.Map // 本次运行写入的备份，清理时保留
+
+	frontMatter *frontMatterRule
+}
+
+// extRule 一条后缀 -> 转换配置规则（后缀已转小写）
+type extRule struct {
+	suffix, to string
 }
 
-func RunFile(cfg FileConfig) error {
-	if cfg.RootDir == "" {
-		cfg.RootDir = "."
+// ParseExtTo 解析 "后缀=转换配置" 列表（如 .zh-TW.md=s2twp），后缀不区分大小写、可省略前导点
+func ParseExtTo(specs []string) (map[string]string, error) {
+	if len(specs) == 0 {
+		return nil, nil
 	}
-	if cfg.Workers <= 0 {
-		cfg.Workers = 4
+	out := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		suffix, to, ok := strings.Cut(spec, "=")
+		suffix, to = strings.TrimSpace(suffix), strings.TrimSpace(to)
+		if !ok || suffix == "" || to == "" {
+			return nil, fmt.Errorf("无效的 ext-to %q（格式：后缀=转换配置，如 .zh-TW.md=s2twp）", spec)
+		}
+		if _, dup := out[suffix]; dup {
+			return nil, fmt.Errorf("ext-to 后缀 %s 重复", suffix)
+		}
+		out[suffix] = to
+	}
+	return out, nil
+}
+
+// compileExtTo 规范化后缀并按长度降序排列（最长匹配优先），同时校验各转换配置
+func compileExtTo(m map[string]string) ([]extRule, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // 多个错误时固定报告顺序
+	rules := make([]extRule, 0, len(m))
+	seen := map[string]string{}
+	for _, suffix := range keys {
+		to := m[suffix]
+		s := strings.ToLower(strings.TrimSpace(suffix))
+		if s == "" {
+			return nil, errors.New("ext-to 后缀不能为空")
+		}
+		if !strings.HasPrefix(s, ".") {
+			s = "." + s
+		}
+		if prev, ok := seen[s]; ok {
+			return nil, fmt.Errorf("ext-to 后缀 %s 与 %s 重复（不区分大小写）", suffix, prev)
+		}
+		seen[s] = suffix
+		if err := WarmUpConverters(to); err != nil {
+			return nil, fmt.Errorf("ext-to %s: %w", suffix, err)
+		}
+		rules = append(rules, extRule{suffix: s, to: to})
 	}
+	sort.Slice(rules, func(i, j int) bool {
+		if len(rules[i].suffix) != len(rules[j].suffix) {
+			return len(rules[i].suffix) > len(rules[j].suffix)
+		}
+		return rules[i].suffix < rules[j].suffix
+	})
+	return rules, nil
+}
 
-	// 规范化扩展名到小写
-	extSet := map[string]struct{}{}
-	for _, e := range cfg.Exts {
+// toFor 返回文件应使用的转换配置：按文件名匹配最长的 ExtTo 后缀，未匹配时为 To
+func (c FileConfig) toFor(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	for _, r := range c.extTo {
+		if strings.HasSuffix(name, r.suffix) {
+			return r.to
+		}
+	}
+	return c.To
+}
+
+// extFilter 按扩展名过滤（已规范化为小写、带前导点）：include 为空表示全部，exclude 再从中排除
+type extFilter struct {
+	include, exclude map[string]struct{}
+}
+
+func newExtFilter(include, exclude []string) extFilter {
+	return extFilter{include: extSetOf(include), exclude: extSetOf(exclude)}
+}
+
+// extSetOf 规范化扩展名到小写并补全前导点，忽略空项
+func extSetOf(exts []string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, e := range exts {
 		e = strings.TrimSpace(strings.ToLower(e))
 		if e != "" && !strings.HasPrefix(e, ".") {
 			e = "." + e
 		}
 		if e != "" {
-			extSet[e] = struct{}{}
+			set[e] = struct{}{}
+		}
+	}
+	return set
+}
+
+// allows 判断文件是否按扩展名纳入处理
+func (f extFilter) allows(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if len(f.include) > 0 {
+		if _, ok := f.include[ext]; !ok {
+			return false
 		}
 	}
+	_, excluded := f.exclude[ext]
+	return !excluded
+}
 
-	type task struct{ path string }
+// ConfirmChoice 交互确认的选择
+type ConfirmChoice int
+
+const (
+	ConfirmYes  ConfirmChoice = iota // 写回该文件
+	ConfirmNo                        // 跳过该文件
+	ConfirmAll                       // 写回该文件及其后所有文件，不再询问
+	ConfirmQuit                      // 跳过该文件并停止处理其余文件
+)
+
+// ErrInteractiveQuit 交互确认中选择退出
+var ErrInteractiveQuit = errors.New("已按 q 退出，其余文件未处理")
+
+// ErrStoppedOnError StopOnError 下遇到首个文件错误后停止
+var ErrStoppedOnError = errors.New("遇到错误已停止，其余文件未处理")
+
+// confirmer 交互确认状态（仅单 worker 使用，无需加锁）
+type confirmer struct {
+	ask              func(path, diff string) ConfirmChoice
+	all, quit        bool
+	applied, skipped int
+}
+
+// approve 询问是否写回；选择 all 后不再询问
+func (c *confirmer) approve(path, orig, out string) bool {
+	if c == nil {
+		return true
+	}
+	if c.all {
+		c.applied++
+		return true
+	}
+	switch c.ask(path, lineDiff(orig, out)) {
+	case ConfirmYes:
+		c.applied++
+		return true
+	case ConfirmAll:
+		c.all = true
+		c.applied++
+		return true
+	case ConfirmQuit:
+		c.quit = true
+	}
+	c.skipped++
+	return false
+}
+
+// FileResult 单个文件的处理结果
+type FileResult struct {
+	Path        string // 源文件路径
+	Output      string // 输出路径（仅 OutputDir 模式）
+	Changed     bool   // 内容需要转换（dry-run 下为“将修改”）
+	Written     bool   // 已写回或写入输出目录（dry-run、交互确认跳过时为 false）
+	Cached      bool   // 命中 CacheFile，未读取/未转换
+	RenamedTo   string // 文件已改名时的新路径
+	BytesBefore int64  // 转换前字节数
+	BytesAfter  int64  // 转换后字节数（未转换时与 BytesBefore 相同）
+	Err         error  // 处理失败的原因
+
+	concat *string // 仅 ConcatOut：该文件写入拼接输出的内容（nil 不写入），交给 concatWriter 后即清空
+}
+
+// RunFile 执行文件转换，返回汇总结果；逐文件结果见 RunFileWithResult
+func RunFile(cfg FileConfig) (RunStats, error) {
+	_, rs, err := RunFileWithResult(cfg)
+	return rs, err
+}
+
+// RunFileWithResult 执行文件转换并返回逐文件结果（按路径排序）与汇总结果，便于嵌入调用方渲染或断言。
+// 被 Exts 过滤掉的文件不在结果中；处理失败的文件带 Err（同时计入 Stats），返回的错误与 RunFile 相同
+func RunFileWithResult(cfg FileConfig) ([]FileResult, RunStats, error) {
+	start := time.Now()
+	if cfg.Stats == nil {
+		cfg.Stats = &Stats{} // 汇总结果中的按值统计
+	}
+	roots := dedupeRoots(cfg.RootDirs)
+	if len(roots) == 0 || cfg.PathsFrom != nil {
+		roots = []string{"."} // 路径清单模式下输出目录按当前目录映射
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	// invalid 参数校验失败：归为 ErrConfigInvalid
+	invalid := func(err error) ([]FileResult, RunStats, error) {
+		return nil, RunStats{}, classify(ErrConfigInvalid, err)
+	}
+	if _, err := ParseNormalizeForm(cfg.Normalize); err != nil {
+		return invalid(err)
+	}
+	eol, err := ParseEOL(cfg.EOL)
+	if err != nil {
+		return invalid(err)
+	}
+	cfg.EOL = eol
+	if cfg.InvalidUTF8, err = ParseInvalidUTF8(cfg.InvalidUTF8); err != nil {
+		return invalid(err)
+	}
+	if err := WarmUpConverters(cfg.To); err != nil {
+		return invalid(err)
+	}
+	if len(cfg.ExtTo) > 0 {
+		rules, err := compileExtTo(cfg.ExtTo)
+		if err != nil {
+			return invalid(err)
+		}
+		cfg.extTo = rules
+	}
+	if len(cfg.CodeStrings) > 0 {
+		m, err := compileCodeStrings(cfg.CodeStrings)
+		if err != nil {
+			return invalid(err)
+		}
+		cfg.codeExt = m
+	}
+	if cfg.DedupeIdentical {
+		cfg.dedupe = newFileDedupe()
+	}
+	if cfg.frontMatter, err = parseFrontMatterRule(cfg.RequireFrontMatter, cfg.RewriteFrontMatter); err != nil {
+		return invalid(err)
+	}
+	if cfg.Confirm != nil {
+		if cfg.DryRun || cfg.Rename || cfg.RenameDirs {
+			return invalid(errors.New("Confirm 不可与 DryRun/Rename/RenameDirs 同时使用"))
+		}
+		cfg.Workers = 1 // 逐个询问，串行处理
+		cfg.confirm = &confirmer{ask: cfg.Confirm}
+	}
+	if cfg.PathsFrom != nil && (cfg.RenameDirs || cfg.PruneBackups) {
+		return invalid(errors.New("PathsFrom 不可与 RenameDirs/PruneBackups 同时使用（不遍历目录）"))
+	}
+	if cfg.OutputDir != "" {
+		if cfg.Rename || cfg.RenameDirs || cfg.CacheFile != "" {
+			return invalid(errors.New("OutputDir 不可与 Rename/RenameDirs/CacheFile 同时使用"))
+		}
+		if err := checkOutputRoots(roots); err != nil {
+			return invalid(err)
+		}
+	}
+	if cfg.Patch != nil {
+		if !cfg.DryRun {
+			return invalid(errors.New("Patch 仅可在 DryRun 时使用"))
+		}
+		if err := checkOutputRoots(roots); err != nil {
+			return invalid(err)
+		}
+	}
+	if cfg.ConcatOut != "" {
+		if cfg.OutputDir != "" || cfg.Rename || cfg.RenameDirs || cfg.CacheFile != "" || cfg.ResumeFile != "" || cfg.Confirm != nil || cfg.Backup {
+			return invalid(errors.New("ConcatOut 不可与 OutputDir/Rename/RenameDirs/CacheFile/ResumeFile/Confirm/Backup 同时使用"))
+		}
+		if err := checkOutputRoots(roots); err != nil {
+			return invalid(err)
+		}
+		if cfg.concat, err = newConcatWriter(cfg.ConcatOut, cfg.ConcatHeader, cfg.ConcatSep, cfg.DryRun); err != nil {
+			return nil, RunStats{}, err
+		}
+	}
+
+	exts := newExtFilter(cfg.Exts, cfg.ExclExts)
+
+	if cfg.PruneBackups {
+		cfg.backups = &sync.Map{}
+		pruneBackups(cfg, roots, exts, "处理前")
+	}
+
+	var cache *fileCache
+	if cfg.CacheFile != "" {
+		c, err := loadFileCache(cfg.CacheFile, cfg.cacheKey())
+		if err != nil {
+			return nil, RunStats{}, err
+		}
+		cache = c
+	}
+	var resume *fileResume
+	if cfg.ResumeFile != "" {
+		r, err := loadFileResume(cfg.ResumeFile, cfg.cacheKey(), cfg.DryRun)
+		if err != nil {
+			return nil, RunStats{}, err
+		}
+		resume = r
+	}
+
+	excluded := newExcludedPaths(cfg.ExcludePaths)
+
+	var ignore *pathIgnorer
+	if !cfg.NoIgnoreFile {
+		ignore = newPathIgnorer()
+	}
+
+	var ren *renamer
+	if cfg.Rename || cfg.RenameDirs {
+		ren = newRenamer(cfg)
+	}
+	var dirs []string // 待改名的目录
+
+	type task struct {
+		path     string
+		dst      string // 输出路径（仅 OutputDir 模式）
+		copyOnly bool   // 仅复制（被 Exts 过滤掉的文件，CopyUnchanged 时）
+		rel      string // 补丁中与拼接标题中的相对路径（仅 Patch / ConcatOut 模式）
+		seq      int    // 拼接输出中的序号，按 rel 排序后分配（仅 ConcatOut 模式）
+	}
 	ch := make(chan task, 128)
 
+	// StopOnError：记录首个错误并停止派发，同时取消 ctx，已在处理中的文件在下一步（读取、写回）前放弃
+	var (
+		stopped  atomic.Bool
+		stopOnce sync.Once
+		stopErr  error
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		resMu   sync.Mutex
+		results []FileResult
+	)
+	record := func(r FileResult) {
+		resMu.Lock()
+		results = append(results, r)
+		resMu.Unlock()
+	}
+	fail := func(r FileResult, err error) {
+		log.Printf("[file] %v", err)
+		cfg.Stats.RecordError()
+		cfg.Events.Error("", r.Path, err)
+		r.Err = err
+		record(r)
+		if cfg.StopOnError {
+			stopOnce.Do(func() {
+				stopErr = err
+				stopped.Store(true)
+				cancel()
+			})
+		}
+	}
+
 	var wg sync.WaitGroup
 	for i := 0; i < cfg.Workers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for t := range ch {
-				if err := processFile(t.path, cfg, extSet); err != nil {
-					log.Printf("[file] %v", err)
+				if cfg.confirm != nil && cfg.confirm.quit || stopped.Load() {
+					cfg.concat.put(t.seq, t.rel, nil)
+					continue // 已选择退出或已出错停止：丢弃其余任务
+				}
+				if t.copyOnly {
+					if err := copyToOutput(t.path, t.dst, cfg.DryRun); err != nil {
+						fail(FileResult{Path: t.path, Output: t.dst}, err)
+					}
+					continue
+				}
+				res, err := processFile(ctx, t.path, t.dst, t.rel, cfg, cache)
+				if errors.Is(err, context.Canceled) {
+					cfg.concat.put(t.seq, t.rel, nil)
+					continue // 已出错停止：放弃处理中的文件，不计入结果
+				}
+				cfg.concat.put(t.seq, t.rel, res.concat)
+				res.concat = nil
+				if err != nil {
+					fail(res, err)
+					continue
 				}
+				if cfg.Rename {
+					if to := ren.rename(t.path); to != t.path {
+						cache.move(t.path, to)
+						res.RenamedTo = to
+					}
+				}
+				if !res.Changed || res.Written { // 交互确认中跳过的文件下次仍需处理
+					done := t.path
+					if res.RenamedTo != "" {
+						done = res.RenamedTo
+					}
+					if err := resume.markDone(done); err != nil {
+						log.Printf("[file] %v", err)
+					}
+				}
+				record(res)
 			}
 		}()
 	}
 
-	// walk 目录
-	err := filepath.WalkDir(cfg.RootDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			log.Printf("[file] walk error: %v", err)
-			return nil
+	var queued []task // 拼接模式：遍历完成后按相对路径排序再分配序号并派发（dispatch 只在遍历的 goroutine 中调用）
+
+	// dispatch 过滤后把文件送入 worker 池：被 Exts 过滤掉的文件仅在 CopyUnchanged 时复制，
+	// 被 .tradifyignore 忽略的文件完全跳过（也不复制）
+	dispatch := func(root, path string) {
+		if cache != nil && isCacheFile(path, cache.path) || resume != nil && cacheAbs(path) == cacheAbs(resume.path) {
+			return
+		}
+		if filepath.Base(path) == IgnoreFileName || cfg.concat.owns(path) || excluded.has(path) {
+			return
+		}
+		if skip, err := ignore.ignored(root, path, false); err != nil {
+			fail(FileResult{Path: path}, err)
+			return
+		} else if skip {
+			cfg.explainSkip(path, skipIgnored)
+			return
+		}
+		t := task{path: path}
+		if cfg.OutputDir != "" {
+			t.dst = outputPath(cfg.OutputDir, cacheAbs(root), cacheAbs(path), len(roots) > 1)
+		}
+		if cfg.Patch != nil || cfg.concat != nil {
+			t.rel = outputPath("", cacheAbs(root), cacheAbs(path), len(roots) > 1)
 		}
-		if d.IsDir() {
-			return nil
+		if !exts.allows(path) {
+			cfg.explainSkip(path, skipFilteredExt)
+			if cfg.OutputDir != "" && cfg.CopyUnchanged {
+				t.copyOnly = true
+				ch <- t
+			}
+			return
+		}
+		if resume.skip(path) {
+			cfg.explainSkip(path, skipResumed)
+			return
+		}
+		if cfg.concat != nil {
+			queued = append(queued, t)
+			return
 		}
-		if len(extSet) > 0 {
-			ext := strings.ToLower(filepath.Ext(d.Name()))
-			if _, ok := extSet[ext]; !ok {
+		ch <- t
+	}
+
+	if cfg.PathsFrom != nil {
+		// 路径清单模式：不遍历目录，逐个读取并派发（同一文件只处理一次）
+		seen := map[string]bool{}
+		err = scanPaths(cfg.PathsFrom, cfg.PathsNUL, func(path string) bool {
+			if stopped.Load() {
+				return false
+			}
+			abs := cacheAbs(path)
+			if seen[abs] {
+				return true
+			}
+			seen[abs] = true
+			fi, serr := os.Stat(path)
+			switch {
+			case serr != nil:
+				fail(FileResult{Path: path}, readError(path, serr))
+			case fi.IsDir():
+				log.Printf("[file] 跳过目录：%s（路径清单只处理文件）", path)
+			case cfg.OutputDir != "" && !isWithin(cacheAbs("."), abs):
+				fail(FileResult{Path: path}, fmt.Errorf("不在当前目录下，无法映射到输出目录：%s", path))
+			case cfg.OutputDir != "" && isWithin(cacheAbs(cfg.OutputDir), abs):
+				log.Printf("[file] 跳过输出目录中的文件：%s", path)
+			default:
+				dispatch(".", path)
+			}
+			return true
+		})
+		if err != nil {
+			err = fmt.Errorf("读取路径清单失败：%w", err)
+		}
+	} else {
+		// 依次 walk 各根目录，文件送入同一 worker 池
+		for _, root := range roots {
+			werr := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if stopped.Load() {
+					return filepath.SkipAll
+				}
+				if err != nil {
+					fail(FileResult{Path: path}, readError(path, err))
+					return nil
+				}
+				if d.IsDir() {
+					if cfg.OutputDir != "" && cacheAbs(path) == cacheAbs(cfg.OutputDir) || path != root && excluded.has(path) {
+						return filepath.SkipDir // 输出目录、状态目录位于根目录内时不遍历
+					}
+					if skip, err := ignore.ignored(root, path, true); err != nil {
+						fail(FileResult{Path: path}, err)
+						return filepath.SkipDir
+					} else if skip {
+						cfg.explainSkip(path+string(filepath.Separator), skipIgnored)
+						return filepath.SkipDir
+					}
+					if cfg.RenameDirs && path != root {
+						dirs = append(dirs, path)
+					}
+					return nil
+				}
+				dispatch(root, path)
 				return nil
+			})
+			if werr != nil && err == nil {
+				err = werr
 			}
 		}
-		ch <- task{path: path}
-		return nil
-	})
+	}
+	// 遍历顺序（目录内按文件名、多个根目录按参数顺序）与路径清单的顺序都不是路径顺序，拼接前统一按相对路径排序
+	sort.SliceStable(queued, func(i, j int) bool { return queued[i].rel < queued[j].rel })
+	for i, t := range queued {
+		if stopped.Load() {
+			break // 未派发的序号由 concat.close 跳过
+		}
+		t.seq = i
+		ch <- t
+	}
 	close(ch)
 	wg.Wait()
+	if cfg.RenameDirs && !stopped.Load() {
+		ren.renameDirs(dirs, cache.moveDir) // 目录改名后迁移其下文件的缓存记录
+	}
+	ren.report()
+	if cfg.PruneBackups && !cfg.DryRun {
+		pruneBackups(cfg, roots, exts, "处理后") // 本次写回后才变得无用的旧备份（dry-run 下与处理前相同，不再重复列出）
+	}
+	if stopErr != nil && err == nil {
+		err = fmt.Errorf("%w：%w", ErrStoppedOnError, stopErr)
+	}
+	if cfg.concat != nil {
+		failed := 0
+		for _, r := range results {
+			if r.Err != nil {
+				failed++
+			}
+		}
+		ok := err == nil && failed == 0
+		if cerr := cfg.concat.close(ok); cerr != nil {
+			ok = false
+			if err == nil {
+				err = cerr
+			}
+		}
+		if !ok && !cfg.DryRun {
+			for i := range results {
+				results[i].Written = false // 拼接输出未落盘
+			}
+			if failed > 0 && err == nil {
+				err = fmt.Errorf("%d 个文件处理失败，未写入拼接输出 %s（已有的输出文件不变）", failed, cfg.ConcatOut)
+			}
+		}
+	}
+
+	if c := cfg.confirm; c != nil {
+		log.Printf("[file] 交互确认：写回 %d 个，跳过 %d 个", c.applied, c.skipped)
+		if c.quit && err == nil {
+			err = ErrInteractiveQuit
+		}
+	}
+	if cache != nil {
+		log.Printf("[file] 缓存命中 %d 个文件，已跳过", atomic.LoadInt64(&cache.hits))
+		if !cfg.DryRun {
+			if serr := cache.save(); serr != nil && err == nil {
+				err = serr
+			}
+		}
+	}
+	if fm := cfg.frontMatter; fm != nil {
+		log.Printf("[file] front-matter 不含 %s: %s，跳过 %d 个文件", fm.key, fm.value, atomic.LoadInt64(&fm.skipped))
+	}
+	if n := ignore.skippedFiles(); n > 0 {
+		log.Printf("[file] %s：跳过 %d 个文件（不含被忽略目录中的文件）", IgnoreFileName, n)
+	}
+	if d := cfg.dedupe; d != nil {
+		log.Printf("[file] 内容去重：共 %d 个文件，实际转换 %d 次", atomic.LoadInt64(&d.files), atomic.LoadInt64(&d.calls))
+	}
+	var resumed int64
+	if resume != nil {
+		resumed = atomic.LoadInt64(&resume.skipped)
+		complete := err == nil
+		for _, r := range results {
+			if r.Err != nil {
+				complete = false
+			}
+		}
+		if rerr := resume.finish(complete); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	rs := fileRunStats(results, cfg.Stats, resumed, time.Since(start))
+	rs.DryRun = cfg.DryRun
+	return results, rs, err
+}
+
+// excludedPaths 遍历时跳过的路径（绝对路径）：与其相同或位于其下的文件、目录都跳过
+type excludedPaths []string
+
+func newExcludedPaths(paths []string) excludedPaths {
+	var out excludedPaths
+	for _, p := range paths {
+		if strings.TrimSpace(p) != "" {
+			out = append(out, cacheAbs(p))
+		}
+	}
+	return out
+}
+
+func (e excludedPaths) has(path string) bool {
+	if len(e) == 0 {
+		return false
+	}
+	abs := cacheAbs(path)
+	for _, p := range e {
+		if isWithin(p, abs) {
+			return true
+		}
+	}
+	return false
+}
 
-	return err
+// isCacheFile 缓存文件（及其临时文件）可能位于被处理目录内，遍历时排除
+func isCacheFile(path, cachePath string) bool {
+	abs := cacheAbs(path)
+	c := cacheAbs(cachePath)
+	return abs == c || abs == c+".tmp"
 }
 
-func processFile(path string, cfg FileConfig, extSet map[string]struct{}) error {
+// dedupeRoots 去掉空值、重复项以及位于其它根目录之下的子目录，避免同一文件被并发处理两次
+func dedupeRoots(dirs []string) []string {
+	type root struct{ path, abs string }
+	var list []root
+	for _, d := range dirs {
+		if strings.TrimSpace(d) == "" {
+			continue
+		}
+		abs, err := filepath.Abs(d)
+		if err != nil {
+			abs = filepath.Clean(d)
+		}
+		list = append(list, root{path: d, abs: abs})
+	}
+	var out []string
+	for i, r := range list {
+		covered := false
+		for j, o := range list {
+			if i == j {
+				continue
+			}
+			// 重复项保留第一次出现；子目录并入其父根目录
+			if (o.abs == r.abs && j < i) || (o.abs != r.abs && isWithin(o.abs, r.abs)) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			log.Printf("[file] 跳过重复或已被覆盖的根目录：%s", r.path)
+			continue
+		}
+		out = append(out, r.path)
+	}
+	return out
+}
+
+// isWithin 判断 path 是否位于 parent 之下
+func isWithin(parent, path string) bool {
+	rel, err := filepath.Rel(parent, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// outputPath 计算输出路径：<out>/<相对路径>，多根目录时为 <out>/<根目录名>/<相对路径>
+func outputPath(out, root, path string, multiRoot bool) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	if multiRoot {
+		return filepath.Join(out, filepath.Base(cacheAbs(root)), rel)
+	}
+	return filepath.Join(out, rel)
+}
+
+// checkOutputRoots 多根目录映射到输出目录（或补丁路径）时以根目录名区分，同名会互相覆盖
+func checkOutputRoots(roots []string) error {
+	if len(roots) < 2 {
+		return nil
+	}
+	seen := map[string]string{}
+	for _, r := range roots {
+		name := filepath.Base(cacheAbs(r))
+		if prev, ok := seen[name]; ok {
+			return fmt.Errorf("根目录 %s 与 %s 同名，无法映射到 --output-dir / --patch-out", prev, r)
+		}
+		seen[name] = r
+	}
+	return nil
+}
+
+// writeOutput 将内容写入输出目录（自动创建上级目录，沿用源文件权限）
+func writeOutput(src, dst string, content []byte) error {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(src); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("创建目录失败 %s: %w", filepath.Dir(dst), err)
+	}
+	if err := os.WriteFile(dst, content, mode); err != nil {
+		return fmt.Errorf("写出失败 %s: %w", dst, err)
+	}
+	return nil
+}
+
+// readError 读取失败的错误；无权限时给出明确提示（仍可用 errors.Is(err, fs.ErrPermission) 判断）
+func readError(path string, err error) error {
+	if errors.Is(err, fs.ErrPermission) {
+		return classify(ErrIO, fmt.Errorf("无读取权限，已跳过 %s: %w", path, fs.ErrPermission))
+	}
+	return classify(ErrIO, fmt.Errorf("读取失败 %s: %w", path, err))
+}
+
+// copyToOutput 原样复制到输出目录
+func copyToOutput(src, dst string, dryRun bool) error {
+	if dryRun {
+		log.Printf("[DRYRUN] 将复制：%s -> %s", src, dst)
+		return nil
+	}
+	bs, err := os.ReadFile(src)
+	if err != nil {
+		return readError(src, err)
+	}
+	return writeOutput(src, dst, bs)
+}
+
+// processFile 转换单个文件；dst 非空时写入输出目录（原文件不动），否则原地写回；rel 为 dry-run 补丁中的路径。
+// ctx 取消（StopOnError 下其它文件出错）时在读取前与写回前放弃，返回 ctx 的错误，文件保持原样
+func processFile(ctx context.Context, path, dst, rel string, cfg FileConfig, cache *fileCache) (FileResult, error) {
+	res := FileResult{Path: path, Output: dst}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return res, readError(path, err)
+	}
+	res.BytesBefore, res.BytesAfter = fi.Size(), fi.Size()
+	if cfg.concat != nil && officeKind(path) != "" {
+		log.Printf("[file] Office 文档无法拼接为文本，已跳过：%s", path)
+		return res, nil
+	}
+	if fi.Size() == 0 {
+		// 空文件无需转换，跳过并计入统计
+		cfg.Stats.Record(OutcomeEmpty)
+		cfg.explainSkip(path, skipEmpty)
+		if cfg.concat != nil {
+			res.concat = new(string)
+		}
+		if dst != "" && cfg.CopyUnchanged {
+			return res, copyToOutput(path, dst, cfg.DryRun)
+		}
+		return res, nil
+	}
+	if cache.fresh(path, fi) {
+		res.Cached = true
+		cfg.explainSkip(path, skipCached)
+		return res, nil
+	}
+	start := cfg.Stats.startTimer()
 	bs, err := os.ReadFile(path)
+	cfg.Stats.addTime(timeFileRead, start)
 	if err != nil {
-		return fmt.Errorf("读取失败 %s: %w", path, err)
+		return res, readError(path, err)
+	}
+	if cache != nil && cache.freshContent(path, fi, bs) {
+		res.Cached = true
+		cfg.explainSkip(path, skipCached)
+		return res, nil
 	}
 	orig := string(bs)
+	res.BytesBefore, res.BytesAfter = int64(len(bs)), int64(len(bs))
+	office := officeKind(path)
+	lenient := false // 只转换有效的 UTF-8 片段
+	if office == "" && !utf8.ValidString(orig) {
+		if isBinary(bs) {
+			cfg.explainSkip(path, skipBinary)
+			cache.put(path, bs)
+			if dst != "" && cfg.CopyUnchanged {
+				return res, copyToOutput(path, dst, cfg.DryRun)
+			}
+			return res, nil
+		}
+		cfg.Stats.RecordInvalidUTF8()
+		switch cfg.InvalidUTF8 {
+		case InvalidUTF8Lenient:
+			lenient = true
+		case InvalidUTF8Replace:
+			orig = string([]rune(orig)) // 每个无效字节替换为 U+FFFD
+		default:
+			return res, invalidUTF8Error(path, orig)
+		}
+	}
+	if !cfg.frontMatter.allows(orig) {
+		cfg.explainSkip(path, skipFrontMatter)
+		cache.put(path, bs)
+		if dst != "" && cfg.CopyUnchanged {
+			return res, copyToOutput(path, dst, cfg.DryRun)
+		}
+		return res, nil
+	}
 
-	out, need, err := ConvertIfNeeded(cfg.To, orig)
+	to := cfg.toFor(path)
+	opts := ConvertOptions{To: to, Normalize: cfg.Normalize, NormalizeInput: cfg.NormalizeInput, PunctOnly: cfg.PunctOnly}
+	lang := cfg.codeLangFor(path)
+	out, oc, done, err := cfg.dedupe.convert(to, lang, orig, func() (string, ConvertOutcome, error) {
+		defer cfg.Stats.addTime(timeConvert, cfg.Stats.startTimer())
+		conv := func(s string) (string, ConvertOutcome, error) {
+			if office != "" {
+				return convertOffice(office, opts, s)
+			}
+			if lang != "" {
+				return convertCodeStrings(lang, opts, s)
+			}
+			return ConvertDetail(opts, s)
+		}
+		if lenient {
+			return convertSpans(orig, validUTF8Spans(orig), conv)
+		}
+		return conv(orig)
+	})
+	written := "" // 写出转换结果的文件，供内容相同的副本读回（--dedupe-identical-files）
+	defer func() { done(written) }()
 	if err != nil {
-		return fmt.Errorf("转换失败 %s: %w", path, err)
+		return res, classify(ErrConvert, fmt.Errorf("转换失败 %s: %w", path, err))
 	}
-	if !need {
-		return nil
+	cfg.Stats.Record(oc)
+	if oc != OutcomeConverted && office == "" && cfg.frontMatter.apply(orig) != orig {
+		out = orig // 正文无需转换，但 front-matter 标记仍需改写（如 lang: zh-CN -> zh-TW），照常写回
+	} else if oc != OutcomeConverted {
+		cfg.explainSkip(path, oc.skipReason())
+		if cfg.concat != nil {
+			res.concat = &orig
+		}
+		cache.put(path, bs)
+		if dst != "" && cfg.CopyUnchanged {
+			return res, copyToOutput(path, dst, cfg.DryRun)
+		}
+		return res, nil
+	}
+	// 差异展示（变更清单、交互确认）与词条统计使用文本；Office 文档为其中文本节点的内容
+	textBefore, textAfter := orig, out
+	if office != "" {
+		textBefore, textAfter = officeText(office, orig), officeText(office, out)
+	} else {
+		out = cfg.frontMatter.apply(out)
+		out = applyEOL(orig, out, cfg.EOL)
+		textAfter = out
+	}
+	res.Changed = true
+	res.BytesAfter = int64(len(out))
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	cfg.TermReport.Observe(to, textBefore, textAfter)
+
+	if cfg.concat != nil {
+		res.concat = &out
 	}
 
 	if cfg.DryRun {
-		log.Printf("[DRYRUN] 将修改文件：%s", path)
-		return nil
+		switch {
+		case cfg.concat != nil:
+			log.Printf("[DRYRUN] 将转换并拼接：%s -> %s", path, cfg.ConcatOut)
+		case dst != "":
+			log.Printf("[DRYRUN] 将写入：%s -> %s", path, dst)
+		default:
+			log.Printf("[DRYRUN] 将修改文件：%s", path)
+		}
+		cfg.ChangeLog.RecordFile(path, textBefore, textAfter)
+		if office != "" {
+			if cfg.Patch != nil {
+				log.Printf("[DRYRUN] Office 文档无法以文本补丁表示，未写入补丁：%s", path)
+			}
+		} else {
+			cfg.Patch.RecordFile(rel, orig, out)
+		}
+		cfg.Events.FileChanged(path, true)
+		return res, nil
+	}
+
+	if cfg.concat != nil {
+		res.Written = true // 写入拼接输出（全部文件成功后才落盘）
+		log.Printf("[OK] 转换完成：%s（拼接）", path)
+		cfg.Events.FileChanged(path, false)
+		return res, nil
+	}
+
+	if !cfg.confirm.approve(path, textBefore, textAfter) {
+		log.Printf("[file] 已跳过：%s", path)
+		cfg.explainSkip(path, skipDeclined)
+		return res, nil
+	}
+
+	defer cfg.Stats.addTime(timeFileWrite, cfg.Stats.startTimer())
+	if dst != "" {
+		if err := writeOutput(path, dst, []byte(out)); err != nil {
+			return res, err
+		}
+		res.Written, written = true, dst
+		log.Printf("[OK] 转换完成：%s -> %s", path, dst)
+		cfg.Events.FileChanged(path, false)
+		return res, nil
 	}
 
-	// 备份
+	// 备份；已有内容相同的备份时不重复写入
 	if cfg.Backup {
-		if err := os.WriteFile(path+".bak", bs, 0644); err != nil {
-			return fmt.Errorf("写备份失败 %s.bak: %w", path, err)
+		if old, err := os.ReadFile(path + backupSuffix); err != nil || !bytes.Equal(old, bs) {
+			if err := os.WriteFile(path+backupSuffix, bs, 0644); err != nil {
+				return res, classify(ErrIO, fmt.Errorf("写备份失败 %s.bak: %w", path, err))
+			}
+		}
+		if cfg.backups != nil {
+			cfg.backups.Store(cacheAbs(path+backupSuffix), true)
 		}
 	}
 
-	if err := os.WriteFile(path, []byte(out), 0644); err != nil {
-		return fmt.Errorf("写回失败 %s: %w", path, err)
+	write := func() error { return os.WriteFile(path, []byte(out), 0644) }
+	if office != "" {
+		write = func() error { return writeFileAtomic(path, []byte(out)) }
 	}
+	if err := write(); err != nil {
+		return res, classify(ErrIO, fmt.Errorf("写回失败 %s: %w", path, err))
+	}
+	res.Written, written = true, path
+	cache.put(path, []byte(out))
 	log.Printf("[OK] 转换完成：%s", path)
-	return nil
+	cfg.Events.FileChanged(path, false)
+	return res, nil
 }
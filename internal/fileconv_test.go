@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunFileCountsEmptyFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.md": "", "b.md": "", "c.md": "简体", "d.md": "hello"})
+	stats := &Stats{}
+	rs, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", DryRun: true, Stats: stats})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := stats.Snapshot()
+	if snap.SkippedEmpty != 2 || snap.Converted != 1 || snap.SkippedASCII != 1 {
+		t.Errorf("stats = %+v", snap)
+	}
+	if rs.Scanned != 4 {
+		t.Errorf("scanned = %d, want 4", rs.Scanned)
+	}
+	var buf bytes.Buffer
+	if err := stats.WriteSummary(&buf, "text"); err != nil || !strings.Contains(buf.String(), "跳过(空文件) 2") {
+		t.Errorf("summary = %q, %v", buf.String(), err)
+	}
+}
+
+func TestProcessFileCanceled(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.md": "简体"})
+	path := filepath.Join(dir, "a.md")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := processFile(ctx, path, "", "", FileConfig{To: "s2t"}, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if bs, _ := os.ReadFile(path); string(bs) != "简体" {
+		t.Errorf("canceled file was written: %q", bs)
+	}
+}
+
+func TestRunFileStopOnErrorSkipsRemaining(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"bad.md": "简体\xff"}
+	for i := range 50 {
+		files[fmt.Sprintf("f%02d.md", i)] = "简体"
+	}
+	writeFiles(t, dir, files)
+	results, _, err := RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", Workers: 1, StopOnError: true})
+	if !errors.Is(err, ErrStoppedOnError) {
+		t.Fatalf("err = %v", err)
+	}
+	// 单 worker 按文件名顺序处理：bad.md 出错后其余文件都未处理
+	if len(results) != 1 || results[0].Err == nil {
+		t.Errorf("results = %+v", results)
+	}
+	if bs, _ := os.ReadFile(filepath.Join(dir, "f00.md")); string(bs) != "简体" {
+		t.Errorf("f00.md = %q", bs)
+	}
+}
+
+// auto-trad 按文件判定方向：简体文件转换，已以繁体为主的文件（含零散简体字）原样保留
+func TestRunFileAutoTrad(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"simp.md":  "这个软件很好用",
+		"trad.md":  "這個軟體很好用",
+		"mixed.md": "這個軟體很好，但是这里",
+	})
+	if _, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: AutoTrad}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"simp.md":  "這個軟體很好用",
+		"trad.md":  "這個軟體很好用",
+		"mixed.md": "這個軟體很好，但是这里",
+	} {
+		if bs, _ := os.ReadFile(filepath.Join(dir, name)); string(bs) != want {
+			t.Errorf("%s = %q, want %q", name, bs, want)
+		}
+	}
+}
+
+// 无法读取的文件计为失败（ErrIO）并跳过，不影响其它文件；空文件静默跳过
+func TestRunFileUnreadableFile(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		make func(t *testing.T, path string)
+		perm bool // 是否为无权限错误
+	}{
+		{"permission denied", func(t *testing.T, path string) {
+			if os.Geteuid() == 0 {
+				t.Skip("root 不受文件权限限制")
+			}
+			writeFiles(t, filepath.Dir(path), map[string]string{filepath.Base(path): "简体"})
+			if err := os.Chmod(path, 0); err != nil {
+				t.Fatal(err)
+			}
+		}, true},
+		{"dangling symlink", func(t *testing.T, path string) {
+			if err := os.Symlink(filepath.Join(filepath.Dir(path), "missing"), path); err != nil {
+				t.Skip(err)
+			}
+		}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"a.md": "简体", "empty.md": ""})
+			bad := filepath.Join(dir, "b.md")
+			tc.make(t, bad)
+			stats := &Stats{}
+			results, rs, err := RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", Stats: stats})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var badErr error
+			for _, r := range results {
+				if r.Path == bad {
+					badErr = r.Err
+				} else if r.Err != nil {
+					t.Errorf("%s: %v", r.Path, r.Err)
+				}
+			}
+			if !errors.Is(badErr, ErrIO) || errors.Is(badErr, fs.ErrPermission) != tc.perm {
+				t.Errorf("unreadable file err = %v", badErr)
+			}
+			if snap := stats.Snapshot(); rs.Failed != 1 || snap.Errors != 1 || snap.SkippedEmpty != 1 {
+				t.Errorf("failed = %d, stats = %+v", rs.Failed, snap)
+			}
+			if bs, _ := os.ReadFile(filepath.Join(dir, "a.md")); string(bs) != "簡體" {
+				t.Errorf("a.md = %q", bs)
+			}
+		})
+	}
+}
+
+func TestRunFileWithResultFixture(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.md":     "U盘",
+		"b.md":     "繁體",
+		"c.txt":    "简体", // 被 Exts 过滤，不在结果中
+		"sub/d.md": "hello",
+	})
+	type res struct {
+		rel            string
+		changed, wrote bool
+		before, after  int64
+	}
+	for _, dryRun := range []bool{true, false} {
+		results, rs, err := RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2twp", DryRun: dryRun})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []res
+		for _, r := range results {
+			if r.Err != nil {
+				t.Errorf("%s: %v", r.Path, r.Err)
+			}
+			rel, _ := filepath.Rel(dir, r.Path)
+			got = append(got, res{filepath.ToSlash(rel), r.Changed, r.Written, r.BytesBefore, r.BytesAfter})
+		}
+		want := []res{
+			{"a.md", true, !dryRun, 4, 9},
+			{"b.md", false, false, 6, 6},
+			{"sub/d.md", false, false, 5, 5},
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("dry-run=%v results = %+v, want %+v", dryRun, got, want)
+		}
+		if rs.Scanned != 3 || rs.Changed != 1 || rs.BytesBefore != 15 || rs.BytesAfter != 20 {
+			t.Errorf("dry-run=%v stats = %+v", dryRun, rs)
+		}
+	}
+}
+
+func TestExtFilter(t *testing.T) {
+	files := []string{"a.md", "b.TXT", "c.png", "d.JPG", "noext"}
+	for _, tc := range []struct {
+		name             string
+		include, exclude []string
+		want             []string
+	}{
+		{"all", nil, nil, files},
+		{"all minus excludes", nil, []string{".png", "jpg"}, []string{"a.md", "b.TXT", "noext"}},
+		{"include only", []string{"md", ".txt"}, nil, []string{"a.md", "b.TXT"}},
+		{"include minus exclude", []string{".md", ".txt"}, []string{" .TXT "}, []string{"a.md"}},
+		{"exclude outside include", []string{".md"}, []string{".png"}, []string{"a.md"}},
+	} {
+		f := newExtFilter(tc.include, tc.exclude)
+		var got []string
+		for _, p := range files {
+			if f.allows(filepath.Join("dir", p)) {
+				got = append(got, p)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: allowed = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// fileDedupe 跨文件内容去重：内容完全相同（且转换配置、字符串模式相同）的文件只转换一次，其余副本复用结果。
+// 只按内容哈希记录首个文件的处理结果与写出的文件，不在内存中保留转换结果：副本需要转换结果时从首个文件的输出读回；
+// 首个文件未写出（dry-run、拼接、交互确认跳过或写入失败）时副本重新转换。
+// 同一内容被多个 worker 同时处理时后到者等待首个文件处理完成。方法对 nil 安全（nil 时直接转换）
+type fileDedupe struct {
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*dedupeEntry
+
+	files, calls int64
+}
+
+type dedupeEntry struct {
+	ready  chan struct{} // 首个文件处理完成后关闭
+	oc     ConvertOutcome
+	err    error
+	output string // 首个文件转换后写出的文件（原地写回时为其自身），未写出时为空
+}
+
+func newFileDedupe() *fileDedupe {
+	return &fileDedupe{entries: map[[sha256.Size]byte]*dedupeEntry{}}
+}
+
+// convert 返回 content 按 to / lang 转换的结果；相同内容的首个文件调用 fn。
+// 调用方处理完该文件后必须调用返回的 done，传入写出转换结果的文件（未写出时传空串）
+func (d *fileDedupe) convert(to, lang, content string, fn func() (string, ConvertOutcome, error)) (out string, oc ConvertOutcome, done func(output string), err error) {
+	if d == nil {
+		out, oc, err = fn()
+		return out, oc, func(string) {}, err
+	}
+	h := sha256.New()
+	h.Write([]byte(to + "\x00" + lang + "\x00"))
+	h.Write([]byte(content))
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+
+	d.mu.Lock()
+	e, ok := d.entries[key]
+	if !ok {
+		e = &dedupeEntry{ready: make(chan struct{})}
+		d.entries[key] = e
+	}
+	d.mu.Unlock()
+	atomic.AddInt64(&d.files, 1)
+
+	if !ok {
+		atomic.AddInt64(&d.calls, 1)
+		out, oc, err = fn()
+		e.oc, e.err = oc, err
+		var once sync.Once
+		return out, oc, func(output string) {
+			once.Do(func() {
+				if err == nil && oc == OutcomeConverted {
+					e.output = output
+				}
+				close(e.ready)
+			})
+		}, err
+	}
+
+	<-e.ready
+	switch {
+	case e.err != nil:
+		return "", e.oc, func(string) {}, e.err
+	case e.oc != OutcomeConverted:
+		return content, e.oc, func(string) {}, nil
+	}
+	if e.output != "" {
+		// 写出的内容已做 front-matter 改写与换行处理，二者对同一结果重复执行不改变内容
+		if bs, rerr := os.ReadFile(e.output); rerr == nil {
+			return string(bs), OutcomeConverted, func(string) {}, nil
+		}
+	}
+	atomic.AddInt64(&d.calls, 1)
+	out, oc, err = fn()
+	return out, oc, func(string) {}, err
+}
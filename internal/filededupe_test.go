@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestFileDedupeReadsBackOutput(t *testing.T) {
+	d := newFileDedupe()
+	calls := 0
+	fn := func() (string, ConvertOutcome, error) {
+		calls++
+		return "簡體", OutcomeConverted, nil
+	}
+
+	out, oc, done, err := d.convert("s2t", "", "简体", fn)
+	if err != nil || out != "簡體" || oc != OutcomeConverted {
+		t.Fatalf("first = %q, %v, %v", out, oc, err)
+	}
+	// 写出的文件已做换行等后处理：副本读回的是写出的内容
+	written := filepath.Join(t.TempDir(), "a.md")
+	if err := os.WriteFile(written, []byte("簡體\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	done(written)
+
+	out, oc, done, err = d.convert("s2t", "", "简体", fn)
+	done("")
+	if err != nil || out != "簡體\r\n" || oc != OutcomeConverted {
+		t.Fatalf("duplicate = %q, %v, %v", out, oc, err)
+	}
+	if calls != 1 || d.calls != 1 || d.files != 2 {
+		t.Errorf("calls = %d (counted %d), files = %d", calls, d.calls, d.files)
+	}
+	if len(d.entries) != 1 {
+		t.Errorf("entries = %d", len(d.entries))
+	}
+
+	// 转换配置不同的相同内容不共用结果
+	_, _, done, _ = d.convert("s2tw", "", "简体", fn)
+	done("")
+	if calls != 2 {
+		t.Errorf("different config should convert again, calls = %d", calls)
+	}
+}
+
+func TestFileDedupeReconvertsWhenNotWritten(t *testing.T) {
+	d := newFileDedupe()
+	calls := 0
+	fn := func() (string, ConvertOutcome, error) {
+		calls++
+		return "簡體", OutcomeConverted, nil
+	}
+	_, _, done, _ := d.convert("s2t", "", "简体", fn)
+	done("") // dry-run：未写出
+	out, _, done, _ := d.convert("s2t", "", "简体", fn)
+	done("")
+	if out != "簡體" || calls != 2 {
+		t.Errorf("out = %q, calls = %d", out, calls)
+	}
+
+	// 首个文件的输出已不存在（如被改名）时同样重新转换
+	_, _, done, _ = d.convert("s2t", "go", "简体", fn)
+	done(filepath.Join(t.TempDir(), "missing"))
+	out, _, done, _ = d.convert("s2t", "go", "简体", fn)
+	done("")
+	if out != "簡體" || calls != 4 {
+		t.Errorf("out = %q, calls = %d", out, calls)
+	}
+}
+
+func TestFileDedupeReusesSkipAndError(t *testing.T) {
+	d := newFileDedupe()
+	_, _, done, _ := d.convert("s2t", "", "繁體", func() (string, ConvertOutcome, error) { return "繁體", OutcomeUnchanged, nil })
+	done("")
+	out, oc, done, err := d.convert("s2t", "", "繁體", func() (string, ConvertOutcome, error) {
+		t.Fatal("unchanged content should not be converted again")
+		return "", 0, nil
+	})
+	done("")
+	if err != nil || out != "繁體" || oc != OutcomeUnchanged {
+		t.Errorf("duplicate = %q, %v, %v", out, oc, err)
+	}
+
+	boom := errors.New("boom")
+	_, _, done, _ = d.convert("s2t", "", "坏", func() (string, ConvertOutcome, error) { return "", OutcomeUnchanged, boom })
+	done("")
+	if _, _, done, err = d.convert("s2t", "", "坏", nil); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want boom", err)
+	}
+	done("")
+}
+
+func TestFileDedupeWaitsForFirst(t *testing.T) {
+	d := newFileDedupe()
+	written := filepath.Join(t.TempDir(), "a.md")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _, done, _ := d.convert("s2t", "", "简体", func() (string, ConvertOutcome, error) {
+			close(started)
+			return "簡體", OutcomeConverted, nil
+		})
+		<-release
+		if err := os.WriteFile(written, []byte("簡體"), 0644); err != nil {
+			t.Error(err)
+		}
+		done(written)
+	}()
+	<-started
+
+	got := make(chan string, 1)
+	go func() {
+		out, _, done, _ := d.convert("s2t", "", "简体", func() (string, ConvertOutcome, error) {
+			t.Error("duplicate should wait for the first file instead of converting")
+			return "", OutcomeConverted, nil
+		})
+		done("")
+		got <- out
+	}()
+	close(release)
+	wg.Wait()
+	if out := <-got; out != "簡體" {
+		t.Errorf("duplicate = %q", out)
+	}
+}
+
+func TestFileDedupeNil(t *testing.T) {
+	var d *fileDedupe
+	out, _, done, err := d.convert("s2t", "", "简体", func() (string, ConvertOutcome, error) { return "簡體", OutcomeConverted, nil })
+	done("")
+	if err != nil || out != "簡體" {
+		t.Errorf("nil dedupe = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// fileResumeHeader 续跑记录首行：转换参数指纹（同 cacheKey），不一致时此前的记录作废
+const fileResumeHeader = "# key="
+
+// fileResume 续跑记录：每行一个已处理完的文件（绝对路径），中断后重跑时跳过。
+// 这是 file 子命令对应 mysql 已完成表清单的机制；dry-run 只读取不写入。方法对 nil 安全
+type fileResume struct {
+	path     string
+	readOnly bool
+	mu       sync.Mutex
+	done     map[string]bool
+	f        *os.File
+	skipped  int64
+}
+
+// loadFileResume 读取续跑记录；文件不存在或参数指纹不一致时从空记录开始
+func loadFileResume(path, key string, readOnly bool) (*fileResume, error) {
+	r := &fileResume{path: path, readOnly: readOnly, done: map[string]bool{}}
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取续跑记录 %s: %w", path, err)
+	}
+	valid := false
+	if err == nil {
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64*1024), 1<<20)
+		for sc.Scan() {
+			line := sc.Text()
+			if h, ok := strings.CutPrefix(line, fileResumeHeader); ok {
+				valid = h == key
+				continue
+			}
+			if line != "" && valid {
+				r.done[line] = true
+			}
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("读取续跑记录 %s: %w", path, err)
+		}
+		if !valid {
+			log.Printf("[file] 转换参数与续跑记录 %s 不一致，此前的记录作废，全部重新处理", path)
+		}
+	}
+	if readOnly {
+		return r, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !valid {
+		flag |= os.O_TRUNC
+	}
+	if r.f, err = os.OpenFile(path, flag, 0644); err != nil {
+		return nil, fmt.Errorf("写续跑记录 %s: %w", path, err)
+	}
+	if !valid {
+		if _, err := fmt.Fprintln(r.f, fileResumeHeader+key); err != nil {
+			r.f.Close()
+			return nil, fmt.Errorf("写续跑记录 %s: %w", path, err)
+		}
+	}
+	return r, nil
+}
+
+// skip 文件已在记录中时计数并返回 true
+func (r *fileResume) skip(path string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	done := r.done[cacheAbs(path)]
+	r.mu.Unlock()
+	if done {
+		atomic.AddInt64(&r.skipped, 1)
+	}
+	return done
+}
+
+// markDone 追加一行（直接写入文件，进程中途退出时已完成的文件不会丢失）
+func (r *fileResume) markDone(path string) error {
+	if r == nil || r.readOnly {
+		return nil
+	}
+	abs := cacheAbs(path)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done[abs] {
+		return nil
+	}
+	if _, err := fmt.Fprintln(r.f, abs); err != nil {
+		return fmt.Errorf("写续跑记录 %s: %w", r.path, err)
+	}
+	r.done[abs] = true
+	return nil
+}
+
+// finish 关闭记录；complete 为 true（全部处理完且无失败）时删除记录，下次续跑从头开始
+func (r *fileResume) finish(complete bool) error {
+	if r == nil {
+		return nil
+	}
+	log.Printf("[file] 续跑：跳过 %d 个此前已处理完的文件", atomic.LoadInt64(&r.skipped))
+	if r.readOnly {
+		return nil
+	}
+	if err := r.f.Close(); err != nil {
+		return fmt.Errorf("写续跑记录 %s: %w", r.path, err)
+	}
+	if complete {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// interruptedRun 模拟一次中断的运行：bad.md 读取失败，其余文件处理完并写入续跑记录；
+// 随后把 a.md 还原为简体，若未被跳过就会再次被转换
+func interruptedRun(t *testing.T, dir, record string) {
+	t.Helper()
+	writeFiles(t, dir, map[string]string{"a.md": "简体", "b.md": "简体"})
+	bad := filepath.Join(dir, "bad.md")
+	if err := os.Symlink(filepath.Join(dir, "missing"), bad); err != nil {
+		t.Fatal(err)
+	}
+	_, _, _ = RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", ResumeFile: record})
+	bs, err := os.ReadFile(record)
+	if err != nil {
+		t.Fatalf("resume record should be kept after a failure: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(bs)), "\n")
+	slices.Sort(lines[1:])
+	if want := []string{fileResumeHeader + "to=s2t;normalize=;normalize_input=false", cacheAbs(filepath.Join(dir, "a.md")), cacheAbs(filepath.Join(dir, "b.md"))}; !slices.Equal(lines, want) {
+		t.Fatalf("record = %q, want %q", lines, want)
+	}
+	if err := os.Remove(bad); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{"a.md": "简体", "c.md": "简体"})
+}
+
+func processedNames(t *testing.T, results []FileResult) []string {
+	t.Helper()
+	var got []string
+	for _, r := range results {
+		got = append(got, filepath.Base(r.Path))
+	}
+	slices.Sort(got)
+	return got
+}
+
+func TestRunFileResume(t *testing.T) {
+	dir := t.TempDir()
+	record := filepath.Join(t.TempDir(), "state", "file-resume.txt")
+	interruptedRun(t, dir, record)
+
+	results, _, err := RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", ResumeFile: record})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := processedNames(t, results); !slices.Equal(got, []string{"c.md"}) {
+		t.Errorf("processed = %v, want only c.md", got)
+	}
+	if bs, _ := os.ReadFile(filepath.Join(dir, "a.md")); string(bs) != "简体" {
+		t.Errorf("a.md = %q, should be skipped", bs)
+	}
+	// 全部成功后删除记录
+	if _, err := os.Stat(record); !os.IsNotExist(err) {
+		t.Errorf("record should be removed after a complete run: %v", err)
+	}
+}
+
+// 转换参数变化时此前的记录作废，全部重新处理
+func TestRunFileResumeToChanged(t *testing.T) {
+	dir := t.TempDir()
+	record := filepath.Join(t.TempDir(), "file-resume.txt")
+	interruptedRun(t, dir, record)
+
+	results, _, err := RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2tw", ResumeFile: record})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := processedNames(t, results); !slices.Equal(got, []string{"a.md", "b.md", "c.md"}) {
+		t.Errorf("processed = %v, want all files", got)
+	}
+	if bs, _ := os.ReadFile(filepath.Join(dir, "a.md")); string(bs) != "簡體" {
+		t.Errorf("a.md = %q", bs)
+	}
+}
+
+// dry-run 只读取记录，不写入也不删除
+func TestRunFileResumeDryRun(t *testing.T) {
+	dir := t.TempDir()
+	record := filepath.Join(t.TempDir(), "file-resume.txt")
+	interruptedRun(t, dir, record)
+	before, _ := os.ReadFile(record)
+
+	results, _, err := RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", DryRun: true, ResumeFile: record})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := processedNames(t, results); !slices.Equal(got, []string{"c.md"}) {
+		t.Errorf("processed = %v, want only c.md", got)
+	}
+	if after, _ := os.ReadFile(record); string(after) != string(before) {
+		t.Errorf("dry-run changed the record:\n%s", after)
+	}
+}
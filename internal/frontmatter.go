@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// frontMatterRule --require-frontmatter：只转换 YAML front-matter 中 key 的取值等于 value 的文件（如 lang: zh-CN），
+// 没有 front-matter、没有该字段或取值不同的文件跳过；rewrite 非空时转换后把该字段改写为 rewrite（如 zh-TW）。方法对 nil 安全
+type frontMatterRule struct {
+	key, value, rewrite string
+	skipped             int64
+}
+
+// parseFrontMatterRule 解析 "字段=取值"；spec 为空时返回 nil
+func parseFrontMatterRule(spec, rewrite string) (*frontMatterRule, error) {
+	if strings.TrimSpace(spec) == "" {
+		if strings.TrimSpace(rewrite) != "" {
+			return nil, errors.New("RewriteFrontMatter 需配合 RequireFrontMatter 使用")
+		}
+		return nil, nil
+	}
+	key, value, ok := strings.Cut(spec, "=")
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if !ok || key == "" || value == "" {
+		return nil, fmt.Errorf("无效的 require-frontmatter %q（格式：字段=取值，如 lang=zh-CN）", spec)
+	}
+	return &frontMatterRule{key: key, value: value, rewrite: strings.TrimSpace(rewrite)}, nil
+}
+
+// frontMatterField 在 front-matter 中查找顶层字段 key：返回取值（去掉引号）及取值在 s 中的字节区间（含引号）。
+// front-matter 须位于文件开头（可有 UTF-8 BOM），以单独一行的 --- 开始，以 --- 或 ... 结束
+func frontMatterField(s, key string) (value string, start, end int, ok bool) {
+	pos := 0
+	if strings.HasPrefix(s, "\ufeff") {
+		pos = len("\ufeff")
+	}
+	line, next := cutLine(s, pos)
+	if strings.TrimRight(line, " \t\r") != "---" {
+		return "", 0, 0, false
+	}
+	for pos = next; pos < len(s); pos = next {
+		line, next = cutLine(s, pos)
+		trimmed := strings.TrimRight(line, " \t\r")
+		if trimmed == "---" || trimmed == "..." {
+			return "", 0, 0, false
+		}
+		k, rest, found := strings.Cut(line, ":")
+		if !found || k != key { // 只看顶层字段（行首无缩进）
+			continue
+		}
+		vs := pos + len(k) + 1
+		v := strings.TrimRight(rest, "\r")
+		if i := strings.Index(v, " #"); i >= 0 {
+			v = v[:i] // 行尾注释
+		}
+		lead := len(v) - len(strings.TrimLeft(v, " \t"))
+		v = strings.TrimSpace(v)
+		vs += lead
+		ve := vs + len(v)
+		if len(v) >= 2 && (v[0] == '"' && v[len(v)-1] == '"' || v[0] == '\'' && v[len(v)-1] == '\'') {
+			return v[1 : len(v)-1], vs, ve, true
+		}
+		return v, vs, ve, true
+	}
+	return "", 0, 0, false
+}
+
+// cutLine 返回 s[pos:] 的第一行（不含 \n）及下一行的起点
+func cutLine(s string, pos int) (string, int) {
+	if i := strings.IndexByte(s[pos:], '\n'); i >= 0 {
+		return s[pos : pos+i], pos + i + 1
+	}
+	return s[pos:], len(s)
+}
+
+// allows 文件内容的 front-matter 是否满足条件；不满足时计数
+func (r *frontMatterRule) allows(content string) bool {
+	if r == nil {
+		return true
+	}
+	v, _, _, ok := frontMatterField(content, r.key)
+	if ok && v == r.value {
+		return true
+	}
+	atomic.AddInt64(&r.skipped, 1)
+	return false
+}
+
+// apply 把转换结果中该字段的取值改写为 rewrite，保留原有的引号风格
+func (r *frontMatterRule) apply(out string) string {
+	if r == nil || r.rewrite == "" {
+		return out
+	}
+	_, start, end, ok := frontMatterField(out, r.key)
+	if !ok {
+		return out
+	}
+	v := r.rewrite
+	if end-start >= 2 && (out[start] == '"' || out[start] == '\'') {
+		v = out[start:start+1] + v + out[start:start+1]
+	}
+	return out[:start] + v + out[end:]
+}
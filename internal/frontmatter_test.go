@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRewriteFrontMatterWithoutBodyChange(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"simp.md":  "---\nlang: zh-CN\n---\n简体中文\n",
+		"trad.md":  "---\nlang: \"zh-CN\"\n---\n繁體中文\n",
+		"ascii.md": "---\nlang: zh-CN\n---\nhello\n",
+		"other.md": "---\nlang: en\n---\n简体\n",
+	})
+	_, err := RunFile(FileConfig{
+		RootDirs: []string{dir}, To: "s2t", Workers: 2,
+		RequireFrontMatter: "lang=zh-CN", RewriteFrontMatter: "zh-TW",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]string{
+		"simp.md":  "---\nlang: zh-TW\n---\n簡體中文\n",
+		"trad.md":  "---\nlang: \"zh-TW\"\n---\n繁體中文\n",
+		"ascii.md": "---\nlang: zh-TW\n---\nhello\n",
+		"other.md": "---\nlang: en\n---\n简体\n",
+	} {
+		bs, err := os.ReadFile(filepath.Join(dir, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bs) != want {
+			t.Errorf("%s = %q, want %q", rel, bs, want)
+		}
+	}
+}
+
+func TestFrontMatterRule(t *testing.T) {
+	r := &frontMatterRule{key: "lang", value: "zh-CN", rewrite: "zh-TW"}
+	for _, tc := range []struct {
+		in    string
+		allow bool
+		out   string
+	}{
+		{"---\nlang: zh-CN\n---\n正文", true, "---\nlang: zh-TW\n---\n正文"},
+		{"---\ntitle: x\nlang: 'zh-CN'\n---\n", true, "---\ntitle: x\nlang: 'zh-TW'\n---\n"},
+		{"---\nlang: zh-TW\n---\n", false, "---\nlang: zh-TW\n---\n"},
+		{"lang: zh-CN\n", false, "lang: zh-CN\n"}, // 不在 front-matter 中
+	} {
+		if got := r.allows(tc.in); got != tc.allow {
+			t.Errorf("allows(%q) = %v", tc.in, got)
+		}
+		if tc.allow {
+			if got := r.apply(tc.in); got != tc.out {
+				t.Errorf("apply(%q) = %q, want %q", tc.in, got, tc.out)
+			}
+		}
+	}
+}
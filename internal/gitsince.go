@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitChangedPaths 返回各目录内自 git 引用 ref 以来有改动的文件（含工作区未提交的修改，不含已删除与未跟踪的文件），
+// 以 NUL 分隔，路径前缀为所在目录，可直接作为 PathsFrom（PathsNUL=true）使用。目录不在 git 工作区内时返回错误
+func GitChangedPaths(dirs []string, ref string) ([]byte, error) {
+	if strings.TrimSpace(ref) == "" {
+		return nil, errors.New("git 引用不能为空")
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	var buf bytes.Buffer
+	for _, dir := range dirs {
+		if _, err := runGit(dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%s 不是 git 仓库（--git-since 需在 git 工作区内使用）：%w", dir, err)
+		}
+		// --relative：只列出 dir 之内的文件，路径相对于 dir
+		out, err := runGit(dir, "diff", "-z", "--name-only", "--relative", "--diff-filter=d", ref, "--")
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s 中自 %s 以来的改动失败：%w", dir, ref, err)
+		}
+		for _, p := range bytes.Split(out, []byte{0}) {
+			if len(p) == 0 {
+				continue
+			}
+			buf.WriteString(filepath.Join(dir, filepath.FromSlash(string(p))))
+			buf.WriteByte(0)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// runGit 在 dir 中执行 git 子命令并返回标准输出；失败时错误带上 git 的错误输出
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("未找到 git 命令：%w", err)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeGit 在 PATH 最前放一个假的 git：记录参数；目录名含 notrepo 时 rev-parse 失败，diff 固定输出三个改动文件
+func fakeGit(t *testing.T) (logFile string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake git is a shell script")
+	}
+	bin := t.TempDir()
+	logFile = filepath.Join(bin, "git.log")
+	script := `#!/bin/sh
+echo "$@" >> "` + logFile + `"
+case "$3" in
+rev-parse)
+	case "$2" in *notrepo*) echo "fatal: not a git repository" >&2; exit 128;; esac
+	echo true;;
+diff)
+	printf 'a.md\000sub/b.md\000c.txt\000';;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "git"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logFile
+}
+
+func TestGitChangedPaths(t *testing.T) {
+	logFile := fakeGit(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.md": "简体", "sub/b.md": "简体", "c.txt": "简体", "untouched.md": "简体"})
+
+	out, err := GitChangedPaths([]string{dir}, "HEAD~1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	want := []string{filepath.Join(dir, "a.md"), filepath.Join(dir, "sub", "b.md"), filepath.Join(dir, "c.txt")}
+	if !slices.Equal(got, want) {
+		t.Errorf("paths = %q, want %q", got, want)
+	}
+	bs, _ := os.ReadFile(logFile)
+	if !strings.Contains(string(bs), "-C "+dir+" diff -z --name-only --relative --diff-filter=d HEAD~1 --") {
+		t.Errorf("git invocations:\n%s", bs)
+	}
+
+	// 改动清单仍按 Exts 过滤，未改动的文件不处理
+	results, _, err := RunFileWithResult(FileConfig{Exts: []string{".md"}, To: "s2t", DryRun: true, PathsFrom: bytes.NewReader(out), PathsNUL: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var processed []string
+	for _, r := range results {
+		rel, _ := filepath.Rel(dir, r.Path)
+		processed = append(processed, filepath.ToSlash(rel))
+	}
+	slices.Sort(processed)
+	if want := []string{"a.md", "sub/b.md"}; !slices.Equal(processed, want) {
+		t.Errorf("processed = %v, want %v", processed, want)
+	}
+}
+
+func TestGitChangedPathsErrors(t *testing.T) {
+	fakeGit(t)
+	notRepo := filepath.Join(t.TempDir(), "notrepo")
+	if err := os.Mkdir(notRepo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	_, err := GitChangedPaths([]string{notRepo}, "main")
+	if err == nil || !strings.Contains(err.Error(), "不是 git 仓库") || !strings.Contains(err.Error(), "not a git repository") {
+		t.Errorf("not a repo: err = %v", err)
+	}
+	if _, err := GitChangedPaths([]string{t.TempDir()}, " "); err == nil {
+		t.Error("empty ref should fail")
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := GitChangedPaths([]string{t.TempDir()}, "main"); err == nil || !strings.Contains(err.Error(), "未找到 git 命令") {
+		t.Errorf("missing git: err = %v", err)
+	}
+}
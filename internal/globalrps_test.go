@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 两张表并行处理时共享 global_rps 令牌：每行各取一个令牌，合计取用数等于两表的总行数
+func TestGlobalRPSSharedAcrossTables(t *testing.T) {
+	tk := newRateTicker(1000)
+	defer tk.Stop()
+	tokens := make(chan time.Time)
+	done := make(chan struct{})
+	var sent int
+	var feeder sync.WaitGroup
+	feeder.Add(1)
+	go func() {
+		defer feeder.Done()
+		for {
+			select {
+			case ts := <-tk.C:
+				select {
+				case tokens <- ts:
+					sent++
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	const rows = 15
+	var wg sync.WaitGroup
+	for _, table := range []string{"a", "b"} {
+		db, mock := newMock(t)
+		rs := sqlmock.NewRows([]string{"id", "name"})
+		for i := 1; i <= rows; i++ {
+			rs.AddRow(fmt.Sprint(i), "hello")
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf("SELECT `id`,`name` FROM `%s` ORDER BY `id` LIMIT ?", table))).
+			WithArgs(100).WillReturnRows(rs)
+		mock.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf("SELECT `id`,`name` FROM `%s` WHERE (`id`) > (?) ORDER BY `id` LIMIT ?", table))).
+			WithArgs(fmt.Sprint(rows), 100).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+		cfg := MySQLConfig{
+			Table: table, PK: []string{"id"}, Columns: []string{"name"}, To: "s2t", BatchSize: 100,
+			QueryRetryMax: 1, QueryRetryDelay: time.Millisecond,
+			counts: &tableMetrics{}, globalRate: tokens,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := processWithPK(context.Background(), db, cfg, nil, nil, 0); err != nil {
+				t.Error(err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	feeder.Wait()
+	if sent != 2*rows {
+		t.Errorf("tokens consumed = %d, want %d", sent, 2*rows)
+	}
+}
+
+func TestThrottleWaitsForBothLimits(t *testing.T) {
+	rate, global := make(chan time.Time, 1), make(chan time.Time, 1)
+	rate <- time.Now()
+	c := MySQLConfig{globalRate: global}
+	got := make(chan struct{})
+	go func() {
+		c.throttle(rate)
+		close(got)
+	}()
+	select {
+	case <-got:
+		t.Fatal("throttle returned without a global_rps token")
+	case <-time.After(20 * time.Millisecond):
+	}
+	global <- time.Now()
+	<-got
+	// 均未配置时立即返回
+	MySQLConfig{}.throttle(nil)
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// heavyIndex 目标列上维护代价高的索引（FULLTEXT / SPATIAL）：每次 UPDATE 都要重建该行的索引项，大批量更新明显变慢
+type heavyIndex struct {
+	Name    string
+	Type    string // FULLTEXT | SPATIAL
+	Columns []string
+}
+
+func (h heavyIndex) String() string {
+	return fmt.Sprintf("%s 索引 %s(%s)", h.Type, h.Name, strings.Join(h.Columns, ","))
+}
+
+// heavyIndexes 列出表上包含 columns 中任一列的 FULLTEXT / SPATIAL 索引（按索引名）
+func heavyIndexes(ctx context.Context, db *sql.DB, table string, columns []string) ([]heavyIndex, error) {
+	rows, err := db.QueryContext(ctx, `SELECT INDEX_NAME, INDEX_TYPE, COLUMN_NAME FROM information_schema.statistics
+	      WHERE table_schema = DATABASE() AND table_name = ? AND INDEX_TYPE IN ('FULLTEXT', 'SPATIAL')
+	      ORDER BY INDEX_NAME, SEQ_IN_INDEX`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var all []heavyIndex
+	for rows.Next() {
+		var name, typ string
+		var col sql.NullString
+		if err := rows.Scan(&name, &typ, &col); err != nil {
+			return nil, err
+		}
+		if len(all) == 0 || all[len(all)-1].Name != name {
+			all = append(all, heavyIndex{Name: name, Type: strings.ToUpper(typ)})
+		}
+		all[len(all)-1].Columns = append(all[len(all)-1].Columns, col.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	var out []heavyIndex
+	for _, h := range all {
+		for _, c := range h.Columns {
+			if indexOfFold(columns, c) >= 0 {
+				out = append(out, h)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// checkHeavyIndexes 目标列带 FULLTEXT / SPATIAL 索引时告警并给出建议（工具不会 ALTER）；
+// 配置了 HeavyIndexRPS 时把该表的 RPS 收紧到不超过该值
+func (c *MySQLConfig) checkHeavyIndexes(ctx context.Context, db *sql.DB) {
+	idx, err := heavyIndexes(ctx, db, c.Table, c.Columns)
+	if err != nil {
+		log.Printf("[mysql] 读取索引信息失败 table=%s（跳过 FULLTEXT/SPATIAL 检查）：%v", c.Table, err)
+		return
+	}
+	if len(idx) == 0 {
+		return
+	}
+	for _, h := range idx {
+		log.Printf("[mysql] 警告：table=%s 目标列上有 %s：每次 UPDATE 都要维护该索引，大批量更新会明显变慢", c.Table, h)
+	}
+	log.Printf("[mysql] 建议：table=%s 在维护窗口执行；数据量大时可先 DROP INDEX、转换完成后再重建（工具不会自动 ALTER）", c.Table)
+	if n := c.HeavyIndexRPS; n > 0 && (c.RPS == 0 || c.RPS > n) {
+		log.Printf("[mysql] table=%s 按 heavy_index_rps 限速为每秒 %d 行（原 rps=%d）", c.Table, n, c.RPS)
+		c.RPS = n
+	}
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCheckHeavyIndexes(t *testing.T) {
+	db, mock := newMock(t)
+	mock.ExpectQuery("FROM information_schema.statistics").WithArgs("posts").
+		WillReturnRows(sqlmock.NewRows([]string{"INDEX_NAME", "INDEX_TYPE", "COLUMN_NAME"}).
+			AddRow("ft_body", "FULLTEXT", "body").
+			AddRow("ft_body", "FULLTEXT", "title").
+			AddRow("sp_geo", "SPATIAL", "geo"))
+
+	c := MySQLConfig{Table: "posts", Columns: []string{"Body"}, RPS: 500, HeavyIndexRPS: 50}
+	out := captureLog(t, func() { c.checkHeavyIndexes(context.Background(), db) })
+	if !strings.Contains(out, "警告：table=posts 目标列上有 FULLTEXT 索引 ft_body(body,title)") {
+		t.Errorf("missing FULLTEXT warning:\n%s", out)
+	}
+	// 不含目标列的索引不告警
+	if strings.Contains(out, "sp_geo") {
+		t.Errorf("index on other columns reported:\n%s", out)
+	}
+	if c.RPS != 50 {
+		t.Errorf("RPS = %d, want heavy_index_rps 50", c.RPS)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckHeavyIndexesNone(t *testing.T) {
+	db, mock := newMock(t)
+	mock.ExpectQuery("FROM information_schema.statistics").WithArgs("posts").
+		WillReturnRows(sqlmock.NewRows([]string{"INDEX_NAME", "INDEX_TYPE", "COLUMN_NAME"}).AddRow("sp_geo", "SPATIAL", "geo"))
+
+	c := MySQLConfig{Table: "posts", Columns: []string{"body"}, HeavyIndexRPS: 50}
+	if out := captureLog(t, func() { c.checkHeavyIndexes(context.Background(), db) }); out != "" {
+		t.Errorf("unexpected log:\n%s", out)
+	}
+	if c.RPS != 0 {
+		t.Errorf("RPS = %d, want unchanged", c.RPS)
+	}
+}
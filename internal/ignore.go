@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// IgnoreFileName file 子命令的忽略文件名：根目录及其子目录中都可以放置，语法同 .gitignore，与 git 无关
+const IgnoreFileName = ".tradifyignore"
+
+// ignoreRule .tradifyignore 中的一条规则
+type ignoreRule struct {
+	segs     []string // 按 / 切分的模式，** 匹配任意层目录
+	negate   bool     // ! 开头：重新纳入此前被忽略的路径
+	dirOnly  bool     // / 结尾：只匹配目录
+	anchored bool     // 含 /（末尾除外）：相对忽略文件所在目录匹配；否则匹配任意层级的名称
+}
+
+// parseIgnoreRule 解析一行；空行与 # 注释返回 ok=false。\# 与 \! 表示字面量的 # / !
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	var r ignoreRule
+	line = strings.TrimRight(line, " \t\r")
+	switch {
+	case line == "", strings.HasPrefix(line, "#"):
+		return r, false
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\#`), strings.HasPrefix(line, `\!`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimLeft(line, "/")
+	}
+	if line == "" {
+		return r, false
+	}
+	r.segs = strings.Split(line, "/")
+	return r, true
+}
+
+// match rel 为相对忽略文件所在目录的路径（/ 分隔）
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.segs[0], path.Base(rel))
+		return ok
+	}
+	return matchIgnoreSegs(r.segs, strings.Split(rel, "/"))
+}
+
+// matchIgnoreSegs 逐段匹配，** 匹配零或多段；末尾的 ** 至少匹配一段（a/** 匹配 a 之内的一切，不含 a 本身）
+func matchIgnoreSegs(pat, parts []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			if len(pat) == 1 {
+				return len(parts) > 0
+			}
+			for i := len(parts); i >= 0; i-- {
+				if matchIgnoreSegs(pat[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], parts[0]); !ok {
+			return false
+		}
+		pat, parts = pat[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// loadIgnoreRules 读取目录中的忽略文件；不存在时返回 nil
+func loadIgnoreRules(dir string) ([]ignoreRule, error) {
+	p := filepath.Join(dir, IgnoreFileName)
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取忽略文件 %s: %w", p, err)
+	}
+	defer f.Close()
+	var rules []ignoreRule
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if r, ok := parseIgnoreRule(sc.Text()); ok {
+			rules = append(rules, r)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("读取忽略文件 %s: %w", p, err)
+	}
+	return rules, nil
+}
+
+// pathIgnorer 按各级目录的 .tradifyignore 判断路径是否被忽略：自根目录向下依次应用各级规则，最后匹配的规则生效
+// （与 .gitignore 相同，子目录的规则可用 ! 重新纳入上级忽略的文件；目录被忽略后其中的文件无法再纳入）。
+// 忽略文件与目录结论均缓存，并发安全；方法对 nil 安全（nil 表示不使用忽略文件）
+type pathIgnorer struct {
+	mu      sync.Mutex
+	rules   map[string][]ignoreRule // 目录绝对路径 -> 规则
+	dirs    map[string]bool         // 目录绝对路径 -> 是否被忽略
+	skipped int64
+}
+
+func newPathIgnorer() *pathIgnorer {
+	return &pathIgnorer{rules: map[string][]ignoreRule{}, dirs: map[string]bool{}}
+}
+
+// ignored 判断 root 下的 path 是否被忽略；path 不在 root 之内时只看其所在目录的忽略文件
+func (ig *pathIgnorer) ignored(root, p string, isDir bool) (bool, error) {
+	if ig == nil {
+		return false, nil
+	}
+	root, abs := cacheAbs(root), cacheAbs(p)
+	if abs == root {
+		return false, nil
+	}
+	if !isWithin(root, abs) {
+		root = filepath.Dir(abs)
+	}
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	skip, err := ig.ignoredLocked(root, abs, isDir)
+	if skip && !isDir {
+		atomic.AddInt64(&ig.skipped, 1)
+	}
+	return skip, err
+}
+
+func (ig *pathIgnorer) ignoredLocked(root, abs string, isDir bool) (bool, error) {
+	if isDir {
+		if v, ok := ig.dirs[abs]; ok {
+			return v, nil
+		}
+	}
+	parent := filepath.Dir(abs)
+	if parent != root {
+		skip, err := ig.ignoredLocked(root, parent, true)
+		if err != nil || skip {
+			return skip, err
+		}
+	}
+	// 自根目录向下的各级目录：root、root/a、...、parent
+	var chain []string
+	for d := parent; ; d = filepath.Dir(d) {
+		chain = append(chain, d)
+		if d == root || d == filepath.Dir(d) {
+			break
+		}
+	}
+	skip := false
+	for i := len(chain) - 1; i >= 0; i-- {
+		d := chain[i]
+		rules, ok := ig.rules[d]
+		if !ok {
+			var err error
+			if rules, err = loadIgnoreRules(d); err != nil {
+				return false, err
+			}
+			ig.rules[d] = rules
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		rel, err := filepath.Rel(d, abs)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, r := range rules {
+			if r.match(rel, isDir) {
+				skip = !r.negate
+			}
+		}
+	}
+	if isDir {
+		ig.dirs[abs] = skip
+	}
+	return skip, nil
+}
+
+func (ig *pathIgnorer) skippedFiles() int64 {
+	if ig == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&ig.skipped)
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIgnoreRuleMatch(t *testing.T) {
+	for _, tc := range []struct {
+		rule, rel string
+		isDir     bool
+		want      bool
+	}{
+		{"*.min.js", "a.min.js", false, true},
+		{"*.min.js", "lib/x/a.min.js", false, true},
+		{"vendor/", "vendor", true, true},
+		{"vendor/", "vendor", false, false},
+		{"/docs/legacy/**", "docs/legacy/a/b.md", false, true},
+		{"/docs/legacy/**", "docs/legacy", true, false},
+		{"docs/**/draft.md", "docs/draft.md", false, true},
+		{"docs/**/draft.md", "docs/a/b/draft.md", false, true},
+		{"docs/*.md", "docs/a/b.md", false, false},
+		{`\#notes.md`, "#notes.md", false, true},
+	} {
+		r, ok := parseIgnoreRule(tc.rule)
+		if !ok {
+			t.Fatalf("parseIgnoreRule(%q) failed", tc.rule)
+		}
+		if got := r.match(tc.rel, tc.isDir); got != tc.want {
+			t.Errorf("%q.match(%q, dir=%v) = %v, want %v", tc.rule, tc.rel, tc.isDir, got, tc.want)
+		}
+	}
+	for _, line := range []string{"", "  ", "# comment", "/"} {
+		if _, ok := parseIgnoreRule(line); ok {
+			t.Errorf("parseIgnoreRule(%q) should be skipped", line)
+		}
+	}
+	if r, _ := parseIgnoreRule("!keep.md"); !r.negate {
+		t.Error("! should negate")
+	}
+}
+
+func TestRunFileTradifyIgnore(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".tradifyignore":           "*.gen.md\nvendor/\n/docs/legacy/**\n!docs/legacy/README.md\n",
+		"a.md":                     "简体",
+		"a.gen.md":                 "简体",
+		"vendor/v.md":              "简体",
+		"docs/legacy/old.md":       "简体",
+		"docs/legacy/README.md":    "简体",
+		"docs/sub/.tradifyignore":  "draft.md\n!keep.gen.md\n",
+		"docs/sub/draft.md":        "简体",
+		"docs/sub/keep.gen.md":     "简体",
+		"docs/sub/other.gen.md":    "简体",
+		"docs/sub/nested/draft.md": "简体",
+	})
+	run := func(noIgnore bool) []string {
+		t.Helper()
+		results, _, err := RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", DryRun: true, NoIgnoreFile: noIgnore})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range results {
+			rel, _ := filepath.Rel(dir, r.Path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		slices.Sort(got)
+		return got
+	}
+	// 子目录的忽略文件可用 ! 重新纳入上级忽略的文件，其规则对更深层目录同样生效
+	want := []string{"a.md", "docs/legacy/README.md", "docs/sub/keep.gen.md"}
+	if got := run(false); !slices.Equal(got, want) {
+		t.Errorf("processed = %v, want %v", got, want)
+	}
+	if got := run(true); len(got) != 9 {
+		t.Errorf("--no-ignore-file processed = %v", got)
+	}
+}
+
+func TestPathIgnorerNil(t *testing.T) {
+	var ig *pathIgnorer
+	if skip, err := ig.ignored("/tmp", "/tmp/a.md", false); skip || err != nil {
+		t.Errorf("nil ignorer = %v, %v", skip, err)
+	}
+	if ig.skippedFiles() != 0 {
+		t.Error("nil ignorer skipped files")
+	}
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resolveSince 解析 since：Go duration（如 24h）表示“距今多久之前”（按本机时钟），其它取值原样作为比较值
+func resolveSince(since string, now time.Time) string {
+	since = strings.TrimSpace(since)
+	if d, err := time.ParseDuration(since); err == nil {
+		return now.Add(-d).Format("2006-01-02 15:04:05")
+	}
+	return since
+}
+
+// incrementalCond 返回增量谓词（无增量配置时为空）
+func (c MySQLConfig) incrementalCond(since string) (string, []interface{}) {
+	if c.IncrementalColumn == "" || since == "" {
+		return "", nil
+	}
+	return quoteIdent(c.IncrementalColumn) + " > ?", []interface{}{since}
+}
+
+// watermarkValue 规范化增量列取值（NULL 返回空串）
+func watermarkValue(ns sql.NullString) string {
+	if !ns.Valid {
+		return ""
+	}
+	return normalizeTimeString(ns.String)
+}
+
+// normalizeTimeString parseTime=true 时驱动返回 RFC3339，转回 MySQL 可比较的格式；其它取值原样返回
+func normalizeTimeString(s string) string {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.Format("2006-01-02 15:04:05.999999")
+	}
+	return s
+}
+
+// watermarkLess 比较两个水位值：均为整数时按数值，否则按字符串（规范化后的时间可直接比较）
+func watermarkLess(a, b string) bool {
+	ai, errA := strconv.ParseInt(a, 10, 64)
+	bi, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return ai < bi
+	}
+	return a < b
+}
+
+// advanceWatermark 返回 mark 与本行增量列取值中较大者（NULL 不推进）
+func advanceWatermark(mark string, ns sql.NullString) string {
+	if v := watermarkValue(ns); v != "" && (mark == "" || watermarkLess(mark, v)) {
+		return v
+	}
+	return mark
+}
+
+// readWatermark 读取上次运行保存的水位；文件不存在返回空串
+func readWatermark(path string) (string, error) {
+	bs, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", classify(ErrIO, fmt.Errorf("读取水位文件 %s: %w", path, err))
+	}
+	return strings.TrimSpace(string(bs)), nil
+}
+
+// writeWatermark 原子写入水位（先写临时文件再 rename）
+func writeWatermark(path, value string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return classify(ErrIO, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(value+"\n"), 0644); err != nil {
+		return classify(ErrIO, fmt.Errorf("写水位文件 %s: %w", tmp, err))
+	}
+	return classify(ErrIO, os.Rename(tmp, path))
+}
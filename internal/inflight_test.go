@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gaugeConn 只支持 Exec 的驱动连接：记录同时执行中的语句数及其峰值
+type gaugeConn struct{ g *gauge }
+
+type gauge struct {
+	mu        sync.Mutex
+	cur, peak int
+}
+
+func (c gaugeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.g.mu.Lock()
+	c.g.cur++
+	c.g.peak = max(c.g.peak, c.g.cur)
+	c.g.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	c.g.mu.Lock()
+	c.g.cur--
+	c.g.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func (gaugeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (gaugeConn) Close() error                        { return nil }
+func (gaugeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+type gaugeConnector struct{ g *gauge }
+
+func (c gaugeConnector) Connect(context.Context) (driver.Conn, error) { return gaugeConn(c), nil }
+func (c gaugeConnector) Driver() driver.Driver                        { return gaugeDriver(c) }
+
+type gaugeDriver struct{ g *gauge }
+
+func (d gaugeDriver) Open(string) (driver.Conn, error) { return gaugeConn(d), nil }
+
+// 多表共享 global_max_inflight：所有表合计同时执行中的 UPDATE 不超过上限
+func TestGlobalMaxInflightAcrossTables(t *testing.T) {
+	const limit = 3
+	g := &gauge{}
+	inflight := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, table := range []string{"a", "b"} {
+		db := sql.OpenDB(gaugeConnector{g})
+		defer db.Close()
+		cfg := MySQLConfig{Table: table, inflight: inflight}
+		for range 8 { // 每表 8 个 worker，合计远超上限
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 5 {
+					if _, err := cfg.execUpdate(db, "UPDATE t SET c = ? WHERE id = ?", "x", 1); err != nil {
+						t.Error(err)
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if g.peak > limit {
+		t.Errorf("peak inflight UPDATEs = %d, want <= %d", g.peak, limit)
+	}
+	if g.peak < 2 {
+		t.Errorf("peak inflight UPDATEs = %d, updates did not run concurrently", g.peak)
+	}
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// 含无效 UTF-8 字节序列的文档的处理方式（FileConfig.InvalidUTF8 / --invalid-utf8）
+const (
+	InvalidUTF8Error   = "error"   // 默认：报错并跳过该文件，计入失败
+	InvalidUTF8Lenient = "lenient" // 只转换其中有效的 UTF-8 片段，无效字节原样保留
+	InvalidUTF8Replace = "replace" // 无效字节替换为 U+FFFD 后再转换（仅在发生转换而写出时生效）
+)
+
+// binarySniffLen 判定二进制文件时检查的前缀长度（与 git 相同）
+const binarySniffLen = 8000
+
+// ParseInvalidUTF8 解析 --invalid-utf8 取值；空串为默认的 error
+func ParseInvalidUTF8(s string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(s)); p {
+	case "":
+		return InvalidUTF8Error, nil
+	case InvalidUTF8Error, InvalidUTF8Lenient, InvalidUTF8Replace:
+		return p, nil
+	}
+	return "", fmt.Errorf("不支持的 invalid-utf8 取值：%q（可选 error、lenient、replace）", s)
+}
+
+// isBinary 前 binarySniffLen 字节中含 NUL 的视为二进制文件（图片、压缩包、UTF-16 文本等），不做转换
+func isBinary(bs []byte) bool {
+	return bytes.IndexByte(bs[:min(len(bs), binarySniffLen)], 0) >= 0
+}
+
+// validUTF8Spans s 中各段有效 UTF-8 的字节区间（按起点升序、互不重叠），无效字节不在任何区间内
+func validUTF8Spans(s string) [][2]int {
+	var spans [][2]int
+	start := 0
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 {
+			if start < i {
+				spans = append(spans, [2]int{start, i})
+			}
+			i++
+			start = i
+			continue
+		}
+		i += n
+	}
+	if start < len(s) {
+		spans = append(spans, [2]int{start, len(s)})
+	}
+	return spans
+}
+
+// invalidUTF8Error policy 为 error 时的错误：指出首个无效字节的位置
+func invalidUTF8Error(path, s string) error {
+	off := 0
+	for _, sp := range validUTF8Spans(s) {
+		if sp[0] != off {
+			break
+		}
+		off = sp[1]
+	}
+	return fmt.Errorf("含无效的 UTF-8 字节序列（首个位于字节偏移 %d），已跳过 %s；可用 --invalid-utf8 lenient（保留无效字节，只转换其余部分）或 replace（替换为 U+FFFD）处理", off, path)
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseInvalidUTF8(t *testing.T) {
+	for in, want := range map[string]string{"": "error", " Lenient ": "lenient", "REPLACE": "replace", "error": "error"} {
+		if got, err := ParseInvalidUTF8(in); err != nil || got != want {
+			t.Errorf("ParseInvalidUTF8(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseInvalidUTF8("skip"); err == nil {
+		t.Error("ParseInvalidUTF8(skip) should fail")
+	}
+}
+
+func TestValidUTF8Spans(t *testing.T) {
+	s := "\xff简体\xfe\xfdok\xc3"
+	var got []string
+	for _, sp := range validUTF8Spans(s) {
+		got = append(got, s[sp[0]:sp[1]])
+	}
+	if strings.Join(got, "|") != "简体|ok" {
+		t.Errorf("spans = %q", got)
+	}
+	if err := invalidUTF8Error("a.txt", "简体\xff"); !strings.Contains(err.Error(), "字节偏移 6") {
+		t.Errorf("invalidUTF8Error = %v", err)
+	}
+}
+
+func TestRunFileInvalidUTF8(t *testing.T) {
+	const content = "简体\xff繁體\xfe软件"
+	for _, tc := range []struct {
+		policy, want string
+		failed       bool
+	}{
+		{"", content, true}, // 默认报错跳过，文件保持原样
+		{InvalidUTF8Lenient, "簡體\xff繁體\xfe軟件", false},
+		{InvalidUTF8Replace, "簡體�繁體�軟件", false},
+	} {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"bad.txt": content, "ok.txt": "简体", "bin.txt": "\x00简体\xff"})
+		stats := &Stats{}
+		results, _, err := RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".txt"}, To: "s2t", InvalidUTF8: tc.policy, Stats: stats})
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, _ := os.ReadFile(filepath.Join(dir, "bad.txt"))
+		if string(bs) != tc.want {
+			t.Errorf("%q: bad.txt = %q, want %q", tc.policy, bs, tc.want)
+		}
+		for _, r := range results {
+			if filepath.Base(r.Path) == "bad.txt" && (r.Err != nil) != tc.failed {
+				t.Errorf("%q: err = %v, want failed=%v", tc.policy, r.Err, tc.failed)
+			}
+		}
+		// 二进制文件（含 NUL）不转换也不计入无效 UTF-8
+		if bs, _ := os.ReadFile(filepath.Join(dir, "bin.txt")); string(bs) != "\x00简体\xff" {
+			t.Errorf("%q: bin.txt = %q", tc.policy, bs)
+		}
+		if bs, _ := os.ReadFile(filepath.Join(dir, "ok.txt")); string(bs) != "簡體" {
+			t.Errorf("%q: ok.txt = %q", tc.policy, bs)
+		}
+		if n := stats.Snapshot().InvalidUTF8; n != 1 {
+			t.Errorf("%q: invalid_utf8 = %d, want 1", tc.policy, n)
+		}
+	}
+}
+
+func TestRunFileInvalidUTF8RejectsUnknownPolicy(t *testing.T) {
+	_, err := RunFile(FileConfig{RootDirs: []string{t.TempDir()}, To: "s2t", InvalidUTF8: "skip"})
+	if !errors.Is(err, ErrConfigInvalid) {
+		t.Errorf("err = %v, want ErrConfigInvalid", err)
+	}
+}
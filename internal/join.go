@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// joinClauseRe join 须以内联接开头；RIGHT/CROSS JOIN 与逗号联接会引入与目标表无关的行，不支持
+var joinClauseRe = regexp.MustCompile(`(?i)^\s*(((inner|left(\s+outer)?)\s+)?join|straight_join)\s`)
+
+// checkJoin 校验联接条目：需提供 pk（UPDATE 仍按目标表主键执行），不可与 select_sql / incremental_column 同用
+func checkJoin(t MySQLTblEntry) error {
+	if t.Join == "" {
+		if t.KeyExpr != "" {
+			return errors.New("key_expr 需配合 join 使用")
+		}
+		return nil
+	}
+	switch {
+	case len(t.PK) == 0:
+		return errors.New("使用 join 时必须提供 pk（UPDATE 按目标表主键执行）")
+	case t.SelectSQL != "":
+		return errors.New("join 与 select_sql 只能二选一")
+	case t.IncrementalColumn != "":
+		return errors.New("join 与 incremental_column 不可同时使用（请把增量条件写进 key_expr）")
+	case !joinClauseRe.MatchString(t.Join):
+		return errors.New("join 须以 JOIN / INNER JOIN / LEFT JOIN / STRAIGHT_JOIN 开头（不支持 RIGHT/CROSS JOIN 与逗号联接）")
+	case strings.Contains(t.Join, ";") || strings.Contains(t.KeyExpr, ";"):
+		return errors.New("join / key_expr 不能包含分号")
+	}
+	return nil
+}
+
+// joinSelectSQL 由 join / key_expr 生成行来源：只从目标表投影 pk + columns，联接放在 IN 子查询中，
+// 一对多联接不会产生重复行，投影也不会与联接表的同名列混淆：
+//
+//	SELECT `id`, `title` FROM `articles` WHERE (`id`) IN (SELECT `articles`.`id` FROM `articles` <join> WHERE <key_expr>)
+func joinSelectSQL(t MySQLTblEntry) string {
+	tbl := quoteIdent(t.Table)
+	outer := make([]string, 0, len(t.PK)+len(t.Columns))
+	for _, c := range append(append([]string{}, t.PK...), t.Columns...) {
+		outer = append(outer, quoteIdent(c))
+	}
+	keys := make([]string, len(t.PK))
+	inner := make([]string, len(t.PK))
+	for i, pk := range t.PK {
+		keys[i] = quoteIdent(pk)
+		inner[i] = tbl + "." + quoteIdent(pk)
+	}
+	sub := "SELECT " + strings.Join(inner, ", ") + " FROM " + tbl + " " + strings.TrimSpace(t.Join)
+	if e := strings.TrimSpace(t.KeyExpr); e != "" {
+		sub += " WHERE " + e
+	}
+	return "SELECT " + strings.Join(outer, ", ") + " FROM " + tbl + " WHERE (" + strings.Join(keys, ", ") + ") IN (" + sub + ")"
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestJoinSelectSQL(t *testing.T) {
+	got := joinSelectSQL(MySQLTblEntry{
+		Table: "articles", PK: []string{"site", "id"}, Columns: []string{"title", "body"},
+		Join:    " JOIN `authors` ON `authors`.`id` = `articles`.`author_id` ",
+		KeyExpr: "`authors`.`region` = 'cn'",
+	})
+	want := "SELECT `site`, `id`, `title`, `body` FROM `articles` WHERE (`site`, `id`) IN (" +
+		"SELECT `articles`.`site`, `articles`.`id` FROM `articles` JOIN `authors` ON `authors`.`id` = `articles`.`author_id` WHERE `authors`.`region` = 'cn')"
+	if got != want {
+		t.Errorf("joinSelectSQL =\n%s\nwant\n%s", got, want)
+	}
+	// 无 key_expr 时子查询不带 WHERE
+	got = joinSelectSQL(MySQLTblEntry{Table: "a", PK: []string{"id"}, Columns: []string{"c"}, Join: "LEFT JOIN b ON b.a_id = a.id"})
+	if want := "SELECT `id`, `c` FROM `a` WHERE (`id`) IN (SELECT `a`.`id` FROM `a` LEFT JOIN b ON b.a_id = a.id)"; got != want {
+		t.Errorf("joinSelectSQL without key_expr = %s", got)
+	}
+}
+
+func TestCheckJoin(t *testing.T) {
+	ok := MySQLTblEntry{Table: "a", PK: []string{"id"}, Columns: []string{"c"}, Join: "inner join b on b.id = a.id", KeyExpr: "b.x = 1"}
+	if err := checkJoin(ok); err != nil {
+		t.Errorf("valid join: %v", err)
+	}
+	for name, mut := range map[string]func(*MySQLTblEntry){
+		"key_expr without join": func(e *MySQLTblEntry) { e.Join = "" },
+		"no pk":                 func(e *MySQLTblEntry) { e.PK = nil },
+		"with select_sql":       func(e *MySQLTblEntry) { e.SelectSQL = "SELECT 1" },
+		"with incremental":      func(e *MySQLTblEntry) { e.IncrementalColumn = "updated_at" },
+		"right join":            func(e *MySQLTblEntry) { e.Join = "RIGHT JOIN b ON b.id = a.id" },
+		"comma join":            func(e *MySQLTblEntry) { e.Join = ", b" },
+		"semicolon":             func(e *MySQLTblEntry) { e.KeyExpr = "1; DROP TABLE a" },
+	} {
+		e := ok
+		mut(&e)
+		if err := checkJoin(e); err == nil {
+			t.Errorf("%s: should fail", name)
+		}
+	}
+}
+
+// 联接条目按生成的 SELECT 读取，UPDATE 仍按目标表主键执行
+func TestProcessWithPKJoin(t *testing.T) {
+	db, mock := newMock(t)
+	entry := MySQLTblEntry{Table: "articles", PK: []string{"id"}, Columns: []string{"title"},
+		Join: "JOIN `authors` ON `authors`.`id` = `articles`.`author_id`", KeyExpr: "`authors`.`region` = 'cn'"}
+	src := "(" + joinSelectSQL(entry) + ") AS `_tradify_src`"
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`title` FROM " + src + " ORDER BY `id` LIMIT ?")).WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("7", "简体标题"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `articles` SET `title` = ? WHERE `id` = ?")).WithArgs("簡體標題", "7").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`title` FROM "+src+" WHERE (`id`) > (?) ORDER BY `id` LIMIT ?")).WithArgs("7", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}))
+	cfg := MySQLConfig{
+		Table: entry.Table, PK: entry.PK, Columns: entry.Columns, SelectSQL: joinSelectSQL(entry), To: "s2t", BatchSize: 10,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{},
+	}
+	if _, err := processWithPK(context.Background(), db, cfg, nil, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
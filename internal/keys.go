@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeyTuple 一行的主键取值（与 pk 列一一对应）。JSON 中可写成数组 [1, "a"]，单列主键也可直接写标量 42
+type KeyTuple []string
+
+func (k *KeyTuple) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		items = []interface{}{v}
+	}
+	out := make(KeyTuple, 0, len(items))
+	for _, it := range items {
+		switch x := it.(type) {
+		case string:
+			out = append(out, x)
+		case json.Number:
+			out = append(out, x.String())
+		case bool:
+			out = append(out, fmt.Sprint(x))
+		default:
+			return fmt.Errorf("keys 中的主键取值只能是字符串或数字：%s", string(b))
+		}
+	}
+	*k = out
+	return nil
+}
+
+// LoadKeysFile 读取主键清单：每行一个主键元组，复合主键各列以逗号分隔（CSV 规则，含逗号的值用双引号包裹）；
+// 空行与 # 开头的注释行忽略
+func LoadKeysFile(path string) ([]KeyTuple, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取主键清单 %s: %w", path, err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1 // 列数由 checkKeys 按 pk 校验
+	r.Comment = '#'
+	var keys []KeyTuple
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析主键清单 %s: %w", path, err)
+		}
+		for i := range rec {
+			rec[i] = strings.TrimSpace(rec[i])
+		}
+		keys = append(keys, KeyTuple(rec))
+	}
+	return keys, nil
+}
+
+// checkKeys 校验每个元组的列数与 pk 一致
+func checkKeys(pk []string, keys []KeyTuple) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if len(pk) == 0 {
+		return errors.New("指定 keys 时必须提供 pk")
+	}
+	for i, k := range keys {
+		if len(k) != len(pk) {
+			return fmt.Errorf("keys 第 %d 项 %v 有 %d 列，与 pk %v 的列数不一致", i+1, []string(k), len(k), pk)
+		}
+	}
+	return nil
+}
+
+// keysCond 生成 pk IN (...) 条件：单列为 `id` IN (?,?)，复合主键为 (`a`,`b`) IN ((?,?),(?,?))
+func keysCond(pk []string, keys []KeyTuple) (string, []interface{}) {
+	args := make([]interface{}, 0, len(keys)*len(pk))
+	for _, k := range keys {
+		for _, v := range k {
+			args = append(args, v)
+		}
+	}
+	ph := "(" + strings.TrimSuffix(strings.Repeat("?,", len(pk)), ",") + ")"
+	if len(pk) == 1 {
+		ph = "?"
+	}
+	list := strings.TrimSuffix(strings.Repeat(ph+",", len(keys)), ",")
+	if len(pk) == 1 {
+		return fmt.Sprintf("%s IN (%s)", quoteIdent(pk[0]), list), args
+	}
+	return fmt.Sprintf("(%s) IN (%s)", strings.Join(quoteAll(pk), ","), list), args
+}
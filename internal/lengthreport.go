@@ -0,0 +1,305 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// LengthReport 按列统计转换前后的长度变化，并结合列定义上限预测截断（并发安全，nil 时为空操作）
+type LengthReport struct {
+	mu   sync.Mutex
+	cols map[string]*ColumnLength // key: table.column（小写）
+}
+
+// ColumnLength 单列的长度统计
+type ColumnLength struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	typ    columnType
+
+	Converted   int64 `json:"converted"`     // 转换次数
+	Grew        int64 `json:"grew"`          // 转换后变长的次数
+	MaxGrowth   int   `json:"max_growth"`    // 单值最大增长（字符）
+	MaxOutChars int   `json:"max_out_chars"` // 转换后最长（字符）
+	MaxOutBytes int   `json:"max_out_bytes"` // 转换后最长（按列字符集编码的字节数）
+	Overflow    int64 `json:"overflow"`      // 转换后超过列上限的次数
+}
+
+// NewLengthReport 创建空报告
+func NewLengthReport() *LengthReport {
+	return &LengthReport{cols: map[string]*ColumnLength{}}
+}
+
+func lengthKey(table, column string) string {
+	return strings.ToLower(table + "." + column)
+}
+
+// SetColumnTypes 登记表的列定义（用于判断是否超过上限）
+func (r *LengthReport) SetColumnTypes(table string, types map[string]columnType) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range types {
+		c := r.col(table, t.Name)
+		c.typ = t
+	}
+}
+
+func (r *LengthReport) col(table, column string) *ColumnLength {
+	k := lengthKey(table, column)
+	c, ok := r.cols[k]
+	if !ok {
+		c = &ColumnLength{Table: table, Column: column}
+		r.cols[k] = c
+	}
+	return c
+}
+
+// Observe 记录一次转换（in -> out）
+func (r *LengthReport) Observe(table, column, in, out string) {
+	if r == nil {
+		return
+	}
+	inChars, outChars := utf8.RuneCountInString(in), utf8.RuneCountInString(out)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.col(table, column)
+	c.Converted++
+	if g := outChars - inChars; g > 0 {
+		c.Grew++
+		if g > c.MaxGrowth {
+			c.MaxGrowth = g
+		}
+	}
+	if outChars > c.MaxOutChars {
+		c.MaxOutChars = outChars
+	}
+	if n := byteLenForCharset(out, c.typ.Charset); n > c.MaxOutBytes {
+		c.MaxOutBytes = n
+	}
+	if c.typ.exceeds(out) {
+		c.Overflow++
+	}
+}
+
+// Columns 返回发生过转换的列（按表名、列名排序）
+func (r *LengthReport) Columns() []ColumnLength {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []ColumnLength
+	for _, c := range r.cols {
+		if c.Converted > 0 {
+			out = append(out, *c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Table != out[j].Table {
+			return out[i].Table < out[j].Table
+		}
+		return out[i].Column < out[j].Column
+	})
+	return out
+}
+
+// Write 输出按列的文本报告
+func (r *LengthReport) Write(w io.Writer) error {
+	cols := r.Columns()
+	if len(cols) == 0 {
+		_, err := fmt.Fprintln(w, "[length-report] 无转换发生")
+		return err
+	}
+	fmt.Fprintln(w, "[length-report] 按列长度变化：")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  列\t转换\t变长\t最大增长(字符)\t转换后最长(字符/字节)\t列上限\t可能截断")
+	for _, c := range cols {
+		limit := c.typ.limitString()
+		warn := ""
+		if c.Overflow > 0 {
+			warn = fmt.Sprintf("%d ⚠", c.Overflow)
+		}
+		fmt.Fprintf(tw, "  %s.%s\t%d\t%d\t%d\t%d/%d\t%s\t%s\n",
+			c.Table, c.Column, c.Converted, c.Grew, c.MaxGrowth, c.MaxOutChars, c.MaxOutBytes, limit, warn)
+	}
+	return tw.Flush()
+}
+
+// WidenStatements 为会溢出的字符列生成加宽语句（仅生成，不执行）。
+// 新长度取 dry-run 中观测到的转换后最大长度；VARCHAR 超出行大小上限（65535 字节）时改用 TEXT 系列
+func (r *LengthReport) WidenStatements() []string {
+	var out []string
+	for _, c := range r.Columns() {
+		if c.Overflow == 0 {
+			continue
+		}
+		typ, ok := c.typ.widenedType(c.MaxOutChars, c.MaxOutBytes)
+		if !ok {
+			out = append(out, fmt.Sprintf("-- %s.%s：%s 无法自动加宽，请手动处理", c.Table, c.Column, c.typ.limitString()))
+			continue
+		}
+		if strings.HasSuffix(typ, "TEXT") && c.typ.defaultLiteral() != "" {
+			out = append(out, fmt.Sprintf("-- 注意：%s.%s 改为 %s 后将不再保留默认值 %s", c.Table, c.Column, typ, c.typ.defaultLiteral()))
+		}
+		out = append(out, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s;", quoteIdent(c.Table), quoteIdent(c.Column), c.typ.definition(typ)))
+	}
+	return out
+}
+
+// WriteWidenSQL 输出加宽语句（附带复核提示）
+func (r *LengthReport) WriteWidenSQL(w io.Writer) error {
+	stmts := r.WidenStatements()
+	if len(stmts) == 0 {
+		_, err := fmt.Fprintln(w, "-- [auto-widen] 无需加宽的列")
+		return err
+	}
+	fmt.Fprintln(w, "-- [auto-widen] 以下语句仅供复核，工具不会执行；大表 ALTER 可能锁表，请在维护窗口手动执行")
+	for _, s := range stmts {
+		if _, err := fmt.Fprintln(w, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byteLenForCharset 返回 s 以 MySQL 字符集 charset 存储时占用的字节数（TEXT 系列的上限按字节计）。
+// 读出的值均为 UTF-8：utf8mb4/utf8mb3 直接取 len；GBK/GB18030/Big5 按实际编码计算（无法编码的字符按 1 字节的 ? 计）；
+// 其它单字节字符集按字符数；字符集未知时按 UTF-8
+func byteLenForCharset(s, charset string) int {
+	switch strings.ToLower(charset) {
+	case "", "utf8mb4", "utf8", "utf8mb3", "binary":
+		return len(s)
+	case "gbk", "gb2312":
+		return encodedLen(simplifiedchinese.GBK, s)
+	case "gb18030":
+		return encodedLen(simplifiedchinese.GB18030, s)
+	case "big5":
+		return encodedLen(traditionalchinese.Big5, s)
+	case "ucs2":
+		return 2 * utf8.RuneCountInString(s)
+	case "utf16", "utf16le":
+		n := 0
+		for _, r := range s {
+			if r > 0xFFFF {
+				n += 4
+			} else {
+				n += 2
+			}
+		}
+		return n
+	case "utf32":
+		return 4 * utf8.RuneCountInString(s)
+	}
+	return utf8.RuneCountInString(s) // latin1、ascii 等单字节字符集
+}
+
+func encodedLen(e encoding.Encoding, s string) int {
+	out, err := encoding.ReplaceUnsupported(e.NewEncoder()).String(s)
+	if err != nil {
+		return len(s)
+	}
+	return len(out)
+}
+
+// bytesPerChar 列字符集单字符最大字节数（由 OCTET/MAX 长度推得，未知按 4）
+func (t columnType) bytesPerChar() int64 {
+	if t.MaxChars > 0 && t.MaxBytes >= t.MaxChars {
+		return t.MaxBytes / t.MaxChars
+	}
+	return 4
+}
+
+// widenedType 计算能容纳 chars 字符 / bytes 字节的新类型
+func (t columnType) widenedType(chars, bytes int) (string, bool) {
+	bpc := t.bytesPerChar()
+	switch t.DataType {
+	case "char":
+		if chars <= 255 {
+			return fmt.Sprintf("CHAR(%d)", chars), true
+		}
+		fallthrough
+	case "varchar":
+		if int64(chars)*bpc <= 65535 {
+			return fmt.Sprintf("VARCHAR(%d)", chars), true
+		}
+		return textTypeFor(int64(chars) * bpc)
+	case "tinytext", "text", "mediumtext":
+		return textTypeFor(int64(bytes))
+	}
+	return "", false
+}
+
+// textTypeFor 返回能容纳 n 字节的最小 TEXT 类型
+func textTypeFor(n int64) (string, bool) {
+	switch {
+	case n <= 255:
+		return "TINYTEXT", true
+	case n <= 65535:
+		return "TEXT", true
+	case n <= 16777215:
+		return "MEDIUMTEXT", true
+	case n <= 4294967295:
+		return "LONGTEXT", true
+	}
+	return "", false
+}
+
+// definition 用新类型拼出完整列定义，保留字符集、排序规则、可空、默认值与注释
+func (t columnType) definition(typ string) string {
+	def := typ
+	if t.Charset != "" {
+		def += " CHARACTER SET " + t.Charset
+	}
+	if t.Collation != "" {
+		def += " COLLATE " + t.Collation
+	}
+	if !t.Nullable {
+		def += " NOT NULL"
+	}
+	if d := t.defaultLiteral(); d != "" && !strings.HasSuffix(typ, "TEXT") {
+		def += " DEFAULT " + d
+	}
+	if t.Comment != "" {
+		def += " COMMENT " + sqlStringLiteral(t.Comment)
+	}
+	return def
+}
+
+// defaultLiteral 返回默认值的 SQL 字面量；无默认值返回空串。
+// MariaDB 10.2+ 的 COLUMN_DEFAULT 为带引号的字面量（NULL 默认值为字符串 NULL），MySQL 为原始值
+func (t columnType) defaultLiteral() string {
+	v, ok := t.defaultValue()
+	if !ok {
+		return ""
+	}
+	return sqlStringLiteral(v)
+}
+
+// defaultValue 返回去掉引号后的默认值；无默认值或默认值为 NULL 时 ok 为 false
+func (t columnType) defaultValue() (v string, ok bool) {
+	if !t.Default.Valid || t.Default.String == "NULL" {
+		return "", false
+	}
+	v = t.Default.String
+	if len(v) >= 2 && strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'") {
+		v = strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+	}
+	return v, true
+}
+
+// sqlStringLiteral 生成单引号字符串字面量
+func sqlStringLiteral(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(s) + "'"
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestByteLenForCharset(t *testing.T) {
+	const s = "臺灣a😀" // 3 个 BMP 字符 + 1 个补充平面字符
+	for cs, want := range map[string]int{
+		"":        len(s),
+		"UTF8MB4": len(s),
+		"gbk":     2 + 2 + 1 + 1, // 😀 无法编码，按 ? 计 1 字节
+		"big5":    2 + 2 + 1 + 1,
+		"utf16":   2 + 2 + 2 + 4,
+		"utf32":   16,
+		"latin1":  4,
+	} {
+		if got := byteLenForCharset(s, cs); got != want {
+			t.Errorf("byteLenForCharset(%q) = %d, want %d", cs, got, want)
+		}
+	}
+}
+
+// 字节数超过 TINYTEXT 上限而字符数未超：TEXT 系列按字节判断溢出，VARCHAR 按字符判断
+func TestLengthReportByteOverflow(t *testing.T) {
+	in := strings.Repeat("简", 90)
+	out := strings.Repeat("簡", 90) // 90 字符 / 270 字节（utf8mb4）
+	if utf8.RuneCountInString(out) > 255 || len(out) <= 255 {
+		t.Fatalf("bad fixture: %d runes, %d bytes", utf8.RuneCountInString(out), len(out))
+	}
+	r := NewLengthReport()
+	r.SetColumnTypes("t", map[string]columnType{
+		"tiny": {Name: "tiny", DataType: "tinytext", MaxChars: 255, MaxBytes: 255, Charset: "utf8mb4"},
+		"gbk":  {Name: "gbk", DataType: "tinytext", MaxChars: 255, MaxBytes: 255, Charset: "gbk"},
+		"vc":   {Name: "vc", DataType: "varchar", MaxChars: 255, MaxBytes: 1020, Charset: "utf8mb4"},
+	})
+	for _, col := range []string{"tiny", "gbk", "vc"} {
+		r.Observe("t", col, in, out)
+	}
+	r.Observe("t", "vc", in, strings.Repeat("簡", 256))
+
+	got := map[string]ColumnLength{}
+	for _, c := range r.Columns() {
+		got[c.Column] = c
+	}
+	if c := got["tiny"]; c.Overflow != 1 || c.MaxOutChars != 90 || c.MaxOutBytes != 270 {
+		t.Errorf("tinytext utf8mb4 = %+v, want overflow by bytes", c)
+	}
+	if c := got["gbk"]; c.Overflow != 0 || c.MaxOutBytes != 180 {
+		t.Errorf("tinytext gbk = %+v, want 180 bytes without overflow", c)
+	}
+	if c := got["vc"]; c.Overflow != 1 || c.MaxOutChars != 256 {
+		t.Errorf("varchar(255) = %+v, want only the 256-char value to overflow", c)
+	}
+	if stmts := r.WidenStatements(); len(stmts) != 2 || !strings.Contains(stmts[0], "TEXT") {
+		t.Errorf("widen = %v", stmts)
+	}
+}
@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"strings"
+	"sync"
+)
+
+// lockGroups 记录各 lock_group（不区分大小写）最近提交的任务结束时关闭的通道
+type lockGroups map[string]chan struct{}
+
+// goAfter 在新 goroutine 中执行 fn：先等同组前一个任务结束，因此同组任务按提交顺序依次执行，不阻塞其它组
+func (lg lockGroups) goAfter(wg *sync.WaitGroup, group string, fn func()) {
+	g := strings.ToLower(group)
+	prev, done := lg[g], make(chan struct{})
+	lg[g] = done
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+		fn()
+	}()
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockGroupsSerializeSameGroup(t *testing.T) {
+	type span struct{ start, end time.Time }
+	var (
+		mu    sync.Mutex
+		spans = map[string]span{}
+		wg    sync.WaitGroup
+	)
+	task := func(name string) func() {
+		return func() {
+			start := time.Now()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			spans[name] = span{start, time.Now()}
+			mu.Unlock()
+		}
+	}
+	lg := lockGroups{}
+	lg.goAfter(&wg, "order", task("orders"))
+	lg.goAfter(&wg, "user", task("users"))
+	lg.goAfter(&wg, "ORDER", task("order_items")) // 组名不区分大小写
+	lg.goAfter(&wg, "order", task("order_logs"))
+	wg.Wait()
+
+	// 同组：按提交顺序依次执行，区间互不重叠
+	seq := []string{"orders", "order_items", "order_logs"}
+	for i := 1; i < len(seq); i++ {
+		prev, cur := spans[seq[i-1]], spans[seq[i]]
+		if cur.start.Before(prev.end) {
+			t.Errorf("%s started before %s finished", seq[i], seq[i-1])
+		}
+	}
+	// 不同组：与 order 组的第一张表同时运行
+	if u, o := spans["users"], spans["orders"]; !u.start.Before(o.end) || !o.start.Before(u.end) {
+		t.Errorf("users %v and orders %v did not overlap", u, o)
+	}
+}
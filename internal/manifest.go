@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// tableManifest 记录已成功处理完的表（每行一个表名），重跑时跳过，实现按表续跑
+type tableManifest struct {
+	path string
+	mu   sync.Mutex
+	done map[string]bool
+}
+
+// loadTableManifest 读取清单；文件不存在视为空清单
+func loadTableManifest(path string) (*tableManifest, error) {
+	m := &tableManifest{path: path, done: map[string]bool{}}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取清单 %s: %w", path, err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if t := strings.TrimSpace(sc.Text()); t != "" && !strings.HasPrefix(t, "#") {
+			m.done[t] = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("读取清单 %s: %w", path, err)
+	}
+	return m, nil
+}
+
+func (m *tableManifest) isDone(table string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.done[table]
+}
+
+// markDone 追加一行并落盘，进程中途退出时已完成的表不会丢失
+func (m *tableManifest) markDone(table string) error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done[table] {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("写清单 %s: %w", m.path, err)
+	}
+	if _, err := fmt.Fprintln(f, table); err != nil {
+		f.Close()
+		return fmt.Errorf("写清单 %s: %w", m.path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	m.done[table] = true
+	return f.Close()
+}
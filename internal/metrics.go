@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics 按表累计运行指标，结束时以 Prometheus 文本格式输出；方法对 nil 安全
+type Metrics struct {
+	mu     sync.Mutex
+	tables map[string]*tableMetrics
+}
+
+type tableMetrics struct {
+	scanned  int64
+	changed  int64 // 有列发生转换的行（dry-run 下为“将会更新”的行）
+	failed   int64 // 读取/转换/UPDATE 出错的行
+	duration time.Duration
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{tables: map[string]*tableMetrics{}}
+}
+
+func (m *Metrics) table(name string) *tableMetrics {
+	t, ok := m.tables[name]
+	if !ok {
+		t = &tableMetrics{}
+		m.tables[name] = t
+	}
+	return t
+}
+
+func (m *Metrics) add(table string, f func(t *tableMetrics)) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	f(m.table(table))
+	m.mu.Unlock()
+}
+
+func (m *Metrics) Scanned(table string) { m.add(table, func(t *tableMetrics) { t.scanned++ }) }
+func (m *Metrics) Changed(table string) { m.add(table, func(t *tableMetrics) { t.changed++ }) }
+func (m *Metrics) Failed(table string)  { m.add(table, func(t *tableMetrics) { t.failed++ }) }
+
+// rows 返回表累计的扫描/变更/失败行数
+func (m *Metrics) rows(table string) (scanned, changed, failed int64) {
+	if m == nil {
+		return 0, 0, 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.tables[table]; ok {
+		return t.scanned, t.changed, t.failed
+	}
+	return 0, 0, 0
+}
+
+// totalChanged 返回所有表合计的变更行数
+func (m *Metrics) totalChanged() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, t := range m.tables {
+		n += t.changed
+	}
+	return n
+}
+
+// ObserveDuration 累加表的处理耗时（同一表在多个配置中出现时求和）
+func (m *Metrics) ObserveDuration(table string, d time.Duration) {
+	m.add(table, func(t *tableMetrics) { t.duration += d })
+}
+
+// WriteText 以 Prometheus 文本格式（0.0.4）输出
+func (m *Metrics) WriteText(w io.Writer) error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.tables))
+	for n := range m.tables {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	series := []struct {
+		name, typ, help string
+		value           func(t *tableMetrics) string
+	}{
+		{"tradify_rows_scanned_total", "counter", "读取的行数", func(t *tableMetrics) string { return fmt.Sprint(t.scanned) }},
+		{"tradify_rows_changed_total", "counter", "发生转换的行数（dry-run 下为将会更新的行数）", func(t *tableMetrics) string { return fmt.Sprint(t.changed) }},
+		{"tradify_rows_failed_total", "counter", "读取、转换或更新失败的行数", func(t *tableMetrics) string { return fmt.Sprint(t.failed) }},
+		{"tradify_table_duration_seconds", "gauge", "表的处理耗时（秒）", func(t *tableMetrics) string { return fmt.Sprintf("%.3f", t.duration.Seconds()) }},
+	}
+	for _, s := range series {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", s.name, s.help, s.name, s.typ)
+		for _, n := range names {
+			fmt.Fprintf(&buf, "%s{table=\"%s\"} %s\n", s.name, escapeLabel(n), s.value(m.tables[n]))
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteFile 原子写入指标文件（适配 node_exporter textfile collector 的读取方式）
+func (m *Metrics) WriteFile(path string) error {
+	if m == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("写指标文件 %s: %w", tmp, err)
+	}
+	if err := m.WriteText(f); err != nil {
+		f.Close()
+		return fmt.Errorf("写指标文件 %s: %w", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Push 推送到 Pushgateway；url 未包含 /metrics/job/ 时默认使用 job=tradify-cli
+func (m *Metrics) Push(url string) error {
+	if m == nil {
+		return nil
+	}
+	if !strings.Contains(url, "/metrics/job/") {
+		url = strings.TrimRight(url, "/") + "/metrics/job/tradify-cli"
+	}
+	var buf bytes.Buffer
+	if err := m.WriteText(&buf); err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, url, &buf)
+	if err != nil {
+		return fmt.Errorf("推送指标失败：%w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("推送指标失败：%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("推送指标失败：%s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
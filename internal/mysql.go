@@ -9,10 +9,10 @@ import (
 	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/vbauerster/mpb/v8"
-	"github.com/vbauerster/mpb/v8/decor"
 )
 
 type MySQLConfig struct {
@@ -21,114 +21,537 @@ type MySQLConfig struct {
 	PK              []string // 支持复合主键；为空表示无主键
 	IdentifyBy      []string // 无主键时用于 WHERE 定位的列
 	Columns         []string
+	SelectSQL       string // 可选：自定义行来源 SELECT（仅有主键时可用）
 	To              string
+	Normalize       string // 输出 Unicode 规范化：nfc | nfkc | none
+	NormalizeInput  bool   // 转换前是否也对输入做规范化
+	PunctOnly       bool   // 仅标点模式：汉字不变
+	Smart           bool   // 按值判定：以繁体为主的值只修正零散的简体字
+	ConvertKeys     bool   // JSON 列的对象键也转换
+	ExplainSkip     bool   // 逐行逐列记录未转换的原因
 	BatchSize       int
 	Workers         int // 预留：后续可做每表内部并发
 	RPS             int
 	DryRun          bool
-	MaxOpenConns    int
-	MaxIdleConns    int
+	MaxOpenConns    PoolSize // 0 不限制；PoolAuto 按 workers 自动估算
+	MaxIdleConns    PoolSize // 0 使用 database/sql 默认；PoolAuto 与 max_open 相同
 	ConnMaxLifetime time.Duration
+
+	StreamResults     bool // 有主键模式下边读边处理，不缓存整批
+	InterpolateParams bool // 驱动端插值参数
+
+	Stats        *Stats        // 可选：统计输出，多表可共享
+	LengthReport *LengthReport // 可选：记录转换前后长度变化
+	TermReport   *TermReport   // 可选：统计词条替换频次（dry-run）
+	Metrics      *Metrics      // 可选：按表累计运行指标（Prometheus 文本格式输出）
+	ChangeLog    *ChangeLog    // 可选：dry-run 时记录每一项拟变更（JSONL）
+	Events       *EventStream  // 可选：向前端输出进度事件（JSONL）
+	BatchHook    *BatchHook    // 可选：每批写入后的外部命令 / webhook
+	Approved     *ApprovalSet  // 可选：只应用已批准的变更
+	RowBackup    *RowBackup    // 可选：真实写入前备份原值
+
+	IdentifyUnique bool // 要求 IdentifyBy 被唯一索引覆盖
+	StrictAffected bool // UPDATE 影响超过 1 行时中止该表
+
+	Label string // 可选：进度条显示名（默认表名）
+
+	// 配置文件模式内部使用：进度条排序与汇总进度条
+	barOrder    string // config | label | size
+	barPriority int
+	aggregate   *aggregateBar
+	counts      *tableMetrics    // 本次运行该表的行计数与耗时
+	inflight    chan struct{}    // 多表共享的 UPDATE 并发预算，nil 表示不限制
+	globalRate  <-chan time.Time // 多表共享的限速令牌，nil 表示不限制
+
+	colTypes map[string]columnType // 表的列定义（key 为小写列名）
+
+	IncrementalColumn string // 可选：增量列（如 updated_at）
+	Since             string // 增量起点：时间/数值或 Go duration
+	WatermarkFile     string // 可选：水位文件，供下次运行续跑
+
+	QueryRetryMax   int           // 批次查询遇暂时性错误的最大重试次数
+	QueryRetryDelay time.Duration // 重试间隔，0 使用默认 5s
+
+	PrefilterNonASCII bool // 只读取目标列含非 ASCII 字符的行
+
+	ConnectTimeout time.Duration // 建立连接的超时，0 使用默认 10s
+
+	Keys []KeyTuple // 可选：只处理这些主键对应的行
+
+	PKMin, PKMax *int64 // 可选：首个主键列的闭区间（数值主键）
+
+	StateDir StateDir // 可选：状态目录
+
+	CountMode string // 进度条总量来源：exact（默认）| information_schema | none
+
+	Shadow bool // 仅限 dry-run：结果写入影子表，原表不变
+
+	Verify bool // 真实写入后按主键回读核对
+
+	Segments map[string]SegmentSpec // 可选：按列只转换值中的某一段
+	segments map[string]*SegmentSpec
+
+	HeavyIndexRPS int // 目标列带 FULLTEXT/SPATIAL 索引时的限速（0 仅告警）
+
+	MaxErrors int // 熔断阈值：累计失败超过该数时中止（负数不限制）
+	errBudget *errorBudget
+
+	TreatWhitespaceEmpty bool // 只含空白字符的值按空串跳过
+	AllowEmptyResult     bool // 允许非空值转换为空串
+
+	Replace  map[string]string // 可选：转换后的自定义替换（原文 -> 替换为）
+	replacer *Replacer
+
+	SessionCollation string // 可选：每个新连接的会话排序规则
+	Isolation        string // 可选：每个新连接的事务隔离级别
+
+	maxPacket int64 // 服务器 max_allowed_packet，0 表示未知
+
+	ReadDSN string  // 可选：只读副本 DSN，读查询走副本
+	readDB  *sql.DB // ReadDSN 对应的连接池
+}
+
+// reader 返回读查询使用的连接池：配置了 ReadDSN 时为副本，否则为主库 db
+func (c MySQLConfig) reader(db *sql.DB) *sql.DB {
+	if c.readDB != nil {
+		return c.readDB
+	}
+	return db
 }
 
-// 单表模式：内部创建一个进度容器
-func RunMySQL(cfg MySQLConfig) error {
+func (c MySQLConfig) convertOptions() ConvertOptions {
+	return ConvertOptions{To: c.To, Normalize: c.Normalize, NormalizeInput: c.NormalizeInput, Replace: c.replacer, PunctOnly: c.PunctOnly, Smart: c.Smart}
+}
+
+// source 返回 SELECT 的 FROM 部分：默认即表本身；自定义 select_sql 时包成派生表，
+// 以便继续叠加主键游标分页与 COUNT(*)
+func (c MySQLConfig) source() string {
+	if c.SelectSQL != "" {
+		return "(" + strings.TrimRight(strings.TrimSpace(c.SelectSQL), ";") + ") AS `_tradify_src`"
+	}
+	return quoteIdent(c.Table)
+}
+
+// rowFilter 组合行过滤条件（增量条件 + 非 ASCII 预过滤 + 指定主键 + 主键区间），供 COUNT 与批次 SELECT 共用；无条件时返回空串
+func (c MySQLConfig) rowFilter() (string, []interface{}) {
+	var conds []string
+	cond, args := c.incrementalCond(c.Since)
+	if cond != "" {
+		conds = append(conds, cond)
+	}
+	if c.PrefilterNonASCII {
+		conds = append(conds, c.nonASCIICond())
+	}
+	if len(c.Keys) > 0 {
+		cond, keyArgs := keysCond(c.PK, c.Keys)
+		conds = append(conds, cond)
+		args = append(args, keyArgs...)
+	}
+	if cond, rangeArgs := c.pkRangeCond(); cond != "" {
+		conds = append(conds, cond)
+		args = append(args, rangeArgs...)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// nonASCIICond 任一目标列的字节长度大于字符长度，即含多字节字符。
+// 不依赖正则实现与排序规则；仅适用于 utf8/utf8mb4/gbk 等多字节字符集（latin1 等单字节字符集会漏掉非 ASCII 行）
+func (c MySQLConfig) nonASCIICond() string {
+	ors := make([]string, len(c.Columns))
+	for i, col := range c.Columns {
+		ors[i] = fmt.Sprintf("LENGTH(%s) <> CHAR_LENGTH(%s)", quoteIdent(col), quoteIdent(col))
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+// 单表模式：内部创建一个进度容器，返回该表的汇总结果。
+// ctx 取消（信号或 --max-runtime 到期）时，处理完当前批次后停止并返回 ctx.Err()
+func RunMySQL(ctx context.Context, cfg MySQLConfig) (RunStats, error) {
+	start := time.Now()
 	p := mpb.New(
 		mpb.WithWidth(60),
 		mpb.WithOutput(os.Stdout), // 进度条只往 STDOUT
 		mpb.WithRefreshRate(120*time.Millisecond),
 	)
-	defer p.Wait()
-	return RunMySQLWithProgress(cfg, p)
+	if cfg.Stats == nil {
+		cfg.Stats = &Stats{} // 汇总结果中的按值统计
+	}
+	if cfg.counts == nil {
+		cfg.counts = &tableMetrics{}
+	}
+	err := RunMySQLWithProgress(ctx, cfg, p)
+	p.Wait()
+	rs := RunStats{Values: cfg.Stats.Snapshot(), Duration: time.Since(start), DryRun: cfg.DryRun}
+	status := "完成"
+	if err != nil {
+		status = "失败"
+	}
+	rs.addTable(tableStats(cfg.Table, status, cfg.counts, err), cfg.DryRun)
+	return rs, err
 }
 
 // 多表模式：外部传入进度容器（便于多条进度条并发显示）
-func RunMySQLWithProgress(cfg MySQLConfig, p *mpb.Progress) error {
+func RunMySQLWithProgress(ctx context.Context, cfg MySQLConfig, p *mpb.Progress) (err error) {
+	start := time.Now()
+	defer func() { cfg.Metrics.ObserveDuration(cfg.Table, time.Since(start)) }()
+	if cfg.counts == nil {
+		cfg.counts = &tableMetrics{}
+	}
+	defer func() {
+		cfg.counts.duration = time.Since(start)
+		if err != nil {
+			cfg.Events.Error(cfg.Table, "", err)
+			return
+		}
+		cfg.Events.TableFinished(cfg.Table, cfg.counts.scanned, cfg.counts.changed, cfg.counts.failed, time.Since(start))
+	}()
+	kind := ErrConfigInvalid // 连库之前未分类的错误均为参数问题（文件读写已归为 ErrIO）；之后按各自来源分类
+	defer func() {
+		if kind != nil {
+			err = classify(kind, err)
+		}
+	}()
 	if len(cfg.Columns) == 0 {
 		return errors.New("必须提供 --columns")
 	}
+	if _, err := ParseNormalizeForm(cfg.Normalize); err != nil {
+		return err
+	}
+	if err := WarmUpConverters(cfg.To); err != nil {
+		return err
+	}
+	if cfg.Smart {
+		if err := checkSmart(cfg.To); err != nil {
+			return err
+		}
+	}
+	if cfg.SelectSQL != "" && len(cfg.PK) == 0 {
+		return errors.New("使用 select_sql 时必须提供 pk（UPDATE 仍按主键定位）")
+	}
+	if cfg.SelectSQL != "" && cfg.IncrementalColumn != "" {
+		return errors.New("select_sql 与 incremental_column 不可同时使用（请直接在 select_sql 中写增量条件）")
+	}
+	if cfg.IncrementalColumn != "" && cfg.WatermarkFile == "" && cfg.StateDir != "" {
+		cfg.WatermarkFile = cfg.StateDir.WatermarkPath(cfg.DSN, cfg.Table)
+	}
+	if cfg.IncrementalColumn != "" {
+		since := cfg.Since
+		if since == "" && cfg.WatermarkFile != "" {
+			mark, err := readWatermark(cfg.WatermarkFile)
+			if err != nil {
+				return err
+			}
+			since = mark
+		}
+		cfg.Since = resolveSince(since, time.Now())
+		log.Printf("[mysql] 增量模式 table=%s：%s > %q", cfg.Table, cfg.IncrementalColumn, cfg.Since)
+	}
+
+	if err := checkKeys(cfg.PK, cfg.Keys); err != nil {
+		return err
+	}
+	if len(cfg.Keys) > 0 {
+		log.Printf("[mysql] 指定主键模式 table=%s：仅处理 %d 个主键对应的行", cfg.Table, len(cfg.Keys))
+	}
+	if err := checkPKRange(cfg.PK, cfg.PKMin, cfg.PKMax); err != nil {
+		return err
+	}
+	if cfg.PKMin != nil || cfg.PKMax != nil {
+		log.Printf("[mysql] 主键区间 table=%s：仅处理 %s 在 %s 内的行", cfg.Table, cfg.PK[0], cfg.pkRangeString())
+	}
+	if cfg.PrefilterNonASCII {
+		log.Printf("[mysql] 非 ASCII 预过滤 table=%s：仅读取 %v 中至少一列含非 ASCII 字符的行", cfg.Table, cfg.Columns)
+	}
+	if err := checkCountMode(cfg.CountMode); err != nil {
+		return err
+	}
+	if cfg.segments, err = compileSegments(cfg.Columns, cfg.Segments); err != nil {
+		return err
+	}
+	if cfg.replacer, err = NewReplacer(cfg.Replace, cfg.Stats); err != nil {
+		return err
+	}
+	if cfg.errBudget == nil {
+		cfg.errBudget = newErrorBudget(cfg.MaxErrors) // 单表运行；配置文件模式由调用方创建并在各表间共享
+	}
+	if cfg.QueryRetryDelay <= 0 {
+		cfg.QueryRetryDelay = defaultQueryRetryDelay
+	}
+
+	if cfg.Shadow && !cfg.DryRun {
+		return errors.New("影子表模式仅可与 dry-run 一起使用（原表不会被修改）")
+	}
+	if cfg.Shadow && len(cfg.PK) == 0 {
+		return errors.New("影子表模式需要提供 pk（影子表按主键与原表对照）")
+	}
 
-	db, err := sql.Open("mysql", cfg.DSN)
+	if cfg.Verify && cfg.DryRun {
+		cfg.Verify = false // 试运行不写入，无需回读
+	}
+	if cfg.Verify && len(cfg.PK) == 0 {
+		log.Printf("[mysql] 警告：table=%s 无主键，不做写后校验（--verify 仅按主键回读）", cfg.Table)
+		cfg.Verify = false
+	}
+
+	open, idle, err := cfg.resolvePool()
 	if err != nil {
-		return fmt.Errorf("open db: %w", err)
+		return err
 	}
-	defer db.Close()
 
-	if cfg.MaxOpenConns > 0 {
-		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	dsn, err := tuneDSN(cfg, cfg.DSN)
+	if err != nil {
+		return err
+	}
+	readDSN := ""
+	if cfg.ReadDSN != "" {
+		if readDSN, err = tuneDSN(cfg, cfg.ReadDSN); err != nil {
+			return fmt.Errorf("read_dsn: %w", err)
+		}
 	}
-	if cfg.MaxIdleConns > 0 {
-		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	kind = nil
+	db, err := openPool(ctx, cfg, dsn, open, idle)
+	if err != nil {
+		return err
 	}
-	if cfg.ConnMaxLifetime > 0 {
-		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	defer db.Close()
+	cfg.maxPacket = serverMaxPacket(ctx, cfg, db)
+	if readDSN != "" {
+		rdb, err := openPool(ctx, cfg, readDSN, open, idle)
+		if err != nil {
+			return fmt.Errorf("read_dsn: %w", err)
+		}
+		defer rdb.Close()
+		cfg.readDB = rdb
+		log.Printf("[mysql] table=%s 读查询走只读副本 read_dsn，UPDATE 走主库；副本延迟期间主库上新写入的行可能漏读，"+
+			"按主键 UPDATE 也可能用过期的原值覆盖较新的修改，请在写入低峰运行并确认副本延迟接近 0", cfg.Table)
 	}
 
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("db ping: %w", err)
+	if cfg.SelectSQL != "" {
+		if err := checkSelectProjection(cfg.reader(db), cfg); err != nil {
+			return err
+		}
+	}
+
+	types, err := getColumnTypes(cfg.reader(db), cfg.Table)
+	if err != nil {
+		log.Printf("[mysql] 读取列定义失败（长度报告将不含列上限，SET/JSON 列按普通文本转换）：%v", err)
+	}
+	cfg.LengthReport.SetColumnTypes(cfg.Table, types)
+	cfg.colTypes = types
+	cfg.checkHeavyIndexes(ctx, cfg.reader(db))
+	if cfg.Shadow {
+		name, err := createShadowTable(db, cfg)
+		if err != nil {
+			return err
+		}
+		log.Printf("[mysql] 影子表模式 table=%s：转换结果写入 %s，原表不变", cfg.Table, name)
 	}
 
 	// 统计总行数（用于进度条总量）
-	total, err := countTotalRows(db, cfg.Table)
+	filter, filterArgs := cfg.rowFilter()
+	total, approx, err := totalRows(cfg.reader(db), cfg, filter, filterArgs...)
 	if err != nil {
 		// 统计失败则使用“动态总量”模式
 		total = -1
 	}
 
-	// 进度条（每表一条）
+	// 进度条（每表一条；总量未知时从 0 开始，按批动态增加总量）
 	var bar *mpb.Bar
 	if p != nil {
-		if total > 0 {
-			// 总量已知
-			bar = p.AddBar(
-				total,
-				mpb.PrependDecorators(
-					decor.Name("["+cfg.Table+"] "),
-					decor.CountersNoUnit("%d/%d"),
-					decor.Percentage(decor.WCSyncWidth),
-				),
-				mpb.AppendDecorators(
-					decor.EwmaETA(decor.ET_STYLE_GO, 60, decor.WCSyncWidth), // 估算剩余时间
-				),
-			)
-		} else {
-			// 总量未知：从 0 开始，按批动态增加总量
-			bar = p.AddBar(
-				0,
-				mpb.PrependDecorators(
-					decor.Name("["+cfg.Table+"] "),
-					decor.CountersNoUnit("%d/%d"),
-					decor.Percentage(decor.WCSyncWidth),
-				),
-				mpb.AppendDecorators(
-					decor.EwmaETA(decor.ET_STYLE_GO, 60, decor.WCSyncWidth),
-				),
-			)
+		label := cfg.Label
+		if label == "" {
+			label = cfg.Table
+		}
+		priority := cfg.barPriority
+		if cfg.barOrder == "size" && total > 0 {
+			priority = -int(total) // 行数多的在上
+		}
+		barTotal := max(total, 0)
+		if approx {
+			barTotal = 0 // 以动态总量创建，近似总量被低估时不会提前完成
+		}
+		bar = newTableBar(p, label, barTotal, priority)
+		if approx {
+			bar.SetTotal(total, false)
 		}
 	}
+	cfg.aggregate.addTotal(max(total, 0))
+	cfg.Events.TableStarted(cfg.Table, cfg.Label, cfg.Columns, total, cfg.DryRun)
 
 	// RPS 节流器
 	var rate <-chan time.Time
 	if cfg.RPS > 0 {
-		interval := time.Second / time.Duration(cfg.RPS)
-		if interval <= 0 {
-			interval = time.Millisecond
-		}
-		tk := time.NewTicker(interval)
+		tk := newRateTicker(cfg.RPS)
 		defer tk.Stop()
 		rate = tk.C
 	}
 
+	var mark string // 本次处理到的增量列最大值
 	if len(cfg.PK) > 0 {
-		return processWithPK(db, cfg, rate, bar, total)
+		mark, err = processWithPK(ctx, db, cfg, rate, bar, total)
+	} else {
+		mark, err = processNoPK(ctx, db, cfg, rate, bar, total)
+	}
+	if approx && err == nil {
+		cfg.aggregate.addTotal(cfg.counts.scanned - total) // 按实际行数校正汇总进度条
+	}
+	if err == nil && cfg.WatermarkFile != "" && mark != "" && !cfg.DryRun {
+		if err := writeWatermark(cfg.WatermarkFile, mark); err != nil {
+			return err
+		}
+		log.Printf("[mysql] 已保存增量水位 table=%s：%s", cfg.Table, mark)
+	}
+	// 中途退出时终止进度条，否则 p.Wait() 会一直等待未完成的进度条
+	if err != nil && bar != nil && !bar.Completed() {
+		bar.Abort(false)
+	}
+	return err
+}
+
+// openPool 打开连接池、设置池大小并在超时内完成首次连接
+func openPool(ctx context.Context, cfg MySQLConfig, dsn string, open, idle int) (*sql.DB, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, classify(ErrDBConnect, fmt.Errorf("open db: %w", err))
+	}
+	if open > 0 {
+		db.SetMaxOpenConns(open)
+	}
+	if idle > 0 {
+		db.SetMaxIdleConns(idle)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if err := pingTimeout(ctx, db, cfg.ConnectTimeout); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// pingTimeout 在超时内完成首次连接：sql.Open 是惰性的，主机被黑洞（无 RST）时 Ping 可能一直挂起
+func pingTimeout(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	pctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := db.PingContext(pctx); err != nil {
+		if errors.Is(pctx.Err(), context.DeadlineExceeded) {
+			return classify(ErrDBConnect, fmt.Errorf("连接数据库超时（%s）：请检查 DSN 中的主机与端口是否可达（网络、防火墙）", timeout))
+		}
+		return classify(ErrDBConnect, fmt.Errorf("db ping: %w", err))
+	}
+	return nil
+}
+
+// tuneDSN 将驱动层调优参数合入 DSN（仅在开启时覆盖 DSN 中的同名参数）。
+// SessionCollation 写成 DSN 的 charset + collation，由驱动在每个新连接建立时执行 SET NAMES … COLLATE …；
+// Isolation 写成 DSN 的系统变量参数 transaction_isolation，由驱动在每个新连接建立时执行 SET transaction_isolation=…；
+// DSN 未指定 maxAllowedPacket 时改为 0，由驱动在连接时读取服务器的 max_allowed_packet（驱动默认固定为 64MiB，
+// 服务器更小时超大值的 UPDATE 会被服务器拒绝并断开连接，更大时又会被驱动提前拒绝）
+func tuneDSN(cfg MySQLConfig, dsn string) (string, error) {
+	dc, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("parse dsn: %w", err)
+	}
+	if dc.MaxAllowedPacket == mysql.NewConfig().MaxAllowedPacket {
+		dc.MaxAllowedPacket = 0
+	}
+	if cfg.InterpolateParams {
+		dc.InterpolateParams = true
+	}
+	if cfg.SessionCollation != "" {
+		charset, err := collationCharset(cfg.SessionCollation)
+		if err != nil {
+			return "", err
+		}
+		if err := dc.Apply(mysql.Charset(charset, cfg.SessionCollation)); err != nil {
+			return "", fmt.Errorf("session collation: %w", err)
+		}
+	}
+	if cfg.Isolation != "" {
+		level, err := parseIsolation(cfg.Isolation)
+		if err != nil {
+			return "", err
+		}
+		if dc.Params == nil {
+			dc.Params = map[string]string{}
+		}
+		dc.Params["transaction_isolation"] = "'" + level + "'"
 	}
-	return processNoPK(db, cfg, rate, bar, total)
+	return dc.FormatDSN(), nil
 }
 
-// 统计表总行数
-func countTotalRows(db *sql.DB, table string) (int64, error) {
+// parseIsolation 校验事务隔离级别并返回 transaction_isolation 的取值：不区分大小写，空格、下划线与连字符等价，
+// 如 "read committed" -> READ-COMMITTED
+func parseIsolation(s string) (string, error) {
+	level := strings.ToUpper(strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	}), "-"))
+	switch level {
+	case "READ-UNCOMMITTED", "READ-COMMITTED", "REPEATABLE-READ", "SERIALIZABLE":
+		return level, nil
+	}
+	return "", fmt.Errorf("无效的 isolation %q（可选 READ COMMITTED、REPEATABLE READ、READ UNCOMMITTED、SERIALIZABLE）", s)
+}
+
+// collationCharset 校验排序规则名（只允许字母、数字、下划线，会拼入 SET NAMES 语句）并返回其字符集：
+// utf8mb4_bin -> utf8mb4，binary -> binary
+func collationCharset(collation string) (string, error) {
+	if collation == "" || strings.Trim(collation, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_") != "" {
+		return "", fmt.Errorf("无效的 session_collation %q（如 utf8mb4_bin、utf8mb4_0900_ai_ci）", collation)
+	}
+	charset, _, _ := strings.Cut(collation, "_")
+	return charset, nil
+}
+
+// 进度条总量来源（CountMode）
+const (
+	CountExact             = "exact"              // SELECT COUNT(*)（默认）
+	CountInformationSchema = "information_schema" // information_schema.TABLES.TABLE_ROWS 近似值，立即可用
+	CountNone              = "none"               // 不统计，按批动态扩充总量
+)
+
+// checkCountMode 校验 count_mode 取值（空等同 exact）
+func checkCountMode(mode string) error {
+	switch mode {
+	case "", CountExact, CountInformationSchema, CountNone:
+		return nil
+	}
+	return fmt.Errorf("不支持的 count_mode：%q（可选 exact、information_schema、none）", mode)
+}
+
+// totalRows 按 CountMode 取得进度条总量，approx 表示为近似值；none 返回 -1（动态总量）。
+// information_schema 只适用于整表：有增量/预过滤/keys 条件或 select_sql 时、以及估算不可用（视图、统计为 0）时退回精确计数
+func totalRows(db *sql.DB, cfg MySQLConfig, filter string, args ...interface{}) (total int64, approx bool, err error) {
+	switch cfg.CountMode {
+	case CountNone:
+		return -1, false, nil
+	case CountInformationSchema:
+		if filter == "" && cfg.SelectSQL == "" {
+			var n sql.NullInt64
+			err := db.QueryRow("SELECT TABLE_ROWS FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", cfg.Table).Scan(&n)
+			if err == nil && n.Valid && n.Int64 > 0 {
+				log.Printf("[mysql] 进度总量 table=%s：约 %d 行（information_schema 估算，完成时按实际行数校正）", cfg.Table, n.Int64)
+				return n.Int64, true, nil
+			}
+		}
+		log.Printf("[mysql] table=%s 无法使用 information_schema 估算总量（有过滤条件、自定义 SELECT 或无统计信息），改用 COUNT(*)", cfg.Table)
+	}
+	total, err = countTotalRows(db, cfg.source(), filter, args...)
+	return total, false, err
+}
+
+// 统计总行数（source 为已引用的表名或派生表，where 为可选过滤条件）
+func countTotalRows(db *sql.DB, source, where string, args ...interface{}) (int64, error) {
 	var total int64
-	row := db.QueryRow("SELECT COUNT(*) FROM `" + table + "`")
+	q := "SELECT COUNT(*) FROM " + source
+	if where != "" {
+		q += " WHERE " + where
+	}
+	row := db.QueryRow(q, args...)
 	if err := row.Scan(&total); err != nil {
 		return 0, err
 	}
@@ -136,42 +559,207 @@ func countTotalRows(db *sql.DB, table string) (int64, error) {
 }
 
 // ---------- 复合主键/单主键 增量遍历 ----------
-func processWithPK(db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.Bar, total int64) error {
+// 返回本次读到的增量列最大值（未配置增量时为空）
+func processWithPK(ctx context.Context, db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.Bar, total int64) (string, error) {
 	log.Printf("[mysql] 开始处理（有主键） table=%s pk=%v cols=%v", cfg.Table, cfg.PK, cfg.Columns)
 
 	lastKey := make([]sql.NullString, len(cfg.PK)) // 初始为空
 	cols := append([]string{}, cfg.PK...)
 	cols = append(cols, cfg.Columns...)
+	if cfg.IncrementalColumn != "" {
+		cols = append(cols, cfg.IncrementalColumn) // 末列：用于推进水位
+	}
 	quoted := quoteAll(cols)
+	filter, filterArgs := cfg.rowFilter()
+	var mark string
+
+	type row struct {
+		pk   []sql.NullString
+		data map[string]*string
+	}
+
+	var done int64 // 已处理行数（用于中止时汇报进度）
+	var batchNo int64
+	eta := &batchETA{bar: bar}
+
+	// 批内去重：同一批中相同列的相同取值（如重复的模板文本）只转换一次，结果复用到其它行；每批清空，不占用额外的长期内存
+	type convResult struct {
+		out string
+		oc  ConvertOutcome
+		err error
+	}
+	memo := map[[2]string]convResult{}
+	var values, calls int64
+
+	var pending []pendingVerify // 本批已写入、待回读校验的行（Verify）
+	convert := func(c, v string) (string, ConvertOutcome, error) {
+		values++
+		k := [2]string{c, v}
+		if res, ok := memo[k]; ok {
+			return res.out, res.oc, res.err
+		}
+		calls++
+		out, oc, err := cfg.convertValue(c, v)
+		memo[k] = convResult{out, oc, err}
+		return out, oc, err
+	}
+
+	// 单行处理：转换 + 按主键 UPDATE + 推进进度；仅 StrictAffected 下影响行数异常时返回错误
+	handle := func(r row) error {
+		done++
+		cfg.throttle(rate)
+		cfg.rowScanned()
+
+		changed := map[string]string{}
+		failed := false
+		where := cfg.explainWhere(r.pk, 0)
+		for _, c := range cfg.Columns {
+			ptr := r.data[c]
+			if cfg.skipEmpty(ptr) {
+				cfg.explainSkip(where, c, emptyReason(ptr))
+				continue
+			}
+			out, oc, err := convert(c, *ptr)
+			if err != nil {
+				log.Printf("[mysql] convert err: %v", err)
+				cfg.Stats.recordConvertErr(err)
+				failed = true
+				continue
+			}
+			cfg.Stats.Record(oc)
+			cfg.explainSkip(where, c, oc.skipReason())
+			if oc == OutcomeConverted {
+				changed[c] = out
+				cfg.LengthReport.Observe(cfg.Table, c, *ptr, out)
+				cfg.TermReport.Observe(cfg.To, *ptr, out)
+			}
+		}
+
+		var key map[string]*string
+		if len(changed) > 0 && (cfg.ChangeLog != nil || cfg.Events != nil || cfg.Approved != nil) {
+			key = map[string]*string{}
+			for i, pk := range cfg.PK {
+				key[pk] = nullPtr(r.pk[i])
+			}
+			cfg.filterApproved(key, changed, where)
+		}
+		if len(changed) > 0 && cfg.DryRun && cfg.ChangeLog != nil {
+			for _, c := range cfg.Columns {
+				if v, ok := changed[c]; ok {
+					cfg.ChangeLog.RecordRow(cfg.Table, key, c, *r.data[c], v)
+				}
+			}
+		}
+		if len(changed) > 0 && cfg.Shadow {
+			if err := cfg.writeShadow(db, r.pk, changed); err != nil {
+				log.Printf("[mysql] 写入影子表失败 table=%s pk=%v：%v", cfg.Table, nullStrings(r.pk), err)
+				failed = true
+				changed = nil
+			}
+		}
+
+		if len(changed) > 0 && !cfg.DryRun {
+			// UPDATE SET … WHERE pk1=? AND pk2=? …
+			setParts := []string{}
+			args := []interface{}{}
+			for _, c := range cfg.Columns {
+				if v, ok := changed[c]; ok {
+					setParts = append(setParts, quoteIdent(c)+" = ?")
+					args = append(args, v)
+				}
+			}
+			where := []string{}
+			for i, pk := range cfg.PK {
+				if r.pk[i].Valid {
+					where = append(where, quoteIdent(pk)+" = ?")
+					args = append(args, r.pk[i].String)
+				} else {
+					where = append(where, quoteIdent(pk)+" IS NULL")
+				}
+			}
+			sqlText := fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteIdent(cfg.Table), strings.Join(setParts, ","), strings.Join(where, " AND "))
+
+			if cfg.RowBackup != nil {
+				var set, by []backupCond
+				for _, c := range cfg.Columns {
+					if _, ok := changed[c]; ok {
+						set = append(set, backupCond{c, r.data[c]})
+					}
+				}
+				for i, pk := range cfg.PK {
+					by = append(by, backupCond{pk, nullPtr(r.pk[i])})
+				}
+				if err := cfg.RowBackup.Restore(cfg.Table, set, by, false); err != nil {
+					return err
+				}
+			}
+			res, err := cfg.execUpdate(db, sqlText, args...)
+			if err != nil {
+				log.Printf("[mysql] update err: %v -- sql=%s -- args=%s", err, sqlText, logArgs(args))
+				failed = true
+				changed = nil
+			} else if err := checkAffected(cfg, res, "pk", args); err != nil {
+				return err
+			} else if cfg.Verify {
+				pending = append(pending, pendingVerify{pk: KeyTuple(nullStrings(r.pk)), changed: changed})
+			}
+		}
+		if err := cfg.recordRow(len(changed) > 0, failed); err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			cfg.Events.RowChanged(cfg.Table, key, changedColumns(cfg.Columns, changed), cfg.DryRun)
+		}
+
+		// 推进进度（行）：单表进度条在批末按实测耗时推进
+		eta.row()
+		cfg.aggregate.increment()
+		return nil
+	}
 
 	for {
+		// 批次边界检查中止：已处理的批次均已完整写入
+		if err := ctx.Err(); err != nil {
+			log.Printf("[mysql] 已中止 table=%s：已处理 %d 行，最后主键=%v（%v）", cfg.Table, done, nullStrings(lastKey), err)
+			return "", err
+		}
+		if err := cfg.errBudget.tripped(); err != nil {
+			return "", err // 其它表已熔断
+		}
+		eta.begin()
+		before := *cfg.counts
+
 		// SELECT
-		selectSQL := fmt.Sprintf("SELECT %s FROM `%s`", strings.Join(quoted, ","), cfg.Table)
+		selectSQL := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quoted, ","), cfg.source())
 		args := []interface{}{}
+		var conds []string
 		if anyValid(lastKey) {
 			ph := make([]string, len(cfg.PK))
 			for i := range ph {
 				ph[i] = "?"
 				args = append(args, nz(lastKey[i]))
 			}
-			selectSQL += fmt.Sprintf(" WHERE (%s) > (%s)", strings.Join(cfg.PK, ","), strings.Join(ph, ","))
+			conds = append(conds, fmt.Sprintf("(%s) > (%s)", strings.Join(quoteAll(cfg.PK), ","), strings.Join(ph, ",")))
+		}
+		if filter != "" {
+			conds = append(conds, filter)
+			args = append(args, filterArgs...)
+		}
+		if len(conds) > 0 {
+			selectSQL += " WHERE " + strings.Join(conds, " AND ")
 		}
-		selectSQL += fmt.Sprintf(" ORDER BY %s LIMIT ?", strings.Join(cfg.PK, ","))
+		selectSQL += fmt.Sprintf(" ORDER BY %s LIMIT ?", strings.Join(quoteAll(cfg.PK), ","))
 		args = append(args, cfg.BatchSize)
 
-		rows, err := db.Query(selectSQL, args...)
+		rows, err := queryRetry(ctx, cfg.reader(db), cfg, selectSQL, args...)
 		if err != nil {
-			log.Printf("[mysql] query err: %v, 5s 后重试…", err)
-			time.Sleep(5 * time.Second)
-			continue
+			return "", err
 		}
+		clear(memo)
 
 		n := 0
-		type row struct {
-			pk   []sql.NullString
-			data map[string]*string
-		}
 		var batch []row
+		var first, last row
 
 		for rows.Next() {
 			dst := make([]interface{}, len(cols))
@@ -181,6 +769,10 @@ func processWithPK(db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.
 			}
 			if err := rows.Scan(dst...); err != nil {
 				log.Printf("[mysql] scan err: %v", err)
+				if err := cfg.recordRow(false, true); err != nil {
+					rows.Close()
+					return "", err
+				}
 				continue
 			}
 			r := row{pk: make([]sql.NullString, len(cfg.PK)), data: map[string]*string{}}
@@ -196,8 +788,28 @@ func processWithPK(db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.
 					r.data[c] = nil
 				}
 			}
-			batch = append(batch, r)
+			if cfg.IncrementalColumn != "" {
+				mark = advanceWatermark(mark, *dst[len(cols)-1].(*sql.NullString))
+			}
+			if n == 0 {
+				first = r
+			}
+			last = r
 			n++
+
+			if cfg.StreamResults {
+				// 流式：边读边处理，不在客户端缓存整批
+				if bar != nil && total <= 0 {
+					bar.SetTotal(bar.Current()+1, false)
+					cfg.aggregate.addTotal(1)
+				}
+				if err := handle(r); err != nil {
+					rows.Close()
+					return "", err
+				}
+				continue
+			}
+			batch = append(batch, r)
 		}
 		rows.Close()
 
@@ -206,101 +818,133 @@ func processWithPK(db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.
 				// 补齐并标记完成
 				bar.SetTotal(bar.Current(), true)
 			}
-			log.Println("[mysql] 处理完成（无更多数据）")
-			return nil
+			if calls < values {
+				log.Printf("[mysql] 批内去重 table=%s：共 %d 个值，实际转换 %d 次", cfg.Table, values, calls)
+			}
+			log.Printf("[mysql] 处理完成（无更多数据） table=%s", cfg.Table)
+			return mark, nil
 		}
 
 		// 未知总量：按批动态扩充总量
-		if bar != nil && total <= 0 {
+		if bar != nil && total <= 0 && !cfg.StreamResults {
 			bar.SetTotal(bar.Current()+int64(n), false)
+			cfg.aggregate.addTotal(int64(n))
 		}
 
 		// 逐行处理
 		for _, r := range batch {
-			if rate != nil {
-				<-rate
-			}
-
-			changed := map[string]string{}
-			for _, c := range cfg.Columns {
-				ptr := r.data[c]
-				if ptr == nil || *ptr == "" {
-					continue
-				}
-				out, need, err := ConvertIfNeeded(cfg.To, *ptr)
-				if err != nil {
-					log.Printf("[mysql] convert err: %v", err)
-					continue
-				}
-				if need {
-					changed[c] = out
-				}
-			}
-
-			if len(changed) > 0 && !cfg.DryRun {
-				// UPDATE SET … WHERE pk1=? AND pk2=? …
-				setParts := []string{}
-				args := []interface{}{}
-				for _, c := range cfg.Columns {
-					if v, ok := changed[c]; ok {
-						setParts = append(setParts, fmt.Sprintf("`%s` = ?", c))
-						args = append(args, v)
-					}
-				}
-				where := []string{}
-				for i, pk := range cfg.PK {
-					if r.pk[i].Valid {
-						where = append(where, fmt.Sprintf("`%s` = ?", pk))
-						args = append(args, r.pk[i].String)
-					} else {
-						where = append(where, fmt.Sprintf("`%s` IS NULL", pk))
-					}
-				}
-				sqlText := fmt.Sprintf("UPDATE `%s` SET %s WHERE %s", cfg.Table, strings.Join(setParts, ","), strings.Join(where, " AND "))
-
-				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-				_, err := db.ExecContext(ctx, sqlText, args...)
-				cancel()
-				if err != nil {
-					log.Printf("[mysql] update err: %v -- sql=%s -- args=%v", err, sqlText, args)
-				}
-			}
-
-			// 推进进度（行）
-			if bar != nil {
-				bar.EwmaIncrement(1)
+			if err := handle(r); err != nil {
+				return "", err
 			}
 		}
 
 		// 记录 lastKey：取本批最后一行的主键值
-		last := batch[len(batch)-1]
 		for i := range cfg.PK {
 			lastKey[i] = last.pk[i]
 		}
+		if err := cfg.verifyRows(ctx, db, pending); err != nil {
+			return "", err
+		}
+		pending = pending[:0]
+		eta.end()
+		batchNo++
+		if err := cfg.batchDone(ctx, batchNo, before, done, first.pk, last.pk); err != nil {
+			return "", err
+		}
+		cfg.Events.BatchDone(cfg.Table, done, total)
 	}
 }
 
 // ---------- 无主键表：使用 identify-by 或整行匹配 ----------
-func processNoPK(db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.Bar, total int64) error {
+func processNoPK(ctx context.Context, db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.Bar, total int64) (string, error) {
 	log.Printf("[mysql] 开始处理（无主键） table=%s cols=%v identifyBy=%v", cfg.Table, cfg.Columns, cfg.IdentifyBy)
 
 	// 读取所有列名
-	allCols, err := getAllColumns(db, cfg.Table)
+	allCols, err := getAllColumns(cfg.reader(db), cfg.Table)
 	if err != nil {
-		return fmt.Errorf("获取列失败：%w", err)
+		return "", fmt.Errorf("获取列失败：%w", err)
 	}
 	if len(allCols) == 0 {
-		return fmt.Errorf("表 %s 无列", cfg.Table)
+		return "", fmt.Errorf("表 %s 无列", cfg.Table)
+	}
+	incIdx := -1
+	if cfg.IncrementalColumn != "" {
+		if incIdx = indexOf(allCols, cfg.IncrementalColumn); incIdx < 0 {
+			return "", fmt.Errorf("增量列 %s 不存在于表 %s", cfg.IncrementalColumn, cfg.Table)
+		}
+	}
+	filter, filterArgs := cfg.rowFilter()
+	var mark string
+
+	// 整行匹配：排除无法按字符串精确比较的列（近似数值、JSON、空间类型），时间列规范化后比较
+	var matchCols []int
+	types, err := getColumnTypes(cfg.reader(db), cfg.Table)
+	if err != nil {
+		return "", fmt.Errorf("读取列定义失败：%w", err)
+	}
+	uniques, uerr := uniqueIndexes(cfg.reader(db), cfg.Table, types)
+	if uerr != nil {
+		log.Printf("[mysql] 读取唯一索引失败：%v", uerr)
+	}
+	// guard：identify_by 未被唯一索引覆盖时，WHERE 追加待转换列的原值，
+	// 保证即使命中多行，这些行的转换结果也与本行完全相同
+	guard := false
+	if len(cfg.IdentifyBy) == 0 {
+		// 优先使用唯一索引作为隐式 identify_by
+		if k := pickUniqueKey(uniques); k != nil {
+			log.Printf("[mysql] 表 %s 无主键，使用唯一索引 %s%v 定位行", cfg.Table, k.Name, k.Columns)
+			cfg.IdentifyBy = k.Columns
+		}
+	} else if uerr != nil || !coveredByUnique(uniques, cfg.IdentifyBy) {
+		if cfg.IdentifyUnique {
+			return "", fmt.Errorf("identify_by %v 未被任何列均为 NOT NULL 的唯一索引覆盖（identify_unique=true）", cfg.IdentifyBy)
+		}
+		guard = true
+		log.Printf("[mysql] 警告：表 %s 的 identify_by %v 未被唯一索引覆盖，同一组取值可能对应多行；UPDATE 将追加待转换列原值作为条件，匹配多行时会记录日志", cfg.Table, cfg.IdentifyBy)
+	}
+	if len(cfg.IdentifyBy) == 0 {
+		var excluded []string
+		for i, c := range allCols {
+			if types[strings.ToLower(c)].unmatchable() {
+				excluded = append(excluded, c)
+				continue
+			}
+			matchCols = append(matchCols, i)
+		}
+		if len(matchCols) == 0 {
+			return "", fmt.Errorf("表 %s 无可用于整行匹配的列，请提供 identify_by", cfg.Table)
+		}
+		log.Printf("[mysql] 警告：表 %s 无主键且未提供 identify_by，将使用整行匹配（每次 UPDATE 需比较所有列，较慢且依赖各列取值能按字符串精确比较）；建议提供唯一列作为 identify_by", cfg.Table)
+		if len(excluded) > 0 {
+			log.Printf("[mysql] 整行匹配已排除无法精确比较的列：%v", excluded)
+		}
 	}
 
 	offset := 0
+	var batchNo int64
+	eta := &batchETA{bar: bar}
 	for {
-		selectSQL := fmt.Sprintf("SELECT %s FROM `%s` LIMIT ? OFFSET ?", strings.Join(quoteAll(allCols), ","), cfg.Table)
-		rows, err := db.Query(selectSQL, cfg.BatchSize, offset)
+		// 批次边界检查中止：已处理的批次均已完整写入
+		if err := ctx.Err(); err != nil {
+			log.Printf("[mysql] 已中止 table=%s：已处理 %d 行（offset）（%v）", cfg.Table, offset, err)
+			return "", err
+		}
+		if err := cfg.errBudget.tripped(); err != nil {
+			return "", err // 其它表已熔断
+		}
+		eta.begin()
+		before := *cfg.counts
+
+		selectSQL := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quoteAll(allCols), ","), quoteIdent(cfg.Table))
+		args := append([]interface{}{}, filterArgs...)
+		if filter != "" {
+			selectSQL += " WHERE " + filter
+		}
+		selectSQL += " LIMIT ? OFFSET ?"
+		args = append(args, cfg.BatchSize, offset)
+		rows, err := queryRetry(ctx, cfg.reader(db), cfg, selectSQL, args...)
 		if err != nil {
-			log.Printf("[mysql] query err: %v, 5s 后重试…", err)
-			time.Sleep(5 * time.Second)
-			continue
+			return "", err
 		}
 		n := 0
 
@@ -317,6 +961,10 @@ func processNoPK(db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.Ba
 			}
 			if err := rows.Scan(dst...); err != nil {
 				log.Printf("[mysql] scan err: %v", err)
+				if err := cfg.recordRow(false, true); err != nil {
+					rows.Close()
+					return "", err
+				}
 				continue
 			}
 			for i := 0; i < len(allCols); i++ {
@@ -328,24 +976,62 @@ func processNoPK(db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.Ba
 					rowVals[i] = nil
 				}
 			}
+			if incIdx >= 0 {
+				mark = advanceWatermark(mark, *dst[incIdx].(*sql.NullString))
+			}
 
 			// 组装需要转换的列
+			cfg.rowScanned()
 			changed := map[string]string{}
+			failed := false
+			where := cfg.explainWhere(nil, offset+n+1)
 			for _, c := range cfg.Columns {
 				idx := indexOf(allCols, c)
-				if idx < 0 || rowVals[idx] == nil || *rowVals[idx] == "" {
+				if idx < 0 {
 					continue
 				}
-				if rate != nil {
-					<-rate
+				if cfg.skipEmpty(rowVals[idx]) {
+					cfg.explainSkip(where, c, emptyReason(rowVals[idx]))
+					continue
 				}
-				out, need, err := ConvertIfNeeded(cfg.To, *rowVals[idx])
+				cfg.throttle(rate)
+				out, oc, err := cfg.convertValue(c, *rowVals[idx])
 				if err != nil {
 					log.Printf("[mysql] convert err: %v", err)
+					cfg.Stats.recordConvertErr(err)
+					failed = true
 					continue
 				}
-				if need {
+				cfg.Stats.Record(oc)
+				cfg.explainSkip(where, c, oc.skipReason())
+				if oc == OutcomeConverted {
 					changed[c] = out
+					cfg.LengthReport.Observe(cfg.Table, c, *rowVals[idx], out)
+					cfg.TermReport.Observe(cfg.To, *rowVals[idx], out)
+				}
+			}
+
+			var key map[string]*string
+			if len(changed) > 0 && (cfg.ChangeLog != nil || cfg.Events != nil || cfg.Approved != nil) {
+				// 定位列：identify-by，否则为整行匹配所用的列
+				key = map[string]*string{}
+				for _, col := range cfg.IdentifyBy {
+					if idx := indexOf(allCols, col); idx >= 0 {
+						key[col] = rowVals[idx]
+					}
+				}
+				if len(cfg.IdentifyBy) == 0 {
+					for _, i := range matchCols {
+						key[allCols[i]] = rowVals[i]
+					}
+				}
+				cfg.filterApproved(key, changed, where)
+			}
+			if len(changed) > 0 && cfg.DryRun && cfg.ChangeLog != nil {
+				for _, c := range cfg.Columns {
+					if v, ok := changed[c]; ok {
+						cfg.ChangeLog.RecordRow(cfg.Table, key, c, *rowVals[indexOf(allCols, c)], v)
+					}
 				}
 			}
 
@@ -357,7 +1043,7 @@ func processNoPK(db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.Ba
 				setParts := []string{}
 				for _, c := range cfg.Columns {
 					if v, ok := changed[c]; ok {
-						setParts = append(setParts, fmt.Sprintf("`%s` = ?", c))
+						setParts = append(setParts, quoteIdent(c)+" = ?")
 						args = append(args, v)
 					}
 				}
@@ -369,42 +1055,74 @@ func processNoPK(db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.Ba
 							continue
 						}
 						if rowVals[idx] == nil {
-							where = append(where, fmt.Sprintf("`%s` IS NULL", col))
+							where = append(where, quoteIdent(col)+" IS NULL")
 						} else {
-							where = append(where, fmt.Sprintf("`%s` = ?", col))
+							where = append(where, quoteIdent(col)+" = ?")
 							args = append(args, *rowVals[idx])
 						}
 					}
+					if guard {
+						for _, c := range cfg.Columns {
+							if _, ok := changed[c]; ok {
+								where = append(where, quoteIdent(c)+" = ?")
+								args = append(args, *rowVals[indexOf(allCols, c)])
+							}
+						}
+					}
 				} else {
 					// 整行匹配（可能较慢），并加 LIMIT 1
-					for i, col := range allCols {
+					for _, i := range matchCols {
+						col := allCols[i]
 						if rowVals[i] == nil {
-							where = append(where, fmt.Sprintf("`%s` IS NULL", col))
+							where = append(where, quoteIdent(col)+" IS NULL")
 						} else {
-							where = append(where, fmt.Sprintf("`%s` = ?", col))
-							args = append(args, *rowVals[i])
+							where = append(where, quoteIdent(col)+" = ?")
+							v := *rowVals[i]
+							if types[strings.ToLower(col)].isTemporal() {
+								v = normalizeTimeString(v)
+							}
+							args = append(args, v)
 						}
 					}
 				}
 
-				sqlText := fmt.Sprintf("UPDATE `%s` SET %s WHERE %s", cfg.Table, strings.Join(setParts, ","), strings.Join(where, " AND "))
+				sqlText := fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteIdent(cfg.Table), strings.Join(setParts, ","), strings.Join(where, " AND "))
 				if len(cfg.IdentifyBy) == 0 {
 					sqlText += " LIMIT 1"
 				}
 
-				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-				_, err := db.ExecContext(ctx, sqlText, args...)
-				cancel()
+				if cfg.RowBackup != nil {
+					set, by, one := noPKRestore(cfg, allCols, rowVals, matchCols, types, changed, guard)
+					if err := cfg.RowBackup.Restore(cfg.Table, set, by, one); err != nil {
+						rows.Close()
+						return "", err
+					}
+				}
+
+				res, err := cfg.execUpdate(db, sqlText, args...)
 				if err != nil {
-					log.Printf("[mysql] update err: %v -- sql=%s -- args=%v", err, sqlText, args)
+					log.Printf("[mysql] update err: %v -- sql=%s -- args=%s", err, sqlText, logArgs(args))
+					failed = true
+					changed = nil
+				} else if len(cfg.IdentifyBy) > 0 {
+					if err := checkAffected(cfg, res, "identify_by", args); err != nil {
+						rows.Close()
+						return "", err
+					}
 				}
 			}
-
-			// 推进进度（行）
-			if bar != nil {
-				bar.EwmaIncrement(1)
+			if err := cfg.recordRow(len(changed) > 0, failed); err != nil {
+				rows.Close()
+				return "", err
+			}
+			if len(changed) > 0 {
+				cfg.Events.RowChanged(cfg.Table, key, changedColumns(cfg.Columns, changed), cfg.DryRun)
 			}
 
+			// 推进进度（行）：单表进度条在批末按实测耗时推进
+			eta.row()
+			cfg.aggregate.increment()
+
 			n++
 		}
 		rows.Close()
@@ -413,17 +1131,238 @@ func processNoPK(db *sql.DB, cfg MySQLConfig, rate <-chan time.Time, bar *mpb.Ba
 			if bar != nil {
 				bar.SetTotal(bar.Current(), true)
 			}
-			log.Println("[mysql] 处理完成（无更多数据）")
-			return nil
+			log.Printf("[mysql] 处理完成（无更多数据） table=%s", cfg.Table)
+			return mark, nil
 		}
 
 		// 未知总量：按批动态扩充总量
 		if bar != nil && total <= 0 {
 			bar.SetTotal(bar.Current()+int64(n), false)
+			cfg.aggregate.addTotal(int64(n))
 		}
 
+		eta.end()
 		offset += n
+		batchNo++
+		if err := cfg.batchDone(ctx, batchNo, before, int64(offset), nil, nil); err != nil {
+			return "", err
+		}
+		cfg.Events.BatchDone(cfg.Table, int64(offset), total)
+	}
+}
+
+// newRateTicker 每秒 rps 次的限速 ticker（间隔至少 1ms）
+func newRateTicker(rps int) *time.Ticker {
+	interval := time.Second / time.Duration(rps)
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	return time.NewTicker(interval)
+}
+
+// throttle 依次等待该表的 rps 令牌与各表共享的 global_rps 令牌（两者均为 nil 时立即返回）
+func (c MySQLConfig) throttle(rate <-chan time.Time) {
+	if rate != nil {
+		<-rate
+	}
+	if c.globalRate != nil {
+		<-c.globalRate
+	}
+}
+
+// execUpdate 执行单行 UPDATE（10s 超时）；设置了 global_max_inflight 时先占用共享预算，排队时间不计入超时
+func (c MySQLConfig) execUpdate(db *sql.DB, query string, args ...interface{}) (sql.Result, error) {
+	if c.inflight != nil {
+		c.inflight <- struct{}{}
+		defer func() { <-c.inflight }()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.checkPacket(query, args); err != nil {
+		return nil, classify(ErrRowUpdate, err)
+	}
+	defer c.Stats.addTime(timeSQLExec, c.Stats.startTimer())
+	res, err := db.ExecContext(ctx, query, args...)
+	return res, classify(ErrRowUpdate, err)
+}
+
+// checkAffected 按键定位的 UPDATE 应至多影响 1 行，超过说明 pk/identify_by 配置有误（或 identify_by 非唯一）。
+// 注意 MySQL 默认返回“实际改变”的行数，已等于新值的行不计入
+func checkAffected(cfg MySQLConfig, res sql.Result, by string, args []interface{}) error {
+	n, err := res.RowsAffected()
+	if err != nil || n <= 1 {
+		return nil
+	}
+	cfg.Stats.RecordUnexpectedAffected()
+	if cfg.StrictAffected {
+		return classify(ErrRowUpdate, fmt.Errorf("按 %s 定位的 UPDATE 影响了 %d 行（期望 1），请检查该配置（strict_affected） -- args=%v", by, n, args))
+	}
+	log.Printf("[mysql] 警告：table=%s 按 %s 定位的 UPDATE 影响了 %d 行（期望 1），请检查该配置 -- args=%v", cfg.Table, by, n, args)
+	return nil
+}
+
+// rowScanned 记录读取到一行
+func (c MySQLConfig) rowScanned() {
+	c.Metrics.Scanned(c.Table)
+	if c.counts != nil {
+		c.counts.scanned++
+	}
+}
+
+// recordRow 按行记录指标：UPDATE 失败的行只计入 failed；失败行累计超过 max_errors 时返回 ErrTooManyErrors
+func (c MySQLConfig) recordRow(changed, failed bool) error {
+	if changed {
+		c.Metrics.Changed(c.Table)
+	}
+	if failed {
+		c.Metrics.Failed(c.Table)
+	}
+	if c.counts != nil {
+		if changed {
+			c.counts.changed++
+		}
+		if failed {
+			c.counts.failed++
+		}
+	}
+	if failed {
+		return c.errBudget.add(1)
+	}
+	return nil
+}
+
+// batchDone 真实写入时在批末调用 BatchHook；before 为本批开始时的行计数，from/to 为本批首末行主键（无主键表为 nil）
+func (c MySQLConfig) batchDone(ctx context.Context, batch int64, before tableMetrics, done int64, from, to []sql.NullString) error {
+	if c.BatchHook == nil || c.DryRun {
+		return nil
+	}
+	b := BatchInfo{
+		Table:   c.Table,
+		Batch:   batch,
+		Rows:    c.counts.scanned - before.scanned,
+		Changed: c.counts.changed - before.changed,
+		Failed:  c.counts.failed - before.failed,
+		Done:    done,
+	}
+	if len(from) > 0 {
+		b.PKFrom, b.PKTo = nullStrings(from), nullStrings(to)
 	}
+	return c.BatchHook.batchDone(ctx, b)
+}
+
+// filterApproved 去掉未批准的列变更（未设置 Approved 时不做处理）
+func (c MySQLConfig) filterApproved(key map[string]*string, changed map[string]string, where string) {
+	if c.Approved == nil {
+		return
+	}
+	for col := range changed {
+		if !c.Approved.Allows(c.Table, key, col) {
+			delete(changed, col)
+			c.explainSkip(where, col, skipNotApproved)
+		}
+	}
+}
+
+// changedColumns 按配置顺序返回发生转换的列
+func changedColumns(columns []string, changed map[string]string) []string {
+	var out []string
+	for _, c := range columns {
+		if _, ok := changed[c]; ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// 校验自定义 select_sql 的投影：必须依次为 PK 列 + 待转换列（列名不区分大小写）
+func checkSelectProjection(db *sql.DB, cfg MySQLConfig) error {
+	rows, err := db.Query("SELECT * FROM " + cfg.source() + " LIMIT 0")
+	if err != nil {
+		return fmt.Errorf("select_sql 无法执行：%w", err)
+	}
+	defer rows.Close()
+	got, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("select_sql 读取列失败：%w", err)
+	}
+	want := append(append([]string{}, cfg.PK...), cfg.Columns...)
+	match := len(got) == len(want)
+	for i := 0; match && i < len(want); i++ {
+		match = strings.EqualFold(got[i], want[i])
+	}
+	if !match {
+		return fmt.Errorf("select_sql 投影不匹配：期望 %v（pk + columns），实际 %v", want, got)
+	}
+	return nil
+}
+
+// uniqueIndex 表上的唯一索引；Usable 表示所有列均 NOT NULL（允许 NULL 的唯一索引可能有多行同为 NULL）、
+// 非函数索引且可精确比较，可安全用于定位行
+type uniqueIndex struct {
+	Name    string
+	Columns []string
+	Usable  bool
+}
+
+// uniqueIndexes 按索引名顺序列出表的唯一索引（含 PRIMARY）
+func uniqueIndexes(db *sql.DB, table string, types map[string]columnType) ([]uniqueIndex, error) {
+	q := `SELECT INDEX_NAME, COLUMN_NAME FROM information_schema.statistics
+	      WHERE table_schema = DATABASE() AND table_name = ? AND NON_UNIQUE = 0
+	      ORDER BY INDEX_NAME, SEQ_IN_INDEX`
+	rows, err := db.Query(q, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []uniqueIndex
+	for rows.Next() {
+		var name string
+		var col sql.NullString
+		if err := rows.Scan(&name, &col); err != nil {
+			return nil, err
+		}
+		if len(out) == 0 || out[len(out)-1].Name != name {
+			out = append(out, uniqueIndex{Name: name, Usable: true})
+		}
+		cur := &out[len(out)-1]
+		t, ok := types[strings.ToLower(col.String)]
+		if !col.Valid || !ok || t.Nullable || t.unmatchable() {
+			cur.Usable = false
+		}
+		cur.Columns = append(cur.Columns, col.String)
+	}
+	return out, rows.Err()
+}
+
+// pickUniqueKey 从可用唯一索引中取列数最少者（同列数按索引名）；无候选时返回 nil
+func pickUniqueKey(indexes []uniqueIndex) *uniqueIndex {
+	var best *uniqueIndex
+	for i := range indexes {
+		if indexes[i].Usable && (best == nil || len(indexes[i].Columns) < len(best.Columns)) {
+			best = &indexes[i]
+		}
+	}
+	return best
+}
+
+// coveredByUnique 判断 cols 是否包含某个可用唯一索引的全部列（即按 cols 定位至多命中一行）
+func coveredByUnique(indexes []uniqueIndex, cols []string) bool {
+	for _, idx := range indexes {
+		if !idx.Usable {
+			continue
+		}
+		covered := true
+		for _, c := range idx.Columns {
+			if indexOfFold(cols, c) < 0 {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
 }
 
 func getAllColumns(db *sql.DB, table string) ([]string, error) {
@@ -446,10 +1385,113 @@ func getAllColumns(db *sql.DB, table string) ([]string, error) {
 	return cols, nil
 }
 
+// columnType information_schema.columns 中与长度相关的列定义
+type columnType struct {
+	Name     string
+	DataType string // varchar / text / ...（小写）
+	MaxChars int64  // CHARACTER_MAXIMUM_LENGTH，非字符类型为 0
+	MaxBytes int64  // CHARACTER_OCTET_LENGTH，非字符类型为 0
+	Charset  string
+
+	// 以下仅用于生成 MODIFY COLUMN（保留原列定义）
+	Nullable  bool
+	Default   sql.NullString
+	Collation string
+	Comment   string
+
+	ColumnType string   // 完整列类型（COLUMN_TYPE），如 set('a','b')
+	Members    []string // SET/ENUM 列允许的成员
+	Extra      string   // EXTRA，如 auto_increment、VIRTUAL GENERATED（非空时不自动生成 MODIFY COLUMN）
+}
+
+// isTextFamily TEXT 系列按字节限制长度，CHAR/VARCHAR 按字符
+func (t columnType) isTextFamily() bool {
+	return strings.HasSuffix(t.DataType, "text")
+}
+
+// exceeds 判断写入 v 是否会超过列上限（未知上限返回 false）
+func (t columnType) exceeds(v string) bool {
+	if t.isTextFamily() {
+		return t.MaxBytes > 0 && int64(byteLenForCharset(v, t.Charset)) > t.MaxBytes
+	}
+	return t.MaxChars > 0 && int64(utf8.RuneCountInString(v)) > t.MaxChars
+}
+
+// unmatchable 近似数值、JSON、空间类型无法与读出的字符串可靠地按 = 比较
+func (t columnType) unmatchable() bool {
+	switch t.DataType {
+	case "float", "double", "real", "json",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection", "geomcollection":
+		return true
+	}
+	return false
+}
+
+// isTemporal 时间类型：parseTime=true 时读出的是 RFC3339 字符串，比较前需转回 MySQL 格式
+func (t columnType) isTemporal() bool {
+	switch t.DataType {
+	case "date", "datetime", "timestamp":
+		return true
+	}
+	return false
+}
+
+func (t columnType) limitString() string {
+	switch {
+	case t.isTextFamily() && t.MaxBytes > 0:
+		return fmt.Sprintf("%s(%d 字节)", t.DataType, t.MaxBytes)
+	case t.MaxChars > 0:
+		return fmt.Sprintf("%s(%d)", t.DataType, t.MaxChars)
+	case t.DataType != "":
+		return t.DataType
+	}
+	return "-"
+}
+
+// getColumnTypes 读取表的列定义（key 为小写列名）
+func getColumnTypes(db *sql.DB, table string) (map[string]columnType, error) {
+	q := `SELECT COLUMN_NAME, DATA_TYPE, CHARACTER_MAXIMUM_LENGTH, CHARACTER_OCTET_LENGTH, CHARACTER_SET_NAME,
+	             IS_NULLABLE, COLUMN_DEFAULT, COLLATION_NAME, COLUMN_COMMENT, COLUMN_TYPE, EXTRA
+	      FROM information_schema.columns
+	      WHERE table_schema = DATABASE() AND table_name = ?`
+	rows, err := db.Query(q, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]columnType{}
+	for rows.Next() {
+		var (
+			t                  columnType
+			maxChars, maxBytes sql.NullInt64
+			charset, collation sql.NullString
+			nullable, comment  string
+			extra              sql.NullString
+		)
+		if err := rows.Scan(&t.Name, &t.DataType, &maxChars, &maxBytes, &charset,
+			&nullable, &t.Default, &collation, &comment, &t.ColumnType, &extra); err != nil {
+			return nil, err
+		}
+		t.DataType = strings.ToLower(t.DataType)
+		t.MaxChars, t.MaxBytes, t.Charset = maxChars.Int64, maxBytes.Int64, charset.String
+		t.Nullable, t.Collation, t.Comment = nullable == "YES", collation.String, comment
+		t.Members = parseSetMembers(t.ColumnType)
+		t.Extra = extra.String
+		out[strings.ToLower(t.Name)] = t
+	}
+	return out, rows.Err()
+}
+
+// quoteIdent 以反引号引用标识符（内部的反引号写作两个）。反引号在任何 sql_mode 下都有效（ANSI_QUOTES 只是让双引号
+// 也可引用标识符），因此不随 sql_mode 切换引号，见 README“sql_mode 与标识符引用”
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
 func quoteAll(cols []string) []string {
 	out := make([]string, len(cols))
 	for i, c := range cols {
-		out[i] = fmt.Sprintf("`%s`", c)
+		out[i] = quoteIdent(c)
 	}
 	return out
 }
@@ -463,6 +1505,15 @@ func indexOf(arr []string, s string) int {
 	return -1
 }
 
+// skipEmpty 判断列值是否跳过：NULL 与空串始终跳过（NULL 不会被写成空串），
+// TreatWhitespaceEmpty 时只含空白字符的值也跳过
+func (c MySQLConfig) skipEmpty(v *string) bool {
+	if v == nil || *v == "" {
+		return true
+	}
+	return c.TreatWhitespaceEmpty && strings.TrimSpace(*v) == ""
+}
+
 func anyValid(keys []sql.NullString) bool {
 	for _, k := range keys {
 		if k.Valid {
@@ -472,6 +1523,27 @@ func anyValid(keys []sql.NullString) bool {
 	return false
 }
 
+// nullStrings 用于日志展示主键值（NULL 显示为 NULL）
+func nullStrings(keys []sql.NullString) []string {
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		if k.Valid {
+			out[i] = k.String
+		} else {
+			out[i] = "NULL"
+		}
+	}
+	return out
+}
+
+func nullPtr(ns sql.NullString) *string {
+	if !ns.Valid {
+		return nil
+	}
+	v := ns.String
+	return &v
+}
+
 func nz(ns sql.NullString) string {
 	if ns.Valid {
 		return ns.String
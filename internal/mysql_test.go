@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+// newMock 返回按正则匹配 SQL 的 mock 连接池，测试结束时关闭
+func newMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+func TestReadDSNRoutesQueries(t *testing.T) {
+	primary, writes := newMock(t)
+	replica, reads := newMock(t)
+	cfg := MySQLConfig{
+		Table: "t", PK: []string{"id"}, Columns: []string{"name"}, To: "s2t", BatchSize: 10, Verify: true,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond,
+		counts: &tableMetrics{}, readDB: replica,
+	}
+
+	// 批次 SELECT 走副本
+	reads.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` ORDER BY `id` LIMIT ?")).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "简体").AddRow("2", "hello"))
+	reads.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE (`id`) > (?) ORDER BY `id` LIMIT ?")).
+		WithArgs("2", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	// UPDATE 与写后校验走主库
+	writes.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).
+		WithArgs("簡體", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	writes.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "簡體"))
+
+	if _, err := processWithPK(context.Background(), primary, cfg, nil, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := reads.ExpectationsWereMet(); err != nil {
+		t.Errorf("replica: %v", err)
+	}
+	if err := writes.ExpectationsWereMet(); err != nil {
+		t.Errorf("primary: %v", err)
+	}
+	if cfg.counts.changed != 1 || cfg.counts.scanned != 2 {
+		t.Errorf("counts = %+v", *cfg.counts)
+	}
+}
+
+func TestSchemaDSN(t *testing.T) {
+	c := &MySQLFileConfig{DSN: "primary"}
+	if got := c.schemaDSN(); got != "primary" {
+		t.Errorf("schemaDSN = %q", got)
+	}
+	c.ReadDSN = "replica"
+	if got := c.schemaDSN(); got != "replica" {
+		t.Errorf("schemaDSN with read_dsn = %q", got)
+	}
+}
+
+func TestTuneDSNIsolation(t *testing.T) {
+	for _, level := range []string{"read committed", "READ_COMMITTED", "Read-Committed", " read  committed "} {
+		dsn, err := tuneDSN(MySQLConfig{Isolation: level}, "u:p@tcp(127.0.0.1:3306)/db")
+		if err != nil {
+			t.Fatalf("%q: %v", level, err)
+		}
+		if !strings.Contains(dsn, "transaction_isolation=%27READ-COMMITTED%27") {
+			t.Errorf("%q: dsn = %s", level, dsn)
+		}
+		dc, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := dc.Params["transaction_isolation"]; got != "'READ-COMMITTED'" {
+			t.Errorf("%q: transaction_isolation = %s", level, got)
+		}
+	}
+	dsn, err := tuneDSN(MySQLConfig{}, "u:p@tcp(127.0.0.1:3306)/db")
+	if err != nil || strings.Contains(dsn, "transaction_isolation") {
+		t.Errorf("no isolation: dsn = %s, %v", dsn, err)
+	}
+}
+
+func TestParseIsolation(t *testing.T) {
+	for in, want := range map[string]string{
+		"repeatable read":  "REPEATABLE-READ",
+		"READ-UNCOMMITTED": "READ-UNCOMMITTED",
+		"serializable":     "SERIALIZABLE",
+	} {
+		if got, err := parseIsolation(in); err != nil || got != want {
+			t.Errorf("parseIsolation(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "read", "committed read", "snapshot", "READ COMMITTED'; DROP TABLE t; --"} {
+		if _, err := parseIsolation(in); err == nil {
+			t.Errorf("parseIsolation(%q) should fail", in)
+		}
+	}
+	if _, err := tuneDSN(MySQLConfig{Isolation: "snapshot"}, "u:p@tcp(127.0.0.1:3306)/db"); err == nil {
+		t.Error("tuneDSN should reject an invalid isolation")
+	}
+	cfg := MySQLFileConfig{DSN: "u:p@tcp(127.0.0.1:3306)/db", Isolation: "snapshot", Tables: []MySQLTblEntry{{Table: "t", PK: []string{"id"}, Columns: []string{"c"}}}}
+	if err := cfg.Validate(); !errors.Is(err, ErrConfigInvalid) {
+		t.Errorf("Validate: err = %v, want ErrConfigInvalid", err)
+	}
+}
+
+// 主机被黑洞时 Ping 一直挂起：超时后返回可读的连接超时错误，归类为 ErrDBConnect
+func TestPingTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectPing().WillDelayFor(time.Second)
+
+	start := time.Now()
+	err = pingTimeout(context.Background(), db, 20*time.Millisecond)
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("ping did not time out early: %s", time.Since(start))
+	}
+	if !errors.Is(err, ErrDBConnect) || !strings.Contains(err.Error(), "连接数据库超时（20ms）") {
+		t.Errorf("err = %v, want ErrDBConnect timeout", err)
+	}
+
+	mock.ExpectPing().WillReturnError(errors.New("access denied"))
+	if err := pingTimeout(context.Background(), db, time.Second); !errors.Is(err, ErrDBConnect) || !strings.Contains(err.Error(), "db ping: access denied") {
+		t.Errorf("ping failure err = %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// colDef 测试表的列定义：name 列名，typ 为 DATA_TYPE，notNull 对应 IS_NULLABLE=NO
+type colDef struct {
+	name, typ string
+	notNull   bool
+}
+
+// expectNoPKSchema 依次期望 processNoPK 读取列名、列定义与唯一索引（uniques 为 [索引名, 列名] 按序排列）
+func expectNoPKSchema(mock sqlmock.Sqlmock, table string, cols []colDef, uniques ...[2]string) {
+	names := sqlmock.NewRows([]string{"COLUMN_NAME"})
+	types := sqlmock.NewRows([]string{"COLUMN_NAME", "DATA_TYPE", "CHARACTER_MAXIMUM_LENGTH", "CHARACTER_OCTET_LENGTH", "CHARACTER_SET_NAME",
+		"IS_NULLABLE", "COLUMN_DEFAULT", "COLLATION_NAME", "COLUMN_COMMENT", "COLUMN_TYPE", "EXTRA"})
+	for _, c := range cols {
+		names.AddRow(c.name)
+		nullable := "YES"
+		if c.notNull {
+			nullable = "NO"
+		}
+		types.AddRow(c.name, c.typ, nil, nil, nil, nullable, nil, nil, "", c.typ, "")
+	}
+	idx := sqlmock.NewRows([]string{"INDEX_NAME", "COLUMN_NAME"})
+	for _, u := range uniques {
+		idx.AddRow(u[0], u[1])
+	}
+	mock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.columns").WithArgs(table).WillReturnRows(names)
+	mock.ExpectQuery("SELECT COLUMN_NAME, DATA_TYPE").WithArgs(table).WillReturnRows(types)
+	mock.ExpectQuery("NON_UNIQUE = 0").WithArgs(table).WillReturnRows(idx)
+}
+
+// noPKConfig 返回直接调用 processNoPK 所需的最小配置
+func noPKConfig(table string, columns ...string) MySQLConfig {
+	return MySQLConfig{
+		Table: table, Columns: columns, To: "s2t", BatchSize: 10,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{},
+	}
+}
+
+// 整行匹配排除 FLOAT 列：读出的 "1.1" 与存储的近似值按 = 比较永远不等，包含它会使 UPDATE 匹配不到行
+func TestNoPKWholeRowSkipsFloat(t *testing.T) {
+	db, mock := newMock(t)
+	expectNoPKSchema(mock, "logs", []colDef{{"name", "varchar", false}, {"score", "float", false}, {"created", "datetime", false}})
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `name`,`score`,`created` FROM `logs` LIMIT ? OFFSET ?")).WithArgs(10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "score", "created"}).AddRow("简体", "1.1", "2024-01-02T03:04:05Z"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `logs` SET `name` = ? WHERE `name` = ? AND `created` = ? LIMIT 1")).
+		WithArgs("簡體", "简体", "2024-01-02 03:04:05").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `name`,`score`,`created` FROM `logs` LIMIT ? OFFSET ?")).WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "score", "created"}))
+
+	var err error
+	out := captureLog(t, func() { _, err = processNoPK(context.Background(), db, noPKConfig("logs", "name"), nil, nil, 0) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "整行匹配已排除无法精确比较的列：[score]") {
+		t.Errorf("missing exclusion log:\n%s", out)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// 无主键但有 NOT NULL 的唯一索引：自动用其列定位行，UPDATE 不再整行匹配、不加 LIMIT 1
+func TestNoPKUsesUniqueIndex(t *testing.T) {
+	db, mock := newMock(t)
+	expectNoPKSchema(mock, "users", []colDef{{"email", "varchar", true}, {"name", "varchar", false}, {"bio", "text", false}},
+		[2]string{"uk_email", "email"})
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `email`,`name`,`bio` FROM `users` LIMIT ? OFFSET ?")).WithArgs(10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "name", "bio"}).AddRow("a@x.com", "简体", "hello"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `name` = ? WHERE `email` = ?")+"$").
+		WithArgs("簡體", "a@x.com").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `email`,`name`,`bio` FROM `users` LIMIT ? OFFSET ?")).WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "name", "bio"}))
+
+	var err error
+	out := captureLog(t, func() { _, err = processNoPK(context.Background(), db, noPKConfig("users", "name"), nil, nil, 0) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "使用唯一索引 uk_email[email] 定位行") || strings.Contains(out, "整行匹配") {
+		t.Errorf("unexpected log:\n%s", out)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// identify_by 未被唯一索引覆盖：identify_unique 时直接拒绝；否则 UPDATE 追加待转换列原值，影响多行时告警
+func TestNoPKNonUniqueIdentifyBy(t *testing.T) {
+	cols := []colDef{{"name", "varchar", true}, {"bio", "text", false}}
+
+	db, mock := newMock(t)
+	expectNoPKSchema(mock, "people", cols)
+	cfg := noPKConfig("people", "bio")
+	cfg.IdentifyBy, cfg.IdentifyUnique = []string{"name"}, true
+	if _, err := processNoPK(context.Background(), db, cfg, nil, nil, 0); err == nil || !strings.Contains(err.Error(), "identify_unique=true") {
+		t.Errorf("identify_unique err = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	db, mock = newMock(t)
+	expectNoPKSchema(mock, "people", cols)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `name`,`bio` FROM `people` LIMIT ? OFFSET ?")).WithArgs(10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "bio"}).AddRow("张三", "简体"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `people` SET `bio` = ? WHERE `name` = ? AND `bio` = ?")+"$").
+		WithArgs("簡體", "张三", "简体").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `name`,`bio` FROM `people` LIMIT ? OFFSET ?")).WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "bio"}))
+	cfg.IdentifyUnique = false
+	var err error
+	out := captureLog(t, func() { _, err = processNoPK(context.Background(), db, cfg, nil, nil, 0) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"未被唯一索引覆盖", "按 identify_by 定位的 UPDATE 影响了 2 行"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,215 @@
+package internal
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// officeKind 按扩展名判断 Office 文档类型：docx / xlsx，其它文件返回空串
+func officeKind(p string) string {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".docx":
+		return "docx"
+	case ".xlsx":
+		return "xlsx"
+	}
+	return ""
+}
+
+// officeTextPart 需要转换文本的部件：docx 为正文、页眉页脚与脚注尾注（<w:t>），xlsx 为共享字符串表（<t>）。
+// 工作表中的内联字符串、批注、图表等其它部件原样保留
+func officeTextPart(kind, name string) bool {
+	switch kind {
+	case "docx":
+		if name == "word/document.xml" || name == "word/footnotes.xml" || name == "word/endnotes.xml" {
+			return true
+		}
+		if dir, base := path.Split(name); dir == "word/" && path.Ext(base) == ".xml" {
+			return strings.HasPrefix(base, "header") || strings.HasPrefix(base, "footer")
+		}
+	case "xlsx":
+		return name == "xl/sharedStrings.xml"
+	}
+	return false
+}
+
+// convertOffice 转换 Office 文档（zip 容器）中文本节点的内容并重新打包：
+// 其它部件按原压缩数据复制，部件顺序、压缩方式与修改时间不变；没有任何文本变化时原样返回
+func convertOffice(kind string, opts ConvertOptions, in string) (string, ConvertOutcome, error) {
+	zr, err := zip.NewReader(strings.NewReader(in), int64(len(in)))
+	if err != nil {
+		return "", OutcomeUnchanged, fmt.Errorf("无法作为 %s 读取（不是有效的 zip 容器）：%w", kind, err)
+	}
+	converted := map[string]string{}
+	for _, f := range zr.File {
+		if !officeTextPart(kind, f.Name) {
+			continue
+		}
+		xml, err := readZipFile(f)
+		if err != nil {
+			return "", OutcomeUnchanged, err
+		}
+		out, oc, err := convertSpans(xml, xmlTextSpans(xml), func(v string) (string, ConvertOutcome, error) {
+			return convertXMLText(opts, v)
+		})
+		if err != nil {
+			return "", OutcomeUnchanged, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if oc == OutcomeConverted {
+			converted[f.Name] = out
+		}
+	}
+	if len(converted) == 0 {
+		return in, OutcomeUnchanged, nil
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	zw.SetComment(zr.Comment)
+	for _, f := range zr.File {
+		out, ok := converted[f.Name]
+		if !ok {
+			if err := zw.Copy(f); err != nil {
+				return "", OutcomeUnchanged, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			continue
+		}
+		fh := f.FileHeader
+		fh.CRC32, fh.CompressedSize, fh.UncompressedSize, fh.CompressedSize64, fh.UncompressedSize64 = 0, 0, 0, 0, 0
+		w, err := zw.CreateHeader(&fh)
+		if err != nil {
+			return "", OutcomeUnchanged, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if _, err := io.WriteString(w, out); err != nil {
+			return "", OutcomeUnchanged, fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", OutcomeUnchanged, err
+	}
+	return buf.String(), OutcomeConverted, nil
+}
+
+func readZipFile(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	bs, err := io.ReadAll(rc)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return string(bs), nil
+}
+
+// xmlTextSpans 返回局部名为 t 的元素（<w:t>、<t>）文本内容在 s 中的字节区间。
+// 只做定位所需的最小扫描，不解析整个文档，其余字节（声明、命名空间、格式属性）原样保留
+func xmlTextSpans(s string) [][2]int {
+	var spans [][2]int
+	for i := 0; i < len(s); {
+		j := strings.IndexByte(s[i:], '<')
+		if j < 0 {
+			break
+		}
+		j += i
+		k := strings.IndexByte(s[j:], '>')
+		if k < 0 {
+			break
+		}
+		k += j
+		tag := s[j+1 : k]
+		i = k + 1
+		if tag == "" || strings.ContainsAny(tag[:1], "/?!") || strings.HasSuffix(tag, "/") {
+			continue
+		}
+		name := tag
+		if sp := strings.IndexAny(name, " \t\r\n"); sp >= 0 {
+			name = name[:sp]
+		}
+		if name[strings.IndexByte(name, ':')+1:] != "t" {
+			continue
+		}
+		end := strings.Index(s[i:], "</"+name+">")
+		if end < 0 {
+			break
+		}
+		spans = append(spans, [2]int{i, i + end})
+		i += end + len(name) + 3
+	}
+	return spans
+}
+
+// convertXMLText 转换一个文本节点：先还原实体再转换，结果按 XML 文本重新转义（规范化可能产生 < 或 &）
+func convertXMLText(opts ConvertOptions, raw string) (string, ConvertOutcome, error) {
+	text := raw
+	if strings.IndexByte(raw, '&') >= 0 {
+		text = html.UnescapeString(raw)
+	}
+	out, oc, err := ConvertDetail(opts, text)
+	if err != nil || oc != OutcomeConverted {
+		return raw, oc, err
+	}
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(out), oc, nil
+}
+
+// officeText 文档中全部文本节点的内容（每个节点一行），用于 dry-run 变更清单与交互确认中的差异展示
+func officeText(kind, content string) string {
+	zr, err := zip.NewReader(strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, f := range zr.File {
+		if !officeTextPart(kind, f.Name) {
+			continue
+		}
+		xml, err := readZipFile(f)
+		if err != nil {
+			continue
+		}
+		for _, sp := range xmlTextSpans(xml) {
+			b.WriteString(html.UnescapeString(xml[sp[0]:sp[1]]))
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// writeFileAtomic 先写同目录下的临时文件再改名替换，保留原文件权限：Office 文档写到一半即无法打开
+func writeFileAtomic(p string, content []byte) error {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(p); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, mode); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildZip 按给定顺序生成 zip 容器（name, content 交替）
+func buildZip(t *testing.T, parts ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < len(parts); i += 2 {
+		w, err := zw.Create(parts[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(parts[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// readZipParts 读取 zip 容器中各部件的内容与顺序
+func readZipParts(t *testing.T, path string) ([]string, map[string]string) {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		s, err := readZipFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name)
+		parts[f.Name] = s
+	}
+	return names, parts
+}
+
+func TestXMLTextSpans(t *testing.T) {
+	s := `<?xml version="1.0"?><w:p><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">简体 </w:t></w:r><w:tbl/><w:t/><t>软件</t><w:tab/></w:p>`
+	var got []string
+	for _, sp := range xmlTextSpans(s) {
+		got = append(got, s[sp[0]:sp[1]])
+	}
+	if strings.Join(got, "|") != "简体 |软件" {
+		t.Errorf("spans = %q", got)
+	}
+}
+
+func TestConvertXMLTextEscapes(t *testing.T) {
+	out, oc, err := convertXMLText(ConvertOptions{To: "s2t"}, "简体 &amp; 软件 &lt;1&gt;")
+	if err != nil || oc != OutcomeConverted {
+		t.Fatalf("convertXMLText = %q, %v, %v", out, oc, err)
+	}
+	if out != "簡體 &amp; 軟件 &lt;1&gt;" {
+		t.Errorf("out = %q", out)
+	}
+	// 无需转换时原样返回（不重新转义）
+	if out, _, _ := convertXMLText(ConvertOptions{To: "s2t"}, "a &quot;b&quot;"); out != "a &quot;b&quot;" {
+		t.Errorf("unchanged = %q", out)
+	}
+}
+
+func TestRunFileOfficeDocuments(t *testing.T) {
+	dir := t.TempDir()
+	styles := `<w:styles><w:style w:styleId="标题"><w:name w:val="简体标题"/></w:style></w:styles>`
+	docx := buildZip(t,
+		"[Content_Types].xml", `<Types/>`,
+		"word/document.xml", `<w:document><w:body><w:p><w:r><w:rPr><w:rFonts w:eastAsia="简体字体"/></w:rPr><w:t>这是简体文档</w:t></w:r></w:p></w:body></w:document>`,
+		"word/header1.xml", `<w:hdr><w:p><w:r><w:t>页眉</w:t></w:r></w:p></w:hdr>`,
+		"word/styles.xml", styles,
+	)
+	xlsx := buildZip(t,
+		"xl/sharedStrings.xml", `<sst><si><t>销售报表</t></si><si><r><rPr/><t>数量</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml", `<worksheet><c t="inlineStr"><is><t>内联</t></is></c></worksheet>`,
+	)
+	if err := os.WriteFile(filepath.Join(dir, "a.docx"), docx, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.xlsx"), xlsx, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".docx", ".xlsx"}, To: "s2t", Backup: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	names, parts := readZipParts(t, filepath.Join(dir, "a.docx"))
+	if strings.Join(names, ",") != "[Content_Types].xml,word/document.xml,word/header1.xml,word/styles.xml" {
+		t.Errorf("docx parts = %v", names)
+	}
+	// 只转换 <w:t> 文本，格式属性与其它部件不变
+	if want := `<w:document><w:body><w:p><w:r><w:rPr><w:rFonts w:eastAsia="简体字体"/></w:rPr><w:t>這是簡體文檔</w:t></w:r></w:p></w:body></w:document>`; parts["word/document.xml"] != want {
+		t.Errorf("document.xml = %s", parts["word/document.xml"])
+	}
+	if !strings.Contains(parts["word/header1.xml"], "<w:t>頁眉</w:t>") {
+		t.Errorf("header1.xml = %s", parts["word/header1.xml"])
+	}
+	if parts["word/styles.xml"] != styles {
+		t.Errorf("styles.xml changed: %s", parts["word/styles.xml"])
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "a.docx")); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("permission not kept: %v, %v", fi.Mode(), err)
+	}
+	if bak, _ := os.ReadFile(filepath.Join(dir, "a.docx"+backupSuffix)); !bytes.Equal(bak, docx) {
+		t.Error("backup differs from the original document")
+	}
+
+	_, parts = readZipParts(t, filepath.Join(dir, "b.xlsx"))
+	if want := `<sst><si><t>銷售報表</t></si><si><r><rPr/><t>數量</t></r></si></sst>`; parts["xl/sharedStrings.xml"] != want {
+		t.Errorf("sharedStrings.xml = %s", parts["xl/sharedStrings.xml"])
+	}
+	if !strings.Contains(parts["xl/worksheets/sheet1.xml"], "<t>内联</t>") {
+		t.Errorf("inline strings should be kept: %s", parts["xl/worksheets/sheet1.xml"])
+	}
+}
+
+func TestConvertOfficeInvalidZip(t *testing.T) {
+	if _, _, err := convertOffice("docx", ConvertOptions{To: "s2t"}, "not a zip"); err == nil {
+		t.Error("invalid zip should fail")
+	}
+	in := string(buildZip(t, "word/document.xml", `<w:t>hello</w:t>`))
+	if out, oc, err := convertOffice("docx", ConvertOptions{To: "s2t"}, in); err != nil || oc != OutcomeUnchanged || out != in {
+		t.Errorf("unchanged document = %v, %v", oc, err)
+	}
+}
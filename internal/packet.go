@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// packetOverhead 估算 UPDATE 大小时为协议头、参数类型等预留的余量
+const packetOverhead = 1024
+
+// serverMaxPacket 读取服务器的 max_allowed_packet（字节）；读取失败时返回 0，不做预检查
+func serverMaxPacket(ctx context.Context, c MySQLConfig, db *sql.DB) int64 {
+	var n int64
+	if err := db.QueryRowContext(ctx, "SELECT @@max_allowed_packet").Scan(&n); err != nil {
+		log.Printf("[mysql] 读取 max_allowed_packet 失败 table=%s（不预检查 UPDATE 大小）：%v", c.Table, err)
+		return 0
+	}
+	return n
+}
+
+// updateSize 估算单条 UPDATE 发送到服务器的字节数：语句文本 + 全部参数（新值与 WHERE 中的原值）
+func updateSize(query string, args []interface{}) int64 {
+	n := int64(len(query) + packetOverhead)
+	for _, a := range args {
+		switch v := a.(type) {
+		case string:
+			n += int64(len(v))
+		case *string:
+			if v != nil {
+				n += int64(len(*v))
+			}
+		default:
+			n += 8
+		}
+	}
+	return n
+}
+
+// checkPacket UPDATE 的估算大小超过 max_allowed_packet 时返回错误，该行不发送：
+// 避免服务器报 “Got a packet bigger than 'max_allowed_packet'” 并断开连接
+func (c MySQLConfig) checkPacket(query string, args []interface{}) error {
+	if c.maxPacket <= 0 {
+		return nil
+	}
+	if n := updateSize(query, args); n > c.maxPacket {
+		return fmt.Errorf("table=%s UPDATE 约 %d 字节，超过服务器 max_allowed_packet=%d，已跳过该行（可调大 max_allowed_packet 后对该行重跑）", c.Table, n, c.maxPacket)
+	}
+	return nil
+}
+
+// logArgs 日志中输出的参数：过长的值截断，避免超大 TEXT 值刷屏
+func logArgs(args []interface{}) string {
+	const limit = 200
+	parts := make([]string, len(args))
+	for i, a := range args {
+		s := fmt.Sprint(a)
+		if v, ok := a.(*string); ok && v != nil {
+			s = *v
+		}
+		if r := []rune(s); len(r) > limit {
+			s = fmt.Sprintf("%s…（共 %d 字节）", string(r[:limit]), len(s))
+		}
+		parts[i] = s
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
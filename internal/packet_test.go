@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestUpdateSize(t *testing.T) {
+	v := strings.Repeat("繁", 100)
+	got := updateSize("UPDATE `t` SET `c` = ? WHERE `id` = ?", []interface{}{v, &v, 42, (*string)(nil)})
+	want := int64(len("UPDATE `t` SET `c` = ? WHERE `id` = ?") + packetOverhead + 2*len(v) + 8)
+	if got != want {
+		t.Errorf("updateSize = %d, want %d", got, want)
+	}
+}
+
+func TestCheckPacket(t *testing.T) {
+	q := "UPDATE `t` SET `c` = ? WHERE `id` = ?"
+	big := strings.Repeat("x", 4096)
+	if err := (MySQLConfig{Table: "t"}).checkPacket(q, []interface{}{big, "1"}); err != nil {
+		t.Errorf("unknown max_allowed_packet should not check: %v", err)
+	}
+	c := MySQLConfig{Table: "t", maxPacket: 2048}
+	if err := c.checkPacket(q, []interface{}{"短", "1"}); err != nil {
+		t.Errorf("small update: %v", err)
+	}
+	if err := c.checkPacket(q, []interface{}{big, "1"}); err == nil || !strings.Contains(err.Error(), "max_allowed_packet=2048") {
+		t.Errorf("big update err = %v", err)
+	}
+}
+
+// 模拟较小的 max_allowed_packet：超限的行不发送 UPDATE、计为失败，其余行照常更新
+func TestProcessWithPKSkipsOversizedUpdate(t *testing.T) {
+	db, mock := newMock(t)
+	big := strings.Repeat("简", 2000)
+	cfg := MySQLConfig{
+		Table: "t", PK: []string{"id"}, Columns: []string{"body"}, To: "s2t", BatchSize: 10,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond,
+		counts: &tableMetrics{}, maxPacket: 4096,
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`body` FROM `t` ORDER BY `id` LIMIT ?")).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "body"}).AddRow("1", big).AddRow("2", "简体"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `body` = ? WHERE `id` = ?")).
+		WithArgs("簡體", "2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`body` FROM `t` WHERE (`id`) > (?) ORDER BY `id` LIMIT ?")).
+		WithArgs("2", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "body"}))
+
+	if _, err := processWithPK(context.Background(), db, cfg, nil, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if cfg.counts.changed != 1 || cfg.counts.failed != 1 {
+		t.Errorf("counts = %+v", *cfg.counts)
+	}
+}
+
+func TestLogArgsTruncates(t *testing.T) {
+	long := strings.Repeat("繁", 300)
+	got := logArgs([]interface{}{&long, 7})
+	if !strings.Contains(got, "…（共 900 字节）") || strings.Count(got, "繁") != 200 || !strings.HasSuffix(got, " 7]") {
+		t.Errorf("logArgs = %q", got)
+	}
+}
@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// patchContext 统一 diff 每个 hunk 前后保留的上下文行数（与 diff -u / git diff 默认一致）
+const patchContext = 3
+
+// Patch 汇总 dry-run 中各文件的拟变更，Close 时按路径排序写成一份可用 git apply / patch -p1 应用的统一 diff；方法对 nil 安全
+type Patch struct {
+	mu    sync.Mutex
+	f     *os.File
+	files map[string]string // 相对路径 -> 该文件的 diff
+}
+
+func NewPatch(path string) (*Patch, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建补丁文件 %s: %w", path, err)
+	}
+	return &Patch{f: f, files: map[string]string{}}, nil
+}
+
+// RecordFile 记录一个文件的拟变更；rel 为相对根目录的路径，即补丁中的 a/<rel>、b/<rel>
+func (p *Patch) RecordFile(rel, old, new string) {
+	if p == nil {
+		return
+	}
+	d := unifiedDiff(filepath.ToSlash(rel), old, new)
+	p.mu.Lock()
+	p.files[rel] = d
+	p.mu.Unlock()
+}
+
+func (p *Patch) Close() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	paths := make([]string, 0, len(p.files))
+	for rel := range p.files {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths) // worker 并发记录，排序后输出稳定
+	var sb strings.Builder
+	for _, rel := range paths {
+		sb.WriteString(p.files[rel])
+	}
+	_, err := p.f.WriteString(sb.String())
+	if cerr := p.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// unifiedDiff 生成单个文件的 git 风格统一 diff。简繁转换不改变行数，逐行对比并合并相邻改动为 hunk；
+// 行数不同时（极少见）整个文件作为一个 hunk。末行无换行时按 git 的约定追加 "\ No newline at end of file"
+func unifiedDiff(path, old, new string) string {
+	a, b := splitLinesKeepEOL(old), splitLinesKeepEOL(new)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+	if len(a) != len(b) {
+		writeHunk(&sb, a, b, 0, len(a), 0, len(b), nil)
+		return sb.String()
+	}
+	var changed []int
+	for i := range a {
+		if a[i] != b[i] {
+			changed = append(changed, i)
+		}
+	}
+	for i := 0; i < len(changed); {
+		j := i
+		// 两处改动之间的相同行不超过前后上下文之和时并入同一个 hunk
+		for j+1 < len(changed) && changed[j+1]-changed[j] <= 2*patchContext+1 {
+			j++
+		}
+		start := max(changed[i]-patchContext, 0)
+		end := min(changed[j]+patchContext+1, len(a))
+		writeHunk(&sb, a, b, start, end, start, end, changed[i:j+1])
+		i = j + 1
+	}
+	return sb.String()
+}
+
+// writeHunk 输出 a[as:ae] -> b[bs:be] 的一个 hunk；changed 为 nil 时全部旧行删除、全部新行加入，
+// 否则两侧行一一对应，只有 changed 中的行号输出为 -旧行 / +新行
+func writeHunk(sb *strings.Builder, a, b []string, as, ae, bs, be int, changed []int) {
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(as, ae-as), hunkRange(bs, be-bs))
+	if changed == nil {
+		for _, l := range a[as:ae] {
+			writePatchLine(sb, '-', l)
+		}
+		for _, l := range b[bs:be] {
+			writePatchLine(sb, '+', l)
+		}
+		return
+	}
+	k := 0
+	for i := as; i < ae; i++ {
+		if k < len(changed) && changed[k] == i {
+			writePatchLine(sb, '-', a[i])
+			writePatchLine(sb, '+', b[i])
+			k++
+			continue
+		}
+		writePatchLine(sb, ' ', a[i])
+	}
+}
+
+// hunkRange 格式化 hunk 头中的 起始行,行数（行号从 1 开始；行数为 0 时起始行为前一行）
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+func writePatchLine(sb *strings.Builder, op byte, line string) {
+	sb.WriteByte(op)
+	sb.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// splitLinesKeepEOL 按 \n 切分并保留每行的换行符（含 \r\n 中的 \r），末行无换行时原样保留
+func splitLinesKeepEOL(s string) []string {
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			lines = append(lines, s)
+			break
+		}
+		lines = append(lines, s[:i+1])
+		s = s[i+1:]
+	}
+	return lines
+}
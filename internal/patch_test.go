@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "简体\n1\n2\n3\n4\n5\n6\n7\n8\n9\n说明\n尾行"
+	got := unifiedDiff("docs/a.md", old, strings.NewReplacer("简体", "簡體", "说明", "說明").Replace(old))
+	want := "diff --git a/docs/a.md b/docs/a.md\n--- a/docs/a.md\n+++ b/docs/a.md\n" +
+		"@@ -1,4 +1,4 @@\n-简体\n+簡體\n 1\n 2\n 3\n" +
+		"@@ -8,5 +8,5 @@\n 7\n 8\n 9\n-说明\n+說明\n 尾行\n\\ No newline at end of file\n"
+	if got != want {
+		t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// dry-run 生成的补丁用 git apply 与 patch -p1 应用后，结果与直接转换一致
+func TestPatchRoundTrip(t *testing.T) {
+	files := map[string]string{
+		"a.md":        "# 标题\n\n第一段\n1\n2\n3\n4\n5\n6\n7\n8\n最后一段简体",
+		"sub/crlf.md": "一行\r\n这里\r\n",
+		"same.md":     "English only\n",
+	}
+	want := t.TempDir()
+	writeFiles(t, want, files)
+	if _, _, err := RunFileWithResult(FileConfig{RootDirs: []string{want}, Exts: []string{".md"}, To: "s2t"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tool := range []struct {
+		name string
+		args []string
+	}{
+		{"git", []string{"apply"}},
+		{"patch", []string{"-p1", "-s", "-i"}},
+	} {
+		t.Run(tool.name, func(t *testing.T) {
+			if _, err := exec.LookPath(tool.name); err != nil {
+				t.Skipf("%s not installed", tool.name)
+			}
+			dir := t.TempDir()
+			writeFiles(t, dir, files)
+			patchFile := filepath.Join(t.TempDir(), "changes.patch")
+			p, err := NewPatch(patchFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := RunFileWithResult(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", DryRun: true, Patch: p}); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Close(); err != nil {
+				t.Fatal(err)
+			}
+			bs, _ := os.ReadFile(patchFile)
+			if strings.Contains(string(bs), "same.md") {
+				t.Errorf("unchanged file in patch:\n%s", bs)
+			}
+			// dry-run 不改动文件
+			if got, _ := os.ReadFile(filepath.Join(dir, "a.md")); string(got) != files["a.md"] {
+				t.Fatal("dry-run modified a.md")
+			}
+
+			cmd := exec.Command(tool.name, append(tool.args, patchFile)...)
+			cmd.Dir = dir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("%s failed: %v\n%s\npatch:\n%s", tool.name, err, out, bs)
+			}
+			for name := range files {
+				exp, _ := os.ReadFile(filepath.Join(want, name))
+				got, _ := os.ReadFile(filepath.Join(dir, name))
+				if string(got) != string(exp) {
+					t.Errorf("%s after %s = %q, want %q", name, tool.name, got, exp)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchNil(t *testing.T) {
+	var p *Patch
+	p.RecordFile("a.md", "a", "b")
+	if err := p.Close(); err != nil {
+		t.Errorf("nil patch Close = %v", err)
+	}
+}
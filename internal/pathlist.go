@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// scanPaths 逐个读取路径清单（换行分隔，或 nul=true 时 NUL 分隔，如 git diff -z / find -print0），
+// 忽略空项；换行模式下去掉行尾 \r。fn 返回 false 时停止读取
+func scanPaths(r io.Reader, nul bool, fn func(path string) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	if nul {
+		sc.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			if i := bytes.IndexByte(data, 0); i >= 0 {
+				return i + 1, data[:i], nil
+			}
+			if atEOF && len(data) > 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		})
+	}
+	for sc.Scan() {
+		p := sc.Text()
+		if !nul {
+			p = strings.TrimSuffix(p, "\r")
+		}
+		if p == "" {
+			continue
+		}
+		if !fn(p) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestScanPaths(t *testing.T) {
+	for _, tc := range []struct {
+		name, in string
+		nul      bool
+		want     []string
+	}{
+		{"newline", "a.md\r\n\nb c.md\nd.md", false, []string{"a.md", "b c.md", "d.md"}},
+		{"nul", "a.md\x00line\nbreak.md\x00\x00d.md\r", true, []string{"a.md", "line\nbreak.md", "d.md\r"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			if err := scanPaths(strings.NewReader(tc.in), tc.nul, func(p string) bool {
+				got = append(got, p)
+				return true
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("paths = %q, want %q", got, tc.want)
+			}
+		})
+	}
+	// fn 返回 false 时停止读取
+	var n int
+	_ = scanPaths(strings.NewReader("a\nb\nc"), false, func(string) bool { n++; return false })
+	if n != 1 {
+		t.Errorf("stopped after %d paths", n)
+	}
+}
+
+// 路径清单只处理列出的文件，仍按 Exts 过滤，重复路径只处理一次
+func TestRunFilePathsFrom(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.md": "简体", "b.md": "简体", "c.txt": "简体", "d e.md": "简体"})
+	p := func(name string) string { return filepath.Join(dir, name) }
+	for _, tc := range []struct {
+		name string
+		list string
+		nul  bool
+	}{
+		{"newline", p("a.md") + "\n" + p("c.txt") + "\n" + p("d e.md") + "\n" + p("a.md") + "\n", false},
+		{"nul", p("a.md") + "\x00" + p("c.txt") + "\x00" + p("d e.md") + "\x00" + p("a.md"), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			results, _, err := RunFileWithResult(FileConfig{Exts: []string{".md"}, To: "s2t", DryRun: true, PathsFrom: strings.NewReader(tc.list), PathsNUL: tc.nul})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, filepath.Base(r.Path))
+			}
+			if want := []string{"a.md", "d e.md"}; !slices.Equal(got, want) {
+				t.Errorf("processed = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// checkPKRange 校验主键区间：需提供 pk（区间作用于首个主键列），下界不大于上界
+func checkPKRange(pk []string, min, max *int64) error {
+	if min == nil && max == nil {
+		return nil
+	}
+	if len(pk) == 0 {
+		return errors.New("pk_min/pk_max 需要提供 pk（区间作用于首个主键列）")
+	}
+	if min != nil && max != nil && *min > *max {
+		return fmt.Errorf("pk_min（%d）不能大于 pk_max（%d）", *min, *max)
+	}
+	return nil
+}
+
+// pkRangeCond 首个主键列的闭区间条件（与主键游标 (pk) > (...) 以 AND 组合，仍走主键索引的范围扫描）；未设置时返回空串
+func (c MySQLConfig) pkRangeCond() (string, []interface{}) {
+	if len(c.PK) == 0 {
+		return "", nil
+	}
+	col := quoteIdent(c.PK[0])
+	switch {
+	case c.PKMin != nil && c.PKMax != nil:
+		return col + " BETWEEN ? AND ?", []interface{}{*c.PKMin, *c.PKMax}
+	case c.PKMin != nil:
+		return col + " >= ?", []interface{}{*c.PKMin}
+	case c.PKMax != nil:
+		return col + " <= ?", []interface{}{*c.PKMax}
+	}
+	return "", nil
+}
+
+// pkRangeString 区间的日志表示，如 [1000000, 2000000]、[1000000, +∞)
+func (c MySQLConfig) pkRangeString() string {
+	lo, hi := "(-∞", "+∞)"
+	if c.PKMin != nil {
+		lo = fmt.Sprintf("[%d", *c.PKMin)
+	}
+	if c.PKMax != nil {
+		hi = fmt.Sprintf("%d]", *c.PKMax)
+	}
+	return lo + ", " + hi
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPKRangeCond(t *testing.T) {
+	lo, hi := int64(1000000), int64(2000000)
+	for _, tc := range []struct {
+		min, max *int64
+		cond     string
+		args     []interface{}
+	}{
+		{&lo, &hi, "`id` BETWEEN ? AND ?", []interface{}{lo, hi}}, // 两端均为闭区间
+		{&lo, nil, "`id` >= ?", []interface{}{lo}},
+		{nil, &hi, "`id` <= ?", []interface{}{hi}},
+		{nil, nil, "", nil},
+	} {
+		cond, args := MySQLConfig{PK: []string{"id", "sub"}, PKMin: tc.min, PKMax: tc.max}.pkRangeCond()
+		if cond != tc.cond || len(args) != len(tc.args) || (len(args) > 0 && args[0] != tc.args[0]) {
+			t.Errorf("pkRangeCond = %q %v, want %q %v", cond, args, tc.cond, tc.args)
+		}
+	}
+}
+
+func TestCheckPKRange(t *testing.T) {
+	one, two := int64(1), int64(2)
+	if err := checkPKRange([]string{"id"}, &one, &one); err != nil {
+		t.Errorf("single-value range: %v", err)
+	}
+	if err := checkPKRange([]string{"id"}, &two, &one); err == nil {
+		t.Error("min > max should fail")
+	}
+	if err := checkPKRange(nil, &one, nil); err == nil {
+		t.Error("range without pk should fail")
+	}
+}
+
+// 区间条件与主键游标以 AND 组合，每一页都带上
+func TestProcessWithPKRange(t *testing.T) {
+	db, mock := newMock(t)
+	lo, hi := int64(10), int64(20)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE `id` BETWEEN ? AND ? ORDER BY `id` LIMIT ?")).
+		WithArgs(lo, hi, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("10", "a").AddRow("15", "b"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE (`id`) > (?) AND `id` BETWEEN ? AND ? ORDER BY `id` LIMIT ?")).
+		WithArgs("15", lo, hi, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("20", "c"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE (`id`) > (?) AND `id` BETWEEN ? AND ? ORDER BY `id` LIMIT ?")).
+		WithArgs("20", lo, hi, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	cfg := MySQLConfig{
+		Table: "t", PK: []string{"id"}, Columns: []string{"name"}, To: "s2t", BatchSize: 2, PKMin: &lo, PKMax: &hi,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{},
+	}
+	if _, err := processWithPK(context.Background(), db, cfg, nil, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if cfg.counts.scanned != 3 {
+		t.Errorf("scanned = %d, want 3", cfg.counts.scanned)
+	}
+}
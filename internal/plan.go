@@ -0,0 +1,413 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// MySQLPlan 只读试运行得到的合并执行计划：各表待更新的行数，以及执行前需要的 DDL
+// （加宽溢出列、为 SET/ENUM 补充转换后的成员、转换表/列注释与 convert_defaults 表的列默认值）。生成过程中不执行任何写入
+type MySQLPlan struct {
+	Tables []PlanTable     `json:"tables"`
+	Values Stats           `json:"values"` // 按值（每行每列）的转换统计
+	DDL    []PlanStatement `json:"ddl"`
+}
+
+// PlanTable 单表的数据变更预估
+type PlanTable struct {
+	Table   string `json:"table"`
+	Scanned int64  `json:"rows_scanned"`
+	Changed int64  `json:"rows_changed"` // 将会更新的行
+	Failed  int64  `json:"rows_failed"`  // 转换出错的行（如 SET 成员不在列定义中）
+
+	HeavyIndexes []string `json:"heavy_indexes,omitempty"` // 目标列上的 FULLTEXT/SPATIAL 索引（更新慢，需规划维护窗口）
+}
+
+// PlanStatement 一条拟执行的 DDL；同一列的多项变更合并为一条 MODIFY COLUMN，避免后一条覆盖前一条。
+// SQL 为空表示无法自动生成，需按 Notes 手动处理
+type PlanStatement struct {
+	Table  string   `json:"table"`
+	Column string   `json:"column,omitempty"` // 为空表示表级（表注释）
+	Kinds  []string `json:"kinds"`            // widen | members | comment | default
+	SQL    string   `json:"sql,omitempty"`
+	Notes  []string `json:"notes,omitempty"`
+
+	dsn     string        // 执行该语句的主库（配置的 dsn），供 ApplyDDL 使用
+	timeout time.Duration // 该配置的 connect_timeout
+}
+
+// AddConfig 以只读方式试运行一个配置文件并把结果并入计划：强制 dry_run，关闭 shadow_table / verify / max_errors，
+// 不写水位、不写已完成清单；随后读取各表列定义生成 DDL（只生成，不执行）
+func (p *MySQLPlan) AddConfig(ctx context.Context, fileCfg *MySQLFileConfig, baseDir string) error {
+	cfg := readOnlyCopy(fileCfg)
+
+	lengths, metrics := NewLengthReport(), NewMetrics()
+	if _, err := RunMySQLFromFileConfig(ctx, cfg, baseDir, MySQLRunOptions{Stats: &p.Values, LengthReport: lengths, Metrics: metrics}); err != nil {
+		return err
+	}
+
+	timeout, _ := time.ParseDuration(cfg.ConnectTimeout)
+	db, err := sql.Open("mysql", cfg.schemaDSN()) // 只读取列定义与索引，配置了 read_dsn 时走副本
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := pingTimeout(ctx, db, timeout); err != nil {
+		return err
+	}
+	widen := map[string]ColumnLength{}
+	for _, c := range lengths.Columns() {
+		if c.Overflow > 0 {
+			widen[lengthKey(c.Table, c.Column)] = c
+		}
+	}
+	for _, t := range mergeTableEntries(cfg.Tables) {
+		scanned, changed, failed := metrics.rows(t.Table)
+		pt := PlanTable{Table: t.Table, Scanned: scanned, Changed: changed, Failed: failed}
+		heavy, err := heavyIndexes(ctx, db, t.Table, t.Columns)
+		if err != nil {
+			return fmt.Errorf("table %s: 读取索引信息失败：%w", t.Table, err)
+		}
+		for _, h := range heavy {
+			pt.HeavyIndexes = append(pt.HeavyIndexes, h.String())
+		}
+		p.Tables = append(p.Tables, pt)
+		to := cfg.To
+		if t.To != "" {
+			to = t.To
+		}
+		opts := ConvertOptions{To: to, Normalize: cfg.Normalize, NormalizeInput: cfg.NormalizeInput, PunctOnly: cfg.PunctOnly, Smart: cfg.Smart}
+		if opts.Replace, err = NewReplacer(mergeReplace(cfg.Replace, t.Replace), nil); err != nil {
+			return fmt.Errorf("table %s: %w", t.Table, err)
+		}
+		stmts, err := planTableDDL(ctx, db, t, opts, widen)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", t.Table, err)
+		}
+		for i := range stmts {
+			stmts[i].dsn, stmts[i].timeout = cfg.DSN, timeout // DDL 在主库执行，即使读取列定义走了副本
+		}
+		p.DDL = append(p.DDL, stmts...)
+	}
+	return nil
+}
+
+// RowsToChange 各表将会更新的行数合计
+func (p *MySQLPlan) RowsToChange() int64 {
+	var n int64
+	for _, t := range p.Tables {
+		n += t.Changed
+	}
+	return n
+}
+
+// ManualDDL 无法自动生成、需手动处理的变更（SQL 为空）；存在时不可 ApplyDDL
+func (p *MySQLPlan) ManualDDL() []PlanStatement {
+	var out []PlanStatement
+	for _, s := range p.DDL {
+		if s.SQL == "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// ApplyDDL 按计划顺序在各配置的主库上执行 DDL（mysql plan --apply）。存在需手动处理的变更时不执行任何语句；
+// 某条失败即停止并返回错误，之前已执行的 DDL 无法回滚（MySQL 的 DDL 隐式提交），重新生成计划即只剩未完成的部分
+func (p *MySQLPlan) ApplyDDL(ctx context.Context) error {
+	return p.applyDDL(ctx, func(s PlanStatement) (*sql.DB, error) {
+		db, err := sql.Open("mysql", s.dsn)
+		if err != nil {
+			return nil, classify(ErrConfigInvalid, fmt.Errorf("open mysql: %w", err))
+		}
+		if err := pingTimeout(ctx, db, s.timeout); err != nil {
+			db.Close()
+			return nil, err
+		}
+		return db, nil
+	})
+}
+
+func (p *MySQLPlan) applyDDL(ctx context.Context, open func(PlanStatement) (*sql.DB, error)) error {
+	if manual := p.ManualDDL(); len(manual) > 0 {
+		return classify(ErrConfigInvalid, fmt.Errorf("计划中有 %d 项变更需手动处理（如 %s.%s），处理后重新生成计划再应用", len(manual), manual[0].Table, manual[0].Column))
+	}
+	dbs := map[string]*sql.DB{}
+	defer func() {
+		for _, db := range dbs {
+			db.Close()
+		}
+	}()
+	for i, s := range p.DDL {
+		db, ok := dbs[s.dsn]
+		if !ok {
+			var err error
+			if db, err = open(s); err != nil {
+				return err
+			}
+			dbs[s.dsn] = db
+		}
+		log.Printf("[plan] 执行 DDL（%d/%d）：%s", i+1, len(p.DDL), s.SQL)
+		if _, err := db.ExecContext(ctx, s.SQL); err != nil {
+			return fmt.Errorf("执行 DDL 失败（%s），已执行前 %d 条：%w", s.SQL, i, err)
+		}
+	}
+	return nil
+}
+
+// mergeTableEntries 同一张表出现在多个条目中时合并为一条（columns 取并集，convert_defaults 任一条目开启即开启，其余字段取首个条目），
+// 与按表名累计的指标保持一致，也避免对同一列生成两条 MODIFY COLUMN
+func mergeTableEntries(tables []MySQLTblEntry) []MySQLTblEntry {
+	var out []MySQLTblEntry
+	pos := map[string]int{}
+	for _, t := range tables {
+		i, ok := pos[strings.ToLower(t.Table)]
+		if !ok {
+			pos[strings.ToLower(t.Table)] = len(out)
+			t.Columns = append([]string(nil), t.Columns...)
+			out = append(out, t)
+			continue
+		}
+		for _, c := range t.Columns {
+			if indexOfFold(out[i].Columns, c) < 0 {
+				out[i].Columns = append(out[i].Columns, c)
+			}
+		}
+		out[i].ConvertDefaults = out[i].ConvertDefaults || t.ConvertDefaults
+	}
+	return out
+}
+
+// planTableDDL 生成单表的 DDL：columns 中的列按需加宽、补充 SET/ENUM 成员；所有列及表本身的注释按需转换；
+// convert_defaults 时所有字符列（TEXT 系列除外）的字符串默认值按需转换，NULL 与表达式默认值不变
+func planTableDDL(ctx context.Context, db *sql.DB, t MySQLTblEntry, opts ConvertOptions, widen map[string]ColumnLength) ([]PlanStatement, error) {
+	types, err := getColumnTypes(db, t.Table)
+	if err != nil {
+		return nil, fmt.Errorf("读取列定义失败：%w", err)
+	}
+	convert := func(s string) (string, bool, error) {
+		if s == "" {
+			return s, false, nil
+		}
+		out, changed, err := ConvertWithOptions(opts, s)
+		return out, changed && out != s, err
+	}
+
+	names := make([]string, 0, len(types))
+	for k := range types {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var out []PlanStatement
+	for _, k := range names {
+		ct := types[k]
+		st := PlanStatement{Table: t.Table, Column: ct.Name}
+		typ := ""
+		members := ct.Members // SET/ENUM 补充成员后的完整成员列表
+		if indexOfFold(t.Columns, ct.Name) >= 0 {
+			if c, ok := widen[lengthKey(t.Table, ct.Name)]; ok {
+				st.Kinds = append(st.Kinds, "widen")
+				if wt, ok := ct.widenedType(c.MaxOutChars, c.MaxOutBytes); ok {
+					typ = wt
+					if strings.HasSuffix(wt, "TEXT") && ct.defaultLiteral() != "" {
+						st.Notes = append(st.Notes, fmt.Sprintf("改为 %s 后将不再保留默认值 %s", wt, ct.defaultLiteral()))
+					}
+				} else {
+					st.Notes = append(st.Notes, fmt.Sprintf("%s 无法自动加宽（转换后最长 %d 字符），请手动处理", ct.limitString(), c.MaxOutChars))
+				}
+			}
+			if ct.DataType == "set" || ct.DataType == "enum" {
+				members = append([]string(nil), ct.Members...)
+				var added []string
+				for _, m := range ct.Members {
+					o, changed, err := convert(m)
+					if err != nil {
+						return nil, err
+					}
+					if changed && !containsFold(members, o) {
+						members = append(members, o)
+						added = append(added, o)
+					}
+				}
+				if len(added) > 0 {
+					st.Kinds = append(st.Kinds, "members")
+					quoted := make([]string, len(members))
+					for i, m := range members {
+						quoted[i] = sqlStringLiteral(m)
+					}
+					typ = strings.ToUpper(ct.DataType) + "(" + strings.Join(quoted, ",") + ")"
+					st.Notes = append(st.Notes, fmt.Sprintf("补充成员 %s；数据转换完成后可再删除旧成员", strings.Join(added, ",")))
+					if ct.DataType == "set" && len(members) > 64 {
+						st.Notes = append(st.Notes, fmt.Sprintf("SET 至多 64 个成员，补充后共 %d 个，请手动处理", len(members)))
+						typ = ""
+					}
+				}
+			}
+		}
+		comment, commentChanged, err := convert(ct.Comment)
+		if err != nil {
+			return nil, err
+		}
+		if commentChanged {
+			st.Kinds = append(st.Kinds, "comment")
+		}
+		newDefault, defaultChanged := "", false
+		if v, ok := ct.defaultValue(); ok && t.ConvertDefaults && ct.isString() && !ct.isTextFamily() &&
+			!strings.Contains(strings.ToUpper(ct.Extra), "DEFAULT_GENERATED") {
+			d, changed, err := convert(v)
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				st.Kinds = append(st.Kinds, "default")
+				if missing := missingMembers(ct.DataType, members, d); missing != "" {
+					st.Notes = append(st.Notes, fmt.Sprintf("转换后的默认值 %s 含非列成员 %s（在 columns 中列出该列可补充成员），请手动处理", d, missing))
+				} else {
+					newDefault, defaultChanged = d, true
+				}
+			}
+		}
+		if len(st.Kinds) == 0 {
+			continue
+		}
+		setDefault := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", quoteIdent(t.Table), quoteIdent(ct.Name), sqlStringLiteral(newDefault))
+		switch {
+		case defaultChanged && len(st.Kinds) == 1:
+			// 只改默认值：ALTER COLUMN 不改写其余列定义
+			st.SQL = setDefault
+		case typ == "" && len(st.Notes) > 0 && !commentChanged && !defaultChanged:
+			// 无法自动处理且只有这一项
+		case ct.Extra != "":
+			st.Notes = append(st.Notes, fmt.Sprintf("列定义含 %s，MODIFY COLUMN 可能丢失该属性，请手动修改（新注释：%s）", ct.Extra, comment))
+			if defaultChanged {
+				st.SQL = setDefault
+			}
+		case !ct.isString():
+			st.Notes = append(st.Notes, fmt.Sprintf("非字符列（%s），注释请手动修改为：%s", ct.ColumnType, comment))
+		default:
+			if typ == "" {
+				typ = strings.ToUpper(ct.ColumnType)
+			}
+			def := ct
+			def.Comment = comment
+			if defaultChanged {
+				def.Default = sql.NullString{String: newDefault, Valid: true}
+			}
+			st.SQL = fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s;", quoteIdent(t.Table), quoteIdent(ct.Name), def.definition(typ))
+		}
+		out = append(out, st)
+	}
+
+	var tableComment string
+	if err := db.QueryRowContext(ctx, "SELECT TABLE_COMMENT FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", t.Table).Scan(&tableComment); err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("读取表注释失败：%w", err)
+	}
+	if c, changed, err := convert(tableComment); err != nil {
+		return nil, err
+	} else if changed {
+		out = append(out, PlanStatement{Table: t.Table, Kinds: []string{"comment"},
+			SQL: fmt.Sprintf("ALTER TABLE %s COMMENT = %s;", quoteIdent(t.Table), sqlStringLiteral(c))})
+	}
+	return out, nil
+}
+
+// missingMembers SET/ENUM 列的默认值 v 中不属于 members 的部分（SET 按逗号拆分）；其余类型返回空
+func missingMembers(dataType string, members []string, v string) string {
+	var parts []string
+	switch dataType {
+	case "enum":
+		parts = []string{v}
+	case "set":
+		if v != "" {
+			parts = strings.Split(v, ",")
+		}
+	}
+	var missing []string
+	for _, p := range parts {
+		if !containsFold(members, p) {
+			missing = append(missing, p)
+		}
+	}
+	return strings.Join(missing, ",")
+}
+
+// isString 字符类型（CHAR/VARCHAR/TEXT 系列/SET/ENUM），其默认值均为字符串字面量，可安全重写列定义
+func (t columnType) isString() bool {
+	switch t.DataType {
+	case "char", "varchar", "set", "enum":
+		return true
+	}
+	return t.isTextFamily()
+}
+
+// WriteJSON 以 JSON 输出执行计划
+func (p *MySQLPlan) WriteJSON(w io.Writer) error {
+	out := *p
+	if out.Tables == nil {
+		out.Tables = []PlanTable{}
+	}
+	if out.DDL == nil {
+		out.DDL = []PlanStatement{}
+	}
+	out.Values = p.Values.Snapshot()
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// WriteText 输出可读的执行计划
+func (p *MySQLPlan) WriteText(w io.Writer) error {
+	fmt.Fprintln(w, "== 数据变更（只读试运行，未写入）==")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  表\t扫描行\t将更新行\t失败行")
+	for _, t := range p.Tables {
+		fmt.Fprintf(tw, "  %s\t%d\t%d\t%d\n", t.Table, t.Scanned, t.Changed, t.Failed)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, t := range p.Tables {
+		for _, h := range t.HeavyIndexes {
+			fmt.Fprintf(w, "  ⚠ %s 目标列上有 %s：UPDATE 需维护该索引，请规划维护窗口\n", t.Table, h)
+		}
+	}
+	v := p.Values.Snapshot()
+	fmt.Fprintf(w, "  值：转换 %d | 跳过(纯ASCII) %d | 跳过(无汉字) %d | 无变化 %d\n\n", v.Converted, v.SkippedASCII, v.SkippedNoChinese, v.Unchanged)
+
+	fmt.Fprintf(w, "== DDL（%d 条，未执行）==\n", len(p.DDL))
+	return p.WriteSQL(w)
+}
+
+// WriteSQL 按执行顺序输出 DDL（需复核后手动执行）：无法自动生成的语句以注释说明
+func (p *MySQLPlan) WriteSQL(w io.Writer) error {
+	if len(p.DDL) == 0 {
+		_, err := fmt.Fprintln(w, "-- [plan] 无需变更表结构")
+		return err
+	}
+	fmt.Fprintln(w, "-- [plan] 以下语句尚未执行：复核后手动执行或用 mysql plan --apply 应用，须在数据转换（dry_run=false）之前执行，大表 ALTER 可能锁表")
+	for _, s := range p.DDL {
+		target := s.Table
+		if s.Column != "" {
+			target += "." + s.Column
+		}
+		fmt.Fprintf(w, "-- %s [%s]\n", target, strings.Join(s.Kinds, ","))
+		for _, n := range s.Notes {
+			fmt.Fprintf(w, "--   %s\n", n)
+		}
+		if s.SQL != "" {
+			if _, err := fmt.Fprintln(w, s.SQL); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
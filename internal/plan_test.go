@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPlanApplyDDL(t *testing.T) {
+	primary, mock := newMock(t)
+	other, mock2 := newMock(t)
+	p := &MySQLPlan{
+		Tables: []PlanTable{{Table: "a", Changed: 3}, {Table: "b", Changed: 2}},
+		DDL: []PlanStatement{
+			{Table: "a", Column: "c", SQL: "ALTER TABLE `a` MODIFY COLUMN `c` VARCHAR(20);", dsn: "one"},
+			{Table: "a", SQL: "ALTER TABLE `a` COMMENT = '軟體';", dsn: "one"},
+			{Table: "b", SQL: "ALTER TABLE `b` COMMENT = '硬體';", dsn: "two"},
+		},
+	}
+	if got := p.RowsToChange(); got != 5 {
+		t.Errorf("RowsToChange = %d", got)
+	}
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `a` MODIFY COLUMN `c` VARCHAR(20);")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `a` COMMENT = '軟體';")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectClose()
+	mock2.ExpectExec(regexp.QuoteMeta("ALTER TABLE `b` COMMENT = '硬體';")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock2.ExpectClose()
+	opened := map[string]int{}
+	err := p.applyDDL(context.Background(), func(s PlanStatement) (*sql.DB, error) {
+		opened[s.dsn]++
+		if s.dsn == "one" {
+			return primary, nil
+		}
+		return other, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened["one"] != 1 || opened["two"] != 1 {
+		t.Errorf("opened = %v, want one connection per dsn", opened)
+	}
+	for _, m := range []sqlmock.Sqlmock{mock, mock2} {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPlanApplyDDLStopsOnError(t *testing.T) {
+	db, mock := newMock(t)
+	p := &MySQLPlan{DDL: []PlanStatement{
+		{Table: "a", SQL: "ALTER TABLE `a` COMMENT = 'x';"},
+		{Table: "b", SQL: "ALTER TABLE `b` COMMENT = 'y';"},
+	}}
+	boom := errors.New("lock wait timeout")
+	mock.ExpectExec("ALTER TABLE `a`").WillReturnError(boom)
+	err := p.applyDDL(context.Background(), func(PlanStatement) (*sql.DB, error) { return db, nil })
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlanApplyDDLRejectsManual(t *testing.T) {
+	p := &MySQLPlan{DDL: []PlanStatement{
+		{Table: "a", SQL: "ALTER TABLE `a` COMMENT = 'x';"},
+		{Table: "a", Column: "id", Kinds: []string{"comment"}, Notes: []string{"列定义含 auto_increment"}},
+	}}
+	if got := p.ManualDDL(); len(got) != 1 || got[0].Column != "id" {
+		t.Errorf("ManualDDL = %+v", got)
+	}
+	err := p.applyDDL(context.Background(), func(PlanStatement) (*sql.DB, error) {
+		t.Fatal("nothing should be executed when a change needs manual handling")
+		return nil, nil
+	})
+	if !errors.Is(err, ErrConfigInvalid) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// expectPlanSchema 模拟 planTableDDL 读取的列定义与表注释；行依次为 COLUMN_NAME, DATA_TYPE, COLUMN_DEFAULT, COLUMN_COMMENT, COLUMN_TYPE, EXTRA
+func expectPlanSchema(mock sqlmock.Sqlmock, table string, cols [][6]any) {
+	types := sqlmock.NewRows([]string{"COLUMN_NAME", "DATA_TYPE", "CHARACTER_MAXIMUM_LENGTH", "CHARACTER_OCTET_LENGTH", "CHARACTER_SET_NAME",
+		"IS_NULLABLE", "COLUMN_DEFAULT", "COLLATION_NAME", "COLUMN_COMMENT", "COLUMN_TYPE", "EXTRA"})
+	for _, c := range cols {
+		types.AddRow(c[0], c[1], 20, 80, "utf8mb4", "NO", c[2], "utf8mb4_general_ci", c[3], c[4], c[5])
+	}
+	mock.ExpectQuery("SELECT COLUMN_NAME, DATA_TYPE").WithArgs(table).WillReturnRows(types)
+	mock.ExpectQuery("SELECT TABLE_COMMENT").WithArgs(table).WillReturnRows(sqlmock.NewRows([]string{"TABLE_COMMENT"}).AddRow(""))
+}
+
+// convert_defaults：中文字符串默认值生成 DDL，NULL、非字符与表达式默认值不动
+func TestPlanTableDDLConvertDefaults(t *testing.T) {
+	cols := [][6]any{
+		{"status", "varchar", "待处理", "", "varchar(20)", ""},
+		{"state", "varchar", "'处理中'", "", "varchar(20)", ""}, // MariaDB 返回带引号的字面量
+		{"title", "varchar", "无标题", "标题", "varchar(20)", ""},
+		{"note", "varchar", nil, "", "varchar(20)", ""},
+		{"en", "varchar", "pending", "", "varchar(20)", ""},
+		{"num", "int", "0", "", "int", ""},
+		{"created", "datetime", "CURRENT_TIMESTAMP", "", "datetime", "DEFAULT_GENERATED"},
+		{"phase", "enum", "开始", "", "enum('开始','结束')", ""},
+	}
+	db, mock := newMock(t)
+	expectPlanSchema(mock, "tasks", cols)
+	got, err := planTableDDL(context.Background(), db, MySQLTblEntry{Table: "tasks", ConvertDefaults: true}, ConvertOptions{To: "s2t"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PlanStatement{
+		{Column: "phase", Kinds: []string{"default"}, Notes: []string{"转换后的默认值 開始 含非列成员 開始（在 columns 中列出该列可补充成员），请手动处理"}},
+		{Column: "state", Kinds: []string{"default"}, SQL: "ALTER TABLE `tasks` ALTER COLUMN `state` SET DEFAULT '處理中';"},
+		{Column: "status", Kinds: []string{"default"}, SQL: "ALTER TABLE `tasks` ALTER COLUMN `status` SET DEFAULT '待處理';"},
+		// 注释也需转换时合并为一条 MODIFY COLUMN
+		{Column: "title", Kinds: []string{"comment", "default"},
+			SQL: "ALTER TABLE `tasks` MODIFY COLUMN `title` VARCHAR(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL DEFAULT '無標題' COMMENT '標題';"},
+	}
+	checkPlanStatements(t, got, want)
+
+	// 未开启时不生成默认值相关的 DDL
+	db, mock = newMock(t)
+	expectPlanSchema(mock, "tasks", cols)
+	got, err = planTableDDL(context.Background(), db, MySQLTblEntry{Table: "tasks"}, ConvertOptions{To: "s2t"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPlanStatements(t, got, []PlanStatement{{Column: "title", Kinds: []string{"comment"},
+		SQL: "ALTER TABLE `tasks` MODIFY COLUMN `title` VARCHAR(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL DEFAULT '无标题' COMMENT '標題';"}})
+}
+
+func checkPlanStatements(t *testing.T, got, want []PlanStatement) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("statements = %+v, want %d", got, len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Table != "tasks" || g.Column != w.Column || !slices.Equal(g.Kinds, w.Kinds) || g.SQL != w.SQL || !slices.Equal(g.Notes, w.Notes) {
+			t.Errorf("statement %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
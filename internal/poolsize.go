@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PoolSize 连接池大小（max_open / max_idle）：正数为显式取值，0 表示不限制（database/sql 默认），
+// PoolAuto 表示按每表并发自动估算。JSON 中可写数字或 "auto"，命令行参数同样支持 auto
+type PoolSize int
+
+// PoolAuto 自动估算连接池大小
+const PoolAuto PoolSize = -1
+
+func (p PoolSize) String() string {
+	if p == PoolAuto {
+		return "auto"
+	}
+	return strconv.Itoa(int(p))
+}
+
+// Set 实现 flag.Value
+func (p *PoolSize) Set(s string) error {
+	v, err := ParsePoolSize(s)
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+func (p *PoolSize) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		v, err := ParsePoolSize(s)
+		if err != nil {
+			return err
+		}
+		*p = v
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("连接池大小须为非负整数或 \"auto\"：%s", string(b))
+	}
+	if n < 0 {
+		return fmt.Errorf("连接池大小不能为负数：%d", n)
+	}
+	*p = PoolSize(n)
+	return nil
+}
+
+func (p PoolSize) MarshalJSON() ([]byte, error) {
+	if p == PoolAuto {
+		return []byte(`"auto"`), nil
+	}
+	return []byte(strconv.Itoa(int(p))), nil
+}
+
+// ParsePoolSize 解析非负整数或 auto
+func ParsePoolSize(s string) (PoolSize, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "auto") {
+		return PoolAuto, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("连接池大小须为非负整数或 auto：%q", s)
+	}
+	return PoolSize(n), nil
+}
+
+// autoPoolSize 估算单表连接池所需的最大连接数。每张表在一个 goroutine 中顺序执行读取、UPDATE 与计数、校验、行备份等辅助查询，
+// 通常只占用 1 个连接；读游标在处理期间保持打开（cursor：stream_results 或无主键表边读边改）时，UPDATE 与辅助查询需要另 1 个。
+// 读查询走 read_dsn（replica）时游标在副本连接池上，两个连接池各需 1 个。每张表使用独立的连接池，tables_parallel 张表同时运行时总连接数为各表之和
+func autoPoolSize(cursor, replica bool) int {
+	if cursor && !replica {
+		return 2
+	}
+	return 1
+}
+
+// openCursor 处理期间是否一直保持读游标：stream_results 或无主键表（边读边改）
+func (c MySQLConfig) openCursor() bool {
+	return c.StreamResults || len(c.PK) == 0
+}
+
+// resolvePool 把 max_open / max_idle 换算为实际设置到连接池的值（0 表示不设置）：
+// auto 按 autoPoolSize 估算（max_idle auto 时与 max_open 相同，批次之间连接不被回收）；
+// 显式取值保持不变，少于读游标与 UPDATE 同时所需的连接数时报错（否则 UPDATE 会一直等待连接）
+func (c MySQLConfig) resolvePool() (open, idle int, err error) {
+	need := autoPoolSize(c.openCursor(), c.ReadDSN != "")
+	switch {
+	case c.MaxOpenConns == PoolAuto:
+		open = need
+		log.Printf("[mysql] table=%s max_open=auto：设为 %d%s", c.Table, open, poolNote(c))
+	case c.MaxOpenConns > 0:
+		open = int(c.MaxOpenConns)
+		if open < need {
+			return 0, 0, fmt.Errorf("table=%s max_open=%d 不足：%s，UPDATE 需要另一个连接，请调大 max_open（至少 %d）或改为 \"auto\"",
+				c.Table, open, cursorReason(c), need)
+		}
+	}
+	switch {
+	case c.MaxIdleConns == PoolAuto:
+		idle = open
+		if idle == 0 {
+			idle = need // max_open 不限制时按估算值保留空闲连接
+		}
+	case c.MaxIdleConns > 0:
+		idle = int(c.MaxIdleConns)
+	}
+	return open, idle, nil
+}
+
+// autoPoolTotal 估算 max_open=auto 时所有表合计同时占用的连接数：连接池最大的 tables_parallel 张表之和（配置 read_dsn 时含副本连接）
+func (c *MySQLFileConfig) autoPoolTotal() int {
+	sizes := make([]int, len(c.Tables))
+	for i, t := range c.Tables {
+		sizes[i] = autoPoolSize(c.StreamResults || len(t.PK) == 0, c.ReadDSN != "")
+		if c.ReadDSN != "" {
+			sizes[i] *= 2
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	total := 0
+	for _, n := range sizes[:min(c.TablesParallel, len(sizes))] {
+		total += n
+	}
+	return total
+}
+
+// cursorReason 读游标一直保持打开的原因
+func cursorReason(c MySQLConfig) string {
+	if c.StreamResults {
+		return "stream_results 的读游标在处理期间占用 1 个连接"
+	}
+	return "无主键表边读边改，读游标在处理期间占用 1 个连接"
+}
+
+// poolNote auto 估算值的说明
+func poolNote(c MySQLConfig) string {
+	switch {
+	case c.ReadDSN != "":
+		return "（读游标在 read_dsn 副本连接池上）"
+	case c.openCursor():
+		return "（" + cursorReason(c) + "）"
+	}
+	return "（读取与 UPDATE 顺序执行）"
+}
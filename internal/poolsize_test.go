@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResolvePool(t *testing.T) {
+	pk := []string{"id"}
+	for _, tc := range []struct {
+		name       string
+		cfg        MySQLConfig
+		open, idle int
+		wantErr    bool
+	}{
+		{"auto with pk", MySQLConfig{PK: pk, MaxOpenConns: PoolAuto, MaxIdleConns: PoolAuto}, 1, 1, false},
+		// workers 不影响连接数：每张表的读取与 UPDATE 顺序执行
+		{"auto ignores workers", MySQLConfig{PK: pk, Workers: 16, MaxOpenConns: PoolAuto}, 1, 0, false},
+		{"auto stream", MySQLConfig{PK: pk, StreamResults: true, MaxOpenConns: PoolAuto, MaxIdleConns: PoolAuto}, 2, 2, false},
+		{"auto no pk", MySQLConfig{MaxOpenConns: PoolAuto}, 2, 0, false},
+		{"auto stream with replica", MySQLConfig{PK: pk, StreamResults: true, ReadDSN: "r", MaxOpenConns: PoolAuto}, 1, 0, false},
+		{"unlimited open, auto idle", MySQLConfig{PK: pk, StreamResults: true, MaxIdleConns: PoolAuto}, 0, 2, false},
+		{"explicit kept", MySQLConfig{PK: pk, MaxOpenConns: 200, MaxIdleConns: 10}, 200, 10, false},
+		{"explicit one with pk", MySQLConfig{PK: pk, MaxOpenConns: 1}, 1, 0, false},
+		{"explicit one stream", MySQLConfig{PK: pk, StreamResults: true, MaxOpenConns: 1}, 0, 0, true},
+		{"explicit one no pk", MySQLConfig{MaxOpenConns: 1}, 0, 0, true},
+		{"explicit one no pk with replica", MySQLConfig{ReadDSN: "r", MaxOpenConns: 1}, 1, 0, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			open, idle, err := tc.cfg.resolvePool()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if open != tc.open || idle != tc.idle {
+				t.Errorf("resolvePool = %d, %d; want %d, %d", open, idle, tc.open, tc.idle)
+			}
+		})
+	}
+}
+
+func TestAutoPoolTotal(t *testing.T) {
+	tables := []MySQLTblEntry{{Table: "a", PK: []string{"id"}}, {Table: "b"}, {Table: "c", PK: []string{"id"}}}
+	for _, tc := range []struct {
+		name string
+		cfg  MySQLFileConfig
+		want int
+	}{
+		{"largest tables first", MySQLFileConfig{Tables: tables, TablesParallel: 2, Workers: 8}, 3},
+		{"all tables", MySQLFileConfig{Tables: tables, TablesParallel: 5}, 4},
+		{"stream", MySQLFileConfig{Tables: tables, TablesParallel: 3, StreamResults: true}, 6},
+		{"replica pools", MySQLFileConfig{Tables: tables, TablesParallel: 2, ReadDSN: "r", StreamResults: true}, 4},
+	} {
+		if got := tc.cfg.autoPoolTotal(); got != tc.want {
+			t.Errorf("%s: autoPoolTotal = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPoolSizeJSON(t *testing.T) {
+	for in, want := range map[string]PoolSize{`"auto"`: PoolAuto, `"AUTO"`: PoolAuto, `8`: 8, `"8"`: 8, `0`: 0} {
+		var p PoolSize
+		if err := json.Unmarshal([]byte(in), &p); err != nil || p != want {
+			t.Errorf("Unmarshal(%s) = %v, %v; want %v", in, p, err, want)
+		}
+	}
+	for _, in := range []string{`-1`, `"x"`, `1.5`, `"-2"`} {
+		var p PoolSize
+		if err := json.Unmarshal([]byte(in), &p); err == nil {
+			t.Errorf("Unmarshal(%s) should fail", in)
+		}
+	}
+	if bs, _ := json.Marshal(PoolAuto); string(bs) != `"auto"` {
+		t.Errorf("Marshal(auto) = %s", bs)
+	}
+}
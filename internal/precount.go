@@ -0,0 +1,36 @@
+package internal
+
+import "context"
+
+// readOnlyCopy 返回用于只读试运行的配置副本：强制 dry_run，关闭 shadow_table / verify / max_errors（转换失败的行照常计数，不熔断）；
+// dry_run 下不写水位、不写已完成清单
+func readOnlyCopy(fileCfg *MySQLFileConfig) *MySQLFileConfig {
+	cfg := *fileCfg
+	cfg.Tables = append([]MySQLTblEntry(nil), fileCfg.Tables...)
+	cfg.DryRun, cfg.ShadowTable, cfg.Verify = true, false, false
+	unlimited := -1
+	cfg.MaxErrors = &unlimited
+	return &cfg
+}
+
+// CountRowsToChange 以只读试运行统计配置文件将会更新的行数（所有表合计），供真实写入前判断是否需要确认
+func CountRowsToChange(ctx context.Context, fileCfg *MySQLFileConfig, baseDir string) (int64, error) {
+	metrics := NewMetrics()
+	if _, err := RunMySQLFromFileConfig(ctx, readOnlyCopy(fileCfg), baseDir, MySQLRunOptions{Metrics: metrics}); err != nil {
+		return 0, err
+	}
+	return metrics.totalChanged(), nil
+}
+
+// CountTableRowsToChange 与 CountRowsToChange 相同，用于单表模式的配置
+func CountTableRowsToChange(ctx context.Context, cfg MySQLConfig) (int64, error) {
+	metrics := NewMetrics()
+	cfg.DryRun, cfg.Shadow, cfg.Verify = true, false, false
+	cfg.MaxErrors = -1
+	cfg.Stats, cfg.LengthReport, cfg.TermReport, cfg.Metrics = &Stats{}, nil, nil, metrics
+	cfg.ChangeLog, cfg.Events, cfg.Approved, cfg.RowBackup = nil, nil, nil, nil
+	if _, err := RunMySQL(ctx, cfg); err != nil {
+		return 0, err
+	}
+	return metrics.totalChanged(), nil
+}
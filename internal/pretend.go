@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vbauerster/mpb/v8"
+)
+
+// PretendConfig 模拟运行（mysql --pretend-rows，开发用）：不连接数据库、不读写任何文件，
+// 按给定行数与逐行耗时驱动真实的进度条、统计、事件与汇总输出，用于调试进度显示与中止流程
+type PretendConfig struct {
+	Rows      int64         // 总行数，平均分配到各模拟表
+	Tables    int           // 模拟表数量（并发处理）
+	Delay     time.Duration // 每行的模拟处理耗时
+	BatchSize int
+
+	Stats   *Stats
+	Metrics *Metrics
+	Events  *EventStream
+}
+
+// pretendTableName 模拟表名，带 pretend_ 前缀以免与真实表混淆
+func pretendTableName(i int) string {
+	return fmt.Sprintf("pretend_%02d", i+1)
+}
+
+// pretendOutcome 第 i 行的模拟转换结果：每 5 行中 1 行纯 ASCII、1 行无变化、其余发生转换
+func pretendOutcome(i int64) ConvertOutcome {
+	switch i % 5 {
+	case 0:
+		return OutcomeASCIIOnly
+	case 1:
+		return OutcomeUnchanged
+	}
+	return OutcomeConverted
+}
+
+// RunPretend 执行模拟运行并返回汇总结果（视同 dry-run，已写入恒为 0）。
+// ctx 取消时与真实运行一样在批次边界停止，未完成的进度条中止，返回 ctx.Err()
+func RunPretend(ctx context.Context, pc PretendConfig) (RunStats, error) {
+	if pc.Rows <= 0 {
+		return RunStats{}, classify(ErrConfigInvalid, errors.New("模拟行数须大于 0"))
+	}
+	if pc.Tables <= 0 {
+		pc.Tables = 1
+	}
+	if int64(pc.Tables) > pc.Rows {
+		pc.Tables = int(pc.Rows) // 每张模拟表至少 1 行：总量为 0 的进度条永远不会完成，p.Wait 会一直阻塞
+	}
+	if pc.BatchSize <= 0 {
+		pc.BatchSize = 500
+	}
+	if pc.Stats == nil {
+		pc.Stats = &Stats{}
+	}
+	log.Printf("[模拟] 模拟运行：%d 行 / %d 张表，每行 %s；不连接数据库、不读写文件，以下进度与统计均为模拟数据", pc.Rows, pc.Tables, pc.Delay)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	p := mpb.New(mpb.WithWidth(60), mpb.WithOutput(os.Stdout), mpb.WithWaitGroup(&wg))
+	var agg *aggregateBar
+	if pc.Tables > 1 {
+		agg = newAggregateBar(p, pc.Tables)
+	}
+	counts := make([]*tableMetrics, pc.Tables)
+	errs := make([]error, pc.Tables)
+	for i := range pc.Tables {
+		rows := pc.Rows / int64(pc.Tables)
+		if int64(i) < pc.Rows%int64(pc.Tables) {
+			rows++
+		}
+		cfg := MySQLConfig{
+			Table:     pretendTableName(i),
+			Columns:   []string{"content"},
+			BatchSize: pc.BatchSize,
+			DryRun:    true,
+			Stats:     pc.Stats,
+			Metrics:   pc.Metrics,
+			Events:    pc.Events,
+			aggregate: agg,
+			counts:    &tableMetrics{},
+		}
+		counts[i] = cfg.counts
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = pretendTable(ctx, cfg, p, i, rows, pc.Delay)
+		}()
+	}
+	wg.Wait()
+	agg.finish()
+	p.Wait()
+
+	rs := RunStats{Values: pc.Stats.Snapshot(), Duration: time.Since(start), DryRun: true}
+	for i := range pc.Tables {
+		status := "完成"
+		if errs[i] != nil {
+			status = "失败"
+		}
+		rs.addTable(tableStats(pretendTableName(i), status, counts[i], errs[i]), true)
+	}
+	var buf strings.Builder
+	_ = writeTableSummaries(&buf, rs.Tables, true)
+	log.Printf("[模拟] 以下为模拟数据\n%s", buf.String())
+	return rs, errors.Join(errs...)
+}
+
+// pretendTable 模拟处理一张表：按批推进，批内逐行等待 delay 后按 pretendOutcome 记录统计
+func pretendTable(ctx context.Context, cfg MySQLConfig, p *mpb.Progress, priority int, total int64, delay time.Duration) (err error) {
+	start := time.Now()
+	defer func() {
+		cfg.counts.duration = time.Since(start)
+		cfg.Metrics.ObserveDuration(cfg.Table, cfg.counts.duration)
+		if err != nil {
+			cfg.Events.Error(cfg.Table, "", err)
+			return
+		}
+		cfg.Events.TableFinished(cfg.Table, cfg.counts.scanned, cfg.counts.changed, cfg.counts.failed, time.Since(start))
+	}()
+	bar := newTableBar(p, "模拟 "+cfg.Table, total, priority)
+	cfg.aggregate.addTotal(total)
+	cfg.Events.TableStarted(cfg.Table, "", cfg.Columns, total, true)
+
+	eta := &batchETA{bar: bar}
+	for done := int64(0); done < total; {
+		if err := ctx.Err(); err != nil {
+			log.Printf("[模拟] 中止 table=%s：已处理 %d/%d 行", cfg.Table, done, total)
+			bar.Abort(false)
+			return err
+		}
+		eta.begin()
+		n := min(int64(cfg.BatchSize), total-done)
+		for j := range n {
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+			oc := pretendOutcome(done + j)
+			cfg.rowScanned()
+			cfg.Stats.Record(oc)
+			_ = cfg.recordRow(oc == OutcomeConverted, false)
+			eta.row()
+			cfg.aggregate.increment()
+		}
+		eta.end()
+		done += n
+		cfg.Events.BatchDone(cfg.Table, done, total)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// runPretend 带超时执行 RunPretend，避免进度条未完成时测试一直挂起
+func runPretend(t *testing.T, ctx context.Context, pc PretendConfig) (RunStats, error) {
+	t.Helper()
+	type result struct {
+		rs  RunStats
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		rs, err := RunPretend(ctx, pc)
+		done <- result{rs, err}
+	}()
+	select {
+	case r := <-done:
+		return r.rs, r.err
+	case <-time.After(10 * time.Second):
+		t.Fatal("RunPretend did not return")
+		return RunStats{}, nil
+	}
+}
+
+func TestRunPretendStats(t *testing.T) {
+	for _, tc := range []struct {
+		rows   int64
+		tables int
+	}{
+		{rows: 23, tables: 3},
+		{rows: 7, tables: 1},
+		{rows: 2, tables: 4}, // 行数少于表数：只模拟 2 张表
+		{rows: 1, tables: 1},
+	} {
+		stats := &Stats{}
+		rs, err := runPretend(t, context.Background(), PretendConfig{Rows: tc.rows, Tables: tc.tables, BatchSize: 4, Stats: stats})
+		if err != nil {
+			t.Fatalf("rows=%d tables=%d: %v", tc.rows, tc.tables, err)
+		}
+
+		// 与 RunPretend 相同的分配方式：各表行数相差不超过 1，行号在每张表内从 0 开始
+		tables := min(int64(tc.tables), tc.rows)
+		var want Stats
+		for i := range tables {
+			n := tc.rows / tables
+			if i < tc.rows%tables {
+				n++
+			}
+			for r := range n {
+				want.Record(pretendOutcome(r))
+			}
+		}
+		got := stats.Snapshot()
+		if got.Converted != want.Converted || got.SkippedASCII != want.SkippedASCII || got.Unchanged != want.Unchanged {
+			t.Errorf("rows=%d tables=%d: stats = %+v, want %+v", tc.rows, tc.tables, got, want)
+		}
+		if len(rs.Tables) != int(tables) {
+			t.Errorf("rows=%d tables=%d: %d table summaries, want %d", tc.rows, tc.tables, len(rs.Tables), tables)
+		}
+		if rs.Scanned != tc.rows {
+			t.Errorf("rows=%d tables=%d: scanned = %d", tc.rows, tc.tables, rs.Scanned)
+		}
+		if !rs.DryRun {
+			t.Error("pretend run should be reported as dry-run")
+		}
+	}
+}
+
+func TestRunPretendCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := runPretend(t, ctx, PretendConfig{Rows: 100, Tables: 2, BatchSize: 10})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestRunPretendInvalid(t *testing.T) {
+	if _, err := RunPretend(context.Background(), PretendConfig{}); !errors.Is(err, ErrConfigInvalid) {
+		t.Fatalf("err = %v, want ErrConfigInvalid", err)
+	}
+}
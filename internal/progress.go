@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+)
+
+// aggregateBar 多表汇总进度条（固定在最下方）：总量为各表总量之和，随各表动态扩充；方法对 nil 安全
+type aggregateBar struct {
+	bar   *mpb.Bar
+	mu    sync.Mutex
+	total int64
+}
+
+func newAggregateBar(p *mpb.Progress, tables int) *aggregateBar {
+	bar := p.AddBar(0,
+		mpb.BarPriority(math.MaxInt),
+		mpb.PrependDecorators(
+			decor.Name(fmt.Sprintf("[总计 %d 表] ", tables)),
+			decor.CountersNoUnit("%d/%d"),
+			decor.Percentage(decor.WCSyncWidth),
+		),
+		mpb.AppendDecorators(
+			decor.AverageETA(decor.ET_STYLE_GO, decor.WCSyncWidth),
+		),
+	)
+	return &aggregateBar{bar: bar}
+}
+
+// addTotal 调整总量；n 可为负（近似总量在表完成时按实际行数校正）
+func (a *aggregateBar) addTotal(n int64) {
+	if a == nil || n == 0 {
+		return
+	}
+	a.mu.Lock()
+	a.total += n
+	a.bar.SetTotal(a.total, false)
+	a.mu.Unlock()
+}
+
+func (a *aggregateBar) increment() {
+	if a == nil {
+		return
+	}
+	a.bar.Increment()
+}
+
+// finish 所有表结束后收尾：跳过/中止的表会让当前值小于总量，按当前值完成，避免 p.Wait() 阻塞
+func (a *aggregateBar) finish() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.bar.SetTotal(a.bar.Current(), true)
+}
+
+// newTableBar 单表进度条：[表名] 当前/总量 百分比，剩余时间以批耗时为样本估算（见 batchETA）
+func newTableBar(p *mpb.Progress, label string, total int64, priority int) *mpb.Bar {
+	return p.AddBar(
+		total,
+		mpb.BarPriority(priority),
+		mpb.PrependDecorators(
+			decor.Name("["+label+"] "),
+			decor.CountersNoUnit("%d/%d"),
+			decor.Percentage(decor.WCSyncWidth),
+		),
+		mpb.AppendDecorators(
+			decor.EwmaETA(decor.ET_STYLE_GO, etaBatchAge, decor.WCSyncWidth), // 估算剩余时间（以批耗时为样本）
+		),
+	)
+}
+
+// etaBatchAge 单表剩余时间 EWMA 的窗口（样本为批，而非行）
+const etaBatchAge = 10
+
+// batchETA 按批推进单表进度条：批内只计数，批末以整批实测耗时（含 SELECT、转换与写回）调用 EwmaIncrInt64，
+// EWMA 的样本即“批耗时/行数”。行长差异很大（短标签与长文章混排）时，剩余时间比逐行计时平稳；bar 为 nil 时不做任何事
+type batchETA struct {
+	bar   *mpb.Bar
+	start time.Time
+	rows  int64
+}
+
+func (e *batchETA) begin() {
+	e.start = time.Now()
+	e.rows = 0
+}
+
+func (e *batchETA) row() { e.rows++ }
+
+func (e *batchETA) end() {
+	if e.bar != nil && e.rows > 0 {
+		e.bar.EwmaIncrInt64(e.rows, time.Since(e.start))
+	}
+	e.rows = 0
+}
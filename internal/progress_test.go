@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+)
+
+// ewmaRecorder 记录进度条 EWMA 装饰器收到的样本
+type ewmaRecorder struct {
+	decor.Decorator
+	mu    sync.Mutex
+	rows  []int64
+	spans []time.Duration
+}
+
+func (r *ewmaRecorder) EwmaUpdate(n int64, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rows = append(r.rows, n)
+	r.spans = append(r.spans, d)
+}
+
+func newRecordedBar(t *testing.T, total int64) (*mpb.Bar, *ewmaRecorder) {
+	t.Helper()
+	p := mpb.New(mpb.WithOutput(io.Discard))
+	rec := &ewmaRecorder{Decorator: decor.Name("")}
+	bar := p.AddBar(total, mpb.AppendDecorators(rec))
+	t.Cleanup(func() {
+		bar.Abort(false)
+		p.Wait()
+	})
+	return bar, rec
+}
+
+func TestBatchETA(t *testing.T) {
+	bar, rec := newRecordedBar(t, 100)
+	eta := &batchETA{bar: bar}
+	eta.begin()
+	for range 3 {
+		eta.row()
+	}
+	time.Sleep(20 * time.Millisecond)
+	eta.end()
+	// 空批不产生样本
+	eta.begin()
+	eta.end()
+
+	// Current 与 EwmaIncrInt64 在进度条内部串行执行，取值即等待此前的样本写入完成
+	if got := bar.Current(); got != 3 {
+		t.Errorf("bar current = %d", got)
+	}
+	if len(rec.rows) != 1 || rec.rows[0] != 3 {
+		t.Fatalf("ewma rows = %v, want [3]", rec.rows)
+	}
+	if d := rec.spans[0]; d < 20*time.Millisecond || d > 5*time.Second {
+		t.Errorf("ewma duration = %v", d)
+	}
+
+	// 无进度条时不做任何事
+	nilETA := &batchETA{}
+	nilETA.begin()
+	nilETA.row()
+	nilETA.end()
+}
+
+// 每批一个样本：行数为该批行数，耗时覆盖 SELECT（此处人为延迟）
+func TestProcessWithPKBatchDurations(t *testing.T) {
+	db, mock := newMock(t)
+	page := func(where string) string {
+		return regexp.QuoteMeta("SELECT `id`,`name` FROM `t`" + where + " ORDER BY `id` LIMIT ?")
+	}
+	mock.ExpectQuery(page("")).WithArgs(2).WillDelayFor(30 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "ok").AddRow("2", "ok"))
+	mock.ExpectQuery(page(" WHERE (`id`) > (?)")).WithArgs("2", 2).WillDelayFor(30 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("3", "ok"))
+	mock.ExpectQuery(page(" WHERE (`id`) > (?)")).WithArgs("3", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	cfg := MySQLConfig{Table: "t", PK: []string{"id"}, Columns: []string{"name"}, To: "s2t", BatchSize: 2,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{}}
+	bar, rec := newRecordedBar(t, 100)
+	if _, err := processWithPK(context.Background(), db, cfg, nil, bar, 100); err != nil {
+		t.Fatal(err)
+	}
+	if got := bar.Current(); got != 3 {
+		t.Errorf("bar current = %d", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.rows) != 2 || rec.rows[0] != 2 || rec.rows[1] != 1 {
+		t.Fatalf("ewma rows = %v, want [2 1]", rec.rows)
+	}
+	for i, d := range rec.spans {
+		if d < 30*time.Millisecond || d > 5*time.Second {
+			t.Errorf("batch %d duration = %v", i+1, d)
+		}
+	}
+}
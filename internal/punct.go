@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// keepNonHanChanges 仅标点模式（--punct-only）：保留 out 相对 in 的非汉字改动（标点、全半角等），汉字改动一律还原。
+//
+// OpenCC 的配置只含汉字词典（s2twp 的 p 指词组，不含标点映射），因此标点改动来自 normalize（如 nfkc 把全角逗号规范为半角）
+// 与 replace 规则。做法：把两串各切成汉字 / 非汉字交替的片段，片段序列对齐（类别与个数相同，OpenCC 的词组替换不改变这一结构）时，
+// 汉字片段取原文、非汉字片段取结果；不对齐时（少见，如替换规则把汉字换成字母）去掉公共前后缀，
+// 中间的差异不含汉字才采用，否则整段还原
+func keepNonHanChanges(in, out string) string {
+	if in == out {
+		return in
+	}
+	a, b := hanRuns(in), hanRuns(out)
+	if len(a) == len(b) {
+		aligned := true
+		for i := range a {
+			if a[i].han != b[i].han {
+				aligned = false
+				break
+			}
+		}
+		if aligned {
+			var sb strings.Builder
+			sb.Grow(len(out))
+			for i := range a {
+				if a[i].han {
+					sb.WriteString(a[i].s)
+				} else {
+					sb.WriteString(b[i].s)
+				}
+			}
+			return sb.String()
+		}
+	}
+	pre, suf := commonAffixes(in, out)
+	if HasChinese(in[pre:len(in)-suf]) || HasChinese(out[pre:len(out)-suf]) {
+		return in
+	}
+	return out
+}
+
+type hanRun struct {
+	s   string
+	han bool
+}
+
+// hanRuns 按“是否汉字”把 s 切成交替的片段
+func hanRuns(s string) []hanRun {
+	var runs []hanRun
+	start := 0
+	for i, r := range s {
+		h := unicode.Is(unicode.Han, r)
+		if i == 0 {
+			runs = append(runs, hanRun{han: h})
+			continue
+		}
+		if last := &runs[len(runs)-1]; last.han != h {
+			last.s = s[start:i]
+			start = i
+			runs = append(runs, hanRun{han: h})
+		}
+	}
+	if len(runs) > 0 {
+		runs[len(runs)-1].s = s[start:]
+	}
+	return runs
+}
+
+// commonAffixes a、b 公共前缀与公共后缀的字节长度（按完整字符计，两者不重叠）
+func commonAffixes(a, b string) (pre, suf int) {
+	for pre < len(a) && pre < len(b) {
+		ra, n := utf8.DecodeRuneInString(a[pre:])
+		rb, _ := utf8.DecodeRuneInString(b[pre:])
+		if ra != rb {
+			break
+		}
+		pre += n
+	}
+	for suf < len(a)-pre && suf < len(b)-pre {
+		ra, n := utf8.DecodeLastRuneInString(a[:len(a)-suf])
+		rb, m := utf8.DecodeLastRuneInString(b[:len(b)-suf])
+		if ra != rb || n != m {
+			break
+		}
+		suf += n
+	}
+	return pre, suf
+}
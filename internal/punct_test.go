@@ -0,0 +1,44 @@
+package internal
+
+import "testing"
+
+func TestPunctOnlyKeepsHan(t *testing.T) {
+	rep, err := NewReplacer(map[string]string{"“": "「", "”": "」"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := ConvertOptions{To: "s2twp", Normalize: "nfkc", Replace: rep, PunctOnly: true}
+	for in, want := range map[string]string{
+		"简体，软件（测试）":  "简体,软件(测试)",
+		"他说“这个软件很好”": "他说「这个软件很好」",
+		"这里ＡＢＣ１２３":   "这里ABC123",
+	} {
+		out, oc, err := ConvertDetail(opts, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != want || oc != OutcomeConverted {
+			t.Errorf("ConvertDetail(%q) = %q, %v; want %q", in, out, oc, want)
+		}
+	}
+	// 只有汉字改动：结果与原文相同
+	if out, oc, _ := ConvertDetail(opts, "这个软件很好"); out != "这个软件很好" || oc != OutcomeUnchanged {
+		t.Errorf("han only = %q, %v", out, oc)
+	}
+}
+
+func TestKeepNonHanChanges(t *testing.T) {
+	for _, tc := range []struct{ in, out, want string }{
+		{"简体，好", "簡體,好", "简体,好"},
+		{"软件", "軟體", "软件"},
+		// 片段不对齐：差异不含汉字时采用
+		{"简体", "简体！", "简体！"},
+		// 片段不对齐且差异含汉字：整段还原
+		{"版本v2", "版本二", "版本v2"},
+		{"", "", ""},
+	} {
+		if got := keepNonHanChanges(tc.in, tc.out); got != tc.want {
+			t.Errorf("keepNonHanChanges(%q, %q) = %q, want %q", tc.in, tc.out, got, tc.want)
+		}
+	}
+}
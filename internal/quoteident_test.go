@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	for in, want := range map[string]string{
+		"id":     "`id`",
+		"order":  "`order`",
+		"a`b":    "`a``b`",
+		"``":     "``````",
+		"名 称":    "`名 称`",
+		`a"b`:    "`a\"b`",
+		"x`;--y": "`x``;--y`",
+	} {
+		if got := quoteIdent(in); got != want {
+			t.Errorf("quoteIdent(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+// 名称含反引号时各处生成的 SQL 都按 quoteIdent 转义
+func TestQueryBuildersEscapeBackticks(t *testing.T) {
+	pk := []string{"i`d", "k"}
+	if got, _ := keysCond(pk, []KeyTuple{{"1", "a"}}); got != "(`i``d`,`k`) IN ((?,?))" {
+		t.Errorf("keysCond = %s", got)
+	}
+	if got, _ := keysCond(pk[:1], []KeyTuple{{"1"}, {"2"}}); got != "`i``d` IN (?,?)" {
+		t.Errorf("keysCond single = %s", got)
+	}
+	if got, _ := (MySQLConfig{IncrementalColumn: "up`d"}).incrementalCond("2024-01-01"); got != "`up``d` > ?" {
+		t.Errorf("incrementalCond = %s", got)
+	}
+	lo := int64(1)
+	if got, _ := (MySQLConfig{PK: pk, PKMin: &lo}).pkRangeCond(); got != "`i``d` >= ?" {
+		t.Errorf("pkRangeCond = %s", got)
+	}
+	got := joinSelectSQL(MySQLTblEntry{Table: "a`t", PK: []string{"i`d"}, Columns: []string{"c`1"}, Join: "JOIN `b` ON `b`.`x` = `a``t`.`i``d`"})
+	if want := "SELECT `i``d`, `c``1` FROM `a``t` WHERE (`i``d`) IN (SELECT `a``t`.`i``d` FROM `a``t` JOIN `b` ON `b`.`x` = `a``t`.`i``d`)"; got != want {
+		t.Errorf("joinSelectSQL = %s", got)
+	}
+}
+
+func TestProcessWithPKEscapesBackticks(t *testing.T) {
+	db, mock := newMock(t)
+	cfg := MySQLConfig{
+		Table: "t`x", PK: []string{"i`d"}, Columns: []string{"ti`tle"}, To: "s2t", BatchSize: 10,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{},
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `i``d`,`ti``tle` FROM `t``x` ORDER BY `i``d` LIMIT ?")).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"i`d", "ti`tle"}).AddRow("1", "简体"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `t``x` SET `ti``tle` = ? WHERE `i``d` = ?")).
+		WithArgs("簡體", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `i``d`,`ti``tle` FROM `t``x` WHERE (`i``d`) > (?) ORDER BY `i``d` LIMIT ?")).
+		WithArgs("1", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"i`d", "ti`tle"}))
+	if _, err := processWithPK(context.Background(), db, cfg, nil, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
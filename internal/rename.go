@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// renamer 转换文件/目录名中的简体字；同一时刻只执行一个“检查目标 + rename”，避免并发下互相覆盖
+type renamer struct {
+	opts      ConvertOptions
+	toFor     func(path string) string // 按文件选择转换配置（ExtTo），目录名使用 opts.To
+	dryRun    bool
+	changeLog *ChangeLog
+
+	mu       sync.Mutex
+	reserved map[string]bool // dry-run 中已“占用”的目标路径，用于在不改名的情况下检测冲突
+	renamed  int
+	conflict int
+}
+
+func newRenamer(cfg FileConfig) *renamer {
+	return &renamer{
+		opts:      ConvertOptions{To: cfg.To, Normalize: cfg.Normalize, NormalizeInput: cfg.NormalizeInput, PunctOnly: cfg.PunctOnly},
+		toFor:     cfg.toFor,
+		dryRun:    cfg.DryRun,
+		changeLog: cfg.ChangeLog,
+		reserved:  map[string]bool{},
+	}
+}
+
+// rename 转换文件名并改名（转换配置按 ExtTo 选择）；返回改名后的路径（未改名时原样返回）
+func (r *renamer) rename(path string) string {
+	if r == nil {
+		return path
+	}
+	opts := r.opts
+	if r.toFor != nil {
+		opts.To = r.toFor(path)
+	}
+	return r.renameWith(path, opts)
+}
+
+// renameWith 用 opts 转换 path 的最后一段名称并改名
+func (r *renamer) renameWith(path string, opts ConvertOptions) string {
+	dir, name := filepath.Split(path)
+	newName, changed, err := ConvertWithOptions(opts, name)
+	if err != nil {
+		log.Printf("[file] 文件名转换失败 %s: %v", path, err)
+		return path
+	}
+	if !changed || newName == name {
+		return path
+	}
+	target := filepath.Join(dir, newName)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := os.Lstat(target); err == nil || r.reserved[target] {
+		r.conflict++
+		log.Printf("[file] 目标已存在，跳过重命名：%s -> %s", path, target)
+		return path
+	}
+	if r.dryRun {
+		r.reserved[target] = true
+		r.renamed++
+		log.Printf("[DRYRUN] 将重命名：%s -> %s", path, target)
+		r.changeLog.RecordRename(path, target)
+		return path
+	}
+	if err := os.Rename(path, target); err != nil {
+		log.Printf("[file] 重命名失败 %s -> %s: %v", path, target, err)
+		return path
+	}
+	r.renamed++
+	log.Printf("[OK] 重命名：%s -> %s", path, target)
+	return target
+}
+
+// renameDirs 由深到浅重命名目录，保证父目录改名时子路径已处理完；每改名一个目录调用一次 moved（可为 nil）
+func (r *renamer) renameDirs(dirs []string, moved func(from, to string)) {
+	if r == nil {
+		return
+	}
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], string(filepath.Separator)) > strings.Count(dirs[j], string(filepath.Separator))
+	})
+	for _, d := range dirs {
+		if to := r.renameWith(d, r.opts); to != d && moved != nil {
+			moved(d, to)
+		}
+	}
+}
+
+func (r *renamer) report() {
+	if r == nil {
+		return
+	}
+	log.Printf("[file] 重命名 %d 个，因目标已存在跳过 %d 个", r.renamed, r.conflict)
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// listDir 返回目录下的文件名（排序）
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range ents {
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+// 目标文件名已存在：内容照常转换，但不改名、不覆盖已有文件
+func TestRunFileRenameCollision(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"简体.md": "简体", "簡體.md": "原有", "说明.md": "说明"})
+	if _, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", Rename: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := listDir(t, dir), []string{"简体.md", "簡體.md", "說明.md"}; !slices.Equal(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	for name, want := range map[string]string{"简体.md": "簡體", "簡體.md": "原有"} {
+		if bs, _ := os.ReadFile(filepath.Join(dir, name)); string(bs) != want {
+			t.Errorf("%s = %q, want %q", name, bs, want)
+		}
+	}
+}
+
+// dry-run 不改名，但两个文件转换后同名时仍能检测到冲突
+func TestRenamerDryRunReservesTargets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"简体.md": "", "簡体.md": ""})
+	r := newRenamer(FileConfig{To: "s2t", DryRun: true})
+	a, b := filepath.Join(dir, "简体.md"), filepath.Join(dir, "簡体.md")
+	if got := r.rename(a); got != a {
+		t.Errorf("dry-run rename returned %s", got)
+	}
+	r.rename(b)
+	if r.renamed != 1 || r.conflict != 1 {
+		t.Errorf("renamed = %d, conflict = %d; want 1, 1", r.renamed, r.conflict)
+	}
+	if got, want := listDir(t, dir), []string{"简体.md", "簡体.md"}; !slices.Equal(got, want) {
+		t.Errorf("dry-run changed files: %v", got)
+	}
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Replacer 转换后的自定义替换（replace）：在 OpenCC 与规范化之后对输出做最后一遍字面量替换，
+// 用于固化 OpenCC 处理不对的词（如品牌名被转成了错误的繁体异体字）。
+// 同一位置多个原文可匹配时取最长者，等长时按原文字典序；替换结果不会再次参与匹配。方法对 nil 安全
+type Replacer struct {
+	rules  []replaceRule
+	byByte map[byte][]int // 原文首字节 -> rules 下标（已按长度降序、字典序排列）
+	stats  *Stats
+}
+
+type replaceRule struct {
+	from, to string
+	key      string // 统计键：原文=>替换为
+}
+
+// NewReplacer 编译替换表；m 为空时返回 nil。命中次数按规则累计到 stats（可为 nil）
+func NewReplacer(m map[string]string, stats *Stats) (*Replacer, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	r := &Replacer{byByte: map[byte][]int{}, stats: stats}
+	for from, to := range m {
+		if from == "" {
+			return nil, errors.New("replace 的原文不能为空")
+		}
+		r.rules = append(r.rules, replaceRule{from: from, to: to, key: from + "=>" + to})
+	}
+	sort.Slice(r.rules, func(i, j int) bool {
+		a, b := r.rules[i].from, r.rules[j].from
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+	for i, rule := range r.rules {
+		r.byByte[rule.from[0]] = append(r.byByte[rule.from[0]], i)
+	}
+	return r, nil
+}
+
+// Apply 自左向右扫描，每个位置取最长的匹配替换；返回结果并累计各规则的命中次数
+func (r *Replacer) Apply(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	var sb strings.Builder
+	var hits map[int]int64
+	last := 0 // s[last:i] 尚未写入 sb
+	for i := 0; i < len(s); {
+		matched := -1
+		for _, k := range r.byByte[s[i]] {
+			if strings.HasPrefix(s[i:], r.rules[k].from) {
+				matched = k
+				break
+			}
+		}
+		if matched < 0 {
+			i++
+			continue
+		}
+		if hits == nil {
+			hits = map[int]int64{}
+			sb.Grow(len(s))
+		}
+		sb.WriteString(s[last:i])
+		sb.WriteString(r.rules[matched].to)
+		hits[matched]++
+		i += len(r.rules[matched].from)
+		last = i
+	}
+	if hits == nil {
+		return s
+	}
+	sb.WriteString(s[last:])
+	for k, n := range hits {
+		r.stats.RecordReplaced(r.rules[k].key, n)
+	}
+	return sb.String()
+}
+
+// mergeReplace 合并全局与表级替换表：表级同名原文覆盖全局；两者皆空时返回 nil
+func mergeReplace(global, table map[string]string) map[string]string {
+	if len(global) == 0 && len(table) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(global)+len(table))
+	for k, v := range global {
+		out[k] = v
+	}
+	for k, v := range table {
+		out[k] = v
+	}
+	return out
+}
+
+// replacedMu 保护所有 Stats 的 Replaced（替换命中相对少见，共用一把锁即可，Stats 本身保持可按值拷贝）
+var replacedMu sync.Mutex
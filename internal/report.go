@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunReport --report-file：把控制台上的汇总（各表结果、合计、按值统计）另存为可归档的文本报告，
+// 失败与中止时同样写出。配置文件模式下每个配置文件一段；方法对 nil 安全
+type RunReport struct {
+	path  string
+	args  []string
+	start time.Time
+	runs  []reportRun
+}
+
+type reportRun struct {
+	title string
+	rs    RunStats
+	err   error
+}
+
+// NewRunReport path 为空时返回 nil；args 为命令行参数（写入报告时隐去 DSN 中的密码）
+func NewRunReport(path string, args []string) *RunReport {
+	if path == "" {
+		return nil
+	}
+	return &RunReport{path: path, args: args, start: time.Now()}
+}
+
+// Add 记录一段运行结果；title 为空时不输出段标题
+func (r *RunReport) Add(title string, rs RunStats, err error) {
+	if r == nil {
+		return
+	}
+	r.runs = append(r.runs, reportRun{title: title, rs: rs, err: err})
+}
+
+// Write 渲染并写出报告（先写临时文件再改名，中途退出不会留下半份报告）；stats 为按值统计，与控制台 --summary text 相同
+func (r *RunReport) Write(stats *Stats) error {
+	if r == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	r.render(&buf, stats)
+	tmp := r.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("写运行报告失败：%w", err)
+	}
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("写运行报告失败：%w", err)
+	}
+	return os.Rename(tmp, r.path)
+}
+
+func (r *RunReport) render(buf *bytes.Buffer, stats *Stats) {
+	end := time.Now()
+	args := redactArgs(r.args)
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			args[i] = strconv.Quote(a)
+		}
+	}
+	fmt.Fprintf(buf, "tradify-cli 运行报告\n命令：tradify-cli %s\n", strings.Join(args, " "))
+	fmt.Fprintf(buf, "开始：%s\n结束：%s（耗时 %s）\n", r.start.Format(time.RFC3339), end.Format(time.RFC3339), end.Sub(r.start).Round(time.Millisecond))
+	status := "成功"
+	for _, run := range r.runs {
+		switch {
+		case errors.Is(run.err, context.Canceled), errors.Is(run.err, context.DeadlineExceeded):
+			status = "已中止"
+		case run.err != nil:
+			status = "失败"
+		}
+	}
+	fmt.Fprintf(buf, "状态：%s\n", status)
+	for _, run := range r.runs {
+		buf.WriteString("\n")
+		if run.title != "" {
+			fmt.Fprintf(buf, "== %s ==\n", run.title)
+		}
+		_ = run.rs.WriteText(buf)
+		if run.err != nil {
+			fmt.Fprintf(buf, "错误：%v\n", run.err)
+		}
+	}
+	buf.WriteString("\n")
+	_ = stats.WriteSummary(buf, "text")
+}
+
+// redactArgs 隐去 --dsn / --read-dsn 取值中的密码
+func redactArgs(args []string) []string {
+	out := make([]string, len(args))
+	copy(out, args)
+	isDSN := func(flag string) bool {
+		flag = strings.TrimLeft(flag, "-")
+		return flag == "dsn" || flag == "read-dsn"
+	}
+	for i, a := range out {
+		if !strings.HasPrefix(a, "-") {
+			continue
+		}
+		if name, v, ok := strings.Cut(a, "="); ok {
+			if isDSN(name) {
+				out[i] = name + "=" + RedactDSN(v)
+			}
+			continue
+		}
+		if isDSN(a) && i+1 < len(out) {
+			out[i+1] = RedactDSN(out[i+1])
+		}
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunReportMatchesConsoleSummary(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.md": "简体", "b.md": "hello"})
+	stats := &Stats{}
+	rs, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", DryRun: true, Stats: stats})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "out", "report.txt")
+	r := NewRunReport(path, []string{"file", "--dir", dir, "--report-file", path})
+	r.Add("", rs, nil)
+	if err := r.Write(stats); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 报告中的合计与统计摘要与控制台输出逐字相同
+	var text, summary bytes.Buffer
+	if err := rs.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if err := stats.WriteSummary(&summary, "text"); err != nil {
+		t.Fatal(err)
+	}
+	got := string(bs)
+	for _, want := range []string{"状态：成功\n", text.String(), summary.String()} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report missing %q:\n%s", want, got)
+		}
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+}
+
+func TestRunReportStatus(t *testing.T) {
+	for _, tc := range []struct {
+		errs []error
+		want string
+	}{
+		{[]error{nil, fmt.Errorf("table=t: %w", ErrRowUpdate)}, "状态：失败"},
+		{[]error{nil, context.Canceled}, "状态：已中止"},
+	} {
+		path := filepath.Join(t.TempDir(), "report.txt")
+		r := NewRunReport(path, []string{"mysql"})
+		for i, err := range tc.errs {
+			r.Add(fmt.Sprintf("配置 %d.json", i), RunStats{}, err)
+		}
+		if err := r.Write(&Stats{}); err != nil {
+			t.Fatal(err)
+		}
+		bs, _ := os.ReadFile(path)
+		if !strings.Contains(string(bs), tc.want) || !strings.Contains(string(bs), "== 配置 1.json ==") {
+			t.Errorf("report = %s", bs)
+		}
+		if last := tc.errs[len(tc.errs)-1]; !strings.Contains(string(bs), "错误："+last.Error()) {
+			t.Errorf("report missing error %v:\n%s", last, bs)
+		}
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	args := []string{"mysql", "--dsn", "u:secret@tcp(h:3306)/db", "--read-dsn=u:secret@tcp(r:3306)/db", "--table", "t"}
+	got := strings.Join(redactArgs(args), " ")
+	if strings.Contains(got, "secret") || !strings.Contains(got, "u:***@tcp(h:3306)/db") || !strings.Contains(got, "--read-dsn=u:***@tcp(r:3306)/db") {
+		t.Errorf("redactArgs = %s", got)
+	}
+	if args[2] != "u:secret@tcp(h:3306)/db" {
+		t.Error("redactArgs modified its input")
+	}
+}
+
+func TestRunReportNil(t *testing.T) {
+	r := NewRunReport("", nil)
+	if r != nil {
+		t.Fatal("empty path should return nil")
+	}
+	r.Add("", RunStats{}, errors.New("x"))
+	if err := r.Write(&Stats{}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// 查询重试策略：连接级错误（断线、数据库重启）先 Ping 等待恢复，自首次出错起超过 reconnectTimeout、
+// 或 Ping 已恢复但查询仍连续 reconnectMaxRetries 次遇到连接错误（如代理接受 Ping 却断开查询）时放弃；
+// 暂时性错误（死锁、锁等待超时等）按 MySQLConfig.QueryRetryMax / QueryRetryDelay 重试；其它错误（语法、未知列等）立即中止。
+// 重连相关的时长为变量，仅供测试调小
+var (
+	reconnectBaseDelay = time.Second
+	reconnectMaxDelay  = 30 * time.Second
+	reconnectTimeout   = 5 * time.Minute
+)
+
+const (
+	reconnectMaxRetries = 10
+
+	defaultQueryRetryMax   = 3
+	defaultQueryRetryDelay = 5 * time.Second
+
+	defaultConnectTimeout = 10 * time.Second
+)
+
+// queryRetry 执行批次 SELECT，按错误类型重试；ctx 仅用于中止等待（不会打断进行中的查询）
+func queryRetry(ctx context.Context, db *sql.DB, cfg MySQLConfig, query string, args ...interface{}) (*sql.Rows, error) {
+	failures, reconnects := 0, 0
+	var down time.Time // 首次出现连接错误的时间，查询成功前不重置
+	for {
+		start := cfg.Stats.startTimer()
+		rows, err := db.Query(query, args...)
+		cfg.Stats.addTime(timeSQLQuery, start)
+		if err == nil {
+			return rows, nil
+		}
+		if isConnError(err) {
+			if down.IsZero() {
+				down = time.Now()
+			}
+			reconnects++
+			if reconnects > reconnectMaxRetries || time.Since(down) > reconnectTimeout {
+				return nil, classify(ErrDBConnect, fmt.Errorf("查询持续遇到连接错误 table=%s（已重连 %d 次，历时 %s），放弃：%w",
+					cfg.Table, reconnects-1, time.Since(down).Round(time.Second), err))
+			}
+			log.Printf("[mysql] 连接异常 table=%s：%v，等待数据库恢复…", cfg.Table, err)
+			if rerr := waitReconnect(ctx, db, down); rerr != nil {
+				if ctx.Err() != nil {
+					return nil, rerr
+				}
+				return nil, classify(ErrDBConnect, rerr)
+			}
+			continue
+		}
+		if !isTransientError(err) {
+			return nil, fmt.Errorf("查询失败 table=%s（不可重试）：%w", cfg.Table, err)
+		}
+		failures++
+		if failures > cfg.QueryRetryMax {
+			return nil, fmt.Errorf("查询失败 table=%s（已重试 %d 次）：%w", cfg.Table, failures-1, err)
+		}
+		log.Printf("[mysql] query err: %v, %s 后重试（%d/%d）…", err, cfg.QueryRetryDelay, failures, cfg.QueryRetryMax)
+		if err := sleepCtx(ctx, cfg.QueryRetryDelay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitReconnect 以指数退避 Ping，直到连接池重新拿到可用连接；自 since 起超过 reconnectTimeout 仍不可达则返回错误
+func waitReconnect(ctx context.Context, db *sql.DB, since time.Time) error {
+	delay := reconnectBaseDelay
+	for attempt := 1; ; attempt++ {
+		if err := sleepCtx(ctx, delay); err != nil {
+			return err
+		}
+		pctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := db.PingContext(pctx)
+		cancel()
+		if err == nil {
+			log.Printf("[mysql] 数据库连接已恢复（第 %d 次尝试）", attempt)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(since) > reconnectTimeout {
+			return fmt.Errorf("数据库持续不可达超过 %s，放弃重连：%w", reconnectTimeout, err)
+		}
+		log.Printf("[mysql] 重连失败（第 %d 次）：%v", attempt, err)
+		delay = min(delay*2, reconnectMaxDelay)
+	}
+}
+
+// isConnError 判断是否为连接级错误（可通过重连恢复），与 SQL 本身的错误区分
+func isConnError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case 1040, // Too many connections
+			1053, // Server shutdown in progress
+			1927, // Connection was killed
+			2006, // MySQL server has gone away
+			2013: // Lost connection during query
+			return true
+		}
+	}
+	return false
+}
+
+// isTransientError 判断是否为重试可能成功的服务端错误；语法错误、未知列/表、权限不足等均不在此列
+func isTransientError(err error) bool {
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
+		return false
+	}
+	switch me.Number {
+	case 1037, 1038, 1041, // Out of memory
+		1158, 1159, 1160, 1161, // 网络读写错误 / 超时
+		1205, // Lock wait timeout exceeded
+		1213, // Deadlock found
+		1317, // Query execution was interrupted
+		3024: // max_execution_time exceeded
+		return true
+	}
+	return false
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
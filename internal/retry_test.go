@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+// fastReconnect 把重连等待调到毫秒级，测试结束后恢复
+func fastReconnect(t *testing.T) {
+	t.Helper()
+	base, max, timeout := reconnectBaseDelay, reconnectMaxDelay, reconnectTimeout
+	reconnectBaseDelay, reconnectMaxDelay = time.Millisecond, time.Millisecond
+	t.Cleanup(func() { reconnectBaseDelay, reconnectMaxDelay, reconnectTimeout = base, max, timeout })
+}
+
+// newRetryMock 返回 mock、所用配置与执行一次 queryRetry 的函数（返回读到的行数）
+func newRetryMock(t *testing.T) (sqlmock.Sqlmock, MySQLConfig, func() (int, error)) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	cfg := MySQLConfig{Table: "t", QueryRetryMax: 2, QueryRetryDelay: time.Millisecond}
+	query := func() (int, error) {
+		rows, err := queryRetry(context.Background(), db, cfg, "SELECT id FROM t")
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		n := 0
+		for rows.Next() {
+			n++
+		}
+		return n, rows.Err()
+	}
+	return mock, cfg, query
+}
+
+func TestQueryRetryReconnects(t *testing.T) {
+	fastReconnect(t)
+	mock, _, query := newRetryMock(t)
+	mock.ExpectQuery("SELECT id FROM t").WillReturnError(mysql.ErrInvalidConn)
+	mock.ExpectPing()
+	mock.ExpectQuery("SELECT id FROM t").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	n, err := query()
+	if err != nil {
+		t.Fatalf("queryRetry: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("rows = %d, want 2", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueryRetryGivesUpOnPersistentConnError(t *testing.T) {
+	fastReconnect(t)
+	mock, _, query := newRetryMock(t)
+	// Ping 每次都成功，查询却一直断线：重连 reconnectMaxRetries 次后放弃
+	for range reconnectMaxRetries {
+		mock.ExpectQuery("SELECT id FROM t").WillReturnError(mysql.ErrInvalidConn)
+		mock.ExpectPing()
+	}
+	mock.ExpectQuery("SELECT id FROM t").WillReturnError(mysql.ErrInvalidConn)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := query()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrDBConnect) || !errors.Is(err, mysql.ErrInvalidConn) {
+			t.Fatalf("err = %v, want ErrDBConnect wrapping ErrInvalidConn", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("queryRetry did not give up")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueryRetryGivesUpAfterTimeout(t *testing.T) {
+	fastReconnect(t)
+	reconnectTimeout = 0 // 首次重连后即超时
+	mock, _, query := newRetryMock(t)
+	mock.ExpectQuery("SELECT id FROM t").WillReturnError(mysql.ErrInvalidConn)
+	mock.ExpectPing()
+	mock.ExpectQuery("SELECT id FROM t").WillReturnError(mysql.ErrInvalidConn)
+
+	if _, err := query(); !errors.Is(err, ErrDBConnect) {
+		t.Fatalf("err = %v, want ErrDBConnect", err)
+	}
+}
+
+func TestQueryRetryTransientAndPermanent(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+	syntax := &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"}
+
+	t.Run("transient recovers", func(t *testing.T) {
+		mock, _, query := newRetryMock(t)
+		mock.ExpectQuery("SELECT id FROM t").WillReturnError(deadlock)
+		mock.ExpectQuery("SELECT id FROM t").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		if _, err := query(); err != nil {
+			t.Fatalf("queryRetry: %v", err)
+		}
+	})
+	t.Run("transient exhausts retries", func(t *testing.T) {
+		mock, cfg, query := newRetryMock(t)
+		for range cfg.QueryRetryMax + 1 {
+			mock.ExpectQuery("SELECT id FROM t").WillReturnError(deadlock)
+		}
+		if _, err := query(); !errors.Is(err, deadlock) {
+			t.Fatalf("err = %v, want deadlock", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("zero retries", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		mock.ExpectQuery("SELECT id FROM t").WillReturnError(deadlock)
+		cfg := MySQLConfig{Table: "t", QueryRetryMax: 0, QueryRetryDelay: time.Millisecond}
+		if _, err := queryRetry(context.Background(), db, cfg, "SELECT id FROM t"); !errors.Is(err, deadlock) {
+			t.Fatalf("err = %v, want deadlock", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("permanent aborts immediately", func(t *testing.T) {
+		mock, _, query := newRetryMock(t)
+		mock.ExpectQuery("SELECT id FROM t").WillReturnError(syntax)
+		if _, err := query(); !errors.Is(err, syntax) {
+			t.Fatalf("err = %v, want syntax error", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
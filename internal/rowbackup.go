@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RowBackup 在每次 UPDATE 之前，把即将被改写的列原值以“还原用 UPDATE 语句”写入 .sql 文件，
+// 只覆盖实际变更的行与列，可直接 mysql < backup.sql 回滚。并发安全，方法对 nil 安全
+type RowBackup struct {
+	mu  sync.Mutex
+	f   *os.File
+	n   int64
+	err error // 首个写入错误
+}
+
+// backupCond 还原语句中的一个 列 = 值（nil 为 NULL）
+type backupCond struct {
+	col string
+	val *string
+}
+
+// NewRowBackup 创建备份文件并写入文件头（逐条直接写入文件，进程中断时已写入的语句不会丢失）
+func NewRowBackup(path string) (*RowBackup, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建行备份 %s: %w", path, err)
+	}
+	b := &RowBackup{f: f}
+	// 语句中的字符串按反斜杠规则转义，执行时临时去掉 NO_BACKSLASH_ESCAPES
+	b.write(fmt.Sprintf("-- tradify-cli 行备份 %s：每条 UPDATE 将一行还原为转换前的值\n"+
+		"SET NAMES utf8mb4;\n"+
+		"SET @OLD_SQL_MODE = @@SESSION.sql_mode;\n"+
+		"SET SESSION sql_mode = REPLACE(@@SESSION.sql_mode, 'NO_BACKSLASH_ESCAPES', '');\n\n",
+		time.Now().Format(time.RFC3339)))
+	if b.err != nil {
+		f.Close()
+		return nil, fmt.Errorf("写行备份 %s: %w", path, b.err)
+	}
+	return b, nil
+}
+
+func (b *RowBackup) write(s string) {
+	if _, err := b.f.WriteString(s); err != nil && b.err == nil {
+		b.err = err
+	}
+}
+
+// Restore 写入一条还原语句：UPDATE table SET set... WHERE where... [LIMIT 1]。
+// 写入失败时返回错误，调用方应放弃本行的 UPDATE（不在没有备份的情况下改写数据）
+func (b *RowBackup) Restore(table string, set, where []backupCond, limitOne bool) error {
+	if b == nil {
+		return nil
+	}
+	sets := make([]string, len(set))
+	for i, c := range set {
+		sets[i] = quoteIdent(c.col) + " = " + sqlLiteral(c.val)
+	}
+	conds := make([]string, len(where))
+	for i, c := range where {
+		if c.val == nil {
+			conds[i] = quoteIdent(c.col) + " IS NULL"
+		} else {
+			conds[i] = quoteIdent(c.col) + " = " + sqlLiteral(c.val)
+		}
+	}
+	stmt := fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteIdent(table), strings.Join(sets, ", "), strings.Join(conds, " AND "))
+	if limitOne {
+		stmt += " LIMIT 1"
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return fmt.Errorf("写行备份失败：%w", b.err)
+	}
+	b.write(stmt + ";\n")
+	if b.err != nil {
+		return fmt.Errorf("写行备份失败：%w", b.err)
+	}
+	b.n++
+	return nil
+}
+
+// Count 已写入的还原语句数
+func (b *RowBackup) Count() int64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.n
+}
+
+// Close 写入文件尾（恢复 sql_mode）并关闭，返回首个写入错误
+func (b *RowBackup) Close() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.write("\nSET SESSION sql_mode = @OLD_SQL_MODE;\n")
+	if err := b.f.Close(); err != nil && b.err == nil {
+		b.err = err
+	}
+	return b.err
+}
+
+// sqlLiteral 将值写成 MySQL 字符串字面量（反斜杠转义规则），nil 为 NULL
+func sqlLiteral(v *string) string {
+	if v == nil {
+		return "NULL"
+	}
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for i := 0; i < len(*v); i++ {
+		switch ch := (*v)[i]; ch {
+		case 0:
+			sb.WriteString(`\0`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case 0x1a:
+			sb.WriteString(`\Z`)
+		case '\'':
+			sb.WriteString(`\'`)
+		case '\\':
+			sb.WriteString(`\\`)
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
+
+// noPKRestore 为无主键表生成还原语句的 SET 与 WHERE：按 identify_by 定位（guard 时追加转换后的新值），
+// 整行匹配时以转换后的整行为条件并加 LIMIT 1，与 processNoPK 中 UPDATE 的定位方式一致
+func noPKRestore(cfg MySQLConfig, allCols []string, rowVals []*string, matchCols []int, types map[string]columnType, changed map[string]string, guard bool) (set, where []backupCond, limitOne bool) {
+	for _, c := range cfg.Columns {
+		if _, ok := changed[c]; ok {
+			set = append(set, backupCond{c, rowVals[indexOf(allCols, c)]})
+		}
+	}
+	newVal := func(col string) (*string, bool) {
+		v, ok := changed[col]
+		return &v, ok
+	}
+	if len(cfg.IdentifyBy) > 0 {
+		for _, col := range cfg.IdentifyBy {
+			if idx := indexOf(allCols, col); idx >= 0 {
+				where = append(where, backupCond{col, rowVals[idx]})
+			}
+		}
+		if guard {
+			for _, c := range cfg.Columns {
+				if v, ok := newVal(c); ok {
+					where = append(where, backupCond{c, v})
+				}
+			}
+		}
+		return set, where, false
+	}
+	for _, i := range matchCols {
+		col := allCols[i]
+		if v, ok := newVal(col); ok {
+			where = append(where, backupCond{col, v})
+			continue
+		}
+		v := rowVals[i]
+		if v != nil && types[strings.ToLower(col)].isTemporal() {
+			s := normalizeTimeString(*v)
+			v = &s
+		}
+		where = append(where, backupCond{col, v})
+	}
+	return set, where, true
+}
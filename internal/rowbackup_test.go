@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// readBackupStmts 关闭备份并返回其中的还原 UPDATE 语句
+func readBackupStmts(t *testing.T, b *RowBackup, path string) []string {
+	t.Helper()
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stmts []string
+	for _, line := range strings.Split(string(bs), "\n") {
+		if strings.HasPrefix(line, "UPDATE ") {
+			stmts = append(stmts, line)
+		}
+	}
+	return stmts
+}
+
+// 备份只包含实际变更的行与列的原值，字符串按反斜杠规则转义
+func TestRowBackupChangedRowsOnly(t *testing.T) {
+	db, mock := newMock(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name`,`note` FROM `t` ORDER BY `id` LIMIT ?")).WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "note"}).
+			AddRow("1", "简体", "hello").
+			AddRow("2", "繁體", "ok").
+			AddRow("3", `it's 简\体`, "说明"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).WithArgs("簡體", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ?,`note` = ? WHERE `id` = ?")).WithArgs(`it's 簡\體`, "說明", "3").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name`,`note` FROM `t` WHERE (`id`) > (?) ORDER BY `id` LIMIT ?")).WithArgs("3", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "note"}))
+
+	path := filepath.Join(t.TempDir(), "backup.sql")
+	b, err := NewRowBackup(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := MySQLConfig{
+		Table: "t", PK: []string{"id"}, Columns: []string{"name", "note"}, To: "s2t", BatchSize: 10,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{}, RowBackup: b,
+	}
+	if _, err := processWithPK(context.Background(), db, cfg, nil, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if b.Count() != 2 {
+		t.Errorf("count = %d, want 2", b.Count())
+	}
+	want := []string{
+		"UPDATE `t` SET `name` = '简体' WHERE `id` = '1';",
+		"UPDATE `t` SET `name` = 'it\\'s 简\\\\体', `note` = '说明' WHERE `id` = '3';",
+	}
+	if got := readBackupStmts(t, b, path); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("backup =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// 无主键整行匹配：还原语句以转换后的整行定位并加 LIMIT 1
+func TestRowBackupNoPKWholeRow(t *testing.T) {
+	db, mock := newMock(t)
+	expectNoPKSchema(mock, "logs", []colDef{{"name", "varchar", false}, {"tag", "varchar", false}})
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `name`,`tag` FROM `logs` LIMIT ? OFFSET ?")).WithArgs(10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "tag"}).AddRow("简体", nil))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `logs` SET `name` = ? WHERE `name` = ? AND `tag` IS NULL LIMIT 1")).
+		WithArgs("簡體", "简体").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `name`,`tag` FROM `logs` LIMIT ? OFFSET ?")).WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "tag"}))
+
+	path := filepath.Join(t.TempDir(), "backup.sql")
+	b, err := NewRowBackup(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := noPKConfig("logs", "name")
+	cfg.RowBackup = b
+	if _, err := processNoPK(context.Background(), db, cfg, nil, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	want := "UPDATE `logs` SET `name` = '简体' WHERE `name` = '簡體' AND `tag` IS NULL LIMIT 1;"
+	if got := readBackupStmts(t, b, path); len(got) != 1 || got[0] != want {
+		t.Errorf("backup = %q, want %q", got, want)
+	}
+}
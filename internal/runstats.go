@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// RunStats 一次运行的汇总结果，由 RunFile / RunMySQL / RunMySQLFromFileConfig 返回，便于嵌入调用方渲染或测试断言。
+// 计数单位：mysql 为行，file 为文件；按值（每行每列）的转换统计见 Values
+type RunStats struct {
+	Values Stats // 按值的转换统计（同 --summary）
+
+	Scanned int64 // mysql：扫描行；file：处理的文件数（含命中缓存与失败的文件，不含续跑跳过的文件）
+	Changed int64 // mysql：有列发生转换的行；file：需转换的文件（dry-run 下均为“将更新/将修改”）
+	Written int64 // 实际写入：mysql 为非 dry-run 时更新的行；file 为已写回或写入输出目录的文件
+	Skipped int64 // mysql：按已完成清单跳过的表；file：命中缓存或续跑记录而跳过的文件
+	Failed  int64 // mysql：失败行；file：失败的文件
+
+	BytesBefore int64 // 仅 file：所有处理的文件转换前的总字节数
+	BytesAfter  int64 // 仅 file：转换后的总字节数（未转换的文件按原大小计）
+
+	Duration time.Duration
+	DryRun   bool
+	Tables   []TableStats // 仅 mysql：各表结果（配置文件模式按配置顺序）
+}
+
+// TableStats 单表的运行结果
+type TableStats struct {
+	Table    string
+	Label    string // 配置了 label 时的显示名
+	Status   string // 完成 | 失败 | 已跳过 | 未开始
+	Scanned  int64
+	Changed  int64
+	Failed   int64
+	Duration time.Duration
+	Err      error
+}
+
+// String 单行文本摘要
+func (r RunStats) String() string {
+	return fmt.Sprintf("扫描 %d | 需转换 %d | 已写入 %d | 跳过 %d | 失败 %d | 耗时 %s",
+		r.Scanned, r.Changed, r.Written, r.Skipped, r.Failed, r.Duration.Round(time.Millisecond))
+}
+
+// WriteText 多行文本摘要：各表结果（仅 mysql）与合计，同控制台输出
+func (r RunStats) WriteText(w io.Writer) error {
+	if len(r.Tables) > 0 {
+		if err := writeTableSummaries(w, r.Tables, r.DryRun); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "[合计] %s\n", r)
+	return err
+}
+
+// addTable 并入一张表的结果
+func (r *RunStats) addTable(t TableStats, dryRun bool) {
+	r.Tables = append(r.Tables, t)
+	r.Scanned += t.Scanned
+	r.Changed += t.Changed
+	r.Failed += t.Failed
+	if !dryRun {
+		r.Written += t.Changed
+	}
+	if t.Status == "已跳过" {
+		r.Skipped++
+	}
+}
+
+// tableStats 由单表计数生成结果；counts 为 nil 表示该表未开始或被跳过
+func tableStats(table, status string, counts *tableMetrics, err error) TableStats {
+	t := TableStats{Table: table, Status: status, Err: err}
+	if counts != nil {
+		t.Scanned, t.Changed, t.Failed, t.Duration = counts.scanned, counts.changed, counts.failed, counts.duration
+	}
+	return t
+}
+
+// fileRunStats 由逐文件结果汇总；skipped 为续跑记录跳过的文件数
+func fileRunStats(results []FileResult, stats *Stats, skipped int64, d time.Duration) RunStats {
+	r := RunStats{Values: stats.Snapshot(), Skipped: skipped, Duration: d}
+	for _, f := range results {
+		r.Scanned++
+		r.BytesBefore += f.BytesBefore
+		r.BytesAfter += f.BytesAfter
+		switch {
+		case f.Err != nil:
+			r.Failed++
+		case f.Cached:
+			r.Skipped++
+		}
+		if f.Changed {
+			r.Changed++
+		}
+		if f.Written {
+			r.Written++
+		}
+	}
+	return r
+}
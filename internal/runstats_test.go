@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 固定目录：1 个需转换、1 个纯 ASCII、1 个已是繁体、1 个读取失败，另有 1 个被 Exts 过滤
+func TestRunFileStats(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.md": "简体", "b.md": "ascii", "c.md": "繁體", "d.txt": "简体"})
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "bad.md")); err != nil {
+		t.Fatal(err)
+	}
+	cache := filepath.Join(t.TempDir(), "cache.json")
+	rs, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", CacheFile: cache})
+	if err != nil {
+		t.Fatal(err) // 单个文件失败只计入结果，不中止运行
+	}
+	want := RunStats{Scanned: 4, Changed: 1, Written: 1, Failed: 1,
+		BytesBefore: int64(len("简体ascii繁體")), BytesAfter: int64(len("簡體ascii繁體"))}
+	want.Values = Stats{Converted: 1, SkippedASCII: 1, Unchanged: 1, Errors: 1}
+	checkRunStats(t, "first run", rs, want)
+
+	// 再次运行：未变化的文件命中缓存
+	if err := os.Remove(filepath.Join(dir, "bad.md")); err != nil {
+		t.Fatal(err)
+	}
+	rs, err = RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", CacheFile: cache, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRunStats(t, "cached run", rs, RunStats{Scanned: 3, Skipped: 3, DryRun: true,
+		BytesBefore: int64(len("簡體ascii繁體")), BytesAfter: int64(len("簡體ascii繁體"))})
+}
+
+func checkRunStats(t *testing.T, name string, got, want RunStats) {
+	t.Helper()
+	if got.Duration <= 0 {
+		t.Errorf("%s: duration = %v", name, got.Duration)
+	}
+	got.Duration, got.Values.Replaced = 0, nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: stats = %+v, want %+v", name, got, want)
+	}
+}
+
+// 单表结果按实际的查询/更新计数汇总：3 行中 1 行转换写入、1 行更新失败、1 行纯 ASCII
+func TestMySQLTableStats(t *testing.T) {
+	db, mock := newMock(t)
+	rows := sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "简体").AddRow("2", "ascii").AddRow("3", "软件")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` ORDER BY `id` LIMIT ?")).WithArgs(10).WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).WithArgs("簡體", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).WithArgs("軟件", "3").
+		WillReturnError(errors.New("deadlock"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE (`id`) > (?) ORDER BY `id` LIMIT ?")).WithArgs("3", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	stats := &Stats{}
+	cfg := MySQLConfig{Table: "t", PK: []string{"id"}, Columns: []string{"name"}, To: "s2t", BatchSize: 10,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{}, Stats: stats}
+	if _, err := processWithPK(context.Background(), db, cfg, nil, nil, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 与 RunMySQL 相同的汇总方式
+	var rs RunStats
+	rs.addTable(tableStats("t", "完成", cfg.counts, nil), false)
+	rs.addTable(tableStats("skipped", "已跳过", nil, nil), false)
+	if rs.Scanned != 3 || rs.Changed != 1 || rs.Written != 1 || rs.Failed != 1 || rs.Skipped != 1 || len(rs.Tables) != 2 {
+		t.Errorf("stats = %+v", rs)
+	}
+	if tbl := rs.Tables[0]; tbl.Table != "t" || tbl.Scanned != 3 || tbl.Changed != 1 || tbl.Failed != 1 {
+		t.Errorf("table = %+v", tbl)
+	}
+	if v := stats.Snapshot(); v.Converted != 2 || v.SkippedASCII != 1 {
+		t.Errorf("values = %+v", v)
+	}
+
+	// dry-run 不计入已写入
+	var dry RunStats
+	dry.addTable(tableStats("t", "完成", cfg.counts, nil), true)
+	if dry.Changed != 1 || dry.Written != 0 {
+		t.Errorf("dry-run stats = %+v", dry)
+	}
+}
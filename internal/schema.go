@@ -0,0 +1,289 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 整库模式默认处理的列类型
+var DefaultSchemaTypes = []string{"char", "varchar", "tinytext", "text", "mediumtext", "longtext"}
+
+type SchemaDiscoverOptions struct {
+	Types          []string // 需要转换的列类型（小写），为空使用 DefaultSchemaTypes
+	ExcludeTables  []string // 排除的表，支持通配符（如 log_*）
+	ExcludeColumns []string // 排除的列：col 或 table.col，支持通配符
+
+	ConnectTimeout time.Duration // 建立连接的超时，0 使用默认 10s
+}
+
+// DiscoverSchemaTables 枚举当前库的所有基表及其候选文本列，生成表条目（主键列不会作为转换目标）。
+// 无主键表不设 identify_by，运行时会自动选用唯一索引或退化为整行匹配
+func DiscoverSchemaTables(ctx context.Context, dsn string, opt SchemaDiscoverOptions) ([]MySQLTblEntry, error) {
+	types := opt.Types
+	if len(types) == 0 {
+		types = DefaultSchemaTypes
+	}
+	for _, p := range append(append([]string{}, opt.ExcludeTables...), opt.ExcludeColumns...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("无效的排除模式 %q：%w", p, err)
+		}
+	}
+	typeSet := map[string]bool{}
+	for _, t := range types {
+		typeSet[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open mysql: %w", err)
+	}
+	defer db.Close()
+	if err := pingTimeout(ctx, db, opt.ConnectTimeout); err != nil {
+		return nil, err
+	}
+
+	tables, err := listBaseTables(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("枚举表失败：%w", err)
+	}
+
+	var out []MySQLTblEntry
+	for _, table := range tables {
+		if isShadowTable(table) {
+			continue // 影子表模式生成的表
+		}
+		if matchAny(opt.ExcludeTables, table) {
+			log.Printf("[mysql] 已排除表 %s", table)
+			continue
+		}
+		pk, err := primaryKeyColumns(ctx, db, table)
+		if err != nil {
+			return nil, fmt.Errorf("读取主键失败 %s：%w", table, err)
+		}
+		colTypes, err := getColumnTypes(db, table)
+		if err != nil {
+			return nil, fmt.Errorf("读取列定义失败 %s：%w", table, err)
+		}
+		allCols, err := getAllColumns(db, table)
+		if err != nil {
+			return nil, fmt.Errorf("获取列失败 %s：%w", table, err)
+		}
+		var cols []string
+		for _, c := range allCols {
+			t := colTypes[strings.ToLower(c)]
+			if !typeSet[t.DataType] || indexOfFold(pk, c) >= 0 {
+				continue
+			}
+			if matchAny(opt.ExcludeColumns, c) || matchAny(opt.ExcludeColumns, table+"."+c) {
+				continue
+			}
+			cols = append(cols, c)
+		}
+		if len(cols) == 0 {
+			continue
+		}
+		out = append(out, MySQLTblEntry{Table: table, PK: pk, Columns: cols})
+	}
+	return out, nil
+}
+
+// tablePatternMatcher 编译 table_pattern：re: 开头为正则，否则为不区分大小写的 SQL LIKE 模式（见 likeRegexp）
+func tablePatternMatcher(pattern string) (func(string) bool, error) {
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 table_pattern 正则 %q：%w", expr, err)
+		}
+		return re.MatchString, nil
+	}
+	if strings.TrimSpace(pattern) == "" {
+		return nil, errors.New("table_pattern 不能为空")
+	}
+	return regexp.MustCompile(likeRegexp(pattern)).MatchString, nil
+}
+
+// likeRegexp 把 SQL LIKE 模式转换为等价的正则（不区分大小写）：% 匹配任意个字符，_ 匹配单个字符，
+// \ 转义其后的字符（如 \_ 只匹配下划线本身；末尾的 \ 按字面匹配）
+func likeRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for i := 0; i < len(pattern); {
+		switch pattern[i] {
+		case '%':
+			b.WriteString(".*")
+			i++
+			continue
+		case '_':
+			b.WriteString(".")
+			i++
+			continue
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+		_, size := utf8.DecodeRuneInString(pattern[i:])
+		b.WriteString(regexp.QuoteMeta(pattern[i : i+size]))
+		i += size
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// checkTablePattern 校验模式条目：每张表各自独立的字段不能套用到多张表
+func checkTablePattern(t MySQLTblEntry) error {
+	if _, err := tablePatternMatcher(t.TablePattern); err != nil {
+		return err
+	}
+	switch {
+	case len(t.Keys) > 0 || t.KeysFile != "":
+		return errors.New("table_pattern 不可与 keys/keys_file 同用")
+	case t.WatermarkFile != "":
+		return errors.New("table_pattern 不可与 watermark_file 同用（水位默认按表保存在 state_dir 中）")
+	case t.SelectSQL != "" || t.Join != "":
+		return errors.New("table_pattern 不可与 select_sql/join 同用")
+	case t.Label != "":
+		return errors.New("table_pattern 不可与 label 同用")
+	}
+	return nil
+}
+
+// expandTablePatterns 将 table_pattern 条目展开为所有匹配的基表（按表名排序），其余字段原样套用。
+// 已显式列出或被更早的模式匹配过的表不再重复加入；exclude_tables 中的表被排除
+func expandTablePatterns(ctx context.Context, fileCfg *MySQLFileConfig, connTimeout time.Duration) error {
+	hasPattern := false
+	for _, t := range fileCfg.Tables {
+		hasPattern = hasPattern || t.TablePattern != ""
+	}
+	if !hasPattern {
+		return nil
+	}
+
+	db, err := sql.Open("mysql", fileCfg.schemaDSN())
+	if err != nil {
+		return fmt.Errorf("open mysql: %w", err)
+	}
+	defer db.Close()
+	if err := pingTimeout(ctx, db, connTimeout); err != nil {
+		return err
+	}
+	return expandTablePatternsDB(ctx, db, fileCfg)
+}
+
+// expandTablePatternsDB 在已建立的连接上按当前库的基表展开 table_pattern 条目（见 expandTablePatterns）
+func expandTablePatternsDB(ctx context.Context, db *sql.DB, fileCfg *MySQLFileConfig) error {
+	tables, err := listBaseTables(ctx, db)
+	if err != nil {
+		return fmt.Errorf("枚举表失败：%w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, t := range fileCfg.Tables {
+		if t.Table != "" {
+			seen[strings.ToLower(t.Table)] = true
+		}
+	}
+	var out []MySQLTblEntry
+	for _, t := range fileCfg.Tables {
+		if t.TablePattern == "" {
+			out = append(out, t)
+			continue
+		}
+		match, err := tablePatternMatcher(t.TablePattern)
+		if err != nil {
+			return err
+		}
+		var names []string
+		for _, name := range tables {
+			if !match(name) || seen[strings.ToLower(name)] || isShadowTable(name) {
+				continue
+			}
+			if matchAny(fileCfg.ExcludeTables, name) {
+				log.Printf("[mysql] 已排除表 %s", name)
+				continue
+			}
+			seen[strings.ToLower(name)] = true
+			e := t
+			e.Table, e.TablePattern = name, ""
+			out = append(out, e)
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			log.Printf("[mysql] 警告：table_pattern %q 未匹配任何表", t.TablePattern)
+			continue
+		}
+		log.Printf("[mysql] table_pattern %q 展开为 %d 张表：%s", t.TablePattern, len(names), strings.Join(names, ", "))
+	}
+	if len(out) == 0 {
+		return errors.New("table_pattern 展开后没有需要处理的表")
+	}
+	fileCfg.Tables = out
+	return nil
+}
+
+func listBaseTables(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT TABLE_NAME FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	return tables, rows.Err()
+}
+
+func primaryKeyColumns(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT COLUMN_NAME FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ? AND INDEX_NAME = 'PRIMARY'
+		ORDER BY SEQ_IN_INDEX`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cols []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+// matchAny 不区分大小写的通配符匹配（语法同 path.Match）
+func matchAny(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func indexOfFold(arr []string, s string) int {
+	for i, v := range arr {
+		if strings.EqualFold(v, s) {
+			return i
+		}
+	}
+	return -1
+}
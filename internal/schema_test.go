@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTablePatternLike(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"log_2023_%", "log_2023_01", true},
+		{"log_2023_%", "LOG_2023_12", true},
+		{"log_2023_%", "log_2023x01", true}, // _ 匹配任意单个字符
+		{`log\_2023\_%`, "log_2023x01", false},
+		{`log\_2023\_%`, "log_2023_01", true},
+		{"log_%", "log", false},
+		{"user_", "users", true},
+		{"user_", "user", false},
+		{"%bak", "orders_bak", true},
+		{"log*", "log_1", false}, // * 不是通配符
+		{"log*", "log*", true},
+		{"a.b", "axb", false},
+		{`100\%`, "100%", true},
+		{`end\`, `end\`, true},
+		{"表_%", "表_一", true},
+	} {
+		match, err := tablePatternMatcher(tc.pattern)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.pattern, err)
+		}
+		if got := match(tc.name); got != tc.want {
+			t.Errorf("%q LIKE %q = %v, want %v", tc.name, tc.pattern, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"", "re:(", " "} {
+		if _, err := tablePatternMatcher(bad); err == nil {
+			t.Errorf("%q should be rejected", bad)
+		}
+	}
+	if match, _ := tablePatternMatcher(`re:^audit_\d{6}$`); !match("audit_202301") || match("AUDIT_202301") {
+		t.Error("re: pattern should be a case-sensitive regexp")
+	}
+}
+
+func TestExpandTablePatterns(t *testing.T) {
+	db, mock := newMock(t)
+	mock.ExpectQuery("SELECT TABLE_NAME FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).
+			AddRow("log_2023_02").AddRow("log_2023_01").AddRow("log_2023_01_bak").
+			AddRow("log_2023_03_tradify_preview").AddRow("audit_202301").AddRow("users"))
+	cfg := &MySQLFileConfig{
+		ExcludeTables: []string{"*_bak"},
+		Tables: []MySQLTblEntry{
+			{Table: "log_2023_02", PK: []string{"id"}, Columns: []string{"title"}},
+			{TablePattern: `log\_2023\_%`, PK: []string{"id"}, Columns: []string{"msg"}},
+			{TablePattern: "re:^audit_", PK: []string{"id"}, Columns: []string{"remark"}},
+			{TablePattern: "orders_%", Columns: []string{"x"}},
+		},
+	}
+	if err := expandTablePatternsDB(context.Background(), db, cfg); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range cfg.Tables {
+		if e.TablePattern != "" {
+			t.Errorf("pattern left unexpanded: %+v", e)
+		}
+		got = append(got, e.Table+":"+strings.Join(e.Columns, ","))
+	}
+	want := "log_2023_02:title log_2023_01:msg audit_202301:remark"
+	if strings.Join(got, " ") != want {
+		t.Errorf("tables = %v, want %s", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExpandTablePatternsNothingLeft(t *testing.T) {
+	db, mock := newMock(t)
+	mock.ExpectQuery("SELECT TABLE_NAME FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("users"))
+	cfg := &MySQLFileConfig{Tables: []MySQLTblEntry{{TablePattern: "log_%", Columns: []string{"msg"}}}}
+	if err := expandTablePatternsDB(context.Background(), db, cfg); err == nil {
+		t.Error("expansion without any table should fail")
+	}
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SegmentSpec 列内只转换的片段，其余部分逐字节保留：
+// 按 delimiter 切分后取第 index 段（从 1 开始），或取 regex 每个匹配的第 1 个捕获组（无捕获组时取整个匹配）
+type SegmentSpec struct {
+	Delimiter string `json:"delimiter,omitempty"`
+	Index     int    `json:"index,omitempty"`
+	Regex     string `json:"regex,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// compile 校验并编译片段规则
+func (s *SegmentSpec) compile() error {
+	switch {
+	case s.Delimiter != "" && s.Regex != "":
+		return errors.New("delimiter 与 regex 只能二选一")
+	case s.Delimiter != "":
+		if s.Index < 1 {
+			return fmt.Errorf("index 须从 1 开始（第几段），实际 %d", s.Index)
+		}
+	case s.Regex != "":
+		re, err := regexp.Compile(s.Regex)
+		if err != nil {
+			return fmt.Errorf("无效的 regex %q：%w", s.Regex, err)
+		}
+		if re.NumSubexp() > 1 {
+			return fmt.Errorf("regex %q 至多只能有 1 个捕获组", s.Regex)
+		}
+		s.re = re
+	default:
+		return errors.New("需提供 delimiter+index 或 regex")
+	}
+	return nil
+}
+
+// compileSegments 校验各列的片段规则：列必须在 columns 中；返回以小写列名为键的已编译规则
+func compileSegments(columns []string, segs map[string]SegmentSpec) (map[string]*SegmentSpec, error) {
+	if len(segs) == 0 {
+		return nil, nil
+	}
+	cols := make([]string, 0, len(segs))
+	for col := range segs {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols) // 多个错误时固定报告顺序
+	out := make(map[string]*SegmentSpec, len(segs))
+	for _, col := range cols {
+		s := segs[col]
+		if indexOfFold(columns, col) < 0 {
+			return nil, fmt.Errorf("segments.%s：列不在 columns 中", col)
+		}
+		if err := s.compile(); err != nil {
+			return nil, fmt.Errorf("segments.%s：%w", col, err)
+		}
+		out[strings.ToLower(col)] = &s
+	}
+	return out, nil
+}
+
+// apply 只把选中的片段交给 conv（按 opts 转换）转换并拼回原位；没有片段发生变化时原样返回 OutcomeUnchanged
+func (s *SegmentSpec) apply(opts ConvertOptions, in string, conv func(string) (string, ConvertOutcome, error)) (string, ConvertOutcome, error) {
+	if oc, skip := quickSkip(opts, in); skip {
+		return in, oc, nil
+	}
+	var spans [][2]int // 待转换片段的字节区间
+	if s.re != nil {
+		for _, m := range s.re.FindAllStringSubmatchIndex(in, -1) {
+			if len(m) >= 4 {
+				if m[2] >= 0 {
+					spans = append(spans, [2]int{m[2], m[3]})
+				}
+				continue
+			}
+			spans = append(spans, [2]int{m[0], m[1]})
+		}
+	} else {
+		start := 0
+		for i := 1; ; i++ {
+			end := strings.Index(in[start:], s.Delimiter)
+			if end < 0 {
+				end = len(in)
+			} else {
+				end += start
+			}
+			if i == s.Index {
+				spans = append(spans, [2]int{start, end})
+				break
+			}
+			if end == len(in) {
+				break // 段数不足
+			}
+			start = end + len(s.Delimiter)
+		}
+	}
+	return convertSpans(in, spans, conv)
+}
+
+// convertSpans 只把 spans（按起点升序、互不重叠的字节区间）交给 conv 转换并拼回原位；
+// 没有区间发生变化时原样返回 OutcomeUnchanged
+func convertSpans(in string, spans [][2]int, conv func(string) (string, ConvertOutcome, error)) (string, ConvertOutcome, error) {
+	var b strings.Builder
+	changed, prev := false, 0
+	for _, sp := range spans {
+		out, oc, err := conv(in[sp[0]:sp[1]])
+		if err != nil {
+			return "", OutcomeUnchanged, err
+		}
+		b.WriteString(in[prev:sp[0]])
+		b.WriteString(out)
+		prev = sp[1]
+		changed = changed || oc == OutcomeConverted
+	}
+	if !changed {
+		return in, OutcomeUnchanged, nil
+	}
+	b.WriteString(in[prev:])
+	return b.String(), OutcomeConverted, nil
+}
@@ -0,0 +1,53 @@
+package internal
+
+import "testing"
+
+func TestSegmentApply(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		spec    SegmentSpec
+		in, out string
+	}{
+		{"delimiter 2nd", SegmentSpec{Delimiter: "|", Index: 2}, "简A01|简体名称|备注", "简A01|簡體名稱|备注"},
+		{"delimiter last", SegmentSpec{Delimiter: "|", Index: 3}, "简A01|简体名称|备注", "简A01|简体名称|備註"},
+		{"multi-byte delimiter", SegmentSpec{Delimiter: "：", Index: 1}, "简体：简体", "簡體：简体"},
+		{"too few segments", SegmentSpec{Delimiter: "|", Index: 4}, "简|体|字", "简|体|字"},
+		{"empty segment", SegmentSpec{Delimiter: "|", Index: 2}, "简||体", "简||体"},
+		{"regex group", SegmentSpec{Regex: `name="([^"]*)"`}, `id="简" name="简体" x name="后来"`, `id="简" name="簡體" x name="後來"`},
+		{"regex whole match", SegmentSpec{Regex: `【[^】]*】`}, "代码简【简体】", "代码简【簡體】"},
+		{"regex no match", SegmentSpec{Regex: `name="([^"]*)"`}, "简体", "简体"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.spec
+			if err := s.compile(); err != nil {
+				t.Fatal(err)
+			}
+			opts := ConvertOptions{To: "s2t"}
+			out, oc, err := s.apply(opts, tc.in, func(v string) (string, ConvertOutcome, error) { return ConvertDetail(opts, v) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tc.out || (oc == OutcomeConverted) != (tc.in != tc.out) {
+				t.Errorf("apply(%q) = %q, %v; want %q", tc.in, out, oc, tc.out)
+			}
+		})
+	}
+}
+
+func TestCompileSegments(t *testing.T) {
+	if _, err := compileSegments([]string{"Name"}, map[string]SegmentSpec{"name": {Delimiter: "|", Index: 1}}); err != nil {
+		t.Errorf("case-insensitive column: %v", err)
+	}
+	for _, segs := range []map[string]SegmentSpec{
+		{"other": {Delimiter: "|", Index: 1}},
+		{"name": {Delimiter: "|"}},
+		{"name": {Delimiter: "|", Index: 1, Regex: "x"}},
+		{"name": {Regex: "("}},
+		{"name": {Regex: "(a)(b)"}},
+		{"name": {}},
+	} {
+		if _, err := compileSegments([]string{"name"}, segs); err == nil {
+			t.Errorf("compileSegments(%v) should fail", segs)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// selfTestCase 一组已知的输入/期望输出，用于发现内置 OpenCC 词典缺失、损坏或版本不符
+type selfTestCase struct {
+	kind      string // 标点 | 常用词 | 专有名词 | 规范化
+	in, want  string
+	normalize string // 可选：输出规范化（nfc / nfkc）
+}
+
+// selfTestCases 按 OpenCC 配置分组的内置用例；期望值与随二进制内置的词典版本一致
+var selfTestCases = map[string][]selfTestCase{
+	"s2t": {
+		{kind: "标点", in: "「简体」“引号”，句号。", want: "「簡體」“引號”，句號。"},
+		{kind: "常用词", in: "头发干燥", want: "頭髮乾燥"},
+		{kind: "常用词", in: "方便面", want: "方便麪"},
+		{kind: "专有名词", in: "周杰伦", want: "周杰倫"},
+	},
+	"s2tw": {
+		{kind: "标点", in: "你好，世界！", want: "你好，世界！"},
+		{kind: "常用词", in: "里面的面条", want: "裡面的麵條"},
+		{kind: "常用词", in: "台湾", want: "臺灣"},
+		{kind: "专有名词", in: "凯文·杜兰特", want: "凱文·杜蘭特"},
+	},
+	"s2twp": {
+		{kind: "标点", in: "「简体」“引号”，句号。", want: "「簡體」“引號”，句號。"},
+		{kind: "规范化", in: "ＡＰＰ软件，", want: "APP軟體,", normalize: "nfkc"},
+		{kind: "常用词", in: "软件和内存", want: "軟體和記憶體"},
+		{kind: "常用词", in: "鼠标与网络信息", want: "滑鼠與網路資訊"},
+		{kind: "常用词", in: "出租车", want: "計程車"},
+		{kind: "专有名词", in: "周杰伦", want: "周杰倫"},
+	},
+	"s2hk": {
+		{kind: "标点", in: "你好，世界！", want: "你好，世界！"},
+		{kind: "常用词", in: "台湾里面", want: "台灣裏面"},
+		{kind: "专有名词", in: "凯文·杜兰特", want: "凱文·杜蘭特"},
+	},
+	"t2s": {
+		{kind: "标点", in: "「繁體」，句號。", want: "「繁体」，句号。"},
+		{kind: "常用词", in: "後來颱風", want: "后来台风"},
+		{kind: "专有名词", in: "周杰倫", want: "周杰伦"},
+	},
+	"tw2sp": {
+		{kind: "标点", in: "「繁體」，句號。", want: "「繁体」，句号。"},
+		{kind: "常用词", in: "滑鼠與記憶體", want: "鼠标与内存"},
+		{kind: "常用词", in: "軟體", want: "软件"},
+		{kind: "专有名词", in: "周杰倫", want: "周杰伦"},
+	},
+}
+
+// SelfTestConfigs 返回内置了自检用例的配置（排序后）
+func SelfTestConfigs() []string {
+	out := make([]string, 0, len(selfTestCases))
+	for k := range selfTestCases {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RunSelfTest 用内置用例检验转换配置（auto-trad / auto-simp 检验其实际使用的配置），逐条写入 w；
+// 返回失败的用例数。未内置用例的配置返回错误
+func RunSelfTest(to string, w io.Writer) (int, error) {
+	config := to
+	if a, ok, err := parseAutoTo(to); err != nil {
+		return 0, err
+	} else if ok {
+		config = a.config
+	}
+	cases, ok := selfTestCases[config]
+	if !ok {
+		return 0, fmt.Errorf("未内置 %s 的自检用例（可选：%s）", config, strings.Join(SelfTestConfigs(), ", "))
+	}
+	if err := WarmUpConverters(config); err != nil {
+		return 0, err
+	}
+	failed := 0
+	for _, c := range cases {
+		got, _, err := ConvertDetail(ConvertOptions{To: config, Normalize: c.normalize}, c.in)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Fprintf(w, "[✗] %s %s：%s\n    错误：%v\n", config, c.kind, c.in, err)
+		case got != c.want:
+			failed++
+			fmt.Fprintf(w, "[✗] %s %s：%s\n    期望 %s，实际 %s\n", config, c.kind, c.in, c.want, got)
+		default:
+			fmt.Fprintf(w, "[✓] %s %s：%s -> %s\n", config, c.kind, c.in, got)
+		}
+	}
+	fmt.Fprintf(w, "\n通过 %d/%d", len(cases)-failed, len(cases))
+	if failed > 0 {
+		fmt.Fprint(w, "；内置 OpenCC 词典可能缺失、损坏或版本不符，转换结果不可信")
+	}
+	fmt.Fprintln(w)
+	return failed, nil
+}
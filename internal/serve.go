@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ServeConfig serve 子命令的选项
+type ServeConfig struct {
+	Addr            string        // 监听地址，如 127.0.0.1:8080
+	To              string        // 请求未指定 to 时使用的转换配置
+	Normalize       string        // 输出 Unicode 规范化：nfc | nfkc | none
+	MaxBodyBytes    int64         // 单个请求体上限（字节），0 使用默认 1MiB
+	MaxBatch        int           // 批量接口单次最多的文本数，0 使用默认 1000
+	ShutdownTimeout time.Duration // 收到停止信号后等待进行中请求的时长，0 使用默认 10s
+}
+
+const (
+	defaultServeMaxBody  = 1 << 20
+	defaultServeMaxBatch = 1000
+)
+
+type convertRequest struct {
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+type convertResponse struct {
+	Text    string `json:"text"`
+	Changed bool   `json:"changed"`
+}
+
+type batchRequest struct {
+	To    string   `json:"to"`
+	Texts []string `json:"texts"`
+}
+
+type batchResponse struct {
+	Texts   []string `json:"texts"`
+	Changed []bool   `json:"changed"`
+}
+
+// NewConvertHandler 返回转换服务的路由：
+//
+//	POST /convert        {"to":"s2twp","text":"..."}     -> {"text":"...","changed":true}
+//	POST /convert/batch  {"to":"s2twp","texts":["..."]}  -> {"texts":[...],"changed":[...]}
+//	GET  /healthz
+//
+// 转换复用进程内的 OpenCC 单例池，与 mysql/file 子命令的转换规则完全一致
+func NewConvertHandler(cfg ServeConfig) http.Handler {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = defaultServeMaxBody
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = defaultServeMaxBatch
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /convert", func(w http.ResponseWriter, r *http.Request) {
+		var req convertRequest
+		if !decodeRequest(w, r, cfg.MaxBodyBytes, &req) {
+			return
+		}
+		opts, err := cfg.requestOptions(req.To)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		out, changed, err := ConvertWithOptions(opts, req.Text)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, convertResponse{Text: out, Changed: changed})
+	})
+	mux.HandleFunc("POST /convert/batch", func(w http.ResponseWriter, r *http.Request) {
+		var req batchRequest
+		if !decodeRequest(w, r, cfg.MaxBodyBytes, &req) {
+			return
+		}
+		if len(req.Texts) > cfg.MaxBatch {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("texts 最多 %d 条，实际 %d 条", cfg.MaxBatch, len(req.Texts)))
+			return
+		}
+		opts, err := cfg.requestOptions(req.To)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		resp := batchResponse{Texts: make([]string, len(req.Texts)), Changed: make([]bool, len(req.Texts))}
+		for i, t := range req.Texts {
+			if resp.Texts[i], resp.Changed[i], err = ConvertWithOptions(opts, t); err != nil {
+				writeJSONError(w, http.StatusInternalServerError, fmt.Errorf("texts[%d]: %w", i, err))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// requestOptions 确定本次请求的转换选项；to 为空时使用服务默认值，未知配置返回错误
+func (c ServeConfig) requestOptions(to string) (ConvertOptions, error) {
+	if to == "" {
+		to = c.To
+	}
+	if err := WarmUpConverters(to); err != nil {
+		return ConvertOptions{}, err
+	}
+	return ConvertOptions{To: to, Normalize: c.Normalize}, nil
+}
+
+// decodeRequest 解析 JSON 请求体（受大小上限约束），失败时已写好错误响应并返回 false
+func decodeRequest(w http.ResponseWriter, r *http.Request, limit int64, v interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("请求体超过上限 %d 字节", limit))
+			return false
+		}
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("请求体不是有效的 JSON：%w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// Serve 启动转换服务，直到 ctx 取消（信号）后优雅停止：不再接受新连接，等待进行中的请求完成
+func Serve(ctx context.Context, cfg ServeConfig) error {
+	if _, err := ParseNormalizeForm(cfg.Normalize); err != nil {
+		return err
+	}
+	if err := WarmUpConverters(cfg.To); err != nil {
+		return err
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 10 * time.Second
+	}
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败：%w", cfg.Addr, err)
+	}
+	srv := &http.Server{
+		Handler:           NewConvertHandler(cfg),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+	log.Printf("[serve] 已监听 http://%s（默认 to=%s）", ln.Addr(), cfg.To)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	log.Printf("[serve] 收到停止信号，等待进行中的请求完成（最长 %s）", cfg.ShutdownTimeout)
+	sctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(sctx); err != nil {
+		return fmt.Errorf("停止服务失败：%w", err)
+	}
+	log.Println("[serve] 已停止")
+	return nil
+}
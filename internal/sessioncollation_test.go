@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// fakeMySQL 只实现握手与 COM_QUERY / COM_PING 的最小 MySQL 服务端：不校验密码，所有命令回 OK，记录收到的查询
+type fakeMySQL struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	queries []string
+}
+
+func newFakeMySQL(t *testing.T) *fakeMySQL {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeMySQL{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *fakeMySQL) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.queries)
+}
+
+func writePacket(c net.Conn, seq byte, payload []byte) error {
+	hdr := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}
+	_, err := c.Write(append(hdr, payload...))
+	return err
+}
+
+func readPacket(c net.Conn) (byte, []byte, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(c, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	buf := make([]byte, int(hdr[0])|int(hdr[1])<<8|int(hdr[2])<<16)
+	_, err := io.ReadFull(c, buf)
+	return hdr[3], buf, err
+}
+
+func (s *fakeMySQL) serve(c net.Conn) {
+	defer c.Close()
+	const caps = 0x1 | 0x200 | 0x2000 | 0x8000 | 0x80000 // LONG_PASSWORD | PROTOCOL_41 | TRANSACTIONS | SECURE_CONNECTION | PLUGIN_AUTH
+	hs := []byte{10}
+	hs = append(hs, "8.0.0-fake\x00"...)
+	hs = append(hs, 1, 0, 0, 0)                            // connection id
+	hs = append(hs, "abcdefgh"...)                         // auth-plugin-data part 1
+	hs = append(hs, 0)                                     // filler
+	hs = binary.LittleEndian.AppendUint16(hs, caps&0xffff) // capability flags (lower)
+	hs = append(hs, 45)                                    // utf8mb4_general_ci
+	hs = append(hs, 2, 0)                                  // status
+	hs = binary.LittleEndian.AppendUint16(hs, caps>>16)    // capability flags (upper)
+	hs = append(hs, 21)                                    // auth-plugin-data length
+	hs = append(hs, make([]byte, 10)...)                   // reserved
+	hs = append(hs, "ijklmnopqrst\x00"...)                 // auth-plugin-data part 2
+	hs = append(hs, "mysql_native_password\x00"...)
+	ok := []byte{0, 0, 0, 2, 0, 0, 0}
+	if writePacket(c, 0, hs) != nil {
+		return
+	}
+	seq, _, err := readPacket(c) // HandshakeResponse41，不校验
+	if err != nil || writePacket(c, seq+1, ok) != nil {
+		return
+	}
+	for {
+		seq, p, err := readPacket(c)
+		if err != nil || len(p) == 0 || p[0] == 0x01 { // COM_QUIT
+			return
+		}
+		if p[0] == 0x03 { // COM_QUERY
+			s.mu.Lock()
+			s.queries = append(s.queries, string(p[1:]))
+			s.mu.Unlock()
+		}
+		if writePacket(c, seq+1, ok) != nil {
+			return
+		}
+	}
+}
+
+// session_collation 写入 DSN 后，驱动在每个新连接建立时执行 SET NAMES … COLLATE …
+func TestSessionCollationOnNewConnections(t *testing.T) {
+	srv := newFakeMySQL(t)
+	dsn, err := tuneDSN(MySQLConfig{SessionCollation: "utf8mb4_bin"}, "u:p@tcp("+srv.ln.Addr().String()+")/db?maxAllowedPacket=1048576")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	c1, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	c2, err := db.Conn(ctx) // c1 未归还，强制建立第二个连接
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	want := []string{"SET NAMES utf8mb4 COLLATE utf8mb4_bin", "SET NAMES utf8mb4 COLLATE utf8mb4_bin"}
+	if got := srv.recorded(); !slices.Equal(got, want) {
+		t.Errorf("queries = %q, want %q", got, want)
+	}
+}
+
+func TestNoSessionCollationNoSetNames(t *testing.T) {
+	srv := newFakeMySQL(t)
+	dsn, err := tuneDSN(MySQLConfig{}, "u:p@tcp("+srv.ln.Addr().String()+")/db?maxAllowedPacket=1048576")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.recorded(); len(got) != 0 {
+		t.Errorf("queries = %q, want none", got)
+	}
+}
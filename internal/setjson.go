@@ -0,0 +1,310 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// errEmptyResult 非空值的转换结果为空串（见 convertValue）
+var errEmptyResult = errors.New("转换结果为空字符串")
+
+// convertValue 按列类型转换单个值：配置了 segments 的列只转换选中的片段，SET 列逐个成员转换，
+// JSON 列逐个字符串元素转换（对象默认只转换值，ConvertKeys 时键也转换），其它列整串交给 ConvertDetail
+func (c MySQLConfig) convertValue(column, in string) (out string, oc ConvertOutcome, err error) {
+	start := c.Stats.startTimer()
+	defer func() {
+		c.Stats.addTime(timeConvert, start)
+		// 非空值转换成空串（如分段/替换配置不当）视为失败，绝不用空串覆盖原值；AllowEmptyResult 时照常写入
+		if err == nil && oc == OutcomeConverted && out == "" && in != "" && !c.AllowEmptyResult {
+			out, oc, err = in, OutcomeUnchanged, fmt.Errorf("table=%s column=%s %w，已拒绝写入（原值 %q；确需写入空串请开启 allow_empty_result）", c.Table, column, errEmptyResult, in)
+		}
+		err = classify(ErrConvert, err)
+	}()
+	if s := c.segments[strings.ToLower(column)]; s != nil {
+		return s.apply(c.convertOptions(), in, func(v string) (string, ConvertOutcome, error) { return ConvertDetail(c.convertOptions(), v) })
+	}
+	t := c.colTypes[strings.ToLower(column)]
+	switch t.DataType {
+	case "set":
+		return c.convertSet(t, in)
+	case "json":
+		return convertJSON(c.convertOptions(), in, c.jsonKeys(column))
+	}
+	return ConvertDetail(c.convertOptions(), in)
+}
+
+// quickSkip 与 ConvertDetail 相同的快速跳过规则；配置了 Replace 时只跳过空串（其余值仍可能被替换），
+// 配置了 Normalize 时不含汉字的值也不跳过（仍需规范化）
+func quickSkip(opts ConvertOptions, in string) (ConvertOutcome, bool) {
+	switch {
+	case in == "":
+		return OutcomeEmpty, true
+	case opts.Replace != nil:
+		return OutcomeConverted, false
+	case IsASCIIOnly(in):
+		return OutcomeASCIIOnly, true
+	case !HasChinese(in):
+		if form, _ := ParseNormalizeForm(opts.Normalize); form != nil {
+			return OutcomeConverted, false
+		}
+		return OutcomeNoChinese, true
+	}
+	return OutcomeConverted, false
+}
+
+// convertSet 逐个转换 SET 成员并保持原顺序；转换后出现重复的成员去重并告警（SET 不允许重复）。
+// 转换后的成员必须在列定义中，否则 MySQL 会拒绝写入或静默丢弃，此时返回错误
+func (c MySQLConfig) convertSet(t columnType, in string) (string, ConvertOutcome, error) {
+	if oc, skip := quickSkip(c.convertOptions(), in); skip {
+		return in, oc, nil
+	}
+	members := strings.Split(in, ",")
+	out := make([]string, 0, len(members))
+	seen := map[string]bool{}
+	for _, m := range members {
+		o, _, err := ConvertDetail(c.convertOptions(), m)
+		if err != nil {
+			return "", OutcomeUnchanged, err
+		}
+		if seen[o] {
+			log.Printf("[mysql] 警告：table=%s column=%s SET 成员 %q 转换后与其它成员重复（%q），已去重", c.Table, t.Name, m, o)
+			continue
+		}
+		seen[o] = true
+		out = append(out, o)
+	}
+	res := strings.Join(out, ",")
+	if res == in {
+		return in, OutcomeUnchanged, nil
+	}
+	if len(t.Members) > 0 {
+		for _, o := range out {
+			if !containsFold(t.Members, o) {
+				return "", OutcomeUnchanged, fmt.Errorf("table=%s column=%s SET 成员 %q 不在列定义 %s 中，请先 ALTER TABLE 加入转换后的成员", c.Table, t.Name, o, t.ColumnType)
+			}
+		}
+	}
+	return res, OutcomeConverted, nil
+}
+
+// setDefinitionOrder 把 SET 值规范成 MySQL 存储的形式：成员按列定义顺序排列、使用定义中的写法并去重；
+// 不在定义中的成员按原顺序放在最后。用于写后校验比较拟写入值与回读值
+func setDefinitionOrder(members []string, v string) string {
+	if v == "" || len(members) == 0 {
+		return v
+	}
+	hit := make([]bool, len(members))
+	var unknown []string
+	for _, m := range strings.Split(v, ",") {
+		i := slices.IndexFunc(members, func(d string) bool { return strings.EqualFold(d, m) })
+		if i < 0 {
+			unknown = append(unknown, m)
+			continue
+		}
+		hit[i] = true
+	}
+	out := make([]string, 0, len(members)+len(unknown))
+	for i, m := range members {
+		if hit[i] {
+			out = append(out, m)
+		}
+	}
+	return strings.Join(append(out, unknown...), ",")
+}
+
+func containsFold(arr []string, s string) bool {
+	for _, v := range arr {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSetMembers 从 COLUMN_TYPE（如 set('a','b')、enum('a','b')，成员中的单引号写作两个单引号）解析允许的成员；
+// 非 SET/ENUM 类型返回 nil
+func parseSetMembers(columnType string) []string {
+	lower := strings.ToLower(columnType)
+	open := strings.IndexByte(lower, '(')
+	if open < 0 || (lower[:open] != "set" && lower[:open] != "enum") || !strings.HasSuffix(lower, ")") {
+		return nil
+	}
+	body := columnType[open+1 : len(columnType)-1]
+	var members []string
+	var cur strings.Builder
+	quoted := false
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+		switch {
+		case !quoted && ch == '\'':
+			quoted = true
+		case quoted && ch == '\'' && i+1 < len(body) && body[i+1] == '\'':
+			cur.WriteByte('\'')
+			i++
+		case quoted && ch == '\'':
+			quoted = false
+			members = append(members, cur.String())
+			cur.Reset()
+		case quoted:
+			cur.WriteByte(ch)
+		}
+	}
+	return members
+}
+
+// jsonKeyConv JSON 对象键的转换设置：nil 表示键保持不变；where 用于冲突告警中标明表与列
+type jsonKeyConv struct {
+	where string
+}
+
+// jsonKeys ConvertKeys 时返回该列的键转换设置
+func (c MySQLConfig) jsonKeys(column string) *jsonKeyConv {
+	if !c.ConvertKeys {
+		return nil
+	}
+	return &jsonKeyConv{where: fmt.Sprintf("table=%s column=%s", c.Table, column)}
+}
+
+// convertJSON 逐个转换 JSON 中的字符串（数组元素与对象的值；keys 非 nil 时对象的键也转换）；
+// 没有任何字符串变化时原样返回，否则按 MySQL 的输出格式（", " / ": " 分隔）重新拼接
+func convertJSON(opts ConvertOptions, in string, keys *jsonKeyConv) (string, ConvertOutcome, error) {
+	if oc, skip := quickSkip(opts, in); skip {
+		return in, oc, nil
+	}
+	if !json.Valid([]byte(in)) {
+		return "", OutcomeUnchanged, fmt.Errorf("JSON 列的值无法解析：%.50q", in)
+	}
+	out, changed, err := convertJSONValue(opts, []byte(in), keys)
+	if err != nil {
+		return "", OutcomeUnchanged, err
+	}
+	if !changed || string(out) == in {
+		return in, OutcomeUnchanged, nil
+	}
+	return string(out), OutcomeConverted, nil
+}
+
+func convertJSONValue(opts ConvertOptions, raw []byte, keys *jsonKeyConv) ([]byte, bool, error) {
+	b := bytes.TrimSpace(raw)
+	if len(b) == 0 {
+		return raw, false, nil
+	}
+	switch b[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, false, err
+		}
+		o, oc, err := ConvertDetail(opts, s)
+		if err != nil || oc != OutcomeConverted {
+			return raw, false, err
+		}
+		enc, err := marshalJSONString(o)
+		return enc, err == nil, err
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(b, &items); err != nil {
+			return nil, false, err
+		}
+		changed := false
+		parts := make([][]byte, len(items))
+		for i, it := range items {
+			o, ch, err := convertJSONValue(opts, it, keys)
+			if err != nil {
+				return nil, false, err
+			}
+			parts[i], changed = o, changed || ch
+		}
+		if !changed {
+			return raw, false, nil
+		}
+		return append(append([]byte("["), bytes.Join(parts, []byte(", "))...), ']'), true, nil
+	case '{':
+		dec := json.NewDecoder(bytes.NewReader(b))
+		if _, err := dec.Token(); err != nil { // {
+			return nil, false, err
+		}
+		var names []string
+		var values []json.RawMessage
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return nil, false, err
+			}
+			var v json.RawMessage
+			if err := dec.Decode(&v); err != nil {
+				return nil, false, err
+			}
+			names, values = append(names, kt.(string)), append(values, v)
+		}
+		changed := false
+		if keys != nil {
+			var err error
+			if names, changed, err = keys.convert(opts, names); err != nil {
+				return nil, false, err
+			}
+		}
+		parts := make([][]byte, len(values))
+		for i, v := range values {
+			o, ch, err := convertJSONValue(opts, v, keys)
+			if err != nil {
+				return nil, false, err
+			}
+			k, err := marshalJSONString(names[i])
+			if err != nil {
+				return nil, false, err
+			}
+			parts[i] = append(append(k, ": "...), o...)
+			changed = changed || ch
+		}
+		if !changed {
+			return raw, false, nil
+		}
+		return append(append([]byte("{"), bytes.Join(parts, []byte(", "))...), '}'), true, nil
+	}
+	return raw, false, nil
+}
+
+// convert 转换同一对象的全部键。转换后的键与本对象的任何原键或先前已转换出的键相同时（如 {"软件":1,"軟體":2}），
+// 该键保留原样并告警：按键的出现顺序，先转换的键优先，结果确定且不会产生重复键、不丢失成员
+func (k *jsonKeyConv) convert(opts ConvertOptions, names []string) ([]string, bool, error) {
+	used := make(map[string]bool, len(names))
+	for _, n := range names {
+		used[n] = true
+	}
+	out := make([]string, len(names))
+	changed := false
+	for i, n := range names {
+		out[i] = n
+		o, oc, err := ConvertDetail(opts, n)
+		if err != nil {
+			return nil, false, err
+		}
+		if oc != OutcomeConverted {
+			continue
+		}
+		if used[o] {
+			log.Printf("[mysql] 警告：%s JSON 键 %q 转换为 %q 后与同一对象中的其它键冲突，保留原键", k.where, n, o)
+			continue
+		}
+		used[o] = true
+		out[i], changed = o, true
+	}
+	return out, changed, nil
+}
+
+// marshalJSONString 编码 JSON 字符串，不转义 <>&（与 MySQL 的输出一致）
+func marshalJSONString(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSetDefinitionOrder(t *testing.T) {
+	members := []string{"紅", "綠", "Blue"}
+	for in, want := range map[string]string{
+		"":         "",
+		"綠,紅":      "紅,綠",
+		"blue,紅":   "紅,Blue",
+		"綠,紅,綠":    "紅,綠",
+		"x,綠,y":    "綠,x,y",
+		"Blue,綠,紅": "紅,綠,Blue",
+		"綠":        "綠",
+	} {
+		if got := setDefinitionOrder(members, in); got != want {
+			t.Errorf("setDefinitionOrder(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := setDefinitionOrder(nil, "b,a"); got != "b,a" {
+		t.Errorf("no members = %q", got)
+	}
+}
+
+func TestParseSetMembers(t *testing.T) {
+	got := parseSetMembers("set('红','it''s','a,b')")
+	if len(got) != 3 || got[0] != "红" || got[1] != "it's" || got[2] != "a,b" {
+		t.Errorf("parseSetMembers = %q", got)
+	}
+	if got := parseSetMembers("varchar(10)"); got != nil {
+		t.Errorf("varchar = %q", got)
+	}
+}
+
+func TestConvertSet(t *testing.T) {
+	c := MySQLConfig{Table: "t", To: "s2t"}
+	col := columnType{Name: "tags", DataType: "set", ColumnType: "set('紅','綠','发','發')", Members: []string{"紅", "綠", "发", "發"}}
+	if out, oc, err := c.convertSet(col, "绿,红"); err != nil || out != "綠,紅" || oc != OutcomeConverted {
+		t.Errorf("convertSet = %q, %v, %v", out, oc, err)
+	}
+	if out, _, err := c.convertSet(col, "发,發"); err != nil || out != "發" {
+		t.Errorf("duplicate members = %q, %v", out, err)
+	}
+	col.Members = []string{"红"}
+	if _, _, err := c.convertSet(col, "红"); err == nil {
+		t.Error("member missing from the definition should fail")
+	}
+}
+
+func TestVerifySetDefinitionOrder(t *testing.T) {
+	db, mock := newMock(t)
+	c := MySQLConfig{
+		Table: "t", PK: []string{"id"}, Columns: []string{"tags"},
+		Stats:    &Stats{},
+		colTypes: map[string]columnType{"tags": {Name: "tags", DataType: "set", Members: []string{"紅", "綠", "藍"}}},
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`tags` FROM `t` WHERE")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tags"}).AddRow("1", "紅,綠").AddRow("2", "紅"))
+	err := c.verifyRows(context.Background(), db, []pendingVerify{
+		{pk: KeyTuple{"1"}, changed: map[string]string{"tags": "綠,紅"}},
+		{pk: KeyTuple{"2"}, changed: map[string]string{"tags": "藍,紅"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Stats.Snapshot().VerifyFailed; got != 1 {
+		t.Errorf("verify_failed = %d, want 1", got)
+	}
+}
+
+func TestConvertJSONValuesOnly(t *testing.T) {
+	opts := ConvertOptions{To: "s2t"}
+	out, oc, err := convertJSON(opts, `{"名称": "软件", "tags": ["简体", 1, null], "n": {"说明": "<a&b>"}}`, nil)
+	if err != nil || oc != OutcomeConverted {
+		t.Fatalf("convertJSON = %q, %v, %v", out, oc, err)
+	}
+	if want := `{"名称": "軟件", "tags": ["簡體", 1, null], "n": {"说明": "<a&b>"}}`; out != want {
+		t.Errorf("out = %s, want %s", out, want)
+	}
+	// 没有字符串变化时原样返回（保留原格式）
+	in := `{"名称":"hello","n":[1,2]}`
+	if out, oc, err := convertJSON(opts, in, nil); err != nil || out != in || oc != OutcomeUnchanged {
+		t.Errorf("unchanged = %q, %v, %v", out, oc, err)
+	}
+	if _, _, err := convertJSON(opts, `{"名称":`, nil); err == nil {
+		t.Error("invalid JSON should fail")
+	}
+	// 不含汉字的 JSON 开启 nfkc 时不快速跳过
+	if out, oc, err := convertJSON(ConvertOptions{To: "s2t", Normalize: "nfkc"}, `["Ｈｉ"]`, nil); err != nil || out != `["Hi"]` || oc != OutcomeConverted {
+		t.Errorf("nfkc without han = %q, %v, %v", out, oc, err)
+	}
+}
+
+func TestConvertJSONKeys(t *testing.T) {
+	c := MySQLConfig{Table: "t", To: "s2t", ConvertKeys: true, colTypes: map[string]columnType{"attrs": {Name: "attrs", DataType: "json"}}}
+	out, oc, err := c.convertValue("attrs", `{"颜色": "红", "尺寸": {"长度": 1}, "list": [{"说明": "x"}]}`)
+	if err != nil || oc != OutcomeConverted {
+		t.Fatalf("convertValue = %q, %v, %v", out, oc, err)
+	}
+	if want := `{"顏色": "紅", "尺寸": {"長度": 1}, "list": [{"說明": "x"}]}`; out != want {
+		t.Errorf("out = %s, want %s", out, want)
+	}
+
+	// 只有键变化时同样视为已转换
+	if out, oc, _ := c.convertValue("attrs", `{"说明": 1}`); out != `{"說明": 1}` || oc != OutcomeConverted {
+		t.Errorf("key only = %q, %v", out, oc)
+	}
+
+	// 冲突：转换后与已有键相同的键保留原样；先出现的键优先，结果确定
+	for in, want := range map[string]string{
+		`{"发": 1, "發": 2}`:   `{"发": 1, "發": 2}`,
+		`{"简体": 1, "簡体": 2}`: `{"簡體": 1, "簡体": 2}`,
+	} {
+		out, _, err := c.convertValue("attrs", in)
+		if err != nil || out != want {
+			t.Errorf("collision %s = %q, %v; want %s", in, out, err, want)
+		}
+	}
+
+	// 默认只转换值
+	c.ConvertKeys = false
+	if out, _, _ := c.convertValue("attrs", `{"说明": "说明"}`); out != `{"说明": "說明"}` {
+		t.Errorf("values only = %q", out)
+	}
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// shadowSuffix 影子表名后缀；整库发现与 table_pattern 展开时跳过这类表
+const shadowSuffix = "_tradify_preview"
+
+// ShadowTableName 返回表对应的影子表名（<table>_tradify_preview）
+func ShadowTableName(table string) string {
+	return table + shadowSuffix
+}
+
+func isShadowTable(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), shadowSuffix)
+}
+
+// createShadowTable 按原表的列定义创建影子表：主键列 + 待转换列，另加写入时间。
+// 已存在时沿用并追加（同一主键覆盖为最新结果）；待转换列为 NULL 表示该列无变化
+func createShadowTable(db *sql.DB, cfg MySQLConfig) (string, error) {
+	var defs []string
+	for _, c := range cfg.PK {
+		t, ok := cfg.colTypes[strings.ToLower(c)]
+		if !ok || t.ColumnType == "" {
+			return "", fmt.Errorf("影子表：读取不到主键列 %s 的定义", c)
+		}
+		defs = append(defs, quoteIdent(c)+" "+t.ColumnType+" NOT NULL")
+	}
+	for _, c := range cfg.Columns {
+		t, ok := cfg.colTypes[strings.ToLower(c)]
+		if !ok || t.ColumnType == "" {
+			return "", fmt.Errorf("影子表：读取不到列 %s 的定义", c)
+		}
+		if indexOfFold(cfg.PK, c) >= 0 {
+			continue
+		}
+		defs = append(defs, quoteIdent(c)+" "+t.ColumnType+" NULL")
+	}
+	defs = append(defs, "`_tradify_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP")
+	defs = append(defs, "PRIMARY KEY ("+strings.Join(quoteAll(cfg.PK), ",")+")")
+
+	name := ShadowTableName(cfg.Table)
+	ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s) DEFAULT CHARSET=utf8mb4", quoteIdent(name), strings.Join(defs, ", "))
+	if _, err := db.Exec(ddl); err != nil {
+		return "", fmt.Errorf("创建影子表 %s 失败：%w", name, err)
+	}
+	return name, nil
+}
+
+// writeShadow 将一行的转换结果写入影子表（按主键 INSERT … ON DUPLICATE KEY UPDATE），只写发生变化的列
+func (c MySQLConfig) writeShadow(db *sql.DB, pk []sql.NullString, changed map[string]string) error {
+	cols := quoteAll(c.PK)
+	args := make([]interface{}, 0, len(c.PK)+len(changed))
+	for _, v := range pk {
+		args = append(args, v)
+	}
+	var updates []string
+	for _, col := range c.Columns {
+		if v, ok := changed[col]; ok {
+			q := quoteIdent(col)
+			cols = append(cols, q)
+			args = append(args, v)
+			updates = append(updates, q+" = VALUES("+q+")")
+		}
+	}
+	updates = append(updates, "`_tradify_at` = CURRENT_TIMESTAMP")
+	ph := strings.TrimSuffix(strings.Repeat("?,", len(cols)), ",")
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
+		quoteIdent(ShadowTableName(c.Table)), strings.Join(cols, ","), ph, strings.Join(updates, ", "))
+	_, err := c.execUpdate(db, query, args...)
+	return err
+}
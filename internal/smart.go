@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkSmart smart 模式只适用于目标为繁体的转换：auto-trad，或首级为 s2* 的配置（含转换链）
+func checkSmart(to string) error {
+	if a, ok, err := parseAutoTo(to); err != nil {
+		return err
+	} else if ok {
+		if !a.toTrad {
+			return fmt.Errorf("smart 模式只适用于转换为繁体（当前 to=%s）", to)
+		}
+		return nil
+	}
+	first, _, _ := strings.Cut(to, ChainSep)
+	if !strings.HasPrefix(strings.TrimSpace(first), "s2") {
+		return fmt.Errorf("smart 模式只适用于简体到繁体的配置（s2t、s2twp 等，或 auto-trad），当前 to=%s", to)
+	}
+	return nil
+}
+
+// smartFixConfig 修正零散简体字所用的配置：按目标地区只做逐字/词组的简到繁映射与该地区的异体字（s2tw / s2hk / s2t），
+// 不含 s2twp 的台湾惯用词改写
+func smartFixConfig(to string) string {
+	first, _, _ := strings.Cut(to, ChainSep)
+	switch first = strings.TrimSpace(first); {
+	case strings.HasPrefix(first, "s2tw"):
+		return "s2tw"
+	case strings.HasPrefix(first, "s2hk"):
+		return "s2hk"
+	}
+	return "s2t"
+}
+
+// smartConfig smart 模式下为单个值选择实际使用的配置：
+//   - 以繁体为主（繁体专用字多于简体专用字）：改用 smartFixConfig，fix=true，由 keepSimplifiedFixes 只保留简体字处的改动；
+//     不含简体专用字时返回 ok=false，原样保留
+//   - 其它情况（以简体为主、或无法区分）：按 to 整体转换；auto-trad 使用其实际配置，无法区分时原样保留
+func smartConfig(to, in string) (config string, fix, ok bool, err error) {
+	simp, trad, err := DetectScript(in)
+	if err != nil {
+		return "", false, false, err
+	}
+	if a, isAuto, _ := parseAutoTo(to); isAuto {
+		to = a.config
+		if simp == trad {
+			return "", false, false, nil // 与 auto-trad 相同：无法区分时原样保留
+		}
+	}
+	if trad > simp {
+		return smartFixConfig(to), true, simp > 0, nil
+	}
+	return to, false, true, nil
+}
+
+// keepSimplifiedFixes 以繁体为主的值：只保留 out 中位于简体专用字（s2t 会改动的字）处的改动，其余字符保持原文，
+// 避免异体字映射改动原文中已是繁体的字。逐字对应不成立（s2t 或 out 的词组映射改变了长度，极少见）时
+// 无法定位简体字，一律退回 s2t 的结果（s2t 不做地区异体字映射，对原有繁体字的改动最少）
+func keepSimplifiedFixes(in, out string) (string, error) {
+	cc, err := GetConverter("s2t")
+	if err != nil {
+		return "", err
+	}
+	mask, err := cc.Convert(in)
+	if err != nil {
+		return "", fmt.Errorf("opencc convert: %w", err)
+	}
+	a, b, m := []rune(in), []rune(out), []rune(mask)
+	if len(a) != len(m) || len(a) != len(b) {
+		return mask, nil
+	}
+	for i := range a {
+		if a[i] != m[i] {
+			a[i] = b[i]
+		}
+	}
+	return string(a), nil
+}
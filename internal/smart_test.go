@@ -0,0 +1,81 @@
+package internal
+
+import "testing"
+
+func TestCheckSmart(t *testing.T) {
+	for _, to := range []string{"s2t", "s2twp", "s2hk>t2tw", "auto-trad", "auto-trad:s2tw"} {
+		if err := checkSmart(to); err != nil {
+			t.Errorf("checkSmart(%q) = %v", to, err)
+		}
+	}
+	for _, to := range []string{"t2s", "tw2sp", "auto-simp", "t2tw>s2t"} {
+		if err := checkSmart(to); err == nil {
+			t.Errorf("checkSmart(%q) should fail", to)
+		}
+	}
+}
+
+func TestSmartFixConfig(t *testing.T) {
+	for to, want := range map[string]string{
+		"s2t":       "s2t",
+		"s2tw":      "s2tw",
+		"s2twp":     "s2tw",
+		"s2hk":      "s2hk",
+		"s2twp>t2s": "s2tw",
+	} {
+		if got := smartFixConfig(to); got != want {
+			t.Errorf("smartFixConfig(%q) = %q, want %q", to, got, want)
+		}
+	}
+}
+
+func TestKeepSimplifiedFixes(t *testing.T) {
+	in := "衣服裏面，但是这里"
+	out, err := keepSimplifiedFixes(in, "衣服裡面，但是這裡")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "衣服裏面，但是這裡"; out != want {
+		t.Errorf("keepSimplifiedFixes = %q, want %q", out, want)
+	}
+
+	// 长度不一致时无法逐字对应：退回 s2t 的结果，而不是 out
+	out, err = keepSimplifiedFixes(in, "衣服裡面，但是這裡啊")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask, _, _ := ConvertIfNeeded("s2t", in)
+	if out != mask {
+		t.Errorf("length mismatch = %q, want s2t result %q", out, mask)
+	}
+}
+
+func TestConvertDetailSmart(t *testing.T) {
+	opts := ConvertOptions{To: "s2twp", Smart: true}
+	out, oc, err := ConvertDetail(opts, "這個軟體很好，衣服裏面，但是这里有几個簡体字")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "這個軟體很好，衣服裏面，但是這裡有幾個簡體字"; out != want || oc != OutcomeConverted {
+		t.Errorf("mostly traditional = %q, %v; want %q", out, oc, want)
+	}
+
+	// 以简体为主：按 To 整体转换
+	out, _, err = ConvertDetail(opts, "这个软件很好")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _, _ := ConvertIfNeeded("s2twp", "这个软件很好"); out != want {
+		t.Errorf("mostly simplified = %q, want %q", out, want)
+	}
+
+	// 以繁体为主且不含简体专用字：原样保留
+	out, oc, err = ConvertDetail(opts, "衣服裏面很好看")
+	if err != nil || out != "衣服裏面很好看" || oc != OutcomeUnchanged {
+		t.Errorf("traditional = %q, %v, %v", out, oc, err)
+	}
+
+	if _, _, err := ConvertDetail(ConvertOptions{To: "t2s", Smart: true}, "简体"); err == nil {
+		t.Error("smart with t2s should fail")
+	}
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// DefaultStateDir 状态目录的默认名称（位于配置文件所在目录；命令行模式为当前目录）
+const DefaultStateDir = ".tradify-state"
+
+// StateDir 集中存放运行状态（已完成表清单、增量水位、文档缓存），整体删除该目录即可清空状态。布局：
+//
+//	<state>/mysql/<库名>-<DSN 指纹>/tables.done        已完成表清单（resume）
+//	<state>/mysql/<库名>-<DSN 指纹>/<表名>.watermark   增量水位
+//	<state>/file/cache.json                           file --checksum-skip 缓存
+//	<state>/file/files.done                           file --resume 续跑记录
+//
+// DSN 指纹只取用户、地址与库名，修改密码或连接参数不会丢失状态
+type StateDir string
+
+// ResolveStateDir dir 为空时使用 DefaultStateDir；相对路径基于 baseDir
+func ResolveStateDir(dir, baseDir string) StateDir {
+	if strings.TrimSpace(dir) == "" {
+		dir = DefaultStateDir
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(baseDir, dir)
+	}
+	return StateDir(dir)
+}
+
+// mysqlDir 返回某个数据库的状态子目录
+func (s StateDir) mysqlDir(dsn string) string {
+	id, db := dsn, ""
+	if c, err := mysql.ParseDSN(dsn); err == nil {
+		id, db = c.User+"@"+c.Net+"("+c.Addr+")/"+c.DBName, c.DBName
+	}
+	sum := sha256.Sum256([]byte(id))
+	name := hex.EncodeToString(sum[:4])
+	if db != "" {
+		name = safeStateName(db) + "-" + name
+	}
+	return filepath.Join(string(s), "mysql", name)
+}
+
+// ManifestPath 已完成表清单路径
+func (s StateDir) ManifestPath(dsn string) string {
+	return filepath.Join(s.mysqlDir(dsn), "tables.done")
+}
+
+// WatermarkPath 某张表的增量水位路径
+func (s StateDir) WatermarkPath(dsn, table string) string {
+	return filepath.Join(s.mysqlDir(dsn), safeStateName(table)+".watermark")
+}
+
+// FileCachePath file 子命令的文档缓存路径
+func (s StateDir) FileCachePath() string {
+	return filepath.Join(string(s), "file", "cache.json")
+}
+
+// FileResumePath file 子命令的续跑记录路径
+func (s StateDir) FileResumePath() string {
+	return filepath.Join(string(s), "file", "files.done")
+}
+
+// safeStateName 替换文件名中不安全的字符
+func safeStateName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', 0:
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunFileSkipsExcludedPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"doc.md":                       "简体",
+		".tradify-state/file/notes.md": "简体",
+		"report.md":                    "简体",
+		"sub/.tradify-state/keep.md":   "简体", // 不是本次的状态目录，照常处理
+	})
+	state := string(ResolveStateDir("", dir))
+	results, _, err := RunFileWithResult(FileConfig{
+		RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", DryRun: true,
+		ExcludePaths: []string{state, filepath.Join(dir, "report.md"), ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range results {
+		rel, _ := filepath.Rel(dir, r.Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	if want := "doc.md,sub/.tradify-state/keep.md"; strings.Join(got, ",") != want {
+		t.Errorf("processed = %v, want %s", got, want)
+	}
+}
+
+func TestRunFileSkipsExcludedPathsFromList(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeFiles(t, dir, map[string]string{"a.md": "简体", "out/report.md": "简体"})
+	results, _, err := RunFileWithResult(FileConfig{
+		PathsFrom: strings.NewReader("a.md\nout/report.md\n"), To: "s2t", DryRun: true,
+		ExcludePaths: []string{"out"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Path != "a.md" {
+		t.Errorf("results = %+v", results)
+	}
+}
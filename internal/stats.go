@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+)
+
+// Stats 运行统计（并发安全；nil 时所有记录操作为空操作）
+// 计数单位为“值”：mysql 为每行每列，file 为每个文件
+type Stats struct {
+	Converted        int64 `json:"converted"`          // 发生转换
+	SkippedASCII     int64 `json:"skipped_ascii"`      // 纯 ASCII 快速跳过
+	SkippedNoChinese int64 `json:"skipped_no_chinese"` // 不含汉字快速跳过
+	Unchanged        int64 `json:"unchanged"`          // 含汉字但转换后无变化
+	SkippedEmpty     int64 `json:"skipped_empty"`      // 仅 file：空文件，无需读取直接跳过（mysql 的 NULL/空串在读取时即跳过，不计入）
+
+	UnexpectedAffected int64 `json:"unexpected_affected"` // 按主键/identify_by 的 UPDATE 影响超过 1 行的次数（按 UPDATE 计）
+	Errors             int64 `json:"errors"`              // 仅 file：读取/转换/写回失败的文件数（含无权限）
+	VerifyFailed       int64 `json:"verify_failed"`       // 仅 mysql --verify：回读值与拟写入值不一致的列数（按列计）
+	EmptyBlocked       int64 `json:"empty_blocked"`       // 仅 mysql：非空值转换结果为空串、被拒绝写入的列数（--allow-empty-result 时不拦截）
+	InvalidUTF8        int64 `json:"invalid_utf8"`        // 仅 file：含无效 UTF-8 字节序列的文件数（不含二进制文件；按 --invalid-utf8 处理）
+
+	Replaced map[string]int64 `json:"replaced,omitempty"` // 自定义替换（replace）各规则的命中次数，键为 "原文=>替换为"
+
+	Timing *Timing `json:"timing,omitempty"` // 仅 --profile：转换与 SQL / 文件 IO 的累计耗时（见 EnableTiming）
+}
+
+// Record 按处理结果累加计数
+func (s *Stats) Record(oc ConvertOutcome) {
+	if s == nil {
+		return
+	}
+	switch oc {
+	case OutcomeConverted:
+		atomic.AddInt64(&s.Converted, 1)
+	case OutcomeASCIIOnly:
+		atomic.AddInt64(&s.SkippedASCII, 1)
+	case OutcomeNoChinese:
+		atomic.AddInt64(&s.SkippedNoChinese, 1)
+	case OutcomeUnchanged:
+		atomic.AddInt64(&s.Unchanged, 1)
+	case OutcomeEmpty:
+		atomic.AddInt64(&s.SkippedEmpty, 1)
+	}
+}
+
+// RecordUnexpectedAffected 记录一次影响行数异常的 UPDATE
+func (s *Stats) RecordUnexpectedAffected() {
+	if s == nil {
+		return
+	}
+	atomic.AddInt64(&s.UnexpectedAffected, 1)
+}
+
+// RecordError 记录一个处理失败的文件
+func (s *Stats) RecordError() {
+	if s == nil {
+		return
+	}
+	atomic.AddInt64(&s.Errors, 1)
+}
+
+// RecordInvalidUTF8 记录一个含无效 UTF-8 字节序列的文件
+func (s *Stats) RecordInvalidUTF8() {
+	if s == nil {
+		return
+	}
+	atomic.AddInt64(&s.InvalidUTF8, 1)
+}
+
+// RecordVerifyFailed 记录一列写后校验不一致
+func (s *Stats) RecordVerifyFailed() {
+	if s == nil {
+		return
+	}
+	atomic.AddInt64(&s.VerifyFailed, 1)
+}
+
+// recordConvertErr 按转换失败的原因计数：目前只区分被拒绝的空结果
+func (s *Stats) recordConvertErr(err error) {
+	if s == nil || !errors.Is(err, errEmptyResult) {
+		return
+	}
+	atomic.AddInt64(&s.EmptyBlocked, 1)
+}
+
+// RecordReplaced 累计一条替换规则的命中次数
+func (s *Stats) RecordReplaced(rule string, n int64) {
+	if s == nil {
+		return
+	}
+	replacedMu.Lock()
+	if s.Replaced == nil {
+		s.Replaced = map[string]int64{}
+	}
+	s.Replaced[rule] += n
+	replacedMu.Unlock()
+}
+
+// Snapshot 返回当前计数的一致快照（值拷贝）
+func (s *Stats) Snapshot() Stats {
+	if s == nil {
+		return Stats{}
+	}
+	var replaced map[string]int64
+	replacedMu.Lock()
+	if len(s.Replaced) > 0 {
+		replaced = make(map[string]int64, len(s.Replaced))
+		for k, v := range s.Replaced {
+			replaced[k] = v
+		}
+	}
+	replacedMu.Unlock()
+	return Stats{
+		Converted:        atomic.LoadInt64(&s.Converted),
+		SkippedASCII:     atomic.LoadInt64(&s.SkippedASCII),
+		SkippedNoChinese: atomic.LoadInt64(&s.SkippedNoChinese),
+		Unchanged:        atomic.LoadInt64(&s.Unchanged),
+		SkippedEmpty:     atomic.LoadInt64(&s.SkippedEmpty),
+
+		UnexpectedAffected: atomic.LoadInt64(&s.UnexpectedAffected),
+		Errors:             atomic.LoadInt64(&s.Errors),
+		VerifyFailed:       atomic.LoadInt64(&s.VerifyFailed),
+		EmptyBlocked:       atomic.LoadInt64(&s.EmptyBlocked),
+		InvalidUTF8:        atomic.LoadInt64(&s.InvalidUTF8),
+
+		Replaced: replaced,
+		Timing:   s.Timing.snapshot(),
+	}
+}
+
+// WriteSummary 输出统计摘要，format 为 text（默认）或 json
+func (s *Stats) WriteSummary(w io.Writer, format string) error {
+	snap := s.Snapshot()
+	switch format {
+	case "", "text":
+		line := fmt.Sprintf("[summary] 转换 %d | 跳过(纯ASCII) %d | 跳过(无汉字) %d | 无变化 %d",
+			snap.Converted, snap.SkippedASCII, snap.SkippedNoChinese, snap.Unchanged)
+		if snap.SkippedEmpty > 0 {
+			line += fmt.Sprintf(" | 跳过(空文件) %d", snap.SkippedEmpty)
+		}
+		if snap.UnexpectedAffected > 0 {
+			line += fmt.Sprintf(" | 影响行数异常 %d", snap.UnexpectedAffected)
+		}
+		if snap.VerifyFailed > 0 {
+			line += fmt.Sprintf(" | 校验不一致 %d", snap.VerifyFailed)
+		}
+		if snap.EmptyBlocked > 0 {
+			line += fmt.Sprintf(" | 拒绝空结果 %d", snap.EmptyBlocked)
+		}
+		if snap.InvalidUTF8 > 0 {
+			line += fmt.Sprintf(" | 无效UTF-8 %d", snap.InvalidUTF8)
+		}
+		if snap.Errors > 0 {
+			line += fmt.Sprintf(" | 失败 %d", snap.Errors)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		if snap.Timing != nil {
+			if _, err := fmt.Fprintf(w, "[summary] 耗时分解：%s\n", snap.Timing); err != nil {
+				return err
+			}
+		}
+		rules := make([]string, 0, len(snap.Replaced))
+		for k := range snap.Replaced {
+			rules = append(rules, k)
+		}
+		sort.Strings(rules)
+		for _, k := range rules {
+			if _, err := fmt.Fprintf(w, "[summary] 替换 %s × %d\n", k, snap.Replaced[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(snap)
+	default:
+		return fmt.Errorf("不支持的 summary 格式：%q（可选 text、json）", format)
+	}
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 表的调度顺序（table_order）：决定 tables_parallel 时各表占用并发名额的先后
+const (
+	TableOrderConfig   = "config"    // 按配置顺序（默认）
+	TableOrderSizeAsc  = "size-asc"  // 按 information_schema 估算行数升序：小表先完成
+	TableOrderSizeDesc = "size-desc" // 按估算行数降序：最大的表最先开始，通常总耗时最短
+)
+
+func checkTableOrder(order string) error {
+	switch order {
+	case "", TableOrderConfig, TableOrderSizeAsc, TableOrderSizeDesc:
+		return nil
+	}
+	return fmt.Errorf("不支持的 table_order：%q（可选 config、size-asc、size-desc）", order)
+}
+
+// scheduleOrder 按 sizes 返回各表的调度下标；行数相同的表保持配置顺序，config 时即配置顺序
+func scheduleOrder(order string, sizes []int64) []int {
+	idx := make([]int, len(sizes))
+	for i := range idx {
+		idx[i] = i
+	}
+	switch order {
+	case TableOrderSizeAsc:
+		sort.SliceStable(idx, func(a, b int) bool { return sizes[idx[a]] < sizes[idx[b]] })
+	case TableOrderSizeDesc:
+		sort.SliceStable(idx, func(a, b int) bool { return sizes[idx[a]] > sizes[idx[b]] })
+	}
+	return idx
+}
+
+// tableSchedule 计算各表的调度顺序：size-* 时先查询 information_schema.TABLE_ROWS（近似值，视图等无统计的表按 0 计），
+// 查询失败时告警并退回配置顺序
+func (c *MySQLFileConfig) tableSchedule(ctx context.Context, connTimeout time.Duration) []int {
+	sizes := make([]int64, len(c.Tables))
+	if c.TableOrder == "" || c.TableOrder == TableOrderConfig || len(c.Tables) < 2 {
+		return scheduleOrder(TableOrderConfig, sizes)
+	}
+	rows, err := approxTableRows(ctx, c.schemaDSN(), connTimeout)
+	if err != nil {
+		log.Printf("[mysql] 警告：table_order=%s 查询表大小失败，按配置顺序调度：%v", c.TableOrder, err)
+		return scheduleOrder(TableOrderConfig, sizes)
+	}
+	for i, t := range c.Tables {
+		sizes[i] = rows[strings.ToLower(t.Table)]
+	}
+	idx := scheduleOrder(c.TableOrder, sizes)
+	names := make([]string, len(idx))
+	for k, i := range idx {
+		names[k] = fmt.Sprintf("%s(~%d)", c.Tables[i].Table, sizes[i])
+	}
+	log.Printf("[mysql] table_order=%s 调度顺序：%s", c.TableOrder, strings.Join(names, ", "))
+	return idx
+}
+
+// approxTableRows 当前库各表的估算行数（表名小写）
+func approxTableRows(ctx context.Context, dsn string, connTimeout time.Duration) (map[string]int64, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open mysql: %w", err)
+	}
+	defer db.Close()
+	if err := pingTimeout(ctx, db, connTimeout); err != nil {
+		return nil, err
+	}
+	return queryTableRows(ctx, db)
+}
+
+// queryTableRows 在已建立的连接上查询当前库各表的估算行数（见 approxTableRows）
+func queryTableRows(ctx context.Context, db *sql.DB) (map[string]int64, error) {
+	rows, err := db.QueryContext(ctx, "SELECT TABLE_NAME, TABLE_ROWS FROM information_schema.tables WHERE table_schema = DATABASE()")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int64{}
+	for rows.Next() {
+		var name string
+		var n sql.NullInt64
+		if err := rows.Scan(&name, &n); err != nil {
+			return nil, err
+		}
+		out[strings.ToLower(name)] = n.Int64
+	}
+	return out, rows.Err()
+}
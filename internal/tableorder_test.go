@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestScheduleOrder(t *testing.T) {
+	sizes := []int64{500, 10, 90000, 10, 3000}
+	for order, want := range map[string][]int{
+		TableOrderConfig:   {0, 1, 2, 3, 4},
+		"":                 {0, 1, 2, 3, 4},
+		TableOrderSizeAsc:  {1, 3, 0, 4, 2}, // 行数相同的表保持配置顺序
+		TableOrderSizeDesc: {2, 4, 0, 1, 3},
+	} {
+		if got := scheduleOrder(order, sizes); !slices.Equal(got, want) {
+			t.Errorf("scheduleOrder(%q) = %v, want %v", order, got, want)
+		}
+	}
+}
+
+func TestCheckTableOrder(t *testing.T) {
+	for _, order := range []string{"", "config", "size-asc", "size-desc"} {
+		if err := checkTableOrder(order); err != nil {
+			t.Errorf("checkTableOrder(%q) = %v", order, err)
+		}
+	}
+	if err := checkTableOrder("size"); err == nil {
+		t.Error("checkTableOrder(size) should fail")
+	}
+}
+
+func TestQueryTableRows(t *testing.T) {
+	db, mock := newMock(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT TABLE_NAME, TABLE_ROWS FROM information_schema.tables")).
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_ROWS"}).
+			AddRow("Orders", 1200).AddRow("users", 30).AddRow("v_report", nil))
+	rows, err := queryTableRows(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 表名按小写索引，无统计（视图）按 0 计
+	if rows["orders"] != 1200 || rows["users"] != 30 || rows["v_report"] != 0 || len(rows) != 3 {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func TestTableScheduleConfigOrder(t *testing.T) {
+	// config 顺序不连库
+	c := &MySQLFileConfig{DSN: "u:p@tcp(127.0.0.1:1)/db", Tables: []MySQLTblEntry{{Table: "a"}, {Table: "b"}, {Table: "c"}}}
+	if got := c.tableSchedule(context.Background(), 0); !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("tableSchedule = %v", got)
+	}
+}
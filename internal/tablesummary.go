@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// tableSummary 配置文件模式中单表条目的运行结果；各表并发结束，全部完成后按配置顺序统一输出，便于阅读与 diff
+type tableSummary struct {
+	status string // 完成 | 失败 | 已跳过 | 未开始
+	counts *tableMetrics
+	err    error
+}
+
+// writeTableSummaries 按配置顺序输出各表结果；未开始或被跳过的表计数显示为 -
+func writeTableSummaries(w io.Writer, tables []TableStats, dryRun bool) error {
+	changed := "更新行"
+	if dryRun {
+		changed = "将更新行"
+	}
+	fmt.Fprintln(w, "[mysql] 各表结果（按配置顺序）：")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  表\t状态\t扫描行\t%s\t失败行\t耗时\t\n", changed)
+	for _, t := range tables {
+		name := t.Table
+		if t.Label != "" {
+			name = t.Label
+		}
+		if t.Status == "已跳过" || t.Status == "未开始" {
+			fmt.Fprintf(tw, "  %s\t%s\t-\t-\t-\t-\t\n", name, t.Status)
+			continue
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%d\t%d\t%d\t%s\t", name, t.Status, t.Scanned, t.Changed, t.Failed, t.Duration.Round(1e6))
+		if t.Err != nil {
+			fmt.Fprintf(tw, "%v", t.Err)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/longbridgeapp/opencc"
+)
+
+// termMaxLen 与 OpenCC 词典前缀匹配的最大长度一致（字符）
+const termMaxLen = 10
+
+// TermReport 统计转换中各词条替换（如 软件 -> 軟體）出现的次数，用于发现系统性误转（并发安全，nil 时为空操作）
+type TermReport struct {
+	mu     sync.Mutex
+	counts map[[2]string]int64
+}
+
+// TermCount 单个词条替换及其次数
+type TermCount struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int64  `json:"count"`
+}
+
+// NewTermReport 创建空报告
+func NewTermReport() *TermReport {
+	return &TermReport{counts: map[[2]string]int64{}}
+}
+
+// Observe 记录一次转换（in -> out）中的词条替换。to 为本次使用的转换配置（auto-* 按实际生效的配置分词）。
+// 分词沿用 OpenCC 各级词典的最长前缀匹配，与转换本身的切分一致；失败时静默忽略（报告仅供参考）
+func (r *TermReport) Observe(to, in, out string) {
+	if r == nil {
+		return
+	}
+	if a, ok, _ := parseAutoTo(to); ok {
+		to = a.config
+	}
+	cc, err := GetConverter(to)
+	if err != nil || len(cc.DictChains) == 0 {
+		return
+	}
+	pairs := termDiff(cc, in, out)
+	if len(pairs) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range pairs {
+		r.counts[p]++
+	}
+}
+
+// termDiff 将 in 切分为词条并与 out 对齐，返回发生变化的 (原词, 新词)。
+// OpenCC 按词典链逐级转换、每级各自切分（如 s2twp 先按简繁词组、再按台湾用词），
+// 逐级转换后字符数不变时取各级切分点的交集作为词条边界，使「软件 -> 軟體」这类跨级替换保持为一个词条；
+// 字符数有变化时退回按首级切分、逐词单独转换
+func termDiff(cc *opencc.OpenCC, in, out string) [][2]string {
+	src, dst := []rune(in), []rune(out)
+	cuts := make([]int, len(src)+1) // 每个位置是几级词典的切分点
+	aligned := len(dst) == len(src)
+	text := in
+	for _, g := range cc.DictChains {
+		r := []rune(text)
+		if !aligned || len(r) != len(src) {
+			aligned = false
+			break
+		}
+		for i := 0; i < len(r); i += termLen(g, r[i:min(len(r), i+termMaxLen)]) {
+			cuts[i]++
+		}
+		var err error
+		if text, err = (&opencc.OpenCC{DictChains: []*opencc.Group{g}}).Convert(text); err != nil {
+			return nil
+		}
+	}
+
+	var pairs [][2]string
+	add := func(i, j int, to string) {
+		from := string(src[i:j])
+		if to != "" && to != from && strings.IndexFunc(from, func(r rune) bool { return unicode.Is(unicode.Han, r) }) >= 0 {
+			pairs = append(pairs, [2]string{from, to})
+		}
+	}
+	if aligned {
+		for i, j := 0, 1; j <= len(src); j++ {
+			if j == len(src) || cuts[j] == len(cc.DictChains) {
+				add(i, j, string(dst[i:j]))
+				i = j
+			}
+		}
+		return pairs
+	}
+	for i := 0; i < len(src); {
+		n := termLen(cc.DictChains[0], src[i:min(len(src), i+termMaxLen)])
+		if c, err := cc.Convert(string(src[i : i+n])); err == nil {
+			add(i, i+n, c)
+		}
+		i += n
+	}
+	return pairs
+}
+
+// termLen 返回 s 开头在词典组 g 中最长匹配的词长（字符），未命中时为 1
+func termLen(g *opencc.Group, s []rune) int {
+	for _, d := range g.Dicts {
+		ret, err := d.PrefixMatch(string(s))
+		if err != nil || len(ret) == 0 {
+			continue
+		}
+		n := 0
+		for k := range ret {
+			n = max(n, utf8.RuneCountInString(k))
+		}
+		return max(n, 1)
+	}
+	return 1
+}
+
+// Top 返回次数最多的 n 个词条替换（次数相同按原词排序）；n <= 0 返回全部
+func (r *TermReport) Top(n int) []TermCount {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	out := make([]TermCount, 0, len(r.counts))
+	for p, c := range r.counts {
+		out = append(out, TermCount{From: p[0], To: p[1], Count: c})
+	}
+	r.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		if out[i].From != out[j].From {
+			return out[i].From < out[j].From
+		}
+		return out[i].To < out[j].To
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// Write 输出前 n 个词条替换的文本报告
+func (r *TermReport) Write(w io.Writer, n int) error {
+	top := r.Top(n)
+	if len(top) == 0 {
+		_, err := fmt.Fprintln(w, "[term-report] 无词条替换")
+		return err
+	}
+	fmt.Fprintf(w, "[term-report] 替换次数最多的 %d 个词条：\n", len(top))
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  次数\t原词\t替换为")
+	for _, t := range top {
+		fmt.Fprintf(tw, "  %d\t%s\t%s\n", t.Count, t.From, t.To)
+	}
+	return tw.Flush()
+}
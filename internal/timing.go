@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Timing --profile 的耗时分解：各热点路径的累计耗时（纳秒，多个 worker / 多张表并发时为各自耗时之和，可超过墙钟时间）。
+// 用于判断运行受限于 OpenCC CPU（可加 workers）还是数据库 / 磁盘 IO（调 batch_size、连接池）
+type Timing struct {
+	ConvertNS   int64 `json:"convert_ns"`    // OpenCC 转换（含规范化、auto 判定、自定义替换）
+	SQLQueryNS  int64 `json:"sql_query_ns"`  // 仅 mysql：批次 SELECT 执行到返回结果（不含逐行读取）
+	SQLExecNS   int64 `json:"sql_exec_ns"`   // 仅 mysql：UPDATE 执行（不含 global_max_inflight 排队）
+	FileReadNS  int64 `json:"file_read_ns"`  // 仅 file：读取文件内容
+	FileWriteNS int64 `json:"file_write_ns"` // 仅 file：写回、写备份、写入输出目录
+}
+
+type timingKind int
+
+const (
+	timeConvert timingKind = iota
+	timeSQLQuery
+	timeSQLExec
+	timeFileRead
+	timeFileWrite
+)
+
+// EnableTiming 开启耗时分解（--profile）；需在运行开始前调用
+func (s *Stats) EnableTiming() {
+	if s != nil && s.Timing == nil {
+		s.Timing = &Timing{}
+	}
+}
+
+// startTimer 返回计时起点；未开启 --profile 时返回零值，热路径上不调用 time.Now
+func (s *Stats) startTimer() time.Time {
+	if s == nil || s.Timing == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// addTime 累计自 start 起的耗时；start 为零值时为空操作
+func (s *Stats) addTime(k timingKind, start time.Time) {
+	if start.IsZero() {
+		return
+	}
+	d := int64(time.Since(start))
+	t := s.Timing
+	switch k {
+	case timeConvert:
+		atomic.AddInt64(&t.ConvertNS, d)
+	case timeSQLQuery:
+		atomic.AddInt64(&t.SQLQueryNS, d)
+	case timeSQLExec:
+		atomic.AddInt64(&t.SQLExecNS, d)
+	case timeFileRead:
+		atomic.AddInt64(&t.FileReadNS, d)
+	case timeFileWrite:
+		atomic.AddInt64(&t.FileWriteNS, d)
+	}
+}
+
+func (t *Timing) snapshot() *Timing {
+	if t == nil {
+		return nil
+	}
+	return &Timing{
+		ConvertNS:   atomic.LoadInt64(&t.ConvertNS),
+		SQLQueryNS:  atomic.LoadInt64(&t.SQLQueryNS),
+		SQLExecNS:   atomic.LoadInt64(&t.SQLExecNS),
+		FileReadNS:  atomic.LoadInt64(&t.FileReadNS),
+		FileWriteNS: atomic.LoadInt64(&t.FileWriteNS),
+	}
+}
+
+// String 单行耗时分解：各项累计耗时及占比，为 0 的 IO 项（如 mysql 下的文件读写）省略
+func (t *Timing) String() string {
+	items := []struct {
+		name string
+		ns   int64
+	}{
+		{"转换", t.ConvertNS},
+		{"SQL 查询", t.SQLQueryNS},
+		{"SQL 更新", t.SQLExecNS},
+		{"读文件", t.FileReadNS},
+		{"写文件", t.FileWriteNS},
+	}
+	var total int64
+	for _, it := range items {
+		total += it.ns
+	}
+	parts := []string{}
+	for i, it := range items {
+		if it.ns == 0 && i > 0 {
+			continue
+		}
+		pct := 0.0
+		if total > 0 {
+			pct = float64(it.ns) * 100 / float64(total)
+		}
+		parts = append(parts, fmt.Sprintf("%s %s (%.0f%%)", it.name, time.Duration(it.ns).Round(time.Millisecond), pct))
+	}
+	return strings.Join(parts, " | ")
+}
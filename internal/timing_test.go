@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestFileTiming(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.md": strings.Repeat("简体中文\n", 1000), "b.md": "软件"})
+	stats := &Stats{}
+	stats.EnableTiming()
+	if _, err := RunFile(FileConfig{RootDirs: []string{dir}, Exts: []string{".md"}, To: "s2t", Stats: stats}); err != nil {
+		t.Fatal(err)
+	}
+	tm := stats.Snapshot().Timing
+	if tm == nil || tm.ConvertNS <= 0 || tm.FileReadNS <= 0 || tm.FileWriteNS <= 0 {
+		t.Fatalf("timing = %+v", tm)
+	}
+	if tm.SQLQueryNS != 0 || tm.SQLExecNS != 0 {
+		t.Errorf("file run recorded sql time: %+v", tm)
+	}
+}
+
+func TestMySQLTiming(t *testing.T) {
+	db, mock := newMock(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` ORDER BY `id` LIMIT ?")).WithArgs(10).WillDelayFor(5 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "简体"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).WithArgs("簡體", "1").WillDelayFor(5 * time.Millisecond).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE (`id`) > (?) ORDER BY `id` LIMIT ?")).WithArgs("1", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	stats := &Stats{}
+	stats.EnableTiming()
+	cfg := MySQLConfig{Table: "t", PK: []string{"id"}, Columns: []string{"name"}, To: "s2t", BatchSize: 10,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{}, Stats: stats}
+	if _, err := processWithPK(context.Background(), db, cfg, nil, nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	tm := stats.Snapshot().Timing
+	if tm == nil || tm.ConvertNS <= 0 || tm.SQLQueryNS < int64(5*time.Millisecond) || tm.SQLExecNS < int64(5*time.Millisecond) {
+		t.Fatalf("timing = %+v", tm)
+	}
+	if tm.FileReadNS != 0 || tm.FileWriteNS != 0 {
+		t.Errorf("mysql run recorded file time: %+v", tm)
+	}
+}
+
+func TestTimingDisabled(t *testing.T) {
+	stats := &Stats{}
+	if !stats.startTimer().IsZero() {
+		t.Error("timer started without --profile")
+	}
+	stats.addTime(timeConvert, time.Time{})
+	if stats.Snapshot().Timing != nil {
+		t.Error("timing should be nil without --profile")
+	}
+}
+
+func TestTimingString(t *testing.T) {
+	tm := &Timing{ConvertNS: int64(3 * time.Second), SQLExecNS: int64(time.Second)}
+	if got, want := tm.String(), "转换 3s (75%) | SQL 更新 1s (25%)"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
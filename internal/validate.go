@@ -0,0 +1,286 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ConfigIssue 配置检查发现的一个问题；Field 指向出错的字段（如 tables[2].columns）
+type ConfigIssue struct {
+	Field   string
+	Message string
+}
+
+func (i ConfigIssue) String() string {
+	if i.Field == "" {
+		return i.Message
+	}
+	return i.Field + ": " + i.Message
+}
+
+// LintMySQLFileConfig 离线检查配置文件（不连接数据库），尽量一次报告所有问题：
+// JSON 结构与未知字段、duration、转换配置、枚举取值，以及表条目的逻辑一致性（重复的表/列、pk 与各字段的依赖等）。
+// 返回的配置已按 Validate 填充默认值；JSON 无法解析时配置为 nil
+func LintMySQLFileConfig(file string) (*MySQLFileConfig, []ConfigIssue) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return nil, []ConfigIssue{{Message: err.Error()}}
+	}
+	var issues []ConfigIssue
+	add := func(field, format string, args ...interface{}) {
+		issues = append(issues, ConfigIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var cfg MySQLFileConfig
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		// 未知字段（多为拼写错误）不影响其余检查，按宽松模式重新解析
+		cfg = MySQLFileConfig{}
+		if lerr := json.Unmarshal(bs, &cfg); lerr != nil {
+			return nil, []ConfigIssue{{Message: fmt.Sprintf("JSON 解析失败：%v", lerr)}}
+		}
+		add("", "%v（字段名拼写错误？）", err)
+	}
+
+	if cfg.DSN == "" {
+		add("dsn", "缺少 dsn")
+	} else if c, err := mysql.ParseDSN(cfg.DSN); err != nil {
+		add("dsn", "无法解析：%v", err)
+	} else if c.DBName == "" {
+		add("dsn", "未指定库名")
+	}
+	if cfg.ReadDSN != "" {
+		if _, err := mysql.ParseDSN(cfg.ReadDSN); err != nil {
+			add("read_dsn", "无法解析：%v", err)
+		}
+	}
+	checkTo := func(field, to string) {
+		if to == "" {
+			return
+		}
+		if err := WarmUpConverters(to); err != nil {
+			add(field, "%v", err)
+			return
+		}
+		if cfg.Smart {
+			if err := checkSmart(to); err != nil {
+				add(field, "%v", err)
+			}
+		}
+	}
+	checkTo("to", cfg.To)
+	if _, err := ParseNormalizeForm(cfg.Normalize); err != nil {
+		add("normalize", "%v", err)
+	}
+	if _, err := NewReplacer(cfg.Replace, nil); err != nil {
+		add("replace", "%v", err)
+	}
+	for _, d := range []struct{ field, v string }{
+		{"conn_max_lifetime", cfg.ConnMaxLifetime},
+		{"query_retry_delay", cfg.QueryRetryDelay},
+		{"connect_timeout", cfg.ConnectTimeout},
+	} {
+		if strings.TrimSpace(d.v) == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d.v); err != nil {
+			add(d.field, "不是有效的 Go duration（如 30m、5s）：%q", d.v)
+		}
+	}
+	if err := checkCountMode(cfg.CountMode); err != nil {
+		add("count_mode", "%v", err)
+	}
+	switch cfg.BarOrder {
+	case "", "config", "label", "size":
+	default:
+		add("bar_order", "不支持的取值 %q（可选 config、label、size）", cfg.BarOrder)
+	}
+	if err := checkTableOrder(cfg.TableOrder); err != nil {
+		add("table_order", "%v", err)
+	}
+	nonNegative := func(field string, v int) {
+		if v < 0 {
+			add(field, "不能为负数：%d", v)
+		}
+	}
+	nonNegative("batch_size", cfg.BatchSize)
+	nonNegative("workers", cfg.Workers)
+	nonNegative("rps", cfg.RPS)
+	nonNegative("tables_parallel", cfg.TablesParallel)
+	nonNegative("global_max_inflight", cfg.GlobalMaxInflight)
+	nonNegative("global_rps", cfg.GlobalRPS)
+	nonNegative("heavy_index_rps", cfg.HeavyIndexRPS)
+	if cfg.SessionCollation != "" {
+		if _, err := collationCharset(cfg.SessionCollation); err != nil {
+			add("session_collation", "%v", err)
+		}
+	}
+	if cfg.Isolation != "" {
+		if _, err := parseIsolation(cfg.Isolation); err != nil {
+			add("isolation", "%v", err)
+		}
+	}
+	for i, p := range cfg.ExcludeTables {
+		if _, err := path.Match(p, ""); err != nil {
+			add(fmt.Sprintf("exclude_tables[%d]", i), "无效的通配符 %q：%v", p, err)
+		}
+	}
+	if cfg.ShadowTable && !cfg.DryRun {
+		add("shadow_table", "仅可与 dry_run=true 一起使用")
+	}
+	if len(cfg.Tables) == 0 {
+		add("tables", "缺少 tables")
+	}
+
+	seen := map[string]int{}
+	for i, t := range cfg.Tables {
+		f := func(name string) string { return fmt.Sprintf("tables[%d].%s", i, name) }
+		switch {
+		case t.Table == "" && t.TablePattern == "":
+			add(f("table"), "缺少 table 或 table_pattern")
+		case t.Table != "" && t.TablePattern != "":
+			add(f("table"), "table 与 table_pattern 只能二选一")
+		case t.TablePattern != "":
+			if err := checkTablePattern(t); err != nil {
+				add(f("table_pattern"), "%v", err)
+			}
+		default:
+			key := strings.ToLower(t.Table)
+			if j, ok := seen[key]; ok {
+				add(f("table"), "表 %s 与 tables[%d] 重复", t.Table, j)
+			} else {
+				seen[key] = i
+			}
+		}
+
+		if len(t.Columns) == 0 {
+			add(f("columns"), "缺少 columns")
+		}
+		cols := map[string]bool{}
+		for _, c := range t.Columns {
+			if cols[strings.ToLower(c)] {
+				add(f("columns"), "列 %s 重复", c)
+			}
+			cols[strings.ToLower(c)] = true
+		}
+		if len(t.PK) > 0 && len(t.IdentifyBy) > 0 {
+			add(f("identify_by"), "已提供 pk 时 identify_by 不会生效，请删除其一")
+		}
+		if t.SelectSQL != "" && len(t.PK) == 0 {
+			add(f("select_sql"), "使用 select_sql 时必须提供 pk")
+		}
+		if t.SelectSQL != "" && t.IncrementalColumn != "" {
+			add(f("select_sql"), "select_sql 与 incremental_column 不可同时使用")
+		}
+		if err := checkJoin(t); err != nil {
+			add(f("join"), "%v", err)
+		}
+		if t.IncrementalColumn == "" && (t.Since != "" || t.WatermarkFile != "") {
+			add(f("since"), "since/watermark_file 需配合 incremental_column 使用")
+		}
+		if len(t.Keys) > 0 && t.KeysFile != "" {
+			add(f("keys"), "keys 与 keys_file 只能二选一")
+		}
+		if err := checkKeys(t.PK, t.Keys); err != nil {
+			add(f("keys"), "%v", err)
+		}
+		if t.KeysFile != "" {
+			if len(t.PK) == 0 {
+				add(f("keys_file"), "指定 keys_file 时必须提供 pk")
+			}
+			p := t.KeysFile
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(filepath.Dir(file), p)
+			}
+			if _, err := os.Stat(p); err != nil {
+				add(f("keys_file"), "%v", err)
+			}
+		}
+		if _, err := compileSegments(t.Columns, t.Segments); err != nil {
+			add(fmt.Sprintf("tables[%d]", i), "%v", err)
+		}
+		if err := checkPKRange(t.PK, t.PKMin, t.PKMax); err != nil {
+			add(f("pk_min"), "%v", err)
+		}
+		if cfg.ShadowTable && len(t.PK) == 0 {
+			add(f("pk"), "shadow_table 模式下每张表都需要 pk")
+		}
+		checkTo(f("to"), t.To)
+		if _, err := NewReplacer(t.Replace, nil); err != nil {
+			add(f("replace"), "%v", err)
+		}
+		nonNegative(f("batch_size"), t.BatchSize)
+		nonNegative(f("workers"), t.Workers)
+		if t.RPS != nil {
+			nonNegative(f("rps"), *t.RPS)
+		}
+	}
+
+	// 兜底：Validate 中的规则均已覆盖，仍报错说明检查有遗漏
+	if len(issues) == 0 {
+		if err := cfg.Validate(); err != nil {
+			add("", "%v", err)
+		}
+	}
+	return &cfg, issues
+}
+
+// CheckConfigSchema 连接数据库核对各表条目：表存在且为基表，columns/pk/identify_by/incremental_column 均存在；
+// table_pattern 按当前库展开后逐表核对
+func CheckConfigSchema(ctx context.Context, fileCfg *MySQLFileConfig) []ConfigIssue {
+	timeout, _ := time.ParseDuration(fileCfg.ConnectTimeout)
+	expanded := *fileCfg
+	if err := expandTablePatterns(ctx, &expanded, timeout); err != nil {
+		return []ConfigIssue{{Field: "tables", Message: err.Error()}}
+	}
+
+	db, err := sql.Open("mysql", fileCfg.DSN)
+	if err != nil {
+		return []ConfigIssue{{Field: "dsn", Message: err.Error()}}
+	}
+	defer db.Close()
+	if err := pingTimeout(ctx, db, timeout); err != nil {
+		return []ConfigIssue{{Field: "dsn", Message: err.Error()}}
+	}
+	tables, err := listBaseTables(ctx, db)
+	if err != nil {
+		return []ConfigIssue{{Field: "tables", Message: fmt.Sprintf("枚举表失败：%v", err)}}
+	}
+
+	var issues []ConfigIssue
+	for _, t := range expanded.Tables {
+		field := "tables[" + t.Table + "]"
+		if indexOfFold(tables, t.Table) < 0 {
+			issues = append(issues, ConfigIssue{Field: field, Message: "表不存在（或不是基表）"})
+			continue
+		}
+		all, err := getAllColumns(db, t.Table)
+		if err != nil {
+			issues = append(issues, ConfigIssue{Field: field, Message: fmt.Sprintf("读取列失败：%v", err)})
+			continue
+		}
+		check := func(name string, cols ...string) {
+			for _, c := range cols {
+				if c != "" && indexOfFold(all, c) < 0 {
+					issues = append(issues, ConfigIssue{Field: field + "." + name, Message: fmt.Sprintf("列 %s 不存在", c)})
+				}
+			}
+		}
+		check("columns", t.Columns...)
+		check("pk", t.PK...)
+		check("identify_by", t.IdentifyBy...)
+		check("incremental_column", t.IncrementalColumn)
+	}
+	return issues
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// pendingVerify 一行已写入、等待回读校验的变更
+type pendingVerify struct {
+	pk      KeyTuple
+	changed map[string]string
+}
+
+// verifyRows 按主键回读本批已写入的行，逐列比较库中的值与拟写入的值。
+// SET 列按列定义顺序比较成员；不一致（截断、触发器改写等）计入 Stats.VerifyFailed 并告警，同时计入 max_errors 熔断；回读查询本身失败时返回错误
+func (c MySQLConfig) verifyRows(ctx context.Context, db *sql.DB, pending []pendingVerify) error {
+	if len(pending) == 0 {
+		return nil
+	}
+	keys := make([]KeyTuple, len(pending))
+	for i, p := range pending {
+		keys[i] = p.pk
+	}
+	cols := append(append([]string{}, c.PK...), c.Columns...)
+	cond, args := keysCond(c.PK, keys)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(quoteAll(cols), ","), quoteIdent(c.Table), cond)
+
+	qctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	rows, err := db.QueryContext(qctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("写后校验查询失败：%w", err)
+	}
+	defer rows.Close()
+	stored := map[string][]sql.NullString{}
+	for rows.Next() {
+		dst := make([]sql.NullString, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range dst {
+			ptrs[i] = &dst[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("写后校验读取失败：%w", err)
+		}
+		stored[strings.Join(nullStrings(dst[:len(c.PK)]), "\x00")] = dst[len(c.PK):]
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("写后校验读取失败：%w", err)
+	}
+
+	var mismatched int64
+	for _, p := range pending {
+		vals, ok := stored[strings.Join(p.pk, "\x00")]
+		if !ok {
+			mismatched++
+			c.Stats.RecordVerifyFailed()
+			log.Printf("[mysql] 写后校验不一致 table=%s pk=%v：回读不到该行", c.Table, []string(p.pk))
+			continue
+		}
+		for i, col := range c.Columns {
+			want, ok := p.changed[col]
+			if !ok {
+				continue
+			}
+			got := vals[i]
+			same := got.Valid && got.String == want
+			if t := c.colTypes[strings.ToLower(col)]; !same && got.Valid && t.DataType == "set" {
+				// MySQL 按列定义顺序存储 SET 成员，与拟写入值的成员顺序无关
+				same = setDefinitionOrder(t.Members, got.String) == setDefinitionOrder(t.Members, want)
+			}
+			if !same {
+				mismatched++
+				c.Stats.RecordVerifyFailed()
+				log.Printf("[mysql] 写后校验不一致 table=%s pk=%v column=%s：期望 %.80q，实际 %.80q（截断或触发器改写？）",
+					c.Table, []string(p.pk), col, want, nz(got))
+			}
+		}
+	}
+	return c.errBudget.add(mismatched)
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 模拟触发器在写入时改写了值：回读结果与拟写入值不一致，计入 verify_failed 并告警
+func TestVerifyDetectsTriggerRewrite(t *testing.T) {
+	db, mock := newMock(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` ORDER BY `id` LIMIT ?")).WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "简体").AddRow("2", "后来"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).WithArgs("簡體", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `t` SET `name` = ? WHERE `id` = ?")).WithArgs("後來", "2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "簡體").AddRow("2", "後來[trigger]"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name` FROM `t` WHERE (`id`) > (?) ORDER BY `id` LIMIT ?")).WithArgs("2", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	stats := &Stats{}
+	cfg := MySQLConfig{
+		Table: "t", PK: []string{"id"}, Columns: []string{"name"}, To: "s2t", BatchSize: 10, Verify: true,
+		QueryRetryMax: 1, QueryRetryDelay: time.Millisecond, counts: &tableMetrics{}, Stats: stats,
+	}
+	var err error
+	out := captureLog(t, func() { _, err = processWithPK(context.Background(), db, cfg, nil, nil, 0) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stats.Snapshot().VerifyFailed; got != 1 {
+		t.Errorf("verify_failed = %d, want 1", got)
+	}
+	if !strings.Contains(out, `写后校验不一致 table=t pk=[2] column=name：期望 "後來"，实际 "後來[trigger]"`) {
+		t.Errorf("missing mismatch warning:\n%s", out)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// countingListener 本地监听地址：记录收到的连接数，用于断言配置错误时没有任何连库尝试
+func countingListener(t *testing.T) (dsn string, accepted *int64) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	accepted = new(int64)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt64(accepted, 1)
+			c.Close()
+		}
+	}()
+	return "u:p@tcp(" + ln.Addr().String() + ")/db?timeout=1s", accepted
+}
+
+func TestRunFileBadToFailsBeforeWalk(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "missing") // 一旦遍历就会报错的根目录
+	results, _, err := RunFileWithResult(FileConfig{RootDirs: []string{root}, Exts: []string{".md"}, To: "no-such-config"})
+	if !errors.Is(err, ErrConfigInvalid) {
+		t.Fatalf("err = %v, want ErrConfigInvalid", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("walked before failing: %+v", results)
+	}
+}
+
+func TestRunMySQLBadToFailsBeforeConnect(t *testing.T) {
+	dsn, accepted := countingListener(t)
+	_, err := RunMySQL(context.Background(), MySQLConfig{DSN: dsn, Table: "t", PK: []string{"id"}, Columns: []string{"c"}, To: "no-such-config"})
+	if !errors.Is(err, ErrConfigInvalid) {
+		t.Fatalf("err = %v, want ErrConfigInvalid", err)
+	}
+	if n := atomic.LoadInt64(accepted); n != 0 {
+		t.Errorf("connected %d times before failing", n)
+	}
+}
+
+func TestRunMySQLFromFileConfigBadToFailsBeforeConnect(t *testing.T) {
+	dsn, accepted := countingListener(t)
+	for name, tables := range map[string][]MySQLTblEntry{
+		"table to": {{Table: "a", PK: []string{"id"}, Columns: []string{"c"}}, {Table: "b", PK: []string{"id"}, Columns: []string{"c"}, To: "s2t>nope"}},
+		// table_pattern 需要连库枚举表：预热须在展开之前
+		"pattern to": {{TablePattern: "log_%", PK: []string{"id"}, Columns: []string{"c"}, To: "nope"}},
+	} {
+		cfg := &MySQLFileConfig{DSN: dsn, Tables: tables}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("%s: Validate = %v", name, err)
+		}
+		_, err := RunMySQLFromFileConfig(context.Background(), cfg, t.TempDir(), MySQLRunOptions{})
+		if !errors.Is(err, ErrConfigInvalid) {
+			t.Errorf("%s: err = %v, want ErrConfigInvalid", name, err)
+		}
+	}
+	if n := atomic.LoadInt64(accepted); n != 0 {
+		t.Errorf("connected %d times before failing", n)
+	}
+}